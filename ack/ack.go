@@ -0,0 +1,204 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ack is the acknowledgement of the probe failures. The acknowledged failure
+// stops the repeat notification and the escalation until the probe recovers or the
+// acknowledgement expires.
+package ack
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+const kind = "ack"
+
+// Settings is the settings of the acknowledgement
+type Settings struct {
+	URL                 string `yaml:"url,omitempty" json:"url,omitempty" jsonschema:"format=uri,title=External URL,description=the external URL of the EaseProbe web server to build the acknowledgement links,example=https://probe.example.com"`
+	SlackSigningSecret  string `yaml:"slack_signing_secret,omitempty" json:"slack_signing_secret,omitempty" jsonschema:"title=Slack Signing Secret,description=the signing secret of the Slack app to acknowledge by the button of the message"`
+	TelegramSecretToken string `yaml:"telegram_secret_token,omitempty" json:"telegram_secret_token,omitempty" jsonschema:"title=Telegram Secret Token,description=the secret token of the Telegram bot webhook to acknowledge by the button of the message"`
+	DiscordPublicKey    string `yaml:"discord_public_key,omitempty" json:"discord_public_key,omitempty" jsonschema:"title=Discord Public Key,description=the public key of the Discord application to acknowledge by the slash command"`
+}
+
+var (
+	mutex     sync.RWMutex
+	settings  Settings
+	linkKey   string
+	publicKey ed25519.PublicKey
+)
+
+// SetSettings sets the acknowledgement settings, the token of the management API signs
+// the acknowledgement links, so that the links are disabled if the token is empty.
+func SetSettings(s Settings, token string) {
+	s.URL = strings.TrimRight(strings.TrimSpace(s.URL), "/")
+	if len(s.URL) > 0 {
+		if u, err := url.Parse(s.URL); err != nil || len(u.Scheme) <= 0 || len(u.Host) <= 0 {
+			log.Errorf("[%s] Bad external URL [%s], the acknowledgement links are disabled", kind, s.URL)
+			s.URL = ""
+		} else if len(token) <= 0 {
+			log.Warnf("[%s] The management API token is not configured, the acknowledgement links are disabled", kind)
+		}
+	}
+
+	var key ed25519.PublicKey
+	if len(s.DiscordPublicKey) > 0 {
+		buf, err := hex.DecodeString(strings.TrimSpace(s.DiscordPublicKey))
+		if err != nil || len(buf) != ed25519.PublicKeySize {
+			log.Errorf("[%s] Bad Discord public key, the Discord slash command is disabled", kind)
+			s.DiscordPublicKey = ""
+		} else {
+			key = ed25519.PublicKey(buf)
+		}
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	settings = s
+	linkKey = token
+	publicKey = key
+}
+
+// SlackEnabled returns true if the failure could be acknowledged by the Slack message button
+func SlackEnabled() bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return len(settings.SlackSigningSecret) > 0
+}
+
+// TelegramEnabled returns true if the failure could be acknowledged by the Telegram message button
+func TelegramEnabled() bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return len(settings.TelegramSecretToken) > 0
+}
+
+// DiscordEnabled returns true if the failure could be acknowledged by the Discord slash command
+func DiscordEnabled() bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return len(publicKey) > 0
+}
+
+// Link returns the link to acknowledge the failure of the probe, empty if the links are disabled
+func Link(name string) string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if len(settings.URL) <= 0 || len(linkKey) <= 0 {
+		return ""
+	}
+	return settings.URL + "/ack/" + url.PathEscape(name) + "?token=" + sign(linkKey, name)
+}
+
+// Verify returns true if the token of the acknowledgement link is valid for the probe
+func Verify(name, token string) bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if len(linkKey) <= 0 || len(token) <= 0 {
+		return false
+	}
+	return hmac.Equal([]byte(sign(linkKey, name)), []byte(token))
+}
+
+func sign(key, name string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte("ack:" + name))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Process clears the acknowledgement if the probe recovers or the acknowledgement expires at the
+// time now. The repeat notification of the acknowledged failure is stopped, and the link to
+// acknowledge is set for the failure which is not acknowledged yet.
+func Process(r *probe.Result, status probe.Status, now time.Time) {
+	r.AckURL = ""
+	if a := r.Stat.Ack; a != nil {
+		if status != probe.StatusDown {
+			log.Infof("[%s] %s - recovered, the acknowledgement by %s is cleared", kind, r.Name, a.By)
+			r.Stat.Ack = nil
+		} else if a.Expired(now) {
+			log.Infof("[%s] %s - the acknowledgement by %s is expired", kind, r.Name, a.By)
+			r.Stat.Ack = nil
+		}
+	}
+	if status != probe.StatusDown {
+		return
+	}
+	if r.IsAcked() {
+		r.Stat.NotificationStrategyData.IsSent = false
+		return
+	}
+	r.AckURL = Link(r.Name)
+}
+
+// Request is the request to acknowledge the failure of a probe
+type Request struct {
+	Probe   string
+	By      string
+	Comment string
+	Expire  time.Duration
+}
+
+// ParseExpire parses the expiry of the acknowledgement, empty means never expires
+func ParseExpire(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if len(s) <= 0 {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid expire [%s] - %v", s, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid expire [%s] - it must not be negative", s)
+	}
+	return d, nil
+}
+
+// Data returns the acknowledgement of the request at the time now
+func (req *Request) Data(now time.Time) probe.AckData {
+	return probe.AckData{
+		By:      strings.TrimSpace(req.By),
+		Comment: strings.TrimSpace(req.Comment),
+		Time:    now,
+		Expire:  req.Expire,
+	}
+}
+
+// Func acknowledges the failure of the probe, it's implemented by the runner of the probes
+type Func func(req Request) (*probe.AckData, error)
+
+// Message returns the readable acknowledgement of the probe
+func Message(name string, a *probe.AckData) string {
+	msg := fmt.Sprintf("✅ %s is acknowledged by %s", name, a.By)
+	if a.Expire > 0 {
+		msg += fmt.Sprintf(" for %s", a.Expire)
+	}
+	if len(a.Comment) > 0 {
+		msg += " - " + a.Comment
+	}
+	return msg
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ack
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func TestLink(t *testing.T) {
+	defer SetSettings(Settings{}, "")
+
+	SetSettings(Settings{URL: "https://probe.example.com/"}, "")
+	assert.Empty(t, Link("web"))
+	assert.False(t, Verify("web", "any"))
+
+	SetSettings(Settings{URL: "not a url"}, "secret")
+	assert.Empty(t, Link("web"))
+
+	SetSettings(Settings{URL: "https://probe.example.com/"}, "secret")
+	link := Link("web site")
+	assert.True(t, strings.HasPrefix(link, "https://probe.example.com/ack/web%20site?token="))
+	token := link[strings.Index(link, "token=")+len("token="):]
+	assert.True(t, Verify("web site", token))
+	assert.False(t, Verify("web", token))
+	assert.False(t, Verify("web site", ""))
+
+	// the links are invalid after the token is changed
+	SetSettings(Settings{URL: "https://probe.example.com"}, "another")
+	assert.False(t, Verify("web site", token))
+
+	SetSettings(Settings{DiscordPublicKey: "bad"}, "")
+	assert.False(t, DiscordEnabled())
+	SetSettings(Settings{DiscordPublicKey: strings.Repeat("ab", 32), SlackSigningSecret: "s", TelegramSecretToken: "t"}, "")
+	assert.True(t, DiscordEnabled())
+	assert.True(t, SlackEnabled())
+	assert.True(t, TelegramEnabled())
+}
+
+func TestProcess(t *testing.T) {
+	SetSettings(Settings{URL: "https://probe.example.com"}, "secret")
+	defer SetSettings(Settings{}, "")
+
+	now := time.Now()
+	r := probe.NewResult()
+	r.Name = "web"
+
+	// the link is only for the failure
+	Process(r, probe.StatusUp, now)
+	assert.Empty(t, r.AckURL)
+	r.Stat.NotificationStrategyData.IsSent = true
+	Process(r, probe.StatusDown, now)
+	assert.Equal(t, Link("web"), r.AckURL)
+	assert.True(t, r.Stat.NotificationStrategyData.IsSent)
+
+	// the repeat notification stops after the acknowledgement
+	r.Stat.Ack = &probe.AckData{By: "alice", Time: now, Expire: time.Hour}
+	r.Stat.NotificationStrategyData.IsSent = true
+	Process(r, probe.StatusDown, now.Add(time.Minute))
+	assert.Empty(t, r.AckURL)
+	assert.False(t, r.Stat.NotificationStrategyData.IsSent)
+	assert.True(t, r.IsAcked())
+
+	// the acknowledgement expires
+	r.Stat.NotificationStrategyData.IsSent = true
+	Process(r, probe.StatusDown, now.Add(time.Hour))
+	assert.False(t, r.IsAcked())
+	assert.True(t, r.Stat.NotificationStrategyData.IsSent)
+	assert.NotEmpty(t, r.AckURL)
+
+	// the acknowledgement is cleared when the probe recovers
+	r.Stat.Ack = &probe.AckData{By: "alice", Time: now}
+	Process(r, probe.StatusDown, now.Add(24*time.Hour))
+	assert.True(t, r.IsAcked())
+	Process(r, probe.StatusUp, now.Add(24*time.Hour))
+	assert.False(t, r.IsAcked())
+}
+
+func TestRequest(t *testing.T) {
+	d, err := ParseExpire("")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), d)
+	d, err = ParseExpire(" 90m ")
+	assert.Nil(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+	_, err = ParseExpire("-1h")
+	assert.NotNil(t, err)
+	_, err = ParseExpire("soon")
+	assert.NotNil(t, err)
+
+	now := time.Now()
+	req := Request{Probe: "web", By: " alice ", Comment: " on it ", Expire: time.Hour}
+	a := req.Data(now)
+	assert.Equal(t, "alice", a.By)
+	assert.Equal(t, "on it", a.Comment)
+	assert.Equal(t, now.Add(time.Hour), a.ExpireTime())
+	assert.Equal(t, "✅ web is acknowledged by alice for 1h0m0s - on it", Message("web", &a))
+}
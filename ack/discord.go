@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ack
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DiscordCommand is the name of the slash command to acknowledge the failure,
+// e.g. `/ack probe:<name> comment:<comment> expire:1h`
+const DiscordCommand = "ack"
+
+// the interaction types of Discord
+// refer to: https://discord.com/developers/docs/interactions/receiving-and-responding
+const (
+	discordPing                 = 1
+	discordApplicationCommand   = 2
+	discordPong                 = 1
+	discordChannelMessageSource = 4
+)
+
+type discordUser struct {
+	Username   string `json:"username"`
+	GlobalName string `json:"global_name"`
+}
+
+type discordInteraction struct {
+	Type int `json:"type"`
+	Data struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string      `json:"name"`
+			Value interface{} `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+	Member *struct {
+		User discordUser `json:"user"`
+	} `json:"member"`
+	User *discordUser `json:"user"`
+}
+
+func (i *discordInteraction) user() string {
+	u := i.User
+	if i.Member != nil {
+		u = &i.Member.User
+	}
+	if u == nil {
+		return "discord"
+	}
+	if len(u.GlobalName) > 0 {
+		return u.GlobalName
+	}
+	return u.Username
+}
+
+func (i *discordInteraction) option(name string) string {
+	for _, o := range i.Data.Options {
+		if o.Name == name {
+			if s, ok := o.Value.(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// verifyDiscord verifies the Ed25519 signature of the Discord interaction
+func verifyDiscord(key ed25519.PublicKey, header http.Header, body []byte) bool {
+	sig, err := hex.DecodeString(header.Get("X-Signature-Ed25519"))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	msg := append([]byte(header.Get("X-Signature-Timestamp")), body...)
+	return ed25519.Verify(key, msg, sig)
+}
+
+// DiscordHandler handles the interaction of the Discord application, it's the
+// `/ack` slash command, because the buttons cannot be sent by the webhook.
+func DiscordHandler(fn Func) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		mutex.RLock()
+		key := publicKey
+		mutex.RUnlock()
+		if len(key) <= 0 {
+			http.Error(w, "the Discord acknowledgement is disabled", http.StatusNotFound)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(req.Body, 64*1024))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// Discord requires 401 for the invalid signature
+		if !verifyDiscord(key, req.Header, body) {
+			log.Warnf("[%s] Unauthorized Discord request from %s", kind, req.RemoteAddr)
+			http.Error(w, "invalid request signature", http.StatusUnauthorized)
+			return
+		}
+		var interaction discordInteraction
+		if err := json.Unmarshal(body, &interaction); err != nil {
+			http.Error(w, fmt.Sprintf("invalid interaction - %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if interaction.Type == discordPing {
+			writeJSON(w, map[string]interface{}{"type": discordPong})
+			return
+		}
+		if interaction.Type != discordApplicationCommand || interaction.Data.Name != DiscordCommand {
+			http.Error(w, "unknown interaction", http.StatusBadRequest)
+			return
+		}
+
+		text := ""
+		name := interaction.option("probe")
+		expire, err := ParseExpire(interaction.option("expire"))
+		if err != nil {
+			text = fmt.Sprintf("❌ Failed to acknowledge %s - %v", name, err)
+		} else if a, err := fn(Request{
+			Probe:   name,
+			By:      interaction.user(),
+			Comment: interaction.option("comment"),
+			Expire:  expire,
+		}); err != nil {
+			text = fmt.Sprintf("❌ Failed to acknowledge %s - %v", name, err)
+		} else {
+			text = Message(name, a)
+		}
+		writeJSON(w, map[string]interface{}{
+			"type": discordChannelMessageSource,
+			"data": map[string]interface{}{"content": text},
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ack
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func discordRequest(key ed25519.PrivateKey, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/ack/discord", strings.NewReader(body))
+	ts := "1700000000"
+	req.Header.Set("X-Signature-Timestamp", ts)
+	req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, []byte(ts+body))))
+	return req
+}
+
+func TestDiscord(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	acked := []Request{}
+	fn := func(req Request) (*probe.AckData, error) {
+		acked = append(acked, req)
+		return &probe.AckData{By: req.By, Comment: req.Comment, Expire: req.Expire}, nil
+	}
+	handler := DiscordHandler(fn)
+
+	w := httptest.NewRecorder()
+	handler(w, discordRequest(priv, `{"type":1}`))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	SetSettings(Settings{DiscordPublicKey: hex.EncodeToString(pub)}, "")
+	defer SetSettings(Settings{}, "")
+
+	w = httptest.NewRecorder()
+	handler(w, discordRequest(other, `{"type":1}`))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	handler(w, discordRequest(priv, `{"type":1}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"type":1}`, w.Body.String())
+
+	body := `{"type":2,"data":{"name":"ack","options":[{"name":"probe","value":"web"},{"name":"comment","value":"on it"},{"name":"expire","value":"1h"}]},` +
+		`"member":{"user":{"username":"alice","global_name":"Alice"}}}`
+	w = httptest.NewRecorder()
+	handler(w, discordRequest(priv, body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []Request{{Probe: "web", By: "Alice", Comment: "on it", Expire: time.Hour}}, acked)
+	reply := struct {
+		Type int `json:"type"`
+		Data struct {
+			Content string `json:"content"`
+		} `json:"data"`
+	}{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &reply))
+	assert.Equal(t, 4, reply.Type)
+	assert.Equal(t, "✅ web is acknowledged by Alice for 1h0m0s - on it", reply.Data.Content)
+
+	// the invalid expire is replied
+	w = httptest.NewRecorder()
+	handler(w, discordRequest(priv, strings.Replace(body, `"1h"`, `"soon"`, 1)))
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &reply))
+	assert.Contains(t, reply.Data.Content, "Failed to acknowledge web")
+	assert.Len(t, acked, 1)
+
+	w = httptest.NewRecorder()
+	handler(w, discordRequest(priv, `{"type":2,"data":{"name":"other"}}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ack
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SlackActionID is the action id of the acknowledgement button of the Slack message.
+// The buttons of the grouped message are suffixed by the index, e.g. "easeprobe_ack_1",
+// because the action ids must be unique in a block.
+const SlackActionID = "easeprobe_ack"
+
+// the max age of the Slack request, the older request is rejected to avoid the replay attack
+const slackMaxAge = 5 * time.Minute
+
+type slackPayload struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+		Name     string `json:"name"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	ResponseURL string `json:"response_url"`
+}
+
+func (p *slackPayload) user() string {
+	for _, u := range []string{p.User.Username, p.User.Name, p.User.ID} {
+		if len(u) > 0 {
+			return u
+		}
+	}
+	return "slack"
+}
+
+// verifySlack verifies the signature of the Slack request
+// refer to: https://api.slack.com/authentication/verifying-requests-from-slack
+func verifySlack(secret string, header http.Header, body []byte, now time.Time) error {
+	ts := header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp [%s]", ts)
+	}
+	if age := now.Sub(time.Unix(sec, 0)); age > slackMaxAge || age < -slackMaxAge {
+		return fmt.Errorf("the request is expired - %s", ts)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// SlackHandler handles the interactive request of the Slack app, which is sent
+// when the acknowledgement button of the message is clicked.
+func SlackHandler(fn Func) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		mutex.RLock()
+		secret := settings.SlackSigningSecret
+		mutex.RUnlock()
+		if len(secret) <= 0 {
+			http.Error(w, "the Slack acknowledgement is disabled", http.StatusNotFound)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(req.Body, 64*1024))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := verifySlack(secret, req.Header, body, time.Now()); err != nil {
+			log.Warnf("[%s] Unauthorized Slack request from %s - %v", kind, req.RemoteAddr, err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var payload slackPayload
+		if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+			http.Error(w, fmt.Sprintf("invalid payload - %v", err), http.StatusBadRequest)
+			return
+		}
+
+		for _, action := range payload.Actions {
+			if !isSlackAction(action.ActionID) {
+				continue
+			}
+			text := ""
+			a, err := fn(Request{Probe: action.Value, By: payload.user()})
+			if err != nil {
+				text = fmt.Sprintf("❌ Failed to acknowledge %s - %v", action.Value, err)
+			} else {
+				text = Message(action.Value, a)
+			}
+			if len(payload.ResponseURL) > 0 {
+				go slackReply(payload.ResponseURL, text)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// isSlackAction returns true if the action is the acknowledgement button
+func isSlackAction(id string) bool {
+	return id == SlackActionID || strings.HasPrefix(id, SlackActionID+"_")
+}
+
+// slackReply posts the result of the acknowledgement to the channel of the message
+func slackReply(responseURL, text string) {
+	body, err := json.Marshal(map[string]interface{}{
+		"response_type":    "in_channel",
+		"replace_original": false,
+		"text":             text,
+	})
+	if err != nil {
+		log.Errorf("[%s] Failed to reply the Slack action - %v", kind, err)
+		return
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(responseURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Errorf("[%s] Failed to reply the Slack action - %v", kind, err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		buf, _ := io.ReadAll(resp.Body)
+		log.Errorf("[%s] Failed to reply the Slack action - code [%d] - msg [%s]", kind, resp.StatusCode, string(buf))
+	}
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func slackRequest(secret string, ts time.Time, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/ack/slack", strings.NewReader(body))
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestSlack(t *testing.T) {
+	var mu sync.Mutex
+	replies := []string{}
+	reply := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		buf := make([]byte, 1024)
+		n, _ := req.Body.Read(buf)
+		mu.Lock()
+		replies = append(replies, string(buf[:n]))
+		mu.Unlock()
+	}))
+	defer reply.Close()
+
+	acked := []Request{}
+	fn := func(req Request) (*probe.AckData, error) {
+		if req.Probe != "web" {
+			return nil, fmt.Errorf("probe [%s] is not found", req.Probe)
+		}
+		acked = append(acked, req)
+		return &probe.AckData{By: req.By}, nil
+	}
+	handler := SlackHandler(fn)
+	payload := `{"type":"block_actions","user":{"id":"U1","username":"alice"},"response_url":"` + reply.URL + `",` +
+		`"actions":[{"action_id":"` + SlackActionID + `","value":"web"},{"action_id":"other","value":"db"}]}`
+	body := url.Values{"payload": {payload}}.Encode()
+
+	// disabled
+	w := httptest.NewRecorder()
+	handler(w, slackRequest("secret", time.Now(), body))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	SetSettings(Settings{SlackSigningSecret: "secret"}, "")
+	defer SetSettings(Settings{}, "")
+
+	w = httptest.NewRecorder()
+	handler(w, slackRequest("bad", time.Now(), body))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = httptest.NewRecorder()
+	handler(w, slackRequest("secret", time.Now().Add(-time.Hour), body))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, acked)
+
+	w = httptest.NewRecorder()
+	handler(w, slackRequest("secret", time.Now(), body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []Request{{Probe: "web", By: "alice"}}, acked)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(replies) == 1 && strings.Contains(replies[0], "web is acknowledged by alice")
+	}, time.Second, 10*time.Millisecond)
+
+	// the failure is replied as well
+	payload = strings.Replace(payload, `"value":"web"`, `"value":"none"`, 1)
+	w = httptest.NewRecorder()
+	handler(w, slackRequest("secret", time.Now(), url.Values{"payload": {payload}}.Encode()))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(replies) == 2 && strings.Contains(replies[1], "Failed to acknowledge none")
+	}, time.Second, 10*time.Millisecond)
+
+	// the button of the grouped message has the index suffix
+	payload = strings.Replace(payload, `"action_id":"`+SlackActionID+`","value":"none"`,
+		`"action_id":"`+SlackActionID+`_1","value":"web"`, 1)
+	w = httptest.NewRecorder()
+	handler(w, slackRequest("secret", time.Now(), url.Values{"payload": {payload}}.Encode()))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, acked, 2)
+	assert.False(t, isSlackAction(SlackActionID+"x"))
+
+	w = httptest.NewRecorder()
+	handler(w, slackRequest("secret", time.Now(), "payload=bad"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ack
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// the prefix of the callback data of the acknowledgement button
+const telegramCallbackPrefix = "ack:"
+
+// the max length of the callback data of the Telegram inline keyboard button
+const telegramMaxCallbackData = 64
+
+// TelegramCallbackData returns the callback data of the acknowledgement button of the probe,
+// it returns false if the probe name is too long for the button.
+func TelegramCallbackData(name string) (string, bool) {
+	data := telegramCallbackPrefix + name
+	return data, len(data) <= telegramMaxCallbackData
+}
+
+type telegramUpdate struct {
+	CallbackQuery *struct {
+		ID   string `json:"id"`
+		From struct {
+			Username  string `json:"username"`
+			FirstName string `json:"first_name"`
+			LastName  string `json:"last_name"`
+		} `json:"from"`
+		Message *struct {
+			MessageID int64 `json:"message_id"`
+			Chat      struct {
+				ID int64 `json:"id"`
+			} `json:"chat"`
+		} `json:"message"`
+		Data string `json:"data"`
+	} `json:"callback_query"`
+}
+
+func (u *telegramUpdate) user() string {
+	from := u.CallbackQuery.From
+	if len(from.Username) > 0 {
+		return "@" + from.Username
+	}
+	if name := strings.TrimSpace(from.FirstName + " " + from.LastName); len(name) > 0 {
+		return name
+	}
+	return "telegram"
+}
+
+// TelegramHandler handles the webhook update of the Telegram bot, which is sent when
+// the acknowledgement button of the message is clicked. The result is replied to the
+// chat in the response of the webhook, so that the bot token is not required.
+func TelegramHandler(fn Func) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		mutex.RLock()
+		secret := settings.TelegramSecretToken
+		mutex.RUnlock()
+		if len(secret) <= 0 {
+			http.Error(w, "the Telegram acknowledgement is disabled", http.StatusNotFound)
+			return
+		}
+		token := req.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			log.Warnf("[%s] Unauthorized Telegram request from %s", kind, req.RemoteAddr)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		var update telegramUpdate
+		if err := json.NewDecoder(io.LimitReader(req.Body, 64*1024)).Decode(&update); err != nil {
+			http.Error(w, fmt.Sprintf("invalid update - %v", err), http.StatusBadRequest)
+			return
+		}
+		// the other updates of the bot are ignored
+		cq := update.CallbackQuery
+		if cq == nil || !strings.HasPrefix(cq.Data, telegramCallbackPrefix) {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		name := strings.TrimPrefix(cq.Data, telegramCallbackPrefix)
+		text := ""
+		a, err := fn(Request{Probe: name, By: update.user()})
+		if err != nil {
+			text = fmt.Sprintf("❌ Failed to acknowledge %s - %v", name, err)
+		} else {
+			text = Message(name, a)
+		}
+
+		reply := map[string]interface{}{
+			"method":            "answerCallbackQuery",
+			"callback_query_id": cq.ID,
+			"text":              text,
+		}
+		// reply to the chat, so that everyone knows the failure is acknowledged
+		if err == nil && cq.Message != nil {
+			reply = map[string]interface{}{
+				"method":              "sendMessage",
+				"chat_id":             cq.Message.Chat.ID,
+				"reply_to_message_id": cq.Message.MessageID,
+				"text":                text,
+			}
+		}
+		writeJSON(w, reply)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(buf)
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func telegramRequest(token, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/ack/telegram", strings.NewReader(body))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", token)
+	return req
+}
+
+func TestTelegram(t *testing.T) {
+	data, ok := TelegramCallbackData("web")
+	assert.True(t, ok)
+	assert.Equal(t, "ack:web", data)
+	_, ok = TelegramCallbackData(strings.Repeat("x", 61))
+	assert.False(t, ok)
+
+	fn := func(req Request) (*probe.AckData, error) {
+		if req.Probe != "web" {
+			return nil, fmt.Errorf("probe [%s] is not found", req.Probe)
+		}
+		return &probe.AckData{By: req.By}, nil
+	}
+	handler := TelegramHandler(fn)
+	body := `{"callback_query":{"id":"42","from":{"username":"alice"},"message":{"message_id":7,"chat":{"id":-100}},"data":"ack:web"}}`
+
+	w := httptest.NewRecorder()
+	handler(w, telegramRequest("secret", body))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	SetSettings(Settings{TelegramSecretToken: "secret"}, "")
+	defer SetSettings(Settings{}, "")
+
+	w = httptest.NewRecorder()
+	handler(w, telegramRequest("bad", body))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// the result is replied to the chat
+	w = httptest.NewRecorder()
+	handler(w, telegramRequest("secret", body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	reply := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &reply))
+	assert.Equal(t, "sendMessage", reply["method"])
+	assert.Equal(t, float64(-100), reply["chat_id"])
+	assert.Equal(t, float64(7), reply["reply_to_message_id"])
+	assert.Equal(t, "✅ web is acknowledged by @alice", reply["text"])
+
+	// the failure is answered to the user
+	w = httptest.NewRecorder()
+	handler(w, telegramRequest("secret", strings.Replace(body, "ack:web", "ack:db", 1)))
+	reply = map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &reply))
+	assert.Equal(t, "answerCallbackQuery", reply["method"])
+	assert.Equal(t, "42", reply["callback_query_id"])
+	assert.Contains(t, reply["text"], "Failed to acknowledge db")
+
+	// the other updates are ignored
+	w = httptest.NewRecorder()
+	handler(w, telegramRequest("secret", `{"message":{"text":"hello"}}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler(w, telegramRequest("secret", "bad"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -37,7 +37,7 @@ type Channel struct {
 	isWatch   int32                    `yaml:"-"`         // is watch
 	done      chan bool                `yaml:"-"`         // done channel
 	channel   chan probe.Result        `yaml:"-"`         // notify channel
-
+	mutex     sync.RWMutex             `yaml:"-"`         // protect the probers and notifiers
 }
 
 // NewEmpty creates a new empty Channel object with nil channel
@@ -76,6 +76,8 @@ func (c *Channel) Send(result probe.Result) {
 
 // GetProber returns the Notify object
 func (c *Channel) GetProber(name string) probe.Prober {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	return c.Probers[name]
 }
 
@@ -91,15 +93,29 @@ func (c *Channel) SetProber(p probe.Prober) {
 	if p == nil {
 		return
 	}
-	if _, ok := c.Probers[p.Name()]; ok {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if old, ok := c.Probers[p.Name()]; ok {
+		if old == p {
+			return
+		}
 		log.Errorf("Prober [%s - %s] name is duplicated, ignored!", p.Kind(), p.Name())
 		return
 	}
 	c.Probers[p.Name()] = p
 }
 
+// RemoveProber removes the Prober object
+func (c *Channel) RemoveProber(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.Probers, name)
+}
+
 // GetNotify returns the Notify object
 func (c *Channel) GetNotify(name string) notify.Notify {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	return c.Notifiers[name]
 }
 
@@ -115,6 +131,8 @@ func (c *Channel) SetNotify(n notify.Notify) {
 	if n == nil {
 		return
 	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	if _, ok := c.Notifiers[n.Name()]; ok {
 		log.Errorf("Notifier [%s - %s] name is duplicated, ignored!", n.Kind(), n.Name())
 		return
@@ -122,6 +140,35 @@ func (c *Channel) SetNotify(n notify.Notify) {
 	c.Notifiers[n.Name()] = n
 }
 
+// replace replaces the probers and notifiers of the channel at runtime,
+// it returns true if the notifiers are changed.
+func (c *Channel) replace(probers map[string]probe.Prober, notifiers map[string]notify.Notify) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	changed := len(c.Notifiers) != len(notifiers)
+	for name, n := range notifiers {
+		if c.Notifiers[name] != n {
+			changed = true
+		}
+	}
+	c.Probers = probers
+	c.Notifiers = notifiers
+	return changed
+}
+
+// notifiers returns the notifiers of the channel
+func (c *Channel) notifiers() []notify.Notify {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	notifiers := make([]notify.Notify, 0, len(c.Notifiers))
+	for _, n := range c.Notifiers {
+		notifiers = append(notifiers, n)
+	}
+	return notifiers
+}
+
 // WatchEvent watches the notification event
 // Go through all of notification to notify the result.
 func (c *Channel) WatchEvent(wg *sync.WaitGroup) {
@@ -146,50 +193,69 @@ func (c *Channel) WatchEvent(wg *sync.WaitGroup) {
 			log.Infof("[%s / %s]: Received the done signal, channel exiting...", kind, c.Name)
 			return
 		case result := <-c.channel:
-			// if it is the first time, and the status is UP, no need notify
-			if result.PreStatus == probe.StatusInit && result.Status == probe.StatusUp {
-				log.Debugf("[%s / %s]: %s (%s) - Initial Status [%s] == [%s], no notification.",
-					kind, c.Name, result.Name, result.Endpoint, result.PreStatus, result.Status)
+			if !notifiable(c.Name, &result) {
 				continue
 			}
 
-			// if the status has no change for UP or Init, no need notify
-			if result.PreStatus == result.Status && (result.Status == probe.StatusUp || result.Status == probe.StatusInit) {
-				log.Debugf("[%s / %s]: %s (%s) - Status no change [%s] == [%s], no notification.",
-					kind, c.Name, result.Name, result.Endpoint, result.PreStatus, result.Status)
-				continue
+			for _, n := range c.notifiers() {
+				send(n, result)
 			}
+		}
+	}
+}
 
-			nsd := &result.Stat.NotificationStrategyData
-			// if the status changed to UP, reset the notification strategy
-			if result.Status == probe.StatusUp {
-				nsd.Reset()
-			}
+// notifiable checks whether the probe result needs to be notified, the name is the
+// channel or the router which receives the result.
+func notifiable(name string, result *probe.Result) bool {
+	// if it is the first time, and the status is UP, no need notify
+	if result.PreStatus == probe.StatusInit && result.Status == probe.StatusUp {
+		log.Debugf("[%s / %s]: %s (%s) - Initial Status [%s] == [%s], no notification.",
+			kind, name, result.Name, result.Endpoint, result.PreStatus, result.Status)
+		return false
+	}
 
-			// if the status is DOWN, check the notification strategy
-			if result.Status == probe.StatusDown {
-				if result.Stat.NotificationStrategyData.NeedToSendNotification() == false {
-					log.Debugf("[%s / %s]: %s (%s) - Don't meet the notification condition [max=%d, notified=%d, failed=%d, next=%d], no notification.",
-						kind, c.Name, result.Name, result.Endpoint, nsd.MaxTimes, nsd.Notified, nsd.Failed, nsd.Next)
-					continue
-				}
-			}
+	// if the probe is in the maintenance window, no need notify
+	if result.InMaintenance() {
+		log.Infof("[%s / %s]: %s (%s) - In the maintenance window [%s], notification is suppressed.",
+			kind, name, result.Name, result.Endpoint, result.Maintenance)
+		return false
+	}
 
-			if result.PreStatus != result.Status {
-				log.Infof("[%s / %s]: %s (%s) - Status changed [%s] ==> [%s], sending notification...",
-					kind, c.Name, result.Name, result.Endpoint, result.PreStatus, result.Status)
-			} else {
-				log.Debugf("[%s / %s]: %s (%s) - Meet the notification condition [max=%d, notified=%d, failed=%d, next=%d], sending notification...",
-					kind, c.Name, result.Name, result.Endpoint, nsd.MaxTimes, nsd.Notified, nsd.Failed, nsd.Next)
-			}
+	// if the failure or its recovery is caused by the parent probe, no need notify
+	if len(result.SuppressedBy) > 0 {
+		log.Infof("[%s / %s]: %s (%s) - The parent [%s] is down, notification is suppressed.",
+			kind, name, result.Name, result.Endpoint, result.SuppressedBy)
+		return false
+	}
 
-			for _, n := range c.Notifiers {
-				if IsDryNotify() == true {
-					n.DryNotify(result)
-				} else {
-					go n.Notify(result)
-				}
-			}
+	// if the status has no change for UP, WARNING or Init, no need notify
+	if result.PreStatus == result.Status && (result.Status.IsAvailable() || result.Status == probe.StatusInit) {
+		log.Debugf("[%s / %s]: %s (%s) - Status no change [%s] == [%s], no notification.",
+			kind, name, result.Name, result.Endpoint, result.PreStatus, result.Status)
+		return false
+	}
+
+	nsd := &result.Stat.NotificationStrategyData
+	// if the status changed to UP or WARNING, reset the notification strategy
+	if result.Status.IsAvailable() {
+		nsd.Reset()
+	}
+
+	// if the status is DOWN, check the notification strategy
+	if result.Status == probe.StatusDown {
+		if result.Stat.NotificationStrategyData.NeedToSendNotification() == false {
+			log.Debugf("[%s / %s]: %s (%s) - Don't meet the notification condition [max=%d, notified=%d, failed=%d, next=%d], no notification.",
+				kind, name, result.Name, result.Endpoint, nsd.MaxTimes, nsd.Notified, nsd.Failed, nsd.Next)
+			return false
 		}
 	}
+
+	if result.PreStatus != result.Status {
+		log.Infof("[%s / %s]: %s (%s) - Status changed [%s] ==> [%s], sending notification...",
+			kind, name, result.Name, result.Endpoint, result.PreStatus, result.Status)
+	} else {
+		log.Debugf("[%s / %s]: %s (%s) - Meet the notification condition [max=%d, notified=%d, failed=%d, next=%d], sending notification...",
+			kind, name, result.Name, result.Endpoint, nsd.MaxTimes, nsd.Notified, nsd.Failed, nsd.Next)
+	}
+	return true
 }
@@ -0,0 +1,56 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package channel
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/notify"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+// EscalatedNotifiers returns the notifiers of the escalation stages which are reached or
+// recovered by the probe result. If the status is changed, the notifiers of the probe
+// channels are excluded, because the channels notify them.
+func EscalatedNotifiers(result *probe.Result) []notify.Notify {
+	names := result.Escalated
+	if len(names) <= 0 {
+		return nil
+	}
+
+	all := allNotifiers()
+	excluded := map[string]bool{}
+	if result.PreStatus != result.Status {
+		excluded = channelNotifiers(result.Name)
+	}
+	notifiers := []notify.Notify{}
+	for _, name := range names {
+		n, ok := all[name]
+		if !ok {
+			log.Warnf("[%s / %s] The escalation notifier [%s] is not found", kind, routerName, name)
+			continue
+		}
+		if excluded[name] {
+			continue
+		}
+		excluded[name] = true
+		log.Infof("[%s / %s]: %s (%s) - Escalated to the notifier [%s]",
+			kind, routerName, result.Name, result.Endpoint, name)
+		notifiers = append(notifiers, n)
+	}
+	return notifiers
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package channel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/notify"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func TestEscalatedNotifiers(t *testing.T) {
+	p := newDummyProber("http", "", "escalate-web", []string{"web"})
+	nWeb := newDummyNotify("email", "escalate-chat", []string{"web"})
+	nSMS := newDummyNotify("sms", "escalate-sms", []string{"nobody"})
+	SetProbers([]probe.Prober{p})
+	SetNotifiers([]notify.Notify{nWeb, nSMS})
+	defer Rewire(nil, nil)
+
+	names := func(notifiers []notify.Notify) []string {
+		list := []string{}
+		for _, n := range notifiers {
+			list = append(list, n.Name())
+		}
+		return list
+	}
+
+	r := &probe.Result{Name: "escalate-web", Status: probe.StatusDown, PreStatus: probe.StatusDown}
+	assert.Nil(t, EscalatedNotifiers(r))
+
+	// the unknown and duplicated notifiers are ignored
+	r.Escalated = []string{"escalate-chat", "escalate-sms", "unknown", "escalate-sms"}
+	assert.Equal(t, []string{"escalate-chat", "escalate-sms"}, names(EscalatedNotifiers(r)))
+
+	// the status is changed, the notifier of the probe channel has been notified
+	r.PreStatus = probe.StatusUp
+	assert.Equal(t, []string{"escalate-sms"}, names(EscalatedNotifiers(r)))
+}
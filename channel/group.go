@@ -0,0 +1,213 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package channel
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/notify"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+// groupable is the notifier which groups the events
+type groupable interface {
+	Grouping() (by []string, wait, interval time.Duration)
+}
+
+// alertGroup is the buffered events of a group
+type alertGroup struct {
+	key     string
+	results []probe.Result
+	timer   *time.Timer
+}
+
+// grouper buffers the events of a notifier, and sends them in one message.
+// The events of a new group are sent after the wait, and the follow-up events
+// are sent as the digest every interval, the group expires if no event comes
+// in an interval.
+type grouper struct {
+	notifier notify.Notify
+	by       []string
+	wait     time.Duration
+	interval time.Duration
+	mutex    sync.Mutex
+	groups   map[string]*alertGroup
+}
+
+var groupers = map[notify.Notify]*grouper{}
+var groupersMutex sync.Mutex
+
+// getGrouper returns the grouper of the notifier, it returns nil if the grouping is disabled
+func getGrouper(n notify.Notify) *grouper {
+	g, ok := n.(groupable)
+	if !ok {
+		return nil
+	}
+	by, wait, interval := g.Grouping()
+	if wait <= 0 {
+		return nil
+	}
+
+	groupersMutex.Lock()
+	defer groupersMutex.Unlock()
+	if gr, ok := groupers[n]; ok {
+		return gr
+	}
+	gr := &grouper{
+		notifier: n,
+		by:       by,
+		wait:     wait,
+		interval: interval,
+		groups:   map[string]*alertGroup{},
+	}
+	groupers[n] = gr
+	return gr
+}
+
+// flushGroupers sends all of the buffered events of the notifiers which are not kept,
+// all of the groupers are flushed if the keep is nil.
+func flushGroupers(keep map[notify.Notify]bool) {
+	groupersMutex.Lock()
+	removed := []*grouper{}
+	for n, g := range groupers {
+		if keep != nil && keep[n] {
+			continue
+		}
+		removed = append(removed, g)
+		delete(groupers, n)
+	}
+	groupersMutex.Unlock()
+
+	for _, g := range removed {
+		g.flushAll()
+	}
+}
+
+// groupKey returns the group of the result, the "kind" is the probe kind, others are the label names
+func (g *grouper) groupKey(result *probe.Result) string {
+	values := make([]string, 0, len(g.by))
+	for _, by := range g.by {
+		if by == "kind" {
+			values = append(values, "kind="+result.Kind)
+			continue
+		}
+		values = append(values, by+"="+result.Labels[by])
+	}
+	return strings.Join(values, ",")
+}
+
+// add buffers the result. The previous result of the same probe in the group is replaced if
+// the status is the same, otherwise both are kept, so that a flap in the wait is not hidden.
+func (g *grouper) add(result probe.Result) {
+	key := g.groupKey(&result)
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	ag, ok := g.groups[key]
+	if !ok {
+		ag = &alertGroup{key: key}
+		g.groups[key] = ag
+		ag.timer = time.AfterFunc(g.wait, func() { g.tick(key) })
+		log.Debugf("[%s / %s]: %s - New alert group [%s], waiting %s",
+			kind, g.notifier.Name(), result.Name, key, g.wait)
+	}
+	for i := len(ag.results) - 1; i >= 0; i-- {
+		if ag.results[i].Name != result.Name {
+			continue
+		}
+		if ag.results[i].Status == result.Status {
+			ag.results[i] = result
+			return
+		}
+		break
+	}
+	ag.results = append(ag.results, result)
+}
+
+// tick sends the buffered events of the group, the group is removed if there is no event
+func (g *grouper) tick(key string) {
+	g.mutex.Lock()
+	ag, ok := g.groups[key]
+	if !ok {
+		g.mutex.Unlock()
+		return
+	}
+	results := ag.results
+	ag.results = nil
+	if len(results) <= 0 {
+		delete(g.groups, key)
+		log.Debugf("[%s / %s]: Alert group [%s] expired", kind, g.notifier.Name(), key)
+	} else {
+		ag.timer = time.AfterFunc(g.interval, func() { g.tick(key) })
+	}
+	g.mutex.Unlock()
+
+	g.send(key, results)
+}
+
+// flushAll stops the timers and sends all of the buffered events
+func (g *grouper) flushAll() {
+	g.mutex.Lock()
+	keys := make([]string, 0, len(g.groups))
+	for key := range g.groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	batches := make([][]probe.Result, 0, len(keys))
+	for _, key := range keys {
+		ag := g.groups[key]
+		ag.timer.Stop()
+		batches = append(batches, ag.results)
+	}
+	g.groups = map[string]*alertGroup{}
+	g.mutex.Unlock()
+
+	for i, results := range batches {
+		g.send(keys[i], results)
+	}
+}
+
+func (g *grouper) send(key string, results []probe.Result) {
+	if len(results) <= 0 {
+		return
+	}
+	log.Infof("[%s / %s]: Sending %d events of the alert group [%s]", kind, g.notifier.Name(), len(results), key)
+	if IsDryNotify() == true {
+		g.notifier.DryNotifyBatch(results)
+	} else {
+		g.notifier.NotifyBatch(results)
+	}
+}
+
+// send sends the result to the notifier, the result is buffered if the notifier groups the events
+func send(n notify.Notify, result probe.Result) {
+	if g := getGrouper(n); g != nil {
+		g.add(result)
+		return
+	}
+	if IsDryNotify() == true {
+		n.DryNotify(result)
+	} else {
+		go n.Notify(result)
+	}
+}
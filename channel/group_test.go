@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package channel
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/notify"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/report"
+)
+
+// batchNotify records the grouped results
+type batchNotify struct {
+	*dummyNotify
+	mutex   sync.Mutex
+	batches [][]string
+}
+
+func (b *batchNotify) NotifyBatch(results []probe.Result) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	names := []string{}
+	for _, r := range results {
+		names = append(names, r.Name)
+	}
+	b.batches = append(b.batches, names)
+}
+
+func (b *batchNotify) getBatches() [][]string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([][]string{}, b.batches...)
+}
+
+func TestGroup(t *testing.T) {
+	SetDryNotify(false)
+	n := &batchNotify{dummyNotify: newDummyNotify("email", "group", nil)}
+	n.GroupBy = []string{"kind", "env"}
+	n.GroupWait = 100 * time.Millisecond
+	n.GroupInterval = 200 * time.Millisecond
+	assert.Nil(t, n.Config(global.NotifySettings{}))
+
+	g := getGrouper(n)
+	assert.NotNil(t, g)
+	assert.Equal(t, g, getGrouper(n))
+	// the grouping is disabled
+	assert.Nil(t, getGrouper(newDummyNotify("email", "no-group", nil)))
+
+	result := func(name, kind, env string) probe.Result {
+		return probe.Result{Name: name, Kind: kind, Labels: map[string]string{"env": env}, Status: probe.StatusDown}
+	}
+	send(n, result("a", "http", "prod"))
+	send(n, result("b", "http", "prod"))
+	send(n, result("b", "http", "prod")) // the same probe is not duplicated
+	send(n, result("c", "tcp", "prod"))
+	// the flap keeps both of the failure and the recovery
+	up := result("c", "tcp", "prod")
+	up.Status = probe.StatusUp
+	send(n, up)
+	assert.Equal(t, "kind=http,env=prod", g.groupKey(&probe.Result{Kind: "http", Labels: map[string]string{"env": "prod"}}))
+
+	assert.Eventually(t, func() bool { return len(n.getBatches()) == 2 }, time.Second, 10*time.Millisecond)
+	batches := n.getBatches()
+	assert.ElementsMatch(t, [][]string{{"a", "b"}, {"c", "c"}}, batches)
+
+	// the follow-up events are sent as the digest after the interval
+	send(n, result("d", "http", "prod"))
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, n.getBatches(), 2)
+	assert.Eventually(t, func() bool { return len(n.getBatches()) == 3 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"d"}, n.getBatches()[2])
+
+	// the idle groups expire
+	assert.Eventually(t, func() bool {
+		g.mutex.Lock()
+		defer g.mutex.Unlock()
+		return len(g.groups) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// the buffered events are sent when the notifier is removed
+	send(n, result("e", "http", "prod"))
+	flushGroupers(map[notify.Notify]bool{})
+	assert.Equal(t, []string{"e"}, n.getBatches()[3])
+	assert.NotSame(t, g, getGrouper(n))
+	flushGroupers(nil)
+}
+
+func TestGroupFallback(t *testing.T) {
+	SetDryNotify(false)
+	sent := make(chan string, 10)
+	n := newDummyNotify("email", "group-fallback", nil)
+	n.NotifyFormat = report.JSON
+	n.NotifySendFunc = func(title, msg string) error {
+		sent <- title
+		return nil
+	}
+	n.GroupWait = 50 * time.Millisecond
+	assert.Nil(t, n.Config(global.NotifySettings{}))
+
+	send(n, probe.Result{Name: "x", Status: probe.StatusDown, PreStatus: probe.StatusUp})
+	send(n, probe.Result{Name: "y", Status: probe.StatusDown, PreStatus: probe.StatusUp})
+	// the JSON format cannot render the grouped results, they are sent one by one
+	titles := []string{<-sent, <-sent}
+	assert.ElementsMatch(t, []string{"x Failure", "y Failure"}, titles)
+	flushGroupers(nil)
+}
+
+func TestGroupSlack(t *testing.T) {
+	SetDryNotify(false)
+	sent := make(chan string, 10)
+	n := newDummyNotify("slack", "group-slack", nil)
+	n.NotifyFormat = report.Slack
+	n.NotifySendFunc = func(title, msg string) error {
+		sent <- title
+		sent <- msg
+		return nil
+	}
+	n.GroupWait = 50 * time.Millisecond
+	assert.Nil(t, n.Config(global.NotifySettings{}))
+
+	for i := 0; i < 40; i++ {
+		send(n, probe.Result{Name: fmt.Sprintf("p%d", i), Status: probe.StatusDown, PreStatus: probe.StatusUp})
+	}
+	// the grouped results are sent in one Slack message
+	assert.Equal(t, "40 Probes Changed (40 down)", <-sent)
+	msg := <-sent
+	assert.True(t, json.Valid([]byte(msg)))
+	assert.Contains(t, msg, "p39 Failure")
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, sent, 0)
+	flushGroupers(nil)
+}
@@ -21,11 +21,14 @@ import (
 	"sync"
 	"sync/atomic"
 
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/global"
 	"github.com/wfusion/easeprobe/notify"
 	"github.com/wfusion/easeprobe/probe"
 )
 
 var channel = make(map[string]*Channel)
+var mutex sync.RWMutex
 var wg sync.WaitGroup
 var dryNotify atomic.Value
 
@@ -45,18 +48,28 @@ func IsDryNotify() bool {
 
 // GetAllChannels returns all channels
 func GetAllChannels() map[string]*Channel {
-	return channel
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	channels := make(map[string]*Channel, len(channel))
+	for name, ch := range channel {
+		channels[name] = ch
+	}
+	return channels
 }
 
 // GetChannel returns the channel
 func GetChannel(name string) *Channel {
+	mutex.RLock()
+	defer mutex.RUnlock()
 	return channel[name]
 }
 
 // SetChannel sets the channel
 func SetChannel(name string) {
-	ch := GetChannel(name)
-	if ch == nil {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if _, ok := channel[name]; !ok {
 		channel[name] = NewEmpty(name)
 	}
 }
@@ -80,6 +93,13 @@ func SetProber(channel string, p probe.Prober) {
 	ch.SetProber(p)
 }
 
+// RemoveProber removes the prober from all of the channels
+func RemoveProber(p probe.Prober) {
+	for _, ch := range GetAllChannels() {
+		ch.RemoveProber(p.Name())
+	}
+}
+
 // SetNotifiers set a notify to the channel
 func SetNotifiers(notifiers []notify.Notify) {
 	for _, n := range notifiers {
@@ -117,22 +137,94 @@ func GetNotifiers(channel []string) map[string]notify.Notify {
 
 // ConfigAllChannels config all channels
 func ConfigAllChannels() {
-	for _, c := range channel {
+	for _, c := range GetAllChannels() {
 		c.Config()
 	}
 }
 
 // WatchForAllEvents watch the event for all channels
 func WatchForAllEvents() {
-	for _, c := range channel {
+	for _, c := range GetAllChannels() {
 		go c.WatchEvent(&wg)
 	}
+	startRouter(&wg)
 }
 
 // AllDone send the done signal to all channels
 func AllDone() {
-	for _, c := range channel {
+	for _, c := range GetAllChannels() {
 		c.Done() <- true
 	}
+	stopRouter()
 	wg.Wait()
+	// send the buffered events of the alert groups
+	flushGroupers(nil)
+}
+
+// channelNames returns the channel names, or the default channel if it is empty
+func channelNames(names []string) []string {
+	if len(names) <= 0 {
+		return []string{global.DefaultChannelName}
+	}
+	return names
+}
+
+// Rewire rebuilds the channels for the probers and notifiers at runtime.
+// The channel which is not used anymore is stopped and removed, the new channel
+// is configured and starts to watch the events, and the existing channel keeps
+// running with the new probers and notifiers.
+func Rewire(probers []probe.Prober, notifiers []notify.Notify) {
+	wanted := map[string]*Channel{}
+	get := func(name string) *Channel {
+		if _, ok := wanted[name]; !ok {
+			wanted[name] = NewEmpty(name)
+		}
+		return wanted[name]
+	}
+	for _, p := range probers {
+		for _, name := range channelNames(p.Channels()) {
+			get(name).SetProber(p)
+		}
+	}
+	for _, n := range notifiers {
+		for _, name := range channelNames(n.Channels()) {
+			get(name).SetNotify(n)
+		}
+	}
+
+	// send the buffered events of the removed or changed notifiers
+	keep := map[notify.Notify]bool{}
+	for _, n := range notifiers {
+		keep[n] = true
+	}
+	flushGroupers(keep)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for name, ch := range channel {
+		if _, ok := wanted[name]; ok {
+			continue
+		}
+		if atomic.LoadInt32(&ch.isWatch) == 1 {
+			ch.Done() <- true
+		}
+		delete(channel, name)
+		log.Infof("[%s / %s]: Channel is not used anymore, removed.", kind, name)
+	}
+
+	for name, w := range wanted {
+		ch, ok := channel[name]
+		if !ok {
+			w.Config()
+			channel[name] = w
+			go w.WatchEvent(&wg)
+			log.Infof("[%s / %s]: Channel is added with %d probers and %d notifiers.",
+				kind, name, len(w.Probers), len(w.Notifiers))
+			continue
+		}
+		if ch.replace(w.Probers, w.Notifiers) {
+			log.Infof("[%s / %s]: Channel notifiers are changed, %d notifiers.", kind, name, len(w.Notifiers))
+		}
+	}
 }
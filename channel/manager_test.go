@@ -20,6 +20,7 @@ package channel
 import (
 	"fmt"
 	"runtime"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -202,6 +203,17 @@ func TestManager(t *testing.T) {
 	AllDone()
 }
 
+func TestRemoveProber(t *testing.T) {
+	p := newDummyProber("http", "", "dummy-remove", []string{"remove-A", "remove-B"})
+	SetProbers([]probe.Prober{p})
+	assert.NotNil(t, GetChannel("remove-A").GetProber("dummy-remove"))
+	assert.NotNil(t, GetChannel("remove-B").GetProber("dummy-remove"))
+
+	RemoveProber(p)
+	assert.Nil(t, GetChannel("remove-A").GetProber("dummy-remove"))
+	assert.Nil(t, GetChannel("remove-B").GetProber("dummy-remove"))
+}
+
 func TestLevelTrigger(t *testing.T) {
 	name := "test"
 	SetNotify(name, newDummyNotify("email", "level-trigger", []string{"test"}))
@@ -236,3 +248,43 @@ func TestLevelTrigger(t *testing.T) {
 
 	AllDone()
 }
+
+func TestRewire(t *testing.T) {
+	pA := newDummyProber("http", "", "rewire-A", []string{"A"})
+	pAB := newDummyProber("http", "", "rewire-AB", []string{"A", "B"})
+	nA := newDummyNotify("email", "notify-A", []string{"A"})
+	nB := newDummyNotify("email", "notify-B", []string{"B"})
+	nDefault := newDummyNotify("email", "notify-default", nil)
+
+	Rewire([]probe.Prober{pA, pAB}, []notify.Notify{nA, nB, nDefault})
+	chs := GetAllChannels()
+	assert.Equal(t, 3, len(chs))
+	a := GetChannel("A")
+	b := GetChannel("B")
+	assert.NotNil(t, a.GetProber("rewire-A"))
+	assert.NotNil(t, a.GetProber("rewire-AB"))
+	assert.NotNil(t, b.GetProber("rewire-AB"))
+	assert.Equal(t, nA, a.GetNotify("notify-A"))
+	assert.Equal(t, nDefault, GetChannel(global.DefaultChannelName).GetNotify("notify-default"))
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&a.isWatch) == 1 && atomic.LoadInt32(&b.isWatch) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// the same prober is not duplicated
+	a.SetProber(pA)
+	assert.Equal(t, 2, len(a.Probers))
+
+	// channel B is removed, channel A keeps running with the new notifier
+	nA2 := newDummyNotify("email", "notify-A2", []string{"A"})
+	Rewire([]probe.Prober{pA}, []notify.Notify{nA2})
+	assert.Nil(t, GetChannel("B"))
+	assert.Nil(t, GetChannel(global.DefaultChannelName))
+	assert.Equal(t, a, GetChannel("A"))
+	assert.Nil(t, a.GetProber("rewire-AB"))
+	assert.Nil(t, a.GetNotify("notify-A"))
+	assert.Equal(t, nA2, a.GetNotify("notify-A2"))
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&b.isWatch) == 0 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&a.isWatch))
+
+	AllDone()
+}
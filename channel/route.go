@@ -0,0 +1,217 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package channel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+// Route is the routing rule which sends the matched probe events to the notifiers.
+// The routes are evaluated in order, and the evaluation stops at the first matched
+// route unless its `continue` is true, which is the same as the Alertmanager routes.
+type Route struct {
+	Name      string   `yaml:"name,omitempty" json:"name,omitempty" jsonschema:"title=Name,description=the name of the route"`
+	Match     Match    `yaml:"match,omitempty" json:"match,omitempty" jsonschema:"title=Match,description=the conditions of the route - all of them must be matched"`
+	Notifiers []string `yaml:"notifiers" json:"notifiers" jsonschema:"required,title=Notifiers,description=the names of the notifiers which the matched events are sent to"`
+	Continue  bool     `yaml:"continue,omitempty" json:"continue,omitempty" jsonschema:"title=Continue,description=continue to evaluate the next routes after the route is matched"`
+}
+
+// Match is the conditions of the route, the condition which is not set matches any probe event.
+//   - kinds: the probe kind is any of them
+//   - name: the regular expression of the probe name
+//   - labels: the regular expressions of the probe label values, all of them must be matched
+//   - status: the probe status is any of them
+//   - time: the time of day when the event happens
+type Match struct {
+	Kinds  []string          `yaml:"kinds,omitempty" json:"kinds,omitempty" jsonschema:"title=Probe Kinds,description=the kinds of the probes"`
+	Name   string            `yaml:"name,omitempty" json:"name,omitempty" jsonschema:"title=Probe Name,description=the regular expression of the probe names,example=^db-.*"`
+	Labels map[string]string `yaml:"labels,omitempty" json:"labels,omitempty" jsonschema:"title=Probe Labels,description=the regular expressions of the probe label values"`
+	Status []probe.Status    `yaml:"status,omitempty" json:"status,omitempty" jsonschema:"type=array,title=Probe Status,description=the status of the probe events e.g. down or up"`
+	Time   *TimeOfDay        `yaml:"time,omitempty" json:"time,omitempty" jsonschema:"title=Time of Day,description=the time of day when the event happens"`
+
+	name   *regexp.Regexp
+	labels map[string]*regexp.Regexp
+}
+
+// TimeOfDay is the time range of a day in the time zone of the settings, e.g. the business hours.
+// The range is overnight if the end is not after the start, e.g. 22:00 - 06:00.
+type TimeOfDay struct {
+	Start    string   `yaml:"start" json:"start" jsonschema:"required,title=Start,description=the start time of day,example=09:00"`
+	End      string   `yaml:"end" json:"end" jsonschema:"required,title=End,description=the end time of day,example=18:00"`
+	Weekdays []string `yaml:"weekdays,omitempty" json:"weekdays,omitempty" jsonschema:"title=Weekdays,description=the days of the week e.g. mon or monday - all days if it's empty"`
+
+	start    time.Duration
+	end      time.Duration
+	weekdays map[time.Weekday]bool
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day [%s], it should be HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Config checks and configures the time of day
+func (t *TimeOfDay) Config() error {
+	var err error
+	if t.start, err = parseClock(t.Start); err != nil {
+		return err
+	}
+	if t.end, err = parseClock(t.End); err != nil {
+		return err
+	}
+	t.weekdays = map[time.Weekday]bool{}
+	for _, d := range t.Weekdays {
+		day := strings.ToLower(strings.TrimSpace(d))
+		if len(day) > 3 {
+			day = day[:3]
+		}
+		wd, ok := weekdays[day]
+		if !ok {
+			return fmt.Errorf("invalid weekday [%s]", d)
+		}
+		t.weekdays[wd] = true
+	}
+	return nil
+}
+
+// Contains returns true if the time is in the time range
+func (t *TimeOfDay) Contains(tm time.Time) bool {
+	tm = tm.In(global.GetTimeLocation())
+	if len(t.weekdays) > 0 && !t.weekdays[tm.Weekday()] {
+		return false
+	}
+	clock := time.Duration(tm.Hour())*time.Hour + time.Duration(tm.Minute())*time.Minute +
+		time.Duration(tm.Second())*time.Second
+	if t.start < t.end {
+		return clock >= t.start && clock < t.end
+	}
+	// overnight
+	return clock >= t.start || clock < t.end
+}
+
+// String returns the readable time range
+func (t *TimeOfDay) String() string {
+	s := t.Start + "-" + t.End
+	if len(t.Weekdays) > 0 {
+		s += " " + strings.Join(t.Weekdays, ",")
+	}
+	return s
+}
+
+// Config checks and configures the route, the name is generated by the index if it's empty
+func (r *Route) Config(idx int) error {
+	r.Name = strings.TrimSpace(r.Name)
+	if len(r.Name) <= 0 {
+		r.Name = fmt.Sprintf("route-%d", idx+1)
+	}
+	if len(r.Notifiers) <= 0 {
+		return fmt.Errorf("route [%s] - the notifiers are required", r.Name)
+	}
+
+	m := &r.Match
+	if len(m.Name) > 0 {
+		re, err := regexp.Compile(m.Name)
+		if err != nil {
+			return fmt.Errorf("route [%s] - invalid name regular expression [%s]: %v", r.Name, m.Name, err)
+		}
+		m.name = re
+	}
+	m.labels = map[string]*regexp.Regexp{}
+	for k, v := range m.Labels {
+		// the label value must be fully matched
+		re, err := regexp.Compile("^(?:" + v + ")$")
+		if err != nil {
+			return fmt.Errorf("route [%s] - invalid label [%s] regular expression [%s]: %v", r.Name, k, v, err)
+		}
+		m.labels[k] = re
+	}
+	if m.Time != nil {
+		if err := m.Time.Config(); err != nil {
+			return fmt.Errorf("route [%s] - %v", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// MatchProbe returns true if the probe matches the kinds, name and labels of the route
+func (r *Route) MatchProbe(name, kind string, labels map[string]string) bool {
+	m := &r.Match
+	if len(m.Kinds) > 0 {
+		found := false
+		for _, k := range m.Kinds {
+			if strings.EqualFold(k, kind) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if m.name != nil && !m.name.MatchString(name) {
+		return false
+	}
+	for k, re := range m.labels {
+		if v, ok := labels[k]; !ok || !re.MatchString(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchEvent returns true if the status and the time of the event match the route
+func (r *Route) MatchEvent(status probe.Status, t time.Time) bool {
+	m := &r.Match
+	if len(m.Status) > 0 {
+		found := false
+		for _, s := range m.Status {
+			if s == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return m.Time == nil || m.Time.Contains(t)
+}
+
+// IsStatic returns true if the route doesn't depend on the status and the time of the event
+func (r *Route) IsStatic() bool {
+	return len(r.Match.Status) <= 0 && r.Match.Time == nil
+}
+
+// Matches returns true if the probe result at the time t matches the route
+func (r *Route) Matches(result *probe.Result, t time.Time) bool {
+	return r.MatchProbe(result.Name, result.Kind, result.Labels) && r.MatchEvent(result.Status, t)
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package channel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRoute(t *testing.T) {
+	var routes []Route
+	err := yaml.Unmarshal([]byte(`
+- match:
+    kinds: [mysql, redis]
+    name: "^db-"
+    labels:
+      env: prod|staging
+    status: [down]
+    time:
+      start: "09:00"
+      end: "18:00"
+      weekdays: [mon, Tuesday]
+  notifiers: [dba]
+  continue: true
+- name: night
+  match:
+    time:
+      start: "22:00"
+      end: "06:00"
+  notifiers: [oncall]
+`), &routes)
+	assert.Nil(t, err)
+	assert.Len(t, routes, 2)
+
+	r := &routes[0]
+	assert.Nil(t, r.Config(0))
+	assert.Equal(t, "route-1", r.Name)
+	assert.Equal(t, []probe.Status{probe.StatusDown}, r.Match.Status)
+	assert.False(t, r.IsStatic())
+
+	loc := global.GetTimeLocation()
+	monday := time.Date(2022, 8, 1, 10, 0, 0, 0, loc)
+	labels := map[string]string{"env": "prod"}
+	assert.True(t, r.MatchProbe("db-1", "MySQL", labels))
+	assert.False(t, r.MatchProbe("db-1", "http", labels))
+	assert.False(t, r.MatchProbe("web-1", "mysql", labels))
+	assert.False(t, r.MatchProbe("db-1", "mysql", nil))
+	// the label value must be fully matched
+	assert.False(t, r.MatchProbe("db-1", "mysql", map[string]string{"env": "production"}))
+
+	assert.True(t, r.MatchEvent(probe.StatusDown, monday))
+	assert.False(t, r.MatchEvent(probe.StatusUp, monday))
+	assert.False(t, r.MatchEvent(probe.StatusDown, monday.Add(9*time.Hour)))
+	assert.True(t, r.MatchEvent(probe.StatusDown, monday.AddDate(0, 0, 1)))
+	assert.False(t, r.MatchEvent(probe.StatusDown, monday.AddDate(0, 0, 2)))
+
+	result := &probe.Result{Name: "db-1", Kind: "mysql", Labels: labels, Status: probe.StatusDown}
+	assert.True(t, r.Matches(result, monday))
+
+	// overnight time range, and the empty match matches any probe
+	r = &routes[1]
+	assert.Nil(t, r.Config(1))
+	assert.Equal(t, "night", r.Name)
+	assert.True(t, r.MatchProbe("any", "http", nil))
+	assert.True(t, r.MatchEvent(probe.StatusUp, monday.Add(13*time.Hour)))
+	assert.True(t, r.MatchEvent(probe.StatusUp, monday.Add(-5*time.Hour)))
+	assert.False(t, r.MatchEvent(probe.StatusUp, monday))
+	assert.Equal(t, "22:00-06:00", r.Match.Time.String())
+
+	// bad configurations
+	bad := []Route{
+		{},
+		{Notifiers: []string{"n"}, Match: Match{Name: "("}},
+		{Notifiers: []string{"n"}, Match: Match{Labels: map[string]string{"env": "["}}},
+		{Notifiers: []string{"n"}, Match: Match{Time: &TimeOfDay{Start: "9am", End: "18:00"}}},
+		{Notifiers: []string{"n"}, Match: Match{Time: &TimeOfDay{Start: "09:00", End: "18:00", Weekdays: []string{"x"}}}},
+	}
+	for i := range bad {
+		assert.NotNil(t, bad[i].Config(i))
+	}
+}
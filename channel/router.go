@@ -0,0 +1,247 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package channel
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/notify"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+const routerName = "router"
+
+// router dispatches the probe events to the notifiers by the routing rules and the escalation policies
+type router struct {
+	routes  []*Route
+	mutex   sync.RWMutex
+	isWatch int32
+	done    chan bool
+	channel chan probe.Result
+}
+
+var routing = &router{}
+
+// SetRoutes sets the routing rules, the bad rules are ignored
+func SetRoutes(routes []Route) {
+	rs := []*Route{}
+	names := map[string]bool{}
+	for i := range routes {
+		r := routes[i]
+		if err := r.Config(i); err != nil {
+			log.Errorf("[%s / %s] Bad route configuration: %v", kind, routerName, err)
+			continue
+		}
+		if names[r.Name] {
+			log.Errorf("[%s / %s] Route [%s] name is duplicated, ignored!", kind, routerName, r.Name)
+			continue
+		}
+		names[r.Name] = true
+		rs = append(rs, &r)
+		log.Infof("[%s / %s] Route [%s] is configured with notifiers %v", kind, routerName, r.Name, r.Notifiers)
+	}
+
+	routing.mutex.Lock()
+	defer routing.mutex.Unlock()
+	routing.routes = rs
+}
+
+// GetRoutes returns the routing rules
+func GetRoutes() []*Route {
+	routing.mutex.RLock()
+	defer routing.mutex.RUnlock()
+	return append([]*Route{}, routing.routes...)
+}
+
+// Dispatch sends the probe result to the router if there are routing rules or escalated notifiers
+func Dispatch(result probe.Result) {
+	if atomic.LoadInt32(&routing.isWatch) == 0 {
+		return
+	}
+	if len(GetRoutes()) <= 0 && len(result.Escalated) <= 0 {
+		return
+	}
+	routing.channel <- result
+}
+
+// allNotifiers returns all of the notifiers in the channels
+func allNotifiers() map[string]notify.Notify {
+	notifiers := map[string]notify.Notify{}
+	for _, ch := range GetAllChannels() {
+		for _, n := range ch.notifiers() {
+			notifiers[n.Name()] = n
+		}
+	}
+	return notifiers
+}
+
+// channelNotifiers returns the names of the notifiers of the channels which the probe belongs to
+func channelNotifiers(name string) map[string]bool {
+	names := map[string]bool{}
+	for _, ch := range GetAllChannels() {
+		if ch.GetProber(name) == nil {
+			continue
+		}
+		for _, n := range ch.notifiers() {
+			names[n.Name()] = true
+		}
+	}
+	return names
+}
+
+// RoutedNotifiers returns the notifiers of the routes which match the probe result at the time t.
+// The notifiers of the channels which the probe belongs to are excluded, because they have been notified.
+func RoutedNotifiers(result *probe.Result, t time.Time) []notify.Notify {
+	all := allNotifiers()
+	excluded := channelNotifiers(result.Name)
+
+	notifiers := []notify.Notify{}
+	for _, r := range GetRoutes() {
+		if !r.Matches(result, t) {
+			continue
+		}
+		for _, name := range r.Notifiers {
+			n, ok := all[name]
+			if !ok {
+				log.Warnf("[%s / %s] The notifier [%s] of the route [%s] is not found", kind, routerName, name, r.Name)
+				continue
+			}
+			if excluded[name] {
+				continue
+			}
+			excluded[name] = true
+			notifiers = append(notifiers, n)
+		}
+		if !r.Continue {
+			break
+		}
+	}
+	return notifiers
+}
+
+// startRouter starts to watch the events for the routing rules
+func startRouter(wg *sync.WaitGroup) {
+	// check if the router is watching
+	if atomic.CompareAndSwapInt32(&routing.isWatch, 0, 1) == false {
+		log.Warnf("[%s / %s]: Router is already watching!", kind, routerName)
+		return
+	}
+	routing.done = make(chan bool)
+	routing.channel = make(chan probe.Result, 256)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer atomic.StoreInt32(&routing.isWatch, 0)
+		for {
+			select {
+			case <-routing.done:
+				log.Infof("[%s / %s]: Received the done signal, router exiting...", kind, routerName)
+				return
+			case result := <-routing.channel:
+				notifiers := map[string]notify.Notify{}
+				for _, n := range EscalatedNotifiers(&result) {
+					notifiers[n.Name()] = n
+				}
+				if len(GetRoutes()) > 0 && notifiable(routerName, &result) {
+					for _, n := range RoutedNotifiers(&result, result.StartTime) {
+						log.Debugf("[%s / %s]: %s (%s) - Routed to the notifier [%s]",
+							kind, routerName, result.Name, result.Endpoint, n.Name())
+						notifiers[n.Name()] = n
+					}
+				}
+				for _, n := range notifiers {
+					send(n, result)
+				}
+			}
+		}
+	}()
+}
+
+// stopRouter stops the router
+func stopRouter() {
+	if atomic.LoadInt32(&routing.isWatch) == 1 {
+		routing.done <- true
+	}
+}
+
+// RouteInfo is the resolved route of a probe
+type RouteInfo struct {
+	Name       string   `json:"name"`
+	Conditions []string `json:"conditions,omitempty"` // the status and time conditions of the events
+	Notifiers  []string `json:"notifiers"`
+	Continue   bool     `json:"continue"`
+}
+
+// Routing is the resolved routing of a probe
+type Routing struct {
+	Channels map[string][]string `json:"channels"` // the channel name => the notifier names
+	Routes   []RouteInfo         `json:"routes,omitempty"`
+}
+
+// GetRouting returns the resolved routing of the probe, the routes whose kinds, name and labels
+// match the probe are listed, and the status and time conditions are evaluated when the event happens.
+func GetRouting(p probe.Prober) Routing {
+	rt := Routing{Channels: map[string][]string{}}
+	for _, name := range channelNames(p.Channels()) {
+		ch := GetChannel(name)
+		if ch == nil {
+			continue
+		}
+		names := []string{}
+		for _, n := range ch.notifiers() {
+			names = append(names, n.Name())
+		}
+		sort.Strings(names)
+		rt.Channels[name] = names
+	}
+
+	for _, r := range GetRoutes() {
+		if !r.MatchProbe(p.Name(), p.Kind(), p.LabelMap()) {
+			continue
+		}
+		info := RouteInfo{
+			Name:      r.Name,
+			Notifiers: append([]string{}, r.Notifiers...),
+			Continue:  r.Continue,
+		}
+		if len(r.Match.Status) > 0 {
+			status := ""
+			for i, s := range r.Match.Status {
+				if i > 0 {
+					status += ","
+				}
+				status += s.String()
+			}
+			info.Conditions = append(info.Conditions, "status: "+status)
+		}
+		if r.Match.Time != nil {
+			info.Conditions = append(info.Conditions, "time: "+r.Match.Time.String())
+		}
+		rt.Routes = append(rt.Routes, info)
+		// the following routes are never evaluated
+		if r.IsStatic() && !r.Continue {
+			break
+		}
+	}
+	return rt
+}
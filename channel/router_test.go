@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package channel
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/notify"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func TestRouter(t *testing.T) {
+	pDB := newDummyProber("mysql", "", "route-db", []string{"db"})
+	pWeb := newDummyProber("http", "", "route-web", nil)
+	pDB.SetLabelMap(map[string]string{"team": "dba"})
+	nDB := newDummyNotify("email", "notify-db", []string{"db"})
+	nOncall := newDummyNotify("email", "notify-oncall", []string{"nobody"})
+	nDefault := newDummyNotify("email", "notify-default", nil)
+
+	var sent int32
+	nOncall.NotifySendFunc = func(string, string) error {
+		atomic.AddInt32(&sent, 1)
+		return nil
+	}
+	nOncall.Config(global.NotifySettings{})
+
+	Rewire([]probe.Prober{pDB, pWeb}, []notify.Notify{nDB, nOncall, nDefault})
+
+	SetRoutes([]Route{
+		{Name: "dba", Match: Match{Labels: map[string]string{"team": "dba"}}, Notifiers: []string{"notify-db", "notify-oncall", "unknown"}},
+		{Name: "dba", Notifiers: []string{"notify-oncall"}}, // duplicated
+		{Name: "bad"},
+		{Name: "down", Match: Match{Status: []probe.Status{probe.StatusDown}}, Notifiers: []string{"notify-oncall"}, Continue: true},
+		{Name: "all", Notifiers: []string{"notify-default"}},
+	})
+	assert.Len(t, GetRoutes(), 3)
+
+	now := time.Now()
+	names := func(notifiers []notify.Notify) []string {
+		list := []string{}
+		for _, n := range notifiers {
+			list = append(list, n.Name())
+		}
+		return list
+	}
+
+	// the first route is matched and stops, the notifier of the probe channel is excluded
+	db := &probe.Result{Name: "route-db", Kind: "mysql", Labels: pDB.LabelMap(), Status: probe.StatusDown}
+	assert.Equal(t, []string{"notify-oncall"}, names(RoutedNotifiers(db, now)))
+
+	// the down route continues to the last route, the default channel is excluded
+	web := &probe.Result{Name: "route-web", Kind: "http", Status: probe.StatusDown}
+	assert.Equal(t, []string{"notify-oncall"}, names(RoutedNotifiers(web, now)))
+	web.Status = probe.StatusUp
+	assert.Empty(t, RoutedNotifiers(web, now))
+
+	rt := GetRouting(pDB)
+	assert.Equal(t, map[string][]string{"db": {"notify-db"}}, rt.Channels)
+	assert.Len(t, rt.Routes, 1)
+	assert.Equal(t, "dba", rt.Routes[0].Name)
+	rt = GetRouting(pWeb)
+	assert.Equal(t, []string{"notify-default"}, rt.Channels[global.DefaultChannelName])
+	assert.Len(t, rt.Routes, 2)
+	assert.Equal(t, []string{"status: down"}, rt.Routes[0].Conditions)
+	assert.True(t, rt.Routes[0].Continue)
+
+	// the routed events are sent by the router
+	SetDryNotify(false)
+	WatchForAllEvents()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&routing.isWatch) == 1 }, time.Second, 10*time.Millisecond)
+	down := probe.Result{Name: "route-web", Kind: "http", Status: probe.StatusDown, PreStatus: probe.StatusUp, StartTime: now}
+	down.Stat.NotificationStrategyData.IsSent = true
+	Dispatch(down)
+	// no change, no notification
+	Dispatch(probe.Result{Name: "route-web", Kind: "http", Status: probe.StatusUp, PreStatus: probe.StatusUp, StartTime: now})
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&sent) == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&sent))
+
+	AllDone()
+	assert.Equal(t, int32(0), atomic.LoadInt32(&routing.isWatch))
+	SetRoutes(nil)
+	assert.Empty(t, GetRoutes())
+}
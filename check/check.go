@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package check is the one-shot check mode, which runs the probes once and reports the results.
+package check
+
+import (
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+// Filter selects the probes by name, kind or label. The names and kinds are
+// matched if any of them is matched, the names support the glob pattern, e.g. "api-*".
+// The labels are matched if all of them are matched.
+type Filter struct {
+	Names  []string
+	Kinds  []string
+	Labels map[string]string
+}
+
+// Match returns true if the prober is selected by the filter
+func (f *Filter) Match(p probe.Prober) bool {
+	if len(f.Names) > 0 && !matchAny(f.Names, p.Name()) {
+		return false
+	}
+	if len(f.Kinds) > 0 && !matchAny(f.Kinds, p.Kind()) {
+		return false
+	}
+	for k, v := range f.Labels {
+		if l, ok := p.LabelMap()[k]; !ok || l != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the probers which are selected by the filter
+func (f *Filter) Filter(probers []probe.Prober) []probe.Prober {
+	selected := []probe.Prober{}
+	for _, p := range probers {
+		if f.Match(p) {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, s); err == nil && ok {
+			return true
+		}
+		if strings.EqualFold(pattern, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Result is the one-shot check result of a probe
+type Result struct {
+	Name     string        `json:"name"`
+	Kind     string        `json:"kind"`
+	Endpoint string        `json:"endpoint"`
+	Status   probe.Status  `json:"status"`
+	Message  string        `json:"message"`
+	Runs     int           `json:"runs"`
+	Failures int           `json:"failures"`
+	Duration time.Duration `json:"duration"` // the round trip time of the last probe
+	Passed   bool          `json:"passed"`
+	Result   probe.Result  `json:"-"` // the last probe result
+}
+
+// Failed returns the number of the failed results
+func Failed(results []Result) int {
+	cnt := 0
+	for _, r := range results {
+		if !r.Passed {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// Run probes each of the probers the given times, and returns the results in the
+// same order. The probers run in parallel, but the prober waits for its parents
+// finished, so that the failure caused by the parent could be suppressed as usual.
+// The probers with the bad configuration are not run and reported as failed.
+func Run(probers []probe.Prober, times int, interval time.Duration) []Result {
+	if times < 1 {
+		times = 1
+	}
+
+	done := map[string]chan struct{}{}
+	for _, p := range probers {
+		done[p.Name()] = make(chan struct{})
+	}
+
+	results := make([]Result, len(probers))
+	var wg sync.WaitGroup
+	for i, p := range probers {
+		wg.Add(1)
+		go func(i int, p probe.Prober) {
+			defer wg.Done()
+			defer close(done[p.Name()])
+			for _, parent := range p.DependsOn() {
+				if ch, ok := done[strings.TrimSpace(parent)]; ok && parent != p.Name() {
+					<-ch
+				}
+			}
+			results[i] = run(p, times, interval)
+		}(i, p)
+	}
+	wg.Wait()
+	return results
+}
+
+func run(p probe.Prober, times int, interval time.Duration) Result {
+	r := Result{
+		Name: p.Name(),
+		Kind: p.Kind(),
+	}
+	if p.Result() == nil || p.Result().Status == probe.StatusBad {
+		r.Status = probe.StatusBad
+		if p.Result() != nil {
+			r.Message = p.Result().Message
+		}
+		return r
+	}
+
+	for i := 0; i < times; i++ {
+		if i > 0 && interval > 0 {
+			time.Sleep(interval)
+		}
+		r.Result = p.Probe()
+		// the dependents read the status of the parent from the result data
+		probe.SetResultData(p.Name(), &r.Result)
+		r.Runs++
+		if !p.Result().Stat.StatusCounter.CurrentStatus {
+			r.Failures++
+		}
+		log.Debugf("[Check] %s - %d/%d: %s", p.Name(), i+1, times, r.Result.Message)
+	}
+
+	r.Endpoint = r.Result.Endpoint
+	r.Message = r.Result.Message
+	r.Duration = r.Result.RoundTripTime
+	r.Status = r.Result.Status
+	// the thresholds are not reached, use the status of the last probe
+	if r.Status == probe.StatusInit {
+		r.Status = probe.StatusDown
+		if p.Result().Stat.StatusCounter.CurrentStatus {
+			r.Status = probe.StatusUp
+		}
+		r.Result.Status = r.Status
+	}
+	r.Passed = r.Status.IsAvailable()
+	return r
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package check
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/metric"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/probe/base"
+)
+
+type dummyProber struct {
+	base.DefaultProbe
+	results []bool
+	count   int32
+}
+
+func (d *dummyProber) Config(g global.ProbeSettings) error {
+	d.DefaultProbe.Config(g, d.ProbeKind, d.ProbeTag, d.ProbeName, "endpoint", d.DoProbe)
+	return nil
+}
+
+func (d *dummyProber) DoProbe() (bool, string) {
+	i := atomic.AddInt32(&d.count, 1) - 1
+	if int(i) >= len(d.results) {
+		i = int32(len(d.results) - 1)
+	}
+	if d.results[i] {
+		return true, "success"
+	}
+	return false, "failure"
+}
+
+func newDummyProber(name, kind string, results ...bool) *dummyProber {
+	d := &dummyProber{
+		DefaultProbe: base.DefaultProbe{
+			ProbeKind: kind,
+			ProbeName: name,
+		},
+		results: results,
+	}
+	d.SetMetricRegistry(metric.NewRegistry(prometheus.NewRegistry()))
+	return d
+}
+
+func TestFilter(t *testing.T) {
+	p1 := newDummyProber("api-user", "http")
+	p1.Labels = prometheus.Labels{"env": "prod"}
+	p2 := newDummyProber("api-order", "http")
+	p2.Labels = prometheus.Labels{"env": "test"}
+	p3 := newDummyProber("redis", "client")
+	probers := []probe.Prober{p1, p2, p3}
+
+	f := Filter{}
+	assert.Len(t, f.Filter(probers), 3)
+
+	f = Filter{Names: []string{"api-*"}}
+	assert.Equal(t, []probe.Prober{p1, p2}, f.Filter(probers))
+
+	f = Filter{Names: []string{"REDIS", "none"}}
+	assert.Equal(t, []probe.Prober{p3}, f.Filter(probers))
+
+	f = Filter{Kinds: []string{"client"}}
+	assert.Equal(t, []probe.Prober{p3}, f.Filter(probers))
+
+	f = Filter{Kinds: []string{"http"}, Labels: map[string]string{"env": "prod"}}
+	assert.Equal(t, []probe.Prober{p1}, f.Filter(probers))
+
+	f = Filter{Names: []string{"api-[*"}}
+	assert.Empty(t, f.Filter(probers))
+}
+
+func TestRun(t *testing.T) {
+	up := newDummyProber("up", "dummy", true)
+	down := newDummyProber("down", "dummy", false)
+	bad := newDummyProber("bad", "dummy", true)
+	// the failure threshold is not reached, the status of the last probe is used
+	flaky := newDummyProber("flaky", "dummy", false, true)
+	flaky.Failure = 3
+	// the failure threshold is not reached, the status is kept
+	unstable := newDummyProber("unstable", "dummy", true, true, false)
+	unstable.Failure = 2
+
+	probers := []probe.Prober{up, down, bad, flaky, unstable}
+	for _, p := range probers {
+		assert.Nil(t, p.Config(global.ProbeSettings{}))
+	}
+	bad.Result().Status = probe.StatusBad
+	bad.Result().Message = "Bad Configuration: error"
+
+	results := Run(probers, 3, time.Millisecond)
+	assert.Len(t, results, 5)
+
+	assert.Equal(t, "up", results[0].Name)
+	assert.True(t, results[0].Passed)
+	assert.Equal(t, probe.StatusUp, results[0].Status)
+	assert.Equal(t, 3, results[0].Runs)
+	assert.Equal(t, 0, results[0].Failures)
+	assert.Equal(t, "endpoint", results[0].Endpoint)
+
+	assert.False(t, results[1].Passed)
+	assert.Equal(t, probe.StatusDown, results[1].Status)
+	assert.Equal(t, 3, results[1].Failures)
+
+	assert.False(t, results[2].Passed)
+	assert.Equal(t, probe.StatusBad, results[2].Status)
+	assert.Equal(t, 0, results[2].Runs)
+	assert.Equal(t, int32(0), bad.count)
+	assert.Contains(t, results[2].Message, "Bad Configuration")
+
+	assert.True(t, results[3].Passed)
+	assert.Equal(t, probe.StatusUp, results[3].Status)
+	assert.Equal(t, 1, results[3].Failures)
+
+	assert.True(t, results[4].Passed)
+	assert.Equal(t, probe.StatusUp, results[4].Status)
+	assert.Equal(t, 1, results[4].Failures)
+
+	assert.Equal(t, 2, Failed(results))
+
+	// run once at least
+	results = Run([]probe.Prober{up}, 0, 0)
+	assert.Equal(t, 1, results[0].Runs)
+}
+
+func TestRunDependency(t *testing.T) {
+	parent := newDummyProber("parent", "dummy", false)
+	child := newDummyProber("child", "dummy", false)
+	child.ProbeDependsOn = []string{"parent", "unknown"}
+	probers := []probe.Prober{child, parent}
+	for _, p := range probers {
+		assert.Nil(t, p.Config(global.ProbeSettings{}))
+	}
+	g, err := probe.NewDependencyGraph(probers)
+	assert.Nil(t, err)
+	probe.SetDependencyGraph(g)
+	defer probe.SetDependencyGraph(nil)
+	defer probe.CleanData(nil)
+
+	// the child runs after the parent, so the failure is suppressed
+	results := Run(probers, 1, 0)
+	assert.Equal(t, "parent", results[0].Result.SuppressedBy)
+	assert.Empty(t, results[1].Result.SuppressedBy)
+	assert.Equal(t, 2, Failed(results))
+}
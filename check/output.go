@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package check
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Format is the output format of the check results
+type Format string
+
+// The output formats
+const (
+	Table Format = "table"
+	JSON  Format = "json"
+	JUnit Format = "junit"
+)
+
+// ParseFormat returns the format by name
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case Table, JSON, JUnit:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown output format [%s], it should be table, json or junit", name)
+}
+
+// Write writes the results in the format
+func Write(w io.Writer, format Format, results []Result) error {
+	switch format {
+	case JSON:
+		return WriteJSON(w, results)
+	case JUnit:
+		return WriteJUnit(w, results)
+	}
+	return WriteTable(w, results)
+}
+
+// WriteTable writes the results as a text table
+func WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tKIND\tENDPOINT\tSTATUS\tRUNS\tFAILURES\tDURATION\tMESSAGE")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s %s\t%d\t%d\t%s\t%s\n",
+			r.Name, r.Kind, r.Endpoint, r.Status.Emoji(), r.Status.String(),
+			r.Runs, r.Failures, r.Duration.Round(time.Millisecond), r.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	failed := Failed(results)
+	_, err := fmt.Fprintf(w, "\n%d probes, %d passed, %d failed\n", len(results), len(results)-failed, failed)
+	return err
+}
+
+// WriteJSON writes the results as a JSON array
+func WriteJSON(w io.Writer, results []Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// WriteJUnit writes the results as a JUnit XML report, each probe is a test case
+func WriteJUnit(w io.Writer, results []Result) error {
+	suite := junitTestSuite{
+		Name:     "easeprobe",
+		Tests:    len(results),
+		Failures: Failed(results),
+	}
+	total := time.Duration(0)
+	for _, r := range results {
+		total += r.Duration
+		tc := junitTestCase{
+			Name:      r.Name,
+			ClassName: r.Kind,
+			Time:      seconds(r.Duration),
+		}
+		if !r.Passed {
+			tc.Failure = &junitFailure{
+				Message: r.Message,
+				Type:    r.Status.String(),
+				Text:    fmt.Sprintf("%s - %d of %d probes failed\n%s", r.Endpoint, r.Failures, r.Runs, r.Message),
+			}
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
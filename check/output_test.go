@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func testResults() []Result {
+	return []Result{
+		{Name: "web", Kind: "http", Endpoint: "https://example.com", Status: probe.StatusUp,
+			Message: "Success (http): 200", Runs: 1, Duration: 120 * time.Millisecond, Passed: true},
+		{Name: "db", Kind: "client", Endpoint: "db:3306", Status: probe.StatusDown,
+			Message: "Error (client/mysql): timeout", Runs: 2, Failures: 2, Duration: time.Second},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, f := range map[string]Format{"table": Table, " JSON ": JSON, "JUnit": JUnit} {
+		format, err := ParseFormat(name)
+		assert.Nil(t, err)
+		assert.Equal(t, f, format)
+	}
+	_, err := ParseFormat("xml")
+	assert.NotNil(t, err)
+}
+
+func TestWriteTable(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, Write(&buf, Table, testResults()))
+	out := buf.String()
+	assert.Contains(t, out, "NAME")
+	assert.Contains(t, out, "https://example.com")
+	assert.Contains(t, out, "down")
+	assert.Contains(t, out, "120ms")
+	assert.Contains(t, out, "2 probes, 1 passed, 1 failed")
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, Write(&buf, JSON, testResults()))
+	var results []map[string]interface{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &results))
+	assert.Len(t, results, 2)
+	assert.Equal(t, "db", results[1]["name"])
+	assert.Equal(t, "down", results[1]["status"])
+	assert.Equal(t, false, results[1]["passed"])
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, Write(&buf, JUnit, testResults()))
+	assert.Contains(t, buf.String(), xml.Header)
+
+	var suite junitTestSuite
+	assert.Nil(t, xml.Unmarshal(buf.Bytes(), &suite))
+	assert.Equal(t, 2, suite.Tests)
+	assert.Equal(t, 1, suite.Failures)
+	assert.Equal(t, "1.120", suite.Time)
+	assert.Nil(t, suite.TestCases[0].Failure)
+	assert.Equal(t, "0.120", suite.TestCases[0].Time)
+	assert.Equal(t, "client", suite.TestCases[1].ClassName)
+	assert.Equal(t, "down", suite.TestCases[1].Failure.Type)
+	assert.Contains(t, suite.TestCases[1].Failure.Text, "2 of 2 probes failed")
+}
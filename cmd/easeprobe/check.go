@@ -0,0 +1,192 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/channel"
+	"github.com/wfusion/easeprobe/check"
+	"github.com/wfusion/easeprobe/conf"
+	"github.com/wfusion/easeprobe/escalation"
+	"github.com/wfusion/easeprobe/maintenance"
+	"github.com/wfusion/easeprobe/metric"
+	"github.com/wfusion/easeprobe/notify"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+// the exit codes of the check mode
+const (
+	checkPassed = 0
+	checkFailed = 1
+	checkError  = 2
+)
+
+// listFlag is the flag which could be repeated or separated by comma
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// runCheck is the one-shot check mode - `easeprobe check [flags]`, it runs the probes
+// once and exits with non-zero code if any probe fails. The web server, the SLA report,
+// and the PID file are skipped.
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	yamlFile := fs.String("f", getEnvOrDefault("PROBE_CONFIG", "config.yaml"), "configuration file")
+	output := fs.String("o", "table", "output format: table, json or junit")
+	times := fs.Int("n", 1, "the times of probing, the failure/success thresholds are respected")
+	interval := fs.Duration("i", 0, "the interval between the probing")
+	sendNotify := fs.Bool("notify", false, "send the notifications of the failed probes")
+	dryNotify := fs.Bool("d", os.Getenv("PROBE_DRY") == "true", "dry notification mode")
+	var names, kinds, labels listFlag
+	fs.Var(&names, "name", "the probe names to check, support the glob pattern, e.g. \"api-*\"")
+	fs.Var(&kinds, "kind", "the probe kinds to check, e.g. \"http\"")
+	fs.Var(&labels, "label", "the probe labels to check, e.g. \"env=prod\"")
+	fs.Parse(args)
+
+	format, err := check.ParseFormat(*output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return checkError
+	}
+	filter := check.Filter{Names: names, Kinds: kinds, Labels: map[string]string{}}
+	for _, l := range labels {
+		kv := strings.SplitN(l, "=", 2)
+		if len(kv) != 2 {
+			fmt.Fprintf(os.Stderr, "invalid label [%s], it should be key=value\n", l)
+			return checkError
+		}
+		filter.Labels[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	// the logs go to stderr, so that the output could be parsed
+	conf.SetStdout(os.Stderr)
+	log.SetOutput(os.Stderr)
+	c, err := conf.New(yamlFile)
+	if err != nil {
+		log.Errorln("Fatal: Cannot read the YAML configuration file!")
+		return checkError
+	}
+	if *dryNotify {
+		c.Settings.Notify.Dry = true
+		channel.SetDryNotify(true)
+	}
+	maintenance.SetWindows(c.Maintenance)
+	channel.SetRoutes(c.Routes)
+	escalation.SetPolicies(c.Escalations)
+
+	// the metrics and the results of the probes are not kept
+	all := c.AllProbers()
+	for _, p := range all {
+		if t, ok := p.(interface{ SetMetricRegistry(*metric.Registry) }); ok {
+			t.SetMetricRegistry(metric.NewRegistry(prometheus.NewRegistry()))
+		}
+	}
+	// the kind of the probe is known after it's configured
+	configProbers(all)
+
+	probers := []probe.Prober{}
+	valid := []probe.Prober{}
+	for _, p := range filter.Filter(all) {
+		// the passive heartbeat probes cannot be checked once
+		if p.Kind() == "heartbeat" {
+			log.Infof("The heartbeat probe [%s] is skipped in the check mode", p.Name())
+			continue
+		}
+		probers = append(probers, p)
+		if p.Result().Status != probe.StatusBad {
+			valid = append(valid, p)
+		}
+	}
+	if len(probers) == 0 {
+		log.Error("No probes matched, exiting...")
+		return checkError
+	}
+	configDependencies(valid)
+
+	results := check.Run(probers, *times, *interval)
+
+	if *sendNotify {
+		notifies := configNotifiers(c.AllNotifiers())
+		configChannels(probers, notifies)
+		notifyFailures(probers, results)
+	}
+
+	if err := check.Write(os.Stdout, format, results); err != nil {
+		log.Errorf("Failed to write the check results: %v", err)
+		return checkError
+	}
+	if check.Failed(results) > 0 {
+		return checkFailed
+	}
+	return checkPassed
+}
+
+// notifyFailures sends the failed results to the notifiers of the probe channels, the routes and the escalations,
+// the results in the maintenance window or suppressed by the parent are not sent.
+func notifyFailures(probers []probe.Prober, results []check.Result) {
+	var wg sync.WaitGroup
+	for i, r := range results {
+		if r.Passed || r.Runs == 0 || r.Result.InMaintenance() || r.Result.IsSuppressed() {
+			continue
+		}
+		notifiers := map[notify.Notify]bool{}
+		for _, name := range probers[i].Channels() {
+			if ch := channel.GetChannel(name); ch != nil {
+				for _, n := range ch.Notifiers {
+					notifiers[n] = true
+				}
+			}
+		}
+		for _, n := range channel.RoutedNotifiers(&r.Result, r.Result.StartTime) {
+			notifiers[n] = true
+		}
+		for _, n := range channel.EscalatedNotifiers(&r.Result) {
+			notifiers[n] = true
+		}
+		for n := range notifiers {
+			if channel.IsDryNotify() {
+				n.DryNotify(r.Result)
+				continue
+			}
+			wg.Add(1)
+			go func(n notify.Notify, result probe.Result) {
+				defer wg.Done()
+				n.Notify(result)
+			}(n, r.Result)
+		}
+	}
+	wg.Wait()
+}
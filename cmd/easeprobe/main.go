@@ -25,15 +25,21 @@ import (
 	"os/signal"
 	"runtime/debug"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
+	"github.com/wfusion/easeprobe/ack"
 	"github.com/wfusion/easeprobe/channel"
 	"github.com/wfusion/easeprobe/conf"
 	"github.com/wfusion/easeprobe/daemon"
+	"github.com/wfusion/easeprobe/escalation"
 	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/maintenance"
+	"github.com/wfusion/easeprobe/metric"
 	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/report"
+	"github.com/wfusion/easeprobe/runner"
+	"github.com/wfusion/easeprobe/store"
 	"github.com/wfusion/easeprobe/web"
 
 	log "github.com/sirupsen/logrus"
@@ -75,6 +81,11 @@ func main() {
 	//          Parse command line arguments and config file settings         //
 	////////////////////////////////////////////////////////////////////////////
 
+	// the one-shot check mode - `easeprobe check [flags]`
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(runCheck(os.Args[2:]))
+	}
+
 	dryNotify := flag.Bool("d", os.Getenv("PROBE_DRY") == "true", "dry notification mode")
 	yamlFile := flag.String("f", getEnvOrDefault("PROBE_CONFIG", "config.yaml"), "configuration file")
 	jsonSchema := flag.Bool("j", false, "show JSON schema")
@@ -129,6 +140,15 @@ func main() {
 	// set the dry notify flag to channel
 	channel.SetDryNotify(c.Settings.Notify.Dry)
 
+	// set the maintenance windows
+	maintenance.SetWindows(c.Maintenance)
+	// set the routing rules
+	channel.SetRoutes(c.Routes)
+	// set the escalation policies
+	escalation.SetPolicies(c.Escalations)
+	// set the acknowledgement settings
+	ack.SetSettings(c.Settings.Ack, c.Settings.HTTPServer.Token)
+
 	////////////////////////////////////////////////////////////////////////////
 	//                          Start the HTTP Server                         //
 	////////////////////////////////////////////////////////////////////////////
@@ -139,13 +159,23 @@ func main() {
 	//                  Configure all of Probers and Notifiers                //
 	////////////////////////////////////////////////////////////////////////////
 
+	// Open the result store, the latest results must be restored before the probes are configured
+	resultStore, err := store.New(c.Settings.Store, c.Settings.SLAReport.DataFile)
+	if err != nil {
+		log.Fatalf("Cannot open the result store: %v", err)
+	}
+	store.SetDefault(resultStore)
+
 	// Probers
 	probers := c.AllProbers()
 	// Notification
 	notifies := c.AllNotifiers()
 	// Configure the Probes
 	probers = configProbers(probers)
-	if len(probers) == 0 {
+	// Build the dependency graph of the Probes
+	probers = configDependencies(probers)
+	// the probe modules could work without the probes - as a blackbox exporter
+	if len(probers) == 0 && len(c.Modules) == 0 {
 		log.Fatal("No probes configured, exiting...")
 	}
 	// Configure the Notifiers
@@ -161,30 +191,31 @@ func main() {
 	//                          Start the EaseProbe                           //
 	////////////////////////////////////////////////////////////////////////////
 
-	// wait group for probers
-	var wg sync.WaitGroup
-	// the exit channel for all probers
-	doneProbe := make(chan bool, len(probers))
 	// the exit channel for saving the data
 	doneSave := make(chan bool)
 	// the channel for saving the probe result data
 	saveChannel := make(chan probe.Result, len(probers))
 
 	// 1) SLA Data Save process
-	probe.CleanData(probers)           // remove the data not in probers
-	go saveData(doneSave, saveChannel) // save the data to file
+	probe.CleanData(probers)                        // remove the data not in probers
+	go saveData(resultStore, doneSave, saveChannel) // save the data to the store
 
 	// 2) Start the Probers
-	runProbers(probers, &wg, doneProbe, saveChannel)
+	probeRunner := runner.New(c.ProbeSettings(), saveChannel)
+	probeRunner.Start(probers)
 	// 3) Start the Event Watching
 	channel.WatchForAllEvents()
 
 	// 4) Set probers into web server
-	web.SetProbers(probers)
+	web.SetRunner(probeRunner)
+	// the SLA windows of the probers are exported as the metrics
+	if err := metric.Register(report.NewSLACollector(probeRunner.Probers)); err != nil {
+		log.Errorf("Failed to register the SLA metrics: %v", err)
+	}
 
 	// 5) Set the Cron Job for SLA Report
 	if conf.Get().Settings.SLAReport.Schedule != conf.None {
-		scheduleSLA(probers)
+		scheduleSLA(probeRunner)
 	} else {
 		log.Info("No SLA Report would be sent!!")
 	}
@@ -223,12 +254,7 @@ func main() {
 	// the graceful shutdown process
 	exit := func() {
 		web.Shutdown()
-		for i := 0; i < len(probers); i++ {
-			if probers[i].Result().Status != probe.StatusBad {
-				doneProbe <- true
-			}
-		}
-		wg.Wait()
+		probeRunner.Stop()
 		channel.AllDone()
 		doneSave <- true
 		doneRotate <- true
@@ -251,15 +277,24 @@ func main() {
 	// Monitor the configuration file
 	monConf := make(chan bool, 1)
 	go monitorYAMLFile(*yamlFile, monConf)
-
-	// wait for the exit and restart signal
-	select {
-	case <-done:
-		log.Info("!!! RECEIVED THE SIGTERM EXIT SIGNAL, EXITING... !!!")
-		exit()
-	case <-monConf:
-		log.Info("!!! RECEIVED THE RESTART EVENT, RESTARTING... !!!")
-		reRun()
+	reload := newReloader(*yamlFile, *dryNotify, probeRunner, c, notifies)
+
+	// wait for the exit and reload signal
+	for running := true; running; {
+		select {
+		case <-done:
+			log.Info("!!! RECEIVED THE SIGTERM EXIT SIGNAL, EXITING... !!!")
+			exit()
+			running = false
+		case <-monConf:
+			log.Info("!!! RECEIVED THE RELOAD EVENT, RELOADING... !!!")
+			if err := reload.reload(); err != nil {
+				log.Warnf("Cannot reload the configuration in process: %v", err)
+				log.Info("!!! RESTARTING... !!!")
+				reRun()
+				running = false
+			}
+		}
 	}
 
 	log.Info("Graceful Exit Successfully!")
@@ -268,9 +303,8 @@ func main() {
 func monitorYAMLFile(path string, monConf chan bool) {
 	for {
 		if conf.IsConfigModified(path) {
-			log.Infof("The configuration file [%s] has been modified, restarting...", path)
+			log.Infof("The configuration file [%s] has been modified, reloading...", path)
 			monConf <- true
-			break
 		}
 		log.Debugf("The configuration file [%s] has not been modified", path)
 		time.Sleep(global.DefaultConfigFileCheckInterval)
@@ -18,26 +18,16 @@
 package main
 
 import (
-	"sync"
-	"time"
-
 	log "github.com/sirupsen/logrus"
 
-	"github.com/wfusion/easeprobe/channel"
 	"github.com/wfusion/easeprobe/conf"
-	"github.com/wfusion/easeprobe/global"
 	"github.com/wfusion/easeprobe/probe"
 )
 
 func configProbers(probers []probe.Prober) []probe.Prober {
 	conf.MergeConstLabels(probers)
 
-	gProbeConf := global.ProbeSettings{
-		Interval:                      conf.Get().Settings.Probe.Interval,
-		Timeout:                       conf.Get().Settings.Probe.Timeout,
-		StatusChangeThresholdSettings: conf.Get().Settings.Probe.StatusChangeThresholdSettings,
-		NotificationStrategySettings:  conf.Get().Settings.Probe.NotificationStrategySettings,
-	}
+	gProbeConf := conf.Get().ProbeSettings()
 	log.Debugf("Global Probe Configuration: %+v", gProbeConf)
 
 	validProbers := []probe.Prober{}
@@ -59,56 +49,40 @@ func configProbers(probers []probe.Prober) []probe.Prober {
 	return validProbers
 }
 
-func runProbers(probers []probe.Prober, wg *sync.WaitGroup, done chan bool, saveChannel chan probe.Result) {
-	// we need to run all probers in equally distributed time, not at the same time.
-	timeGap := global.DefaultProbeInterval / time.Duration(len(probers))
-	// if less than or equal to 60 probers, use 1 second instead
-	if time.Duration(len(probers))*time.Second <= time.Minute {
-		timeGap = time.Second
-	}
-	log.Debugf("Start Time Gap: %v = %v / %d", timeGap, global.DefaultProbeInterval, len(probers))
-
-	probeFn := func(p probe.Prober, index int) {
-		wg.Add(1)
-		defer wg.Done()
-
-		// Sleep a round time to avoid all probers start at the same time.
-		t := time.Duration(index) * timeGap
-		log.Debugf("[%s / %s] Delay %v = (%d * %v) seconds to start the probe work",
-			p.Kind(), p.Name(), t, index, timeGap)
-		time.Sleep(t)
+// configDependencies build the dependency graph of the probers,
+// the probers in the dependency cycle are marked as bad configuration and removed.
+func configDependencies(probers []probe.Prober) []probe.Prober {
+	for {
+		g, err := probe.NewDependencyGraph(probers)
+		if err == nil {
+			probe.SetDependencyGraph(g)
+			return probers
+		}
 
-		interval := time.NewTimer(p.Interval())
-		defer interval.Stop()
-		for {
-			res := p.Probe()
-			log.Debugf("%s: %s", p.Kind(), res.DebugJSON())
-			// send the result to the persistent channel
-			saveChannel <- res
-			// send the result to all channels
-			for _, cName := range p.Channels() {
-				if ch := channel.GetChannel(cName); ch != nil {
-					ch.Send(res)
-				}
-			}
+		log.Errorf("Bad Probe Dependency Configuration: %v", err)
+		cycleErr, ok := err.(*probe.DependencyCycleError)
+		if !ok {
+			return probers
+		}
 
-			select {
-			case <-done:
-				log.Infof("%s / %s - Received the done signal, exiting...", p.Kind(), p.Name())
-				return
-			case <-interval.C:
-				interval.Reset(p.Interval())
-				log.Debugf("%s / %s - %s Interval is up, continue...", p.Kind(), p.Name(), p.Interval())
+		validProbers := []probe.Prober{}
+		for _, p := range probers {
+			if !contains(cycleErr.Cycle, p.Name()) {
+				validProbers = append(validProbers, p)
+				continue
 			}
+			p.Result().Status = probe.StatusBad
+			p.Result().Message = "Bad Configuration: " + err.Error()
 		}
+		probers = validProbers
 	}
+}
 
-	for i := 0; i < len(probers); i++ {
-		p := probers[i]
-		if p.Result().Status == probe.StatusBad {
-			continue
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
 		}
-		log.Infof("Ready to monitor(%s): %s - %s", p.Kind(), p.Result().Name, p.Result().Endpoint)
-		go probeFn(p, i)
 	}
+	return false
 }
@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/ack"
+	"github.com/wfusion/easeprobe/channel"
+	"github.com/wfusion/easeprobe/conf"
+	"github.com/wfusion/easeprobe/escalation"
+	"github.com/wfusion/easeprobe/maintenance"
+	"github.com/wfusion/easeprobe/notify"
+	"github.com/wfusion/easeprobe/notify/alertmanager"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/runner"
+)
+
+// reloader reloads the configuration in process, only the changed probes,
+// notifiers, and channels are touched, the others keep running.
+type reloader struct {
+	path      string
+	dryNotify bool
+	runner    *runner.Runner
+	notifiers map[string]notify.Notify // the running notifiers by "kind/name"
+}
+
+func newReloader(path string, dryNotify bool, r *runner.Runner, c *conf.Conf, notifiers []notify.Notify) *reloader {
+	rl := &reloader{
+		path:      path,
+		dryNotify: dryNotify,
+		runner:    r,
+		notifiers: map[string]notify.Notify{},
+	}
+	// only keep the notifiers which are configured successfully
+	for key, n := range c.NotifierMap() {
+		for _, valid := range notifiers {
+			if n == valid {
+				rl.notifiers[key] = n
+			}
+		}
+	}
+	return rl
+}
+
+func (rl *reloader) reload() error {
+	c, diff, err := conf.Reload(rl.path)
+	if err != nil {
+		return err
+	}
+	// the dry notification mode from the command line is kept
+	if rl.dryNotify {
+		c.Settings.Notify.Dry = true
+		channel.SetDryNotify(true)
+	}
+
+	maintenance.SetWindows(c.Maintenance)
+	channel.SetRoutes(c.Routes)
+	escalation.SetPolicies(c.Escalations)
+	ack.SetSettings(c.Settings.Ack, c.Settings.HTTPServer.Token)
+	if diff.IsEmpty() {
+		log.Info("The probes and notifications are not changed.")
+		return nil
+	}
+
+	newProbers := map[string]probe.Prober{}
+	for _, p := range c.AllProbers() {
+		newProbers[p.Name()] = p
+	}
+	if err := rl.checkLabels(c.AllProbers()); err != nil {
+		return err
+	}
+
+	// 1) stop the removed and changed probers
+	for _, name := range append(diff.RemovedProbers, diff.ChangedProbers...) {
+		if err := rl.runner.Remove(name); err != nil {
+			log.Warnf("Failed to remove the probe [%s]: %v", name, err)
+		}
+	}
+
+	// 2) configure the added and changed notifiers
+	oldNotifiers := map[string]notify.Notify{}
+	for _, key := range append(diff.RemovedNotifiers, diff.ChangedNotifiers...) {
+		// stop the background routine of the notifier, e.g. the alertmanager repeat ticker
+		if s, ok := rl.notifiers[key].(interface{ Stop() }); ok {
+			s.Stop()
+		}
+		oldNotifiers[key] = rl.notifiers[key]
+		delete(rl.notifiers, key)
+	}
+	newNotifiers := c.NotifierMap()
+	for _, key := range append(diff.AddedNotifiers, diff.ChangedNotifiers...) {
+		n := newNotifiers[key]
+		if len(configNotifiers([]notify.Notify{n})) > 0 {
+			// the firing alerts of the changed alertmanager are still re-posted after the reload
+			if am, ok := n.(*alertmanager.NotifyConfig); ok {
+				if old, ok := oldNotifiers[key].(*alertmanager.NotifyConfig); ok {
+					am.TakeOver(old)
+				}
+			}
+			rl.notifiers[key] = n
+		}
+	}
+
+	// 3) rebuild the channels before the new probers start
+	added := []probe.Prober{}
+	for _, name := range append(diff.AddedProbers, diff.ChangedProbers...) {
+		added = append(added, newProbers[name])
+	}
+	notifiers := []notify.Notify{}
+	for _, n := range rl.notifiers {
+		notifiers = append(notifiers, n)
+	}
+	channel.Rewire(append(rl.runner.Probers(), added...), notifiers)
+
+	// 4) start the added and changed probers
+	rl.runner.SetSettings(c.ProbeSettings())
+	for _, p := range added {
+		if err := rl.runner.Add(p); err != nil {
+			log.Errorf("Bad Probe Configuration: [%s] %v", p.Name(), err)
+		}
+	}
+	checkChannels()
+	return nil
+}
+
+// checkLabels checks the label keys, because all of the Prometheus metrics
+// with the same name must have the same set of labels, the new label key
+// cannot be used in process.
+func (rl *reloader) checkLabels(probers []probe.Prober) error {
+	if len(rl.runner.Probers()) == 0 {
+		return nil
+	}
+	running := map[string]bool{}
+	for _, p := range rl.runner.Probers() {
+		for k := range p.LabelMap() {
+			running[k] = true
+		}
+	}
+	labels := map[string]bool{}
+	for _, p := range probers {
+		for k := range p.LabelMap() {
+			labels[k] = true
+		}
+	}
+	for k := range labels {
+		if !running[k] {
+			return fmt.Errorf("%w - the label [%s] is added", conf.ErrRestartRequired, k)
+		}
+	}
+	return nil
+}
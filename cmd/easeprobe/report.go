@@ -26,50 +26,54 @@ import (
 	"github.com/wfusion/easeprobe/conf"
 	"github.com/wfusion/easeprobe/global"
 	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/runner"
+	"github.com/wfusion/easeprobe/store"
 )
 
-func saveData(doneSave chan bool, saveChannel chan probe.Result) {
+func saveData(s store.Store, doneSave chan bool, saveChannel chan probe.Result) {
 	c := conf.Get()
-	file := c.Settings.SLAReport.DataFile
 	save := func() {
-		if err := probe.SaveDataToFile(file); err != nil {
-			log.Errorf("Failed to save the SLA data to file(%s): %v", file, err)
+		if err := s.Flush(); err != nil {
+			log.Errorf("Failed to save the SLA data to the %s store: %v", s.Kind(), err)
 		} else {
-			log.Debugf("Successfully save the SLA data to file: %s", file)
+			log.Debugf("Successfully save the SLA data to the %s store", s.Kind())
 		}
 	}
 
-	// if DataFile is explicitly disabled redefine as empty
-	if c.Settings.SLAReport.DataFile == "-" {
-		save = func() {}
-	}
-
-	// save data to file when start the EaseProbe
+	// save data to the store when start the EaseProbe
 	save()
 
 	interval := time.NewTimer(c.Settings.Probe.Interval)
 	defer interval.Stop()
+	compact := time.NewTicker(global.DefaultStoreCompactInterval)
+	defer compact.Stop()
 	for {
 		select {
 		case res := <-saveChannel:
-			probe.SetResultData(res.Name, &res)
+			if err := s.Record(res); err != nil {
+				log.Errorf("Failed to record the result of [%s]: %v", res.Name, err)
+			}
 		case <-doneSave:
-			save()
+			if err := s.Close(); err != nil {
+				log.Errorf("Failed to close the %s store: %v", s.Kind(), err)
+			}
 			log.Info("Received the exit signal. Saving data, process is exiting...")
 			return
 		case <-interval.C:
-			log.Debugf("SaveData - %s Interval is up, Saving data to file...", c.Settings.Probe.Interval)
+			log.Debugf("SaveData - %s Interval is up, Saving data to the store...", c.Settings.Probe.Interval)
 			save()
 			interval.Reset(c.Settings.Probe.Interval)
+		case t := <-compact.C:
+			if err := s.Compact(t); err != nil {
+				log.Errorf("Failed to compact the %s store: %v", s.Kind(), err)
+			}
 		}
 	}
 }
 
-func scheduleSLA(probers []probe.Prober) {
+func scheduleSLA(r *runner.Runner) {
 	cron := gocron.NewScheduler(global.GetEaseProbe().TimeLoc)
 
-	dryNotify := conf.Get().Settings.Notify.Dry
-
 	notifies := channel.GetNotifiers(conf.Get().Settings.SLAReport.Channels)
 	if len(notifies) == 0 {
 		log.Warnf("No notify settings found for SLA report...")
@@ -82,8 +86,11 @@ func scheduleSLA(probers []probe.Prober) {
 	}
 
 	SLAFn := func() {
+		// the probers and notifiers could be changed at runtime
+		probers := r.Probers()
+		notifies := channel.GetNotifiers(conf.Get().Settings.SLAReport.Channels)
 		for _, n := range notifies {
-			if dryNotify {
+			if channel.IsDryNotify() {
 				n.DryNotifyStat(probers)
 			} else {
 				log.Debugf("[%s] notifying the SLA...", n.Kind())
@@ -31,19 +31,27 @@ import (
 	"strings"
 	"time"
 
+	"github.com/wfusion/easeprobe/ack"
 	"github.com/wfusion/easeprobe/channel"
+	"github.com/wfusion/easeprobe/escalation"
 	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/maintenance"
 	"github.com/wfusion/easeprobe/notify"
 	"github.com/wfusion/easeprobe/probe"
 	"github.com/wfusion/easeprobe/probe/client"
+	"github.com/wfusion/easeprobe/probe/dns"
+	"github.com/wfusion/easeprobe/probe/grpc"
+	"github.com/wfusion/easeprobe/probe/heartbeat"
 	"github.com/wfusion/easeprobe/probe/host"
 	"github.com/wfusion/easeprobe/probe/http"
+	"github.com/wfusion/easeprobe/probe/httpflow"
 	"github.com/wfusion/easeprobe/probe/ping"
 	"github.com/wfusion/easeprobe/probe/shell"
 	"github.com/wfusion/easeprobe/probe/ssh"
 	"github.com/wfusion/easeprobe/probe/tcp"
 	"github.com/wfusion/easeprobe/probe/tls"
 	"github.com/wfusion/easeprobe/probe/websocket"
+	"github.com/wfusion/easeprobe/store"
 
 	"github.com/invopop/jsonschema"
 	log "github.com/sirupsen/logrus"
@@ -110,6 +118,7 @@ type Notify struct {
 type Probe struct {
 	Interval                             time.Duration `yaml:"interval" json:"interval,omitempty" jsonschema:"type=string,format=duration,title=Probe Interval,description=the interval of probe,default=1m"`
 	Timeout                              time.Duration `yaml:"timeout" json:"timeout,omitempty" jsonschema:"type=string,format=duration,title=Probe Timeout,description=the timeout of probe,default=30s"`
+	SLO                                  float64       `yaml:"slo,omitempty" json:"slo,omitempty" jsonschema:"title=SLO,description=the SLO target of all probes in percentage,example=99.9,default=99.9"`
 	global.StatusChangeThresholdSettings `yaml:",inline" json:",inline"`
 	global.NotificationStrategySettings  `yaml:"alert" json:"alert" jsonschema:"title=Alert,description=the alert settings"`
 }
@@ -126,10 +135,12 @@ type SLAReport struct {
 
 // HTTPServer is the settings of http server
 type HTTPServer struct {
-	IP              string        `yaml:"ip" json:"ip" jsonschema:"title=Web Server IP,description=the local ip address of the http server need to listen on,example=0.0.0.0"`
-	Port            string        `yaml:"port" json:"port" jsonschema:"type=integer,title=Web Server Port,description=port of the http server,default=8181"`
-	AutoRefreshTime time.Duration `yaml:"refresh" json:"refresh,omitempty" jsonschema:"type=string,title=Auto Refresh Time,description=auto refresh time of the http server,example=5s"`
-	AccessLog       Log           `yaml:"log" json:"log,omitempty" jsonschema:"title=Access Log,description=access log of the http server"`
+	IP                string        `yaml:"ip" json:"ip" jsonschema:"title=Web Server IP,description=the local ip address of the http server need to listen on,example=0.0.0.0"`
+	Port              string        `yaml:"port" json:"port" jsonschema:"type=integer,title=Web Server Port,description=port of the http server,default=8181"`
+	AutoRefreshTime   time.Duration `yaml:"refresh" json:"refresh,omitempty" jsonschema:"type=string,title=Auto Refresh Time,description=auto refresh time of the http server,example=5s"`
+	AccessLog         Log           `yaml:"log" json:"log,omitempty" jsonschema:"title=Access Log,description=access log of the http server"`
+	Token             string        `yaml:"token,omitempty" json:"token,omitempty" jsonschema:"title=API Token,description=the bearer token of the management API - the API is disabled if it is empty"`
+	AllowUnsafeProbes bool          `yaml:"allow_unsafe_probes,omitempty" json:"allow_unsafe_probes,omitempty" jsonschema:"title=Allow Unsafe Probes,description=allow to add the shell and client probes by the management API,default=false"`
 }
 
 // Prometheus is the settings of prometheus
@@ -141,33 +152,44 @@ type Prometheus struct {
 
 // Settings is the EaseProbe configuration
 type Settings struct {
-	Name       string     `yaml:"name" json:"name,omitempty" jsonschema:"title=EaseProbe Name,description=The name of the EaseProbe instance,default=EaseProbe"`
-	IconURL    string     `yaml:"icon" json:"icon,omitempty" jsonschema:"title=Icon URL,description=The URL of the icon of the EaseProbe instance"`
-	PIDFile    string     `yaml:"pid" json:"pid,omitempty" jsonschema:"title=PID File,description=The PID file of the EaseProbe instance ('' or '-' means no PID file)"`
-	Log        Log        `yaml:"log" json:"log,omitempty" jsonschema:"title=EaseProbe Log,description=The log settings of the EaseProbe instance"`
-	TimeFormat string     `yaml:"timeformat" json:"timeformat,omitempty" jsonschema:"title=Time Format,description=The time format of the EaseProbe instance,default=2006-01-02 15:04:05Z07:00"`
-	TimeZone   string     `yaml:"timezone" json:"timezone,omitempty" jsonschema:"title=Time Zone,description=The time zone of the EaseProbe instance,example=Asia/Shanghai,example=Europe/Berlin,default=UTC"`
-	Probe      Probe      `yaml:"probe" json:"probe,omitempty" jsonschema:"title=Probe Settings,description=The global probe settings of the EaseProbe instance"`
-	Notify     Notify     `yaml:"notify" json:"notify,omitempty" jsonschema:"title=Notify Settings,description=The global notify settings of the EaseProbe instance"`
-	SLAReport  SLAReport  `yaml:"sla" json:"sla,omitempty" jsonschema:"title=SLA Report Settings,description=The SLA report settings of the EaseProbe instance"`
-	HTTPServer HTTPServer `yaml:"http" json:"http,omitempty" jsonschema:"title=HTTP Server Settings,description=The HTTP server settings of the EaseProbe instance"`
-	Prometheus Prometheus `yaml:"prometheus" json:"prometheus,omitempty" jsonschema:"title=Prometheus Settings,description=The Prometheus settings of the EaseProbe instance"`
+	Name       string         `yaml:"name" json:"name,omitempty" jsonschema:"title=EaseProbe Name,description=The name of the EaseProbe instance,default=EaseProbe"`
+	IconURL    string         `yaml:"icon" json:"icon,omitempty" jsonschema:"title=Icon URL,description=The URL of the icon of the EaseProbe instance"`
+	PIDFile    string         `yaml:"pid" json:"pid,omitempty" jsonschema:"title=PID File,description=The PID file of the EaseProbe instance ('' or '-' means no PID file)"`
+	Log        Log            `yaml:"log" json:"log,omitempty" jsonschema:"title=EaseProbe Log,description=The log settings of the EaseProbe instance"`
+	TimeFormat string         `yaml:"timeformat" json:"timeformat,omitempty" jsonschema:"title=Time Format,description=The time format of the EaseProbe instance,default=2006-01-02 15:04:05Z07:00"`
+	TimeZone   string         `yaml:"timezone" json:"timezone,omitempty" jsonschema:"title=Time Zone,description=The time zone of the EaseProbe instance,example=Asia/Shanghai,example=Europe/Berlin,default=UTC"`
+	Probe      Probe          `yaml:"probe" json:"probe,omitempty" jsonschema:"title=Probe Settings,description=The global probe settings of the EaseProbe instance"`
+	Notify     Notify         `yaml:"notify" json:"notify,omitempty" jsonschema:"title=Notify Settings,description=The global notify settings of the EaseProbe instance"`
+	SLAReport  SLAReport      `yaml:"sla" json:"sla,omitempty" jsonschema:"title=SLA Report Settings,description=The SLA report settings of the EaseProbe instance"`
+	Store      store.Settings `yaml:"store" json:"store,omitempty" jsonschema:"title=Result Store Settings,description=The result store settings of the EaseProbe instance"`
+	HTTPServer HTTPServer     `yaml:"http" json:"http,omitempty" jsonschema:"title=HTTP Server Settings,description=The HTTP server settings of the EaseProbe instance"`
+	Prometheus Prometheus     `yaml:"prometheus" json:"prometheus,omitempty" jsonschema:"title=Prometheus Settings,description=The Prometheus settings of the EaseProbe instance"`
+	Ack        ack.Settings   `yaml:"ack" json:"ack,omitempty" jsonschema:"title=Acknowledgement Settings,description=The acknowledgement settings of the EaseProbe instance"`
 }
 
 // Conf is Probe configuration
 type Conf struct {
-	Version   string                `yaml:"version" json:"version,omitempty" jsonschema:"title=Version,description=Version of the EaseProbe configuration"`
-	HTTP      []http.HTTP           `yaml:"http" json:"http,omitempty" jsonschema:"title=HTTP Probe,description=HTTP Probe Configuration"`
-	TCP       []tcp.TCP             `yaml:"tcp" json:"tcp,omitempty" jsonschema:"title=TCP Probe,description=TCP Probe Configuration"`
-	Shell     []shell.Shell         `yaml:"shell" json:"shell,omitempty" jsonschema:"title=Shell Probe,description=Shell Probe Configuration"`
-	Client    []client.Client       `yaml:"client" json:"client,omitempty" jsonschema:"title=Native Client Probe,description=Native Client Probe Configuration"`
-	SSH       ssh.SSH               `yaml:"ssh" json:"ssh,omitempty" jsonschema:"title=SSH Probe,description=SSH Probe Configuration"`
-	TLS       []tls.TLS             `yaml:"tls" json:"tls,omitempty" jsonschema:"title=TLS Probe,description=TLS Probe Configuration"`
-	Host      host.Host             `yaml:"host" json:"host,omitempty" jsonschema:"title=Host Probe,description=Host Probe Configuration"`
-	Ping      []ping.Ping           `yaml:"ping" json:"ping,omitempty" jsonschema:"title=Ping Probe,description=Ping Probe Configuration"`
-	WebSocket []websocket.WebSocket `yaml:"websocket" json:"websocket,omitempty" jsonschema:"title=WebSocket Probe,description=WebSocket Probe Configuration"`
-	Notify    notify.Config         `yaml:"notify" json:"notify,omitempty" jsonschema:"title=Notification,description=Notification Configuration"`
-	Settings  Settings              `yaml:"settings" json:"settings,omitempty" jsonschema:"title=Global Settings,description=EaseProbe Global configuration"`
+	Version     string                `yaml:"version" json:"version,omitempty" jsonschema:"title=Version,description=Version of the EaseProbe configuration"`
+	HTTP        []http.HTTP           `yaml:"http" json:"http,omitempty" jsonschema:"title=HTTP Probe,description=HTTP Probe Configuration"`
+	TCP         []tcp.TCP             `yaml:"tcp" json:"tcp,omitempty" jsonschema:"title=TCP Probe,description=TCP Probe Configuration"`
+	Shell       []shell.Shell         `yaml:"shell" json:"shell,omitempty" jsonschema:"title=Shell Probe,description=Shell Probe Configuration"`
+	Client      []client.Client       `yaml:"client" json:"client,omitempty" jsonschema:"title=Native Client Probe,description=Native Client Probe Configuration"`
+	SSH         ssh.SSH               `yaml:"ssh" json:"ssh,omitempty" jsonschema:"title=SSH Probe,description=SSH Probe Configuration"`
+	TLS         []tls.TLS             `yaml:"tls" json:"tls,omitempty" jsonschema:"title=TLS Probe,description=TLS Probe Configuration"`
+	Host        host.Host             `yaml:"host" json:"host,omitempty" jsonschema:"title=Host Probe,description=Host Probe Configuration"`
+	Ping        []ping.Ping           `yaml:"ping" json:"ping,omitempty" jsonschema:"title=Ping Probe,description=Ping Probe Configuration"`
+	WebSocket   []websocket.WebSocket `yaml:"websocket" json:"websocket,omitempty" jsonschema:"title=WebSocket Probe,description=WebSocket Probe Configuration"`
+	GRPC        []grpc.GRPC           `yaml:"grpc" json:"grpc,omitempty" jsonschema:"title=gRPC Probe,description=gRPC Probe Configuration"`
+	DNS         []dns.DNS             `yaml:"dns" json:"dns,omitempty" jsonschema:"title=DNS Probe,description=DNS Probe Configuration"`
+	HTTPFlow    []httpflow.HTTPFlow   `yaml:"http_flow" json:"http_flow,omitempty" jsonschema:"title=HTTP Flow Probe,description=Multi-step HTTP Transaction Probe Configuration"`
+	Heartbeat   []heartbeat.Heartbeat `yaml:"heartbeat" json:"heartbeat,omitempty" jsonschema:"title=Heartbeat Probe,description=Passive Heartbeat Probe Configuration"`
+	Maintenance []maintenance.Window  `yaml:"maintenance" json:"maintenance,omitempty" jsonschema:"title=Maintenance Windows,description=the maintenance windows which suppress the alerts and SLA penalties"`
+	Escalations []escalation.Policy   `yaml:"escalations" json:"escalations,omitempty" jsonschema:"title=Escalation Policies,description=the multi-tier escalation policies of the probe failures"`
+	Routes      []channel.Route       `yaml:"routes" json:"routes,omitempty" jsonschema:"title=Routing Rules,description=the routing rules which send the probe events to the notifiers"`
+	Modules     map[string]Module     `yaml:"modules" json:"modules,omitempty" jsonschema:"title=Probe Modules,description=the probe templates of the blackbox-exporter compatible /probe endpoint"`
+	Notify      notify.Config         `yaml:"notify" json:"notify,omitempty" jsonschema:"title=Notification,description=Notification Configuration"`
+	Settings    Settings              `yaml:"settings" json:"settings,omitempty" jsonschema:"title=Global Settings,description=EaseProbe Global configuration"`
+	snapshot    *Snapshot
 }
 
 // JSONSchema return the json schema of the configuration
@@ -305,20 +327,21 @@ func IsConfigModified(path string) bool {
 	return modified
 }
 
-// New read the configuration from yaml
-func New(conf *string) (*Conf, error) {
+// load reads the configuration from yaml without the initialization,
+// and takes the snapshot of the configuration.
+func load(conf string, sshBastion, hostBastion *ssh.BastionMapType) (*Conf, error) {
 	c := Conf{
 		HTTP:   []http.HTTP{},
 		TCP:    []tcp.TCP{},
 		Shell:  []shell.Shell{},
 		Client: []client.Client{},
 		SSH: ssh.SSH{
-			Bastion: &ssh.BastionMap,
+			Bastion: sshBastion,
 			Servers: []ssh.Server{},
 		},
 		TLS: []tls.TLS{},
 		Host: host.Host{
-			Bastion: &host.BastionMap,
+			Bastion: hostBastion,
 			Servers: []host.Server{},
 		},
 		Notify: notify.Config{},
@@ -355,7 +378,7 @@ func New(conf *string) (*Conf, error) {
 			},
 		},
 	}
-	y, err := getYamlFile(*conf)
+	y, err := getYamlFile(conf)
 	if err != nil {
 		log.Errorf("error: %v ", err)
 		return &c, err
@@ -369,6 +392,17 @@ func New(conf *string) (*Conf, error) {
 		return &c, err
 	}
 
+	c.snapshot = c.takeSnapshot()
+	return &c, nil
+}
+
+// New read the configuration from yaml
+func New(conf *string) (*Conf, error) {
+	c, err := load(*conf, &ssh.BastionMap, &host.BastionMap)
+	if err != nil {
+		return c, err
+	}
+
 	// Initialization
 	c.Settings.Log.InitLog(nil)
 	global.InitEaseProbeWithTime(c.Settings.Name, c.Settings.IconURL,
@@ -381,7 +415,7 @@ func New(conf *string) (*Conf, error) {
 	// pass the dry run to the channel
 	channel.SetDryNotify(c.Settings.Notify.Dry)
 
-	config = &c
+	config = c
 
 	log.Infoln("Load the configuration file successfully!")
 	if log.GetLevel() >= log.DebugLevel {
@@ -393,7 +427,7 @@ func New(conf *string) (*Conf, error) {
 		}
 	}
 
-	return &c, err
+	return c, nil
 }
 
 // InitAllLogs initialize all logs
@@ -452,6 +486,17 @@ func isProbe(t reflect.Type) bool {
 	return t.Implements(modelType)
 }
 
+// ProbeSettings return the global settings of the probers
+func (conf *Conf) ProbeSettings() global.ProbeSettings {
+	return global.ProbeSettings{
+		Interval:                      conf.Settings.Probe.Interval,
+		Timeout:                       conf.Settings.Probe.Timeout,
+		SLO:                           conf.Settings.Probe.SLO,
+		StatusChangeThresholdSettings: conf.Settings.Probe.StatusChangeThresholdSettings,
+		NotificationStrategySettings:  conf.Settings.Probe.NotificationStrategySettings,
+	}
+}
+
 // AllProbers return all probers
 func (conf *Conf) AllProbers() []probe.Prober {
 	log.Debugf("--------- Process the probers settings ---------")
@@ -30,6 +30,15 @@ import (
 // LogLevel is the log level
 type LogLevel log.Level
 
+// stdout is the log writer if no log file is configured
+var stdout io.Writer = os.Stdout
+
+// SetStdout set the log writer if no log file is configured,
+// e.g. the check mode writes the logs to stderr to keep the output clean.
+func SetStdout(w io.Writer) {
+	stdout = w
+}
+
 var levelToString = map[LogLevel]string{
 	LogLevel(log.DebugLevel): "debug",
 	LogLevel(log.InfoLevel):  "info",
@@ -118,7 +127,7 @@ func (l *Log) Open() {
 	// using stdout if no log file
 	if l.File == "" {
 		l.IsStdout = true
-		l.Writer = os.Stdout
+		l.Writer = stdout
 		return
 	}
 	// using lumberjack if self rotate
@@ -140,7 +149,7 @@ func (l *Log) Open() {
 		log.Warnf("[Log] Cannot open log file: %v", err)
 		log.Infoln("[Log] Using Standard Output as the log output...")
 		l.IsStdout = true
-		l.Writer = os.Stdout
+		l.Writer = stdout
 		return
 	}
 	l.IsStdout = false
@@ -194,3 +194,13 @@ func TestOpenLogFail(t *testing.T) {
 	l.Close()
 	os.Remove(l.File)
 }
+
+func TestSetStdout(t *testing.T) {
+	SetStdout(os.Stderr)
+	defer SetStdout(os.Stdout)
+
+	l := NewLog()
+	l.InitLog(log.New())
+	assert.Equal(t, true, l.IsStdout)
+	assert.Equal(t, os.Stderr, l.GetWriter())
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wfusion/easeprobe/metric"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/probe/base"
+	"github.com/wfusion/easeprobe/probe/client"
+	"github.com/wfusion/easeprobe/probe/http"
+	"github.com/wfusion/easeprobe/probe/tcp"
+	"github.com/wfusion/easeprobe/probe/tls"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultModule is the module of the `/probe` endpoint if the module is not specified
+const DefaultModule = "http_2xx"
+
+// Module is the probe template of the blackbox-exporter compatible `/probe` endpoint.
+// Only one kind of probe could be configured, the target of the request is used as
+// the URL of the HTTP probe, or the host of the TCP, TLS and native client probes.
+type Module struct {
+	HTTP   *http.HTTP     `yaml:"http,omitempty" json:"http,omitempty" jsonschema:"title=HTTP Probe,description=HTTP Probe Template"`
+	TCP    *tcp.TCP       `yaml:"tcp,omitempty" json:"tcp,omitempty" jsonschema:"title=TCP Probe,description=TCP Probe Template"`
+	TLS    *tls.TLS       `yaml:"tls,omitempty" json:"tls,omitempty" jsonschema:"title=TLS Probe,description=TLS Probe Template"`
+	Client *client.Client `yaml:"client,omitempty" json:"client,omitempty" jsonschema:"title=Native Client Probe,description=Native Client Probe Template"`
+}
+
+// clone deep copies the probe template, so that the template is never configured
+func clone[T any](t *T) (*T, error) {
+	buf, err := yaml.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	n := new(T)
+	if err := yaml.Unmarshal(buf, n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// NewProber creates a transient prober from the module for the target. The prober is
+// named by the target, its metrics are registered into the dedicated registry, and
+// its timeout is limited by the timeout if it's positive.
+func (m *Module) NewProber(target string, timeout time.Duration, registry *metric.Registry) (probe.Prober, error) {
+	var (
+		p   probe.Prober
+		d   *base.DefaultProbe
+		err error
+		cnt int
+	)
+	if m.HTTP != nil {
+		var h *http.HTTP
+		if h, err = clone(m.HTTP); err == nil {
+			h.URL = target
+			p, d = h, &h.DefaultProbe
+		}
+		cnt++
+	}
+	if m.TCP != nil {
+		var t *tcp.TCP
+		if t, err = clone(m.TCP); err == nil {
+			t.Host = target
+			p, d = t, &t.DefaultProbe
+		}
+		cnt++
+	}
+	if m.TLS != nil {
+		var t *tls.TLS
+		if t, err = clone(m.TLS); err == nil {
+			t.Host = target
+			p, d = t, &t.DefaultProbe
+		}
+		cnt++
+	}
+	if m.Client != nil {
+		var c *client.Client
+		if c, err = clone(m.Client); err == nil {
+			c.Host = target
+			p, d = c, &c.DefaultProbe
+		}
+		cnt++
+	}
+
+	if cnt != 1 {
+		return nil, fmt.Errorf("the module must have exactly one probe, but %d found", cnt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d.ProbeName = target
+	if timeout > 0 && (d.ProbeTimeout <= 0 || d.ProbeTimeout > timeout) {
+		d.ProbeTimeout = timeout
+	}
+	d.SetMetricRegistry(registry)
+	return p, nil
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/metric"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/probe/http"
+	"gopkg.in/yaml.v3"
+)
+
+func TestModule(t *testing.T) {
+	var c Conf
+	err := yaml.Unmarshal([]byte(`
+modules:
+  tcp_connect:
+    tcp:
+      timeout: 10s
+  bad:
+    tcp: {}
+    http: {}
+`), &c)
+	assert.Nil(t, err)
+	assert.Len(t, c.Modules, 2)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer ln.Close()
+	target := ln.Addr().String()
+
+	m := c.Modules["tcp_connect"]
+	registry := prometheus.NewRegistry()
+	p, err := m.NewProber(target, 2*time.Second, metric.NewRegistry(registry))
+	assert.Nil(t, err)
+	assert.Equal(t, target, p.Name())
+	assert.Equal(t, 2*time.Second, p.Timeout())
+	// the template is not changed
+	assert.Empty(t, m.TCP.Host)
+	assert.Equal(t, 10*time.Second, m.TCP.ProbeTimeout)
+
+	assert.Nil(t, p.Config(global.ProbeSettings{}))
+	result := p.Probe()
+	assert.Equal(t, probe.StatusUp, result.Status)
+
+	// the metrics and the result are not kept globally
+	families, err := registry.Gather()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, families)
+	for _, f := range families {
+		assert.Nil(t, metric.Gauge(f.GetName()))
+	}
+	assert.Nil(t, probe.GetResultData(target))
+
+	// the timeout of the module is kept if it's shorter
+	p, err = m.NewProber(target, time.Minute, metric.NewRegistry(prometheus.NewRegistry()))
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Second, p.Timeout())
+
+	// only one kind of probe is allowed
+	bad := c.Modules["bad"]
+	_, err = bad.NewProber(target, 0, metric.NewRegistry(prometheus.NewRegistry()))
+	assert.NotNil(t, err)
+	empty := Module{}
+	_, err = empty.NewProber(target, 0, metric.NewRegistry(prometheus.NewRegistry()))
+	assert.NotNil(t, err)
+
+	// the target is the URL of the HTTP probe
+	m = Module{HTTP: &http.HTTP{Method: "HEAD"}}
+	p, err = m.NewProber("http://example.com", 0, metric.NewRegistry(prometheus.NewRegistry()))
+	assert.Nil(t, err)
+	assert.Equal(t, "http://example.com", p.(*http.HTTP).URL)
+	assert.Equal(t, "HEAD", p.(*http.HTTP).Method)
+	assert.Empty(t, m.HTTP.URL)
+}
@@ -0,0 +1,192 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/channel"
+	"github.com/wfusion/easeprobe/notify"
+	"github.com/wfusion/easeprobe/probe/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrRestartRequired is the error of the configuration cannot be reloaded in process
+var ErrRestartRequired = errors.New("restart is required")
+
+// Snapshot is the fingerprints of the configuration. It must be taken before
+// the configuration is initialized, because the initialization fills the default values.
+type Snapshot struct {
+	Settings  string            // the settings which cannot be reloaded in process
+	Probe     string            // the global probe settings
+	Notify    string            // the global notification settings
+	Probers   map[string]string // probe name -> fingerprint
+	Notifiers map[string]string // notifier key -> fingerprint
+}
+
+// Diff is the difference between two configurations
+type Diff struct {
+	Restart          bool
+	AddedProbers     []string
+	RemovedProbers   []string
+	ChangedProbers   []string
+	AddedNotifiers   []string
+	RemovedNotifiers []string
+	ChangedNotifiers []string
+}
+
+// IsEmpty returns true if nothing is changed
+func (d *Diff) IsEmpty() bool {
+	return !d.Restart &&
+		len(d.AddedProbers)+len(d.RemovedProbers)+len(d.ChangedProbers) == 0 &&
+		len(d.AddedNotifiers)+len(d.RemovedNotifiers)+len(d.ChangedNotifiers) == 0
+}
+
+func (d *Diff) String() string {
+	return fmt.Sprintf("probes(added=%v, removed=%v, changed=%v), notifiers(added=%v, removed=%v, changed=%v)",
+		d.AddedProbers, d.RemovedProbers, d.ChangedProbers,
+		d.AddedNotifiers, d.RemovedNotifiers, d.ChangedNotifiers)
+}
+
+// fingerprint returns the sha256 of the yaml, or empty if it cannot be marshaled
+func fingerprint(v interface{}) string {
+	buf, err := yaml.Marshal(v)
+	if err != nil {
+		log.Warnf("Failed to take the fingerprint of the configuration: %v", err)
+		return ""
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+func (conf *Conf) takeSnapshot() *Snapshot {
+	settings := conf.Settings
+	settings.Probe = Probe{}
+	settings.Notify = Notify{}
+
+	s := &Snapshot{
+		Settings:  fingerprint([]interface{}{settings, conf.SSH.Bastion, conf.Host.Bastion}),
+		Probe:     fingerprint(conf.Settings.Probe),
+		Notify:    fingerprint(conf.Settings.Notify),
+		Probers:   map[string]string{},
+		Notifiers: map[string]string{},
+	}
+	for _, p := range conf.AllProbers() {
+		s.Probers[p.Name()] = fingerprint(p)
+	}
+	for key, n := range conf.NotifierMap() {
+		s.Notifiers[key] = fingerprint(n)
+	}
+	return s
+}
+
+// Diff returns the difference from the snapshot to the new one
+func (s *Snapshot) Diff(n *Snapshot) *Diff {
+	d := &Diff{Restart: s.Settings != n.Settings || len(n.Settings) == 0}
+	d.AddedProbers, d.RemovedProbers, d.ChangedProbers = diffFingerprints(s.Probers, n.Probers, s.Probe != n.Probe)
+	d.AddedNotifiers, d.RemovedNotifiers, d.ChangedNotifiers = diffFingerprints(s.Notifiers, n.Notifiers, s.Notify != n.Notify)
+	return d
+}
+
+// diffFingerprints returns the sorted keys of the added, removed, and changed items,
+// all of the existing items are changed if the global settings are changed.
+func diffFingerprints(old, new map[string]string, all bool) (added, removed, changed []string) {
+	for k, v := range new {
+		ov, ok := old[k]
+		switch {
+		case !ok:
+			added = append(added, k)
+		case all || ov != v || len(v) == 0:
+			changed = append(changed, k)
+		}
+	}
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+// NotifierMap returns all notifiers with the key - "kind/name", e.g. "slack/my-slack"
+func (conf *Conf) NotifierMap() map[string]notify.Notify {
+	notifiers := map[string]notify.Notify{}
+
+	t := reflect.TypeOf(conf.Notify)
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Kind() != reflect.Slice {
+			continue
+		}
+		kind := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		v := reflect.ValueOf(conf.Notify).Field(i)
+		for j := 0; j < v.Len(); j++ {
+			if !isNotify(v.Index(j).Addr().Type()) {
+				continue
+			}
+			n := v.Index(j).Addr().Interface().(notify.Notify)
+			notifiers[kind+"/"+n.Name()] = n
+		}
+	}
+	return notifiers
+}
+
+// Reload reads the configuration file again for the in-process reload, and returns
+// the difference from the current configuration. Only the probes, the notifications,
+// the maintenance windows, and their global settings could be reloaded in process,
+// ErrRestartRequired is returned if the other settings are changed.
+func Reload(path string) (*Conf, *Diff, error) {
+	old := Get()
+	if old == nil || old.snapshot == nil {
+		return nil, nil, fmt.Errorf("%w - the configuration is not loaded", ErrRestartRequired)
+	}
+
+	// the running bastion hosts are not touched
+	c, err := load(path, &ssh.BastionMapType{}, &ssh.BastionMapType{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	diff := old.snapshot.Diff(c.snapshot)
+	if diff.Restart {
+		return nil, nil, fmt.Errorf("%w - the global settings or the bastion hosts are changed", ErrRestartRequired)
+	}
+
+	// take over the initialized settings
+	settings := old.Settings
+	settings.Probe = c.Settings.Probe
+	settings.Notify = c.Settings.Notify
+	c.Settings = settings
+	c.SSH.Bastion = old.SSH.Bastion
+	c.Host.Bastion = old.Host.Bastion
+
+	channel.SetDryNotify(c.Settings.Notify.Dry)
+
+	config = c
+	log.Infof("Reload the configuration file successfully! %s", diff)
+	return c, diff, nil
+}
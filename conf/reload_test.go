@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/probe/host"
+	"github.com/wfusion/easeprobe/probe/ssh"
+)
+
+const reloadYAML = `
+http:
+  - name: web
+    url: https://example.com
+  - name: api
+    url: https://api.example.com
+tcp:
+  - name: redis
+    host: 127.0.0.1:6379
+notify:
+  log:
+    - name: log-a
+      file: /tmp/easeprobe-a.log
+    - name: log-b
+      file: /tmp/easeprobe-b.log
+settings:
+  sla:
+    data: "-"
+`
+
+func TestDiffFingerprints(t *testing.T) {
+	old := map[string]string{"a": "1", "b": "2", "c": "3"}
+	new := map[string]string{"b": "2", "c": "4", "d": "5"}
+	added, removed, changed := diffFingerprints(old, new, false)
+	assert.Equal(t, []string{"d"}, added)
+	assert.Equal(t, []string{"a"}, removed)
+	assert.Equal(t, []string{"c"}, changed)
+
+	// all of the existing items are changed
+	_, _, changed = diffFingerprints(old, new, true)
+	assert.Equal(t, []string{"b", "c"}, changed)
+
+	// the item without fingerprint is always changed
+	_, _, changed = diffFingerprints(map[string]string{"a": ""}, map[string]string{"a": ""}, false)
+	assert.Equal(t, []string{"a"}, changed)
+}
+
+func TestReload(t *testing.T) {
+	file := "./reload.yaml"
+	defer os.RemoveAll(file)
+	// the bastion hosts of the other tests
+	ssh.BastionMap = nil
+	host.BastionMap = nil
+
+	assert.Nil(t, writeConfig(file, reloadYAML))
+	c, err := New(&file)
+	assert.Nil(t, err)
+	assert.NotNil(t, c.snapshot)
+	assert.Len(t, c.snapshot.Probers, 3)
+	assert.Equal(t, []string{"log/log-a", "log/log-b"}, sortedKeys(c.NotifierMap()))
+
+	// nothing changed
+	c2, diff, err := Reload(file)
+	assert.Nil(t, err)
+	assert.True(t, diff.IsEmpty())
+	assert.Equal(t, c2, Get())
+
+	// the probes and notifiers are changed
+	modified := `
+http:
+  - name: web
+    url: https://example.com
+  - name: api
+    url: https://api2.example.com
+ping:
+  - name: gateway
+    host: 10.0.0.1
+notify:
+  log:
+    - name: log-a
+      file: /tmp/easeprobe-a.log
+  slack:
+    - name: slack
+      webhook: https://hooks.slack.com/services/xxx
+settings:
+  sla:
+    data: "-"
+`
+	assert.Nil(t, writeConfig(file, modified))
+	_, diff, err = Reload(file)
+	assert.Nil(t, err)
+	assert.False(t, diff.IsEmpty())
+	assert.Equal(t, []string{"gateway"}, diff.AddedProbers)
+	assert.Equal(t, []string{"redis"}, diff.RemovedProbers)
+	assert.Equal(t, []string{"api"}, diff.ChangedProbers)
+	assert.Equal(t, []string{"slack/slack"}, diff.AddedNotifiers)
+	assert.Equal(t, []string{"log/log-b"}, diff.RemovedNotifiers)
+	assert.Empty(t, diff.ChangedNotifiers)
+	assert.Contains(t, diff.String(), "added=[gateway]")
+
+	// the global probe settings change all of the probes
+	assert.Nil(t, writeConfig(file, modified+"  probe:\n    interval: 30s\n"))
+	c3, diff, err := Reload(file)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"api", "gateway", "web"}, diff.ChangedProbers)
+	assert.Empty(t, diff.ChangedNotifiers)
+	assert.Equal(t, "30s", c3.Settings.Probe.Interval.String())
+
+	// the other settings cannot be reloaded
+	assert.Nil(t, writeConfig(file, modified+"  http:\n    port: 8282\n"))
+	_, _, err = Reload(file)
+	assert.True(t, errors.Is(err, ErrRestartRequired))
+	assert.Equal(t, c3, Get())
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package escalation is the multi-tier escalation of the probe failures.
+// The stages of a policy notify more notifiers as the failure lasts longer,
+// and the escalation stops when the probe recovers or the failure is acknowledged.
+package escalation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Stage is a tier of the escalation
+type Stage struct {
+	After     time.Duration `yaml:"after,omitempty" json:"after,omitempty" jsonschema:"type=string,format=duration,title=After,description=the time since the failure starts - the stage is notified immediately if it's zero,example=10m"`
+	Notifiers []string      `yaml:"notifiers" json:"notifiers" jsonschema:"required,title=Notifiers,description=the names of the notifiers of the stage"`
+}
+
+// Policy is the escalation policy, it's attached to the probes or the channels.
+// The policy without any target applies to all of the probes.
+type Policy struct {
+	Name     string   `yaml:"name" json:"name" jsonschema:"required,title=Name,description=the unique name of the escalation policy"`
+	Probes   []string `yaml:"probes,omitempty" json:"probes,omitempty" jsonschema:"title=Probe Names,description=the names of the target probes"`
+	Channels []string `yaml:"channels,omitempty" json:"channels,omitempty" jsonschema:"title=Channels,description=the channels of the target probes"`
+	Stages   []Stage  `yaml:"stages" json:"stages" jsonschema:"required,title=Stages,description=the stages of the escalation in order"`
+}
+
+// Config checks and configures the escalation policy
+func (p *Policy) Config() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if len(p.Name) <= 0 {
+		return fmt.Errorf("the escalation policy name is empty")
+	}
+	if len(p.Stages) <= 0 {
+		return fmt.Errorf("escalation policy [%s] - the stages are required", p.Name)
+	}
+	for i, s := range p.Stages {
+		if len(s.Notifiers) <= 0 {
+			return fmt.Errorf("escalation policy [%s] - the notifiers of stage %d are required", p.Name, i+1)
+		}
+		if s.After < 0 {
+			return fmt.Errorf("escalation policy [%s] - the after of stage %d must not be negative", p.Name, i+1)
+		}
+		if i > 0 && s.After < p.Stages[i-1].After {
+			return fmt.Errorf("escalation policy [%s] - the after of stage %d must not be earlier than stage %d", p.Name, i+1, i)
+		}
+	}
+	return nil
+}
+
+// Match returns true if the probe is the target of the policy
+func (p *Policy) Match(name string, channels []string) bool {
+	if len(p.Probes) <= 0 && len(p.Channels) <= 0 {
+		return true
+	}
+	for _, n := range p.Probes {
+		if n == name {
+			return true
+		}
+	}
+	for _, c := range p.Channels {
+		for _, ch := range channels {
+			if c == ch {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// String returns the readable policy
+func (p *Policy) String() string {
+	stages := make([]string, 0, len(p.Stages))
+	for _, s := range p.Stages {
+		stages = append(stages, fmt.Sprintf("%s: %s", s.After, strings.Join(s.Notifiers, ",")))
+	}
+	return fmt.Sprintf("%s [%s]", p.Name, strings.Join(stages, " -> "))
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package escalation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func TestPolicyConfig(t *testing.T) {
+	cases := []struct {
+		p   Policy
+		err bool
+	}{
+		{Policy{Name: "ops", Stages: []Stage{{Notifiers: []string{"slack"}}, {After: time.Minute, Notifiers: []string{"sms"}}}}, false},
+		{Policy{Name: " ", Stages: []Stage{{Notifiers: []string{"slack"}}}}, true},
+		{Policy{Name: "no stages"}, true},
+		{Policy{Name: "no notifiers", Stages: []Stage{{After: time.Minute}}}, true},
+		{Policy{Name: "negative", Stages: []Stage{{After: -time.Minute, Notifiers: []string{"slack"}}}}, true},
+		{Policy{Name: "disorder", Stages: []Stage{{After: time.Hour, Notifiers: []string{"slack"}}, {After: time.Minute, Notifiers: []string{"sms"}}}}, true},
+	}
+	for _, c := range cases {
+		err := c.p.Config()
+		assert.Equal(t, c.err, err != nil, c.p.Name)
+	}
+
+	p := Policy{Name: "ops", Stages: []Stage{{Notifiers: []string{"slack"}}, {After: time.Minute, Notifiers: []string{"sms", "email"}}}}
+	assert.Equal(t, "ops [0s: slack -> 1m0s: sms,email]", p.String())
+}
+
+func TestPolicyMatch(t *testing.T) {
+	p := Policy{Name: "all"}
+	assert.True(t, p.Match("any", nil))
+
+	p = Policy{Name: "targets", Probes: []string{"web"}, Channels: []string{"ops"}}
+	assert.True(t, p.Match("web", nil))
+	assert.True(t, p.Match("db", []string{"dev", "ops"}))
+	assert.False(t, p.Match("db", []string{"dev"}))
+}
+
+func TestManager(t *testing.T) {
+	SetPolicies([]Policy{
+		{Name: "web", Probes: []string{"web"}, Stages: []Stage{
+			{Notifiers: []string{"slack"}},
+			{After: 10 * time.Minute, Notifiers: []string{"sms"}},
+			{After: 30 * time.Minute, Notifiers: []string{"email"}},
+		}},
+		{Name: "web", Stages: []Stage{{Notifiers: []string{"duplicated"}}}},
+		{Name: "bad"},
+		{Name: "ops", Channels: []string{"ops"}, Stages: []Stage{{After: time.Minute, Notifiers: []string{"ops"}}}},
+	})
+	defer SetPolicies(nil)
+
+	assert.Len(t, All(), 2)
+	assert.Equal(t, "web", Find("web", []string{"ops"}).Name)
+	assert.Equal(t, "ops", Find("db", []string{"ops"}).Name)
+	assert.Nil(t, Find("db", nil))
+	assert.NotNil(t, Get("ops"))
+	assert.Nil(t, Get("none"))
+
+	now := time.Now()
+	r := probe.NewResult()
+	r.Name = "web"
+
+	// the first stage is notified immediately
+	Process(r, probe.StatusDown, nil, now)
+	assert.Equal(t, []string{"slack"}, r.Escalated)
+	assert.Equal(t, "web", r.Stat.Escalation.Policy)
+	assert.Equal(t, 1, r.Stat.Escalation.Stage)
+	assert.Equal(t, now, r.Stat.Escalation.Since)
+
+	Process(r, probe.StatusDown, nil, now.Add(5*time.Minute))
+	assert.Empty(t, r.Escalated)
+	assert.Equal(t, 1, r.Stat.Escalation.Stage)
+
+	// the stages are caught up after a long gap
+	Process(r, probe.StatusDown, nil, now.Add(time.Hour))
+	assert.Equal(t, []string{"sms", "email"}, r.Escalated)
+	assert.Equal(t, 3, r.Stat.Escalation.Stage)
+
+	Process(r, probe.StatusDown, nil, now.Add(2*time.Hour))
+	assert.Empty(t, r.Escalated)
+
+	// the recovery is sent to all of the escalated notifiers
+	Process(r, probe.StatusUp, nil, now.Add(3*time.Hour))
+	assert.Equal(t, []string{"slack", "sms", "email"}, r.Escalated)
+	assert.Nil(t, r.Stat.Escalation)
+
+	Process(r, probe.StatusUp, nil, now.Add(4*time.Hour))
+	assert.Empty(t, r.Escalated)
+
+	// the escalation holds after the acknowledgement
+	Process(r, probe.StatusDown, nil, now)
+	r.Stat.Ack = &probe.AckData{By: "alice", Time: now}
+	Process(r, probe.StatusDown, nil, now.Add(time.Hour))
+	assert.Empty(t, r.Escalated)
+	assert.Equal(t, 1, r.Stat.Escalation.Stage)
+	Process(r, probe.StatusUp, nil, now.Add(2*time.Hour))
+	assert.Equal(t, []string{"slack"}, r.Escalated)
+	assert.Nil(t, r.Stat.Escalation)
+	r.Stat.Ack = nil
+
+	// the escalation holds in the maintenance window
+	r.Maintenance = "deploy"
+	Process(r, probe.StatusDown, nil, now)
+	assert.Empty(t, r.Escalated)
+	assert.Equal(t, 0, r.Stat.Escalation.Stage)
+	r.Maintenance = ""
+	Process(r, probe.StatusDown, nil, now.Add(15*time.Minute))
+	assert.Equal(t, []string{"slack", "sms"}, r.Escalated)
+
+	// the escalation holds if the failure is suppressed by the parent
+	o := probe.NewResult()
+	o.Name = "db"
+	o.Status = probe.StatusDown
+	o.SuppressedBy = "network"
+	Process(o, probe.StatusDown, []string{"ops"}, now)
+	Process(o, probe.StatusDown, []string{"ops"}, now.Add(time.Hour))
+	assert.Empty(t, o.Escalated)
+	assert.Equal(t, "ops", o.Stat.Escalation.Policy)
+
+	// the probe without any policy is not escalated
+	o.Status = probe.StatusDown
+	o.SuppressedBy = ""
+	Process(o, probe.StatusDown, nil, now)
+	assert.Empty(t, o.Escalated)
+	assert.Nil(t, o.Stat.Escalation)
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package escalation
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+const kind = "escalation"
+
+var (
+	mutex    sync.RWMutex
+	policies []*Policy
+)
+
+// SetPolicies replace all of the escalation policies, the invalid policy is skipped.
+func SetPolicies(ps []Policy) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	policies = []*Policy{}
+	for i := range ps {
+		p := ps[i]
+		if err := p.Config(); err != nil {
+			log.Errorf("[%s] Bad escalation policy configuration: %v", kind, err)
+			continue
+		}
+		if find(p.Name) != nil {
+			log.Errorf("[%s] Escalation policy [%s] name is duplicated, ignored!", kind, p.Name)
+			continue
+		}
+		policies = append(policies, &p)
+		log.Infof("[%s] Escalation policy [%s] is configured", kind, p.String())
+	}
+}
+
+// All return all of the escalation policies
+func All() []Policy {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	result := make([]Policy, 0, len(policies))
+	for _, p := range policies {
+		result = append(result, *p)
+	}
+	return result
+}
+
+// Find return the first escalation policy of the probe, or nil if no policy is attached
+func Find(name string, channels []string) *Policy {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for _, p := range policies {
+		if p.Match(name, channels) {
+			result := *p
+			return &result
+		}
+	}
+	return nil
+}
+
+// Get return the escalation policy by name, or nil if it's not found
+func Get(name string) *Policy {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if p := find(name); p != nil {
+		result := *p
+		return &result
+	}
+	return nil
+}
+
+func find(name string) *Policy {
+	for _, p := range policies {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Process updates the escalation state of the probe result with the current status,
+// and sets the notifiers of the stages which are reached at the time now. When the
+// probe recovers, the notifiers of the escalated stages are set to receive the recovery.
+// The escalation holds during the maintenance window, the parent failure, or after
+// the failure is acknowledged.
+func Process(r *probe.Result, status probe.Status, channels []string, now time.Time) {
+	r.Escalated = nil
+	e := r.Stat.Escalation
+
+	if status.IsAvailable() || status == probe.StatusInit {
+		if e != nil {
+			if p := Get(e.Policy); p != nil {
+				for i := 0; i < e.Stage && i < len(p.Stages); i++ {
+					r.Escalated = append(r.Escalated, p.Stages[i].Notifiers...)
+				}
+			}
+		}
+		r.Stat.Escalation = nil
+		return
+	}
+
+	p := Find(r.Name, channels)
+	if p == nil {
+		r.Stat.Escalation = nil
+		return
+	}
+	// a new failure, or the policy is changed
+	if e == nil || e.Policy != p.Name {
+		e = &probe.EscalationData{Policy: p.Name, Since: now}
+		r.Stat.Escalation = e
+	}
+	if r.IsAcked() || r.InMaintenance() || r.IsSuppressed() {
+		return
+	}
+	for e.Stage < len(p.Stages) && now.Sub(e.Since) >= p.Stages[e.Stage].After {
+		log.Infof("[%s] %s - escalated to stage %d of the policy [%s] after %s",
+			kind, r.Name, e.Stage+1, p.Name, now.Sub(e.Since).Round(time.Second))
+		r.Escalated = append(r.Escalated, p.Stages[e.Stage].Notifiers...)
+		e.Stage++
+	}
+}
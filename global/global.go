@@ -64,6 +64,8 @@ const (
 	DefaultProbeInterval = time.Second * 60
 	// DefaultTimeOut is 30 seconds
 	DefaultTimeOut = time.Second * 30
+	// DefaultHeartbeatGrace is the default grace period of the heartbeat probe
+	DefaultHeartbeatGrace = time.Second * 60
 	// DefaultChannelName  is the default wide channel name
 	DefaultChannelName = "__EaseProbe_Channel__"
 	// DefaultStatusChangeThresholdSetting is the threshold of status change
@@ -74,8 +76,22 @@ const (
 	DefaultMaxNotificationTimes = 1
 	// DefaultNotificationFactor is the default notification factor
 	DefaultNotificationFactor = 1
+	// DefaultGroupWait is the default time to buffer the events of a new alert group
+	DefaultGroupWait = time.Second * 30
+	// DefaultGroupInterval is the default interval of the follow-up digests of an alert group
+	DefaultGroupInterval = time.Minute * 5
+	// DefaultSLO is the default SLO target in percentage
+	DefaultSLO = 99.9
 	// DefaultConfigFileCheckInterval is the default config file checking interval
 	DefaultConfigFileCheckInterval = time.Second * 5
+	// DefaultStoreRetention is 90 days
+	DefaultStoreRetention = time.Hour * 24 * 90
+	// DefaultDownsampleAfter is 7 days
+	DefaultDownsampleAfter = time.Hour * 24 * 7
+	// DefaultDownsampleInterval is 1 hour
+	DefaultDownsampleInterval = time.Hour
+	// DefaultStoreCompactInterval is 1 hour
+	DefaultStoreCompactInterval = time.Hour
 )
 
 const (
@@ -85,10 +101,18 @@ const (
 	DefaultHTTPServerPort = "8181"
 	// DefaultPageSize is the default page size
 	DefaultPageSize = 100
+	// DefaultUptimeDays is the days of the uptime bar in the probe page
+	DefaultUptimeDays = 90
+	// DefaultHistoryPoints is the number of points of the history if the step is not specified
+	DefaultHistoryPoints = 300
+	// MaxHistoryPoints is the max number of points of the history
+	MaxHistoryPoints = 10000
 	// DefaultAccessLogFile is the default access log file name
 	DefaultAccessLogFile = "access.log"
 	// DefaultDataFile is the default data file name
 	DefaultDataFile = "data/data.yaml"
+	// DefaultStoreFile is the default file name of the result store, it's in the same directory of the data file
+	DefaultStoreFile = "easeprobe.db"
 	// DefaultPIDFile is the default pid file name
 	DefaultPIDFile = "easeprobe.pid"
 )
@@ -34,6 +34,7 @@ type StatusChangeThresholdSettings struct {
 type ProbeSettings struct {
 	Interval time.Duration
 	Timeout  time.Duration
+	SLO      float64
 	StatusChangeThresholdSettings
 	NotificationStrategySettings
 }
@@ -48,6 +49,18 @@ func (p *ProbeSettings) NormalizeInterval(t time.Duration) time.Duration {
 	return normalize(p.Interval, t, 0, DefaultProbeInterval)
 }
 
+// NormalizeSLO return a normalized SLO target, it must be in (0, 100]
+func (p *ProbeSettings) NormalizeSLO(t float64) float64 {
+	if t > 100 {
+		t = 0
+	}
+	slo := p.SLO
+	if slo > 100 {
+		slo = 0
+	}
+	return normalize(slo, t, 0, DefaultSLO)
+}
+
 // NormalizeThreshold return a normalized threshold value
 func (p *ProbeSettings) NormalizeThreshold(t StatusChangeThresholdSettings) StatusChangeThresholdSettings {
 	return StatusChangeThresholdSettings{
@@ -50,6 +50,19 @@ func TestProbe(t *testing.T) {
 	assert.Equal(t, time.Duration(20), r)
 }
 
+func TestNormalizeSLO(t *testing.T) {
+	p := ProbeSettings{}
+	assert.Equal(t, DefaultSLO, p.NormalizeSLO(0))
+	assert.Equal(t, 99.5, p.NormalizeSLO(99.5))
+	assert.Equal(t, DefaultSLO, p.NormalizeSLO(101))
+
+	p.SLO = 99
+	assert.Equal(t, float64(99), p.NormalizeSLO(0))
+	assert.Equal(t, float64(100), p.NormalizeSLO(100))
+	p.SLO = 200
+	assert.Equal(t, DefaultSLO, p.NormalizeSLO(-1))
+}
+
 func TestStatusChangeThresholdSettings(t *testing.T) {
 	p := ProbeSettings{}
 
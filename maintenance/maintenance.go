@@ -0,0 +1,193 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package maintenance is the maintenance window package.
+// During a maintenance window, the notifications of the target probes are suppressed,
+// and the downtime is booked as maintenance time instead of counting against the SLA.
+package maintenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Window is a one-off or recurring maintenance window
+type Window struct {
+	Name        string            `yaml:"name" json:"name" jsonschema:"required,title=Name,description=the unique name of the maintenance window"`
+	Description string            `yaml:"description,omitempty" json:"description,omitempty" jsonschema:"title=Description,description=the description of the maintenance window"`
+	Start       time.Time         `yaml:"start,omitempty" json:"start,omitempty" jsonschema:"format=date-time,title=Start Time,description=the start time of the one-off maintenance window"`
+	End         time.Time         `yaml:"end,omitempty" json:"end,omitempty" jsonschema:"format=date-time,title=End Time,description=the end time of the one-off maintenance window"`
+	Cron        string            `yaml:"cron,omitempty" json:"cron,omitempty" jsonschema:"title=Cron,description=the cron expression of the recurring maintenance window start time,example=0 2 * * SAT"`
+	Duration    time.Duration     `yaml:"duration,omitempty" json:"duration,omitempty" jsonschema:"type=string,format=duration,title=Duration,description=the duration of the recurring maintenance window"`
+	Probes      []string          `yaml:"probes,omitempty" json:"probes,omitempty" jsonschema:"title=Probe Names,description=the names of the target probes"`
+	Kinds       []string          `yaml:"kinds,omitempty" json:"kinds,omitempty" jsonschema:"title=Probe Kinds,description=the kinds of the target probes"`
+	Labels      map[string]string `yaml:"labels,omitempty" json:"labels,omitempty" jsonschema:"title=Probe Labels,description=the labels of the target probes"`
+
+	schedule cron.Schedule `yaml:"-" json:"-"`
+}
+
+// Config check and config the maintenance window
+func (w *Window) Config() error {
+	w.Name = strings.TrimSpace(w.Name)
+	if len(w.Name) <= 0 {
+		return fmt.Errorf("the maintenance window name is empty")
+	}
+
+	w.Cron = strings.TrimSpace(w.Cron)
+	if len(w.Cron) > 0 {
+		if !w.Start.IsZero() || !w.End.IsZero() {
+			return fmt.Errorf("maintenance window [%s] - the cron and start/end time could not be set at the same time", w.Name)
+		}
+		if w.Duration <= 0 {
+			return fmt.Errorf("maintenance window [%s] - the duration must be positive for the cron", w.Name)
+		}
+		schedule, err := cron.ParseStandard(w.Cron)
+		if err != nil {
+			return fmt.Errorf("maintenance window [%s] - invalid cron [%s]: %v", w.Name, w.Cron, err)
+		}
+		w.schedule = schedule
+		return nil
+	}
+
+	if w.Start.IsZero() || w.End.IsZero() {
+		return fmt.Errorf("maintenance window [%s] - either the cron or the start/end time must be set", w.Name)
+	}
+	if !w.End.After(w.Start) {
+		return fmt.Errorf("maintenance window [%s] - the end time must be after the start time", w.Name)
+	}
+	return nil
+}
+
+// IsRecurring return true if the window is defined by the cron
+func (w *Window) IsRecurring() bool {
+	return w.schedule != nil
+}
+
+// Occurrence return the start and end time of the window occurrence which covers the time t.
+// The ok is false if the window is not active at the time t.
+func (w *Window) Occurrence(t time.Time) (start time.Time, end time.Time, ok bool) {
+	if w.schedule == nil {
+		if !t.Before(w.Start) && t.Before(w.End) {
+			return w.Start, w.End, true
+		}
+		return time.Time{}, time.Time{}, false
+	}
+	// the latest start time which is after (t - duration)
+	start = w.schedule.Next(t.Add(-w.Duration))
+	if start.After(t) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, start.Add(w.Duration), true
+}
+
+// Active return true if the window is active at the time t
+func (w *Window) Active(t time.Time) bool {
+	_, _, ok := w.Occurrence(t)
+	return ok
+}
+
+// Expired return true if the one-off window is over at the time t
+func (w *Window) Expired(t time.Time) bool {
+	return w.schedule == nil && !t.Before(w.End)
+}
+
+// Match return true if the probe is the target of the window.
+// The window without any target matches all of the probes.
+func (w *Window) Match(name, kind string, labels map[string]string) bool {
+	if len(w.Probes) <= 0 && len(w.Kinds) <= 0 && len(w.Labels) <= 0 {
+		return true
+	}
+	for _, n := range w.Probes {
+		if n == name {
+			return true
+		}
+	}
+	for _, k := range w.Kinds {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	if len(w.Labels) > 0 {
+		for k, v := range w.Labels {
+			if lv, ok := labels[k]; !ok || lv != v {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// String return the readable description of the window
+func (w *Window) String() string {
+	if w.schedule != nil {
+		return fmt.Sprintf("%s (cron: %s, duration: %s)", w.Name, w.Cron, w.Duration)
+	}
+	return fmt.Sprintf("%s (%s ~ %s)", w.Name, w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339))
+}
+
+// MarshalJSON marshal the window, the duration is marshaled as the readable string
+func (w Window) MarshalJSON() ([]byte, error) {
+	type alias Window
+	duration := ""
+	if w.Duration > 0 {
+		duration = w.Duration.String()
+	}
+	return json.Marshal(&struct {
+		alias
+		Start    *time.Time `json:"start,omitempty"`
+		End      *time.Time `json:"end,omitempty"`
+		Duration string     `json:"duration,omitempty"`
+	}{
+		alias:    alias(w),
+		Start:    timePtr(w.Start),
+		End:      timePtr(w.End),
+		Duration: duration,
+	})
+}
+
+// UnmarshalJSON unmarshal the window, the duration could be the readable string
+func (w *Window) UnmarshalJSON(data []byte) error {
+	type alias Window
+	aux := &struct {
+		*alias
+		Duration string `json:"duration,omitempty"`
+	}{alias: (*alias)(w)}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	w.Duration = 0
+	if len(aux.Duration) > 0 {
+		d, err := time.ParseDuration(aux.Duration)
+		if err != nil {
+			return fmt.Errorf("invalid duration [%s]: %v", aux.Duration, err)
+		}
+		w.Duration = d
+	}
+	return nil
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
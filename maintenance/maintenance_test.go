@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maintenance
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowConfig(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		w   Window
+		err bool
+	}{
+		{Window{Name: "one-off", Start: now, End: now.Add(time.Hour)}, false},
+		{Window{Name: "cron", Cron: "0 2 * * SAT", Duration: time.Hour}, false},
+		{Window{Name: "tz", Cron: "CRON_TZ=Asia/Shanghai 0 2 * * *", Duration: time.Hour}, false},
+		{Window{Name: " ", Start: now, End: now.Add(time.Hour)}, true},
+		{Window{Name: "empty"}, true},
+		{Window{Name: "end before start", Start: now, End: now.Add(-time.Hour)}, true},
+		{Window{Name: "no end", Start: now}, true},
+		{Window{Name: "no duration", Cron: "0 2 * * *"}, true},
+		{Window{Name: "bad cron", Cron: "bad", Duration: time.Hour}, true},
+		{Window{Name: "both", Cron: "0 2 * * *", Duration: time.Hour, Start: now, End: now.Add(time.Hour)}, true},
+	}
+	for _, c := range cases {
+		err := c.w.Config()
+		assert.Equal(t, c.err, err != nil, c.w.Name)
+	}
+}
+
+func TestWindowActive(t *testing.T) {
+	start := time.Date(2022, 10, 1, 2, 0, 0, 0, time.UTC)
+	w := Window{Name: "deploy", Start: start, End: start.Add(time.Hour)}
+	assert.Nil(t, w.Config())
+	assert.False(t, w.IsRecurring())
+	assert.False(t, w.Active(start.Add(-time.Second)))
+	assert.True(t, w.Active(start))
+	assert.True(t, w.Active(start.Add(59*time.Minute)))
+	assert.False(t, w.Active(start.Add(time.Hour)))
+	assert.False(t, w.Expired(start))
+	assert.True(t, w.Expired(start.Add(time.Hour)))
+	assert.Contains(t, w.String(), "2022-10-01T02:00:00Z")
+
+	// every Saturday 02:00 - 03:30, 2022-10-01 is Saturday
+	w = Window{Name: "weekly", Cron: "0 2 * * SAT", Duration: 90 * time.Minute}
+	assert.Nil(t, w.Config())
+	assert.True(t, w.IsRecurring())
+	assert.False(t, w.Expired(start.Add(time.Hour*24*365)))
+	local := time.Date(2022, 10, 1, 2, 0, 0, 0, time.Local)
+	assert.False(t, w.Active(local.Add(-time.Minute)))
+	assert.True(t, w.Active(local))
+	s, e, ok := w.Occurrence(local.Add(80 * time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, local, s)
+	assert.Equal(t, local.Add(90*time.Minute), e)
+	assert.False(t, w.Active(local.Add(90*time.Minute)))
+	assert.True(t, w.Active(local.Add(7*24*time.Hour+time.Minute)))
+	assert.False(t, w.Active(local.Add(24*time.Hour)))
+	assert.Contains(t, w.String(), "cron: 0 2 * * SAT")
+}
+
+func TestWindowMatch(t *testing.T) {
+	w := Window{Name: "all"}
+	assert.True(t, w.Match("any", "http", nil))
+
+	w = Window{
+		Name:   "target",
+		Probes: []string{"web"},
+		Kinds:  []string{"TCP"},
+		Labels: map[string]string{"env": "prod", "team": "infra"},
+	}
+	assert.True(t, w.Match("web", "http", nil))
+	assert.True(t, w.Match("db", "tcp", nil))
+	assert.True(t, w.Match("api", "http", map[string]string{"env": "prod", "team": "infra", "x": "y"}))
+	assert.False(t, w.Match("api", "http", map[string]string{"env": "prod"}))
+	assert.False(t, w.Match("api", "http", map[string]string{"env": "test", "team": "infra"}))
+	assert.False(t, w.Match("api", "http", nil))
+
+	w = Window{Name: "kinds", Kinds: []string{"ping"}}
+	assert.False(t, w.Match("api", "http", map[string]string{"env": "prod"}))
+}
+
+func TestWindowJSON(t *testing.T) {
+	w := Window{}
+	err := json.Unmarshal([]byte(`{"name":"weekly","cron":"0 2 * * SAT","duration":"1h30m","kinds":["http"]}`), &w)
+	assert.Nil(t, err)
+	assert.Equal(t, 90*time.Minute, w.Duration)
+	assert.Equal(t, []string{"http"}, w.Kinds)
+
+	buf, err := json.Marshal(w)
+	assert.Nil(t, err)
+	assert.Contains(t, string(buf), `"duration":"1h30m0s"`)
+	assert.NotContains(t, string(buf), `"start"`)
+
+	err = json.Unmarshal([]byte(`{"name":"bad","duration":"1x"}`), &w)
+	assert.NotNil(t, err)
+
+	w = Window{}
+	err = json.Unmarshal([]byte(`{"name":"deploy","start":"2022-10-01T02:00:00Z","end":"2022-10-01T03:00:00Z"}`), &w)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), w.Duration)
+	assert.Nil(t, w.Config())
+	buf, err = json.Marshal(w)
+	assert.Nil(t, err)
+	assert.Contains(t, string(buf), `"start":"2022-10-01T02:00:00Z"`)
+}
+
+func TestManager(t *testing.T) {
+	now := time.Now()
+	SetWindows([]Window{
+		{Name: "deploy", Start: now.Add(-time.Minute), End: now.Add(time.Hour), Probes: []string{"web"}},
+		{Name: "deploy", Start: now, End: now.Add(time.Hour)},
+		{Name: "bad"},
+		{Name: "future", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)},
+	})
+	assert.Equal(t, 2, len(All()))
+
+	w := Find("web", "http", nil, now)
+	assert.NotNil(t, w)
+	assert.Equal(t, "deploy", w.Name)
+	assert.Nil(t, Find("api", "http", nil, now))
+	assert.Nil(t, Find("web", "http", nil, now.Add(2*time.Hour)))
+
+	assert.NotNil(t, Add(Window{Name: "deploy", Start: now, End: now.Add(time.Hour)}))
+	assert.NotNil(t, Add(Window{Name: "invalid"}))
+	assert.Nil(t, Add(Window{Name: "db", Start: now, End: now.Add(time.Hour), Kinds: []string{"mysql"}}))
+	assert.NotNil(t, Find("master", "mysql", nil, now.Add(time.Second)))
+
+	_, ok := Get("db")
+	assert.True(t, ok)
+	assert.Nil(t, Remove("db"))
+	assert.NotNil(t, Remove("db"))
+	_, ok = Get("db")
+	assert.False(t, ok)
+
+	// the expired windows are removed when a window is added
+	SetWindows([]Window{
+		{Name: "over", Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)},
+		{Name: "weekly", Cron: "0 2 * * SAT", Duration: time.Hour},
+	})
+	assert.Equal(t, 2, len(All()))
+	assert.NotNil(t, Add(Window{Name: "past", Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)}))
+	assert.Nil(t, Add(Window{Name: "hotfix", Start: now, End: now.Add(time.Hour)}))
+	_, ok = Get("over")
+	assert.False(t, ok)
+	_, ok = Get("weekly")
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(All()))
+
+	SetWindows(nil)
+	assert.Equal(t, 0, len(All()))
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maintenance
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const kind = "maintenance"
+
+var (
+	mutex   sync.RWMutex
+	windows []*Window
+)
+
+// SetWindows replace all of the maintenance windows, the invalid window is skipped.
+func SetWindows(ws []Window) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	windows = []*Window{}
+	for i := range ws {
+		w := ws[i]
+		if err := w.Config(); err != nil {
+			log.Errorf("[%s] Bad maintenance window configuration: %v", kind, err)
+			continue
+		}
+		if find(w.Name) != nil {
+			log.Errorf("[%s] Maintenance window [%s] name is duplicated, ignored!", kind, w.Name)
+			continue
+		}
+		windows = append(windows, &w)
+		log.Infof("[%s] Maintenance window [%s] is configured", kind, w.String())
+	}
+}
+
+// Add add a new maintenance window, the expired windows are removed at the same time
+func Add(w Window) error {
+	if err := w.Config(); err != nil {
+		return err
+	}
+	now := time.Now()
+	if w.Expired(now) {
+		return fmt.Errorf("maintenance window [%s] is already expired", w.Name)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	prune(now)
+	if find(w.Name) != nil {
+		return fmt.Errorf("maintenance window [%s] already exists", w.Name)
+	}
+	windows = append(windows, &w)
+	log.Infof("[%s] Maintenance window [%s] is added", kind, w.String())
+	return nil
+}
+
+// Remove remove the maintenance window by name
+func Remove(name string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	name = strings.TrimSpace(name)
+	for i, w := range windows {
+		if w.Name == name {
+			windows = append(windows[:i], windows[i+1:]...)
+			log.Infof("[%s] Maintenance window [%s] is removed", kind, name)
+			return nil
+		}
+	}
+	return fmt.Errorf("maintenance window [%s] is not found", name)
+}
+
+// Get return the maintenance window by name
+func Get(name string) (Window, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if w := find(strings.TrimSpace(name)); w != nil {
+		return *w, true
+	}
+	return Window{}, false
+}
+
+// All return all of the maintenance windows
+func All() []Window {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	result := make([]Window, 0, len(windows))
+	for _, w := range windows {
+		result = append(result, *w)
+	}
+	return result
+}
+
+// Find return the active maintenance window of the probe at the time t, or nil if no window is active.
+func Find(name, kind string, labels map[string]string, t time.Time) *Window {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for _, w := range windows {
+		if w.Match(name, kind, labels) && w.Active(t) {
+			result := *w
+			return &result
+		}
+	}
+	return nil
+}
+
+// prune removes the one-off windows which are over
+func prune(now time.Time) {
+	active := windows[:0]
+	for _, w := range windows {
+		if w.Expired(now) {
+			log.Infof("[%s] Maintenance window [%s] is expired and removed", kind, w.Name)
+			continue
+		}
+		active = append(active, w)
+	}
+	windows = active
+}
+
+func find(name string) *Window {
+	for _, w := range windows {
+		if w.Name == name {
+			return w
+		}
+	}
+	return nil
+}
@@ -39,15 +39,37 @@ type MetricsType interface {
 	*prometheus.CounterVec | *prometheus.GaugeVec | *prometheus.HistogramVec | *prometheus.SummaryVec
 }
 
-var (
-	registries   = make([]*prometheus.Registry, 0)
-	counterMap   = make(map[string]*prometheus.CounterVec)
-	gaugeMap     = make(map[string]*prometheus.GaugeVec)
-	histogramMap = make(map[string]*prometheus.HistogramVec)
-	summaryMap   = make(map[string]*prometheus.SummaryVec)
+// Registry is a set of metrics which are registered into the same Prometheus registerer
+type Registry struct {
+	registerer   prometheus.Registerer
+	counterMap   map[string]*prometheus.CounterVec
+	gaugeMap     map[string]*prometheus.GaugeVec
+	histogramMap map[string]*prometheus.HistogramVec
+	summaryMap   map[string]*prometheus.SummaryVec
+	collectors   []prometheus.Collector
+
+	rwlock sync.RWMutex
+}
 
-	rwlock = new(sync.RWMutex)
-)
+// NewRegistry create a metric registry with a dedicated Prometheus registerer,
+// e.g. the registry for a transient probe whose metrics are not exposed by `/metrics`
+func NewRegistry(registerer prometheus.Registerer) *Registry {
+	return &Registry{
+		registerer:   registerer,
+		counterMap:   make(map[string]*prometheus.CounterVec),
+		gaugeMap:     make(map[string]*prometheus.GaugeVec),
+		histogramMap: make(map[string]*prometheus.HistogramVec),
+		summaryMap:   make(map[string]*prometheus.SummaryVec),
+	}
+}
+
+// defaultRegistry is the registry of the metrics exposed by `/metrics`
+var defaultRegistry = NewRegistry(prometheus.DefaultRegisterer)
+
+// DefaultRegistry return the registry of the global metrics
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
 
 var (
 	validMetric = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
@@ -56,23 +78,61 @@ var (
 
 // Counter get the counter metric by key
 func Counter(key string) *prometheus.CounterVec {
-	rwlock.RLock()
-	defer rwlock.RUnlock()
-	return counterMap[key]
+	return defaultRegistry.Counter(key)
 }
 
 // Gauge get the gauge metric by key
 func Gauge(key string) *prometheus.GaugeVec {
-	rwlock.RLock()
-	defer rwlock.RUnlock()
-	return gaugeMap[key]
+	return defaultRegistry.Gauge(key)
+}
+
+// Register registers the custom collector into the default registry
+func Register(c prometheus.Collector) error {
+	return defaultRegistry.Register(c)
 }
 
 // NewCounter create the counter metric
 func NewCounter(namespace, subsystem, name, metric string,
 	help string, labels []string, constLabels prometheus.Labels) *prometheus.CounterVec {
-	rwlock.Lock()
-	defer rwlock.Unlock()
+	return defaultRegistry.NewCounter(namespace, subsystem, name, metric, help, labels, constLabels)
+}
+
+// NewGauge create the gauge metric
+func NewGauge(namespace, subsystem, name, metric string,
+	help string, labels []string, constLabels prometheus.Labels) *prometheus.GaugeVec {
+	return defaultRegistry.NewGauge(namespace, subsystem, name, metric, help, labels, constLabels)
+}
+
+// Register registers the custom collector into the registry
+func (r *Registry) Register(c prometheus.Collector) error {
+	r.rwlock.Lock()
+	defer r.rwlock.Unlock()
+	if err := r.registerer.Register(c); err != nil {
+		return err
+	}
+	r.collectors = append(r.collectors, c)
+	return nil
+}
+
+// Counter get the counter metric by key
+func (r *Registry) Counter(key string) *prometheus.CounterVec {
+	r.rwlock.RLock()
+	defer r.rwlock.RUnlock()
+	return r.counterMap[key]
+}
+
+// Gauge get the gauge metric by key
+func (r *Registry) Gauge(key string) *prometheus.GaugeVec {
+	r.rwlock.RLock()
+	defer r.rwlock.RUnlock()
+	return r.gaugeMap[key]
+}
+
+// NewCounter create the counter metric in the registry
+func (r *Registry) NewCounter(namespace, subsystem, name, metric string,
+	help string, labels []string, constLabels prometheus.Labels) *prometheus.CounterVec {
+	r.rwlock.Lock()
+	defer r.rwlock.Unlock()
 
 	metricName, err := getAndValid(namespace, subsystem, name, metric, labels, constLabels)
 	if err != nil {
@@ -81,12 +141,12 @@ func NewCounter(namespace, subsystem, name, metric string,
 		return nil
 	}
 
-	if m, find := counterMap[metricName]; find {
+	if m, find := r.counterMap[metricName]; find {
 		log.Debugf("[%s] Counter <%s> already created!", module, metricName)
 		return m
 	}
 
-	counterMap[metricName] = prometheus.NewCounterVec(
+	r.counterMap[metricName] = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: metricName,
 			Help: help,
@@ -94,16 +154,16 @@ func NewCounter(namespace, subsystem, name, metric string,
 		mergeLabels(labels, constLabels),
 	)
 
-	prometheus.MustRegister(counterMap[metricName])
+	r.registerer.MustRegister(r.counterMap[metricName])
 	log.Infof("[%s] Counter <%s> is created!", module, metricName)
-	return counterMap[metricName]
+	return r.counterMap[metricName]
 }
 
-// NewGauge create the gauge metric
-func NewGauge(namespace, subsystem, name, metric string,
+// NewGauge create the gauge metric in the registry
+func (r *Registry) NewGauge(namespace, subsystem, name, metric string,
 	help string, labels []string, constLabels prometheus.Labels) *prometheus.GaugeVec {
-	rwlock.Lock()
-	defer rwlock.Unlock()
+	r.rwlock.Lock()
+	defer r.rwlock.Unlock()
 
 	metricName, err := getAndValid(namespace, subsystem, name, metric, labels, constLabels)
 	if err != nil {
@@ -111,12 +171,12 @@ func NewGauge(namespace, subsystem, name, metric string,
 		return nil
 	}
 
-	if m, find := gaugeMap[metricName]; find {
+	if m, find := r.gaugeMap[metricName]; find {
 		log.Debugf("[%s] Gauge <%s> already created!", module, metricName)
 		return m
 	}
 
-	gaugeMap[metricName] = prometheus.NewGaugeVec(
+	r.gaugeMap[metricName] = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: metricName,
 			Help: help,
@@ -124,10 +184,10 @@ func NewGauge(namespace, subsystem, name, metric string,
 		mergeLabels(labels, constLabels),
 	)
 
-	prometheus.MustRegister(gaugeMap[metricName])
+	r.registerer.MustRegister(r.gaugeMap[metricName])
 
 	log.Infof("[%s] Gauge <%s> is created!", module, metricName)
-	return gaugeMap[metricName]
+	return r.gaugeMap[metricName]
 }
 
 func mergeLabels(labels []string, constLabels prometheus.Labels) []string {
@@ -272,20 +332,24 @@ func (p *PrometheusPushSink) Collect(c chan<- prometheus.Metric) {
 }
 
 func (p *PrometheusPushSink) collectAtTime(c chan<- prometheus.Metric, t time.Time) {
-	rwlock.RLock()
-	defer rwlock.RUnlock()
-	for _, gauge := range gaugeMap {
+	r := defaultRegistry
+	r.rwlock.RLock()
+	defer r.rwlock.RUnlock()
+	for _, gauge := range r.gaugeMap {
 		gauge.Collect(c)
 	}
-	for _, counter := range counterMap {
+	for _, counter := range r.counterMap {
 		counter.Collect(c)
 	}
-	for _, histogram := range histogramMap {
+	for _, histogram := range r.histogramMap {
 		histogram.Collect(c)
 	}
-	for _, summary := range summaryMap {
+	for _, summary := range r.summaryMap {
 		summary.Collect(c)
 	}
+	for _, collector := range r.collectors {
+		collector.Collect(c)
+	}
 }
 
 func (p *PrometheusPushSink) flushMetrics() {
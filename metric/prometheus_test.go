@@ -154,3 +154,46 @@ func TestDuplicateLabels(t *testing.T) {
 		[]string{"label1", "label2"}, prometheus.Labels{"label1": "value1"})
 	assert.Error(t, err)
 }
+
+func TestRegistry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	r := NewRegistry(reg)
+	counter := r.NewCounter("namespace", "registry", "counter", "metric",
+		"help", []string{"label"}, prometheus.Labels{})
+	assert.NotNil(t, counter)
+	assert.Equal(t, counter, r.Counter("namespace_registry_counter_metric"))
+	gauge := r.NewGauge("namespace", "registry", "gauge", "metric",
+		"help", []string{"label"}, prometheus.Labels{})
+	assert.NotNil(t, gauge)
+	assert.Equal(t, gauge, r.Gauge("namespace_registry_gauge_metric"))
+
+	// the metrics are not in the default registry
+	assert.Nil(t, Counter("namespace_registry_counter_metric"))
+	assert.Nil(t, Gauge("namespace_registry_gauge_metric"))
+	assert.NotEqual(t, r, DefaultRegistry())
+
+	counter.WithLabelValues("value").Inc()
+	gauge.WithLabelValues("value").Set(1)
+	families, err := reg.Gather()
+	assert.Nil(t, err)
+	assert.Len(t, families, 2)
+}
+
+func TestRegistryCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	r := NewRegistry(reg)
+
+	collector := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "namespace_registry_collector_metric",
+		Help: "help",
+	}, func() float64 { return 1 })
+	assert.Nil(t, r.Register(collector))
+	// the duplicated collector is rejected
+	assert.NotNil(t, r.Register(collector))
+	assert.Len(t, r.collectors, 1)
+
+	families, err := reg.Gather()
+	assert.Nil(t, err)
+	assert.Len(t, families, 1)
+	assert.Equal(t, "namespace_registry_collector_metric", families[0].GetName())
+}
@@ -0,0 +1,372 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package alertmanager is the Prometheus Alertmanager notification package.
+package alertmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/notify/base"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/report"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// the default settings of the Alertmanager notification
+const (
+	DefaultAlertName      = "EaseProbeFailure"
+	DefaultRepeatInterval = time.Minute
+)
+
+// the alert is resolved by Alertmanager if it's not re-posted in the times of the repeat interval
+const endsAtFactor = 4
+
+// Alert is the alert of the Alertmanager API v2
+type Alert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     string            `json:"startsAt,omitempty"`
+	EndsAt       string            `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// NotifyConfig is the Alertmanager notification configuration
+type NotifyConfig struct {
+	base.DefaultNotify `yaml:",inline"`
+
+	URL            string            `yaml:"url" json:"url" jsonschema:"required,format=uri,title=Alertmanager URL,description=The base URL of the Alertmanager, e.g. http://alertmanager:9093"`
+	AlertName      string            `yaml:"alertname,omitempty" json:"alertname,omitempty" jsonschema:"title=Alert Name,description=The alertname label of the alerts,default=EaseProbeFailure"`
+	Labels         map[string]string `yaml:"labels,omitempty" json:"labels,omitempty" jsonschema:"title=Labels,description=The extra labels of the alerts"`
+	Headers        map[string]string `yaml:"headers,omitempty" json:"headers,omitempty" jsonschema:"title=Headers,description=The HTTP headers of the request, e.g. Authorization"`
+	GeneratorURL   string            `yaml:"generator_url,omitempty" json:"generator_url,omitempty" jsonschema:"format=uri,title=Generator URL,description=The URL linked from the alerts, e.g. the SLA live report"`
+	RepeatInterval time.Duration     `yaml:"repeat_interval,omitempty" json:"repeat_interval,omitempty" jsonschema:"type=string,format=duration,title=Repeat Interval,description=The interval of re-posting the firing alerts,default=1m"`
+
+	state *state `yaml:"-" json:"-"`
+}
+
+// state is the firing alerts and the repeat ticker of the notification
+type state struct {
+	mutex   sync.Mutex
+	firing  map[string]Alert // the firing alerts by the probe name
+	started bool
+	done    chan struct{}
+}
+
+// Config configures the Alertmanager notification
+func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
+	c.NotifyKind = "alertmanager"
+	c.NotifyFormat = report.JSON
+	c.NotifySendFunc = c.SendAlertmanager
+	if err := c.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(c.URL)) == 0 {
+		return fmt.Errorf("[%s / %s] - the url is required", c.NotifyKind, c.NotifyName)
+	}
+	c.URL = strings.TrimSuffix(c.URL, "/")
+	if c.AlertName == "" {
+		c.AlertName = DefaultAlertName
+	}
+	if c.RepeatInterval <= 0 {
+		c.RepeatInterval = DefaultRepeatInterval
+	}
+	c.state = &state{
+		firing: map[string]Alert{},
+		done:   make(chan struct{}),
+	}
+	log.Debugf("Notification [%s] - [%s] configuration: %+v", c.NotifyKind, c.NotifyName, c)
+	return nil
+}
+
+// NewAlert returns the alert of the result, the labels are built from the
+// extra labels, the probe labels, the probe kind, name and endpoint. The labels
+// don't depend on the status, so that the recovery resolves the same alert.
+func (c *NotifyConfig) NewAlert(r *probe.Result) *Alert {
+	labels := map[string]string{}
+	for k, v := range c.Labels {
+		labels[k] = v
+	}
+	for k, v := range r.Labels {
+		labels[k] = v
+	}
+	labels["alertname"] = c.AlertName
+	labels["kind"] = r.Kind
+	labels["name"] = r.Name
+	labels["endpoint"] = r.Endpoint
+
+	startsAt := r.StartTime
+	if !r.LatestDownTime.IsZero() {
+		startsAt = r.LatestDownTime
+	}
+	a := &Alert{
+		Labels: labels,
+		Annotations: map[string]string{
+			"summary": c.ResultTitle(*r),
+			"status":  r.Status.String(),
+			"message": r.Message,
+			"rtt":     r.RoundTripTime.Round(time.Millisecond).String(),
+		},
+		StartsAt:     startsAt.UTC().Format(time.RFC3339),
+		GeneratorURL: c.GeneratorURL,
+	}
+	if c.HasMessageTemplate() {
+		a.Annotations["description"] = c.ResultMessage(*r)
+	}
+	return a
+}
+
+// update tracks the firing alert of the result, and returns the alerts to post,
+// it returns nil if the result should not be sent.
+func (c *NotifyConfig) update(r *probe.Result, now time.Time) []*Alert {
+	s := c.state
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch r.Status {
+	case probe.StatusInit:
+		return nil
+	case probe.StatusUp:
+		firing, ok := s.firing[r.Name]
+		// nothing to resolve if the probe is just started
+		if !ok && r.PreStatus == probe.StatusInit {
+			return nil
+		}
+		delete(s.firing, r.Name)
+		a := c.NewAlert(r)
+		if ok {
+			a.StartsAt = firing.StartsAt
+		}
+		a.EndsAt = now.UTC().Format(time.RFC3339)
+		return []*Alert{a}
+	}
+
+	a := c.NewAlert(r)
+	a.EndsAt = now.Add(endsAtFactor * c.RepeatInterval).UTC().Format(time.RFC3339)
+	s.firing[r.Name] = *a
+	return []*Alert{a}
+}
+
+// refresh returns the firing alerts to re-post, the alert is resolved if the probe
+// is recovered or removed, in case the recovery notification is missed.
+func (c *NotifyConfig) refresh(now time.Time) []*Alert {
+	s := c.state
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	alerts := []*Alert{}
+	for name, a := range s.firing {
+		if r := probe.GetResultData(name); r == nil || r.Status == probe.StatusUp {
+			delete(s.firing, name)
+			a.EndsAt = now.UTC().Format(time.RFC3339)
+		} else {
+			a.EndsAt = now.Add(endsAtFactor * c.RepeatInterval).UTC().Format(time.RFC3339)
+			s.firing[name] = a
+		}
+		a := a
+		alerts = append(alerts, &a)
+	}
+	return alerts
+}
+
+// start starts the ticker to re-post the firing alerts, so that the alerts are not
+// resolved by Alertmanager while the probe stays down. Because the notification is
+// only sent when the status is changed or the alert strategy is triggered.
+func (c *NotifyConfig) start() {
+	s := c.state
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.started || s.done == nil {
+		return
+	}
+	s.started = true
+	done := s.done
+	go func() {
+		ticker := time.NewTicker(c.RepeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				log.Debugf("[%s / %s] - the repeat ticker is stopped", c.NotifyKind, c.NotifyName)
+				return
+			case t := <-ticker.C:
+				alerts := c.refresh(t)
+				if len(alerts) == 0 {
+					continue
+				}
+				buf, err := json.Marshal(alerts)
+				if err == nil {
+					err = c.SendAlertmanager("Repeat", string(buf))
+				}
+				if err != nil {
+					log.Errorf("[%s / %s] - failed to re-post %d firing alerts: %v", c.NotifyKind, c.NotifyName, len(alerts), err)
+				}
+			}
+		}
+	}()
+}
+
+// TakeOver carries over the firing alerts of the old notification while the configuration is
+// reloaded, because the probe which stays down is not notified again. The alerts are rebuilt by
+// the new configuration, and they are re-posted by the repeat ticker of the new notification.
+func (c *NotifyConfig) TakeOver(old *NotifyConfig) {
+	if old == nil || old.state == nil || c.state == nil {
+		return
+	}
+	old.state.mutex.Lock()
+	firing := make(map[string]Alert, len(old.state.firing))
+	for name, a := range old.state.firing {
+		firing[name] = a
+	}
+	old.state.mutex.Unlock()
+	if len(firing) == 0 {
+		return
+	}
+
+	s := c.state
+	s.mutex.Lock()
+	for name, a := range firing {
+		// the alert is resolved by the repeat ticker if the probe is recovered or removed
+		if r := probe.GetResultData(name); r != nil && r.Status != probe.StatusUp {
+			startsAt := a.StartsAt
+			a = *c.NewAlert(r)
+			a.StartsAt = startsAt
+		}
+		s.firing[name] = a
+	}
+	s.mutex.Unlock()
+	log.Infof("[%s / %s] - %d firing alerts are carried over", c.NotifyKind, c.NotifyName, len(firing))
+
+	if !c.Dry {
+		c.start()
+	}
+}
+
+// Stop stops the ticker of the firing alerts
+func (c *NotifyConfig) Stop() {
+	if c.state == nil {
+		return
+	}
+	s := c.state
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.done != nil {
+		close(s.done)
+		s.done = nil
+	}
+}
+
+// Notify posts the firing or resolved alert of the result to Alertmanager
+func (c *NotifyConfig) Notify(result probe.Result) {
+	if c.Dry {
+		c.DryNotify(result)
+		return
+	}
+	alerts := c.update(&result, time.Now())
+	if alerts == nil {
+		log.Debugf("[%s / %s] - %s is not sent to Alertmanager", c.NotifyKind, c.NotifyName, result.Title())
+		return
+	}
+	if !result.Status.IsAvailable() {
+		c.start()
+	}
+	buf, err := json.Marshal(alerts)
+	if err != nil {
+		report.LogSend(c.NotifyKind, c.NotifyName, "Notification", result.Title(), err)
+		return
+	}
+	c.SendWithRetry(result.Title(), string(buf), "Notification")
+}
+
+// NotifyBatch posts the alerts of the grouped results one by one, Alertmanager groups them by itself
+func (c *NotifyConfig) NotifyBatch(results []probe.Result) {
+	for _, r := range results {
+		c.Notify(r)
+	}
+}
+
+// NotifyStat does nothing, the SLA report is not an alert
+func (c *NotifyConfig) NotifyStat(probers []probe.Prober) {
+	log.Debugf("[%s / %s] - the SLA report is not sent to Alertmanager", c.NotifyKind, c.NotifyName)
+}
+
+// DryNotify just log the alert
+func (c *NotifyConfig) DryNotify(result probe.Result) {
+	a := c.NewAlert(&result)
+	buf, err := json.Marshal([]*Alert{a})
+	if err != nil {
+		log.Errorf("[%s / %s] JSON Marshal Error : %v", c.NotifyKind, c.NotifyName, err)
+		return
+	}
+	log.Infof("[%s / %s] Dry notify - %s", c.NotifyKind, c.NotifyName, string(buf))
+}
+
+// DryNotifyBatch just log the alerts of the grouped results
+func (c *NotifyConfig) DryNotifyBatch(results []probe.Result) {
+	for _, r := range results {
+		c.DryNotify(r)
+	}
+}
+
+// DryNotifyStat does nothing, the SLA report is not an alert
+func (c *NotifyConfig) DryNotifyStat(probers []probe.Prober) {
+	c.NotifyStat(probers)
+}
+
+// SendAlertmanager posts the alerts to the Alertmanager API v2
+func (c *NotifyConfig) SendAlertmanager(title, msg string) error {
+	req, err := http.NewRequest(http.MethodPost, c.URL+"/api/v2/alerts", bytes.NewBufferString(msg))
+	if err != nil {
+		return &global.ErrNoRetry{Message: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Close = true
+
+	client := &http.Client{Timeout: c.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusBadRequest {
+		return &global.ErrNoRetry{
+			Message: fmt.Sprintf("Error response from Alertmanager [%s] - code [%d] - msg [%s]", title, resp.StatusCode, string(buf)),
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Error response from Alertmanager [%s] - code [%d] - msg [%s]", title, resp.StatusCode, string(buf))
+	}
+	return nil
+}
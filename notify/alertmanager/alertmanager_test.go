@@ -0,0 +1,286 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alertmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func newDummyResult(name string, status, pre probe.Status) probe.Result {
+	r := probe.NewResult()
+	r.Name = name
+	r.Endpoint = "http://endpoint:8080"
+	r.Kind = "http"
+	r.Labels = map[string]string{"env": "prod"}
+	r.Status = status
+	r.PreStatus = pre
+	r.Message = "dummy message"
+	r.RoundTripTime = 120 * time.Millisecond
+	if !status.IsAvailable() {
+		r.LatestDownTime = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return *r
+}
+
+// newServer is a local stand-in for the Alertmanager API v2
+func newServer(t *testing.T, code int) (*httptest.Server, chan []Alert) {
+	ch := make(chan []Alert, 10)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v2/alerts", r.URL.Path)
+		b, err := io.ReadAll(r.Body)
+		assert.Nil(t, err)
+		var alerts []Alert
+		assert.Nil(t, json.Unmarshal(b, &alerts))
+		ch <- alerts
+		w.WriteHeader(code)
+	}))
+	return s, ch
+}
+
+func TestConfig(t *testing.T) {
+	conf := &NotifyConfig{}
+	conf.NotifyName = "dummy"
+	assert.NotNil(t, conf.Config(global.NotifySettings{}))
+
+	conf.URL = "http://localhost:9093/"
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+	assert.Equal(t, "alertmanager", conf.Kind())
+	assert.Equal(t, "http://localhost:9093", conf.URL)
+	assert.Equal(t, DefaultAlertName, conf.AlertName)
+	assert.Equal(t, DefaultRepeatInterval, conf.RepeatInterval)
+	assert.NotNil(t, conf.state)
+}
+
+func TestNewAlert(t *testing.T) {
+	conf := &NotifyConfig{
+		URL:          "http://localhost:9093",
+		Labels:       map[string]string{"severity": "critical", "env": "test"},
+		GeneratorURL: "http://localhost:8181",
+	}
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	r := newDummyResult("dummy", probe.StatusDown, probe.StatusUp)
+	a := conf.NewAlert(&r)
+	assert.Equal(t, map[string]string{
+		"alertname": DefaultAlertName,
+		"kind":      "http",
+		"name":      "dummy",
+		"endpoint":  "http://endpoint:8080",
+		"env":       "prod", // the probe label overrides the extra label
+		"severity":  "critical",
+	}, a.Labels)
+	assert.Equal(t, "dummy message", a.Annotations["message"])
+	assert.Equal(t, "120ms", a.Annotations["rtt"])
+	assert.Equal(t, "down", a.Annotations["status"])
+	assert.Equal(t, "2022-01-01T00:00:00Z", a.StartsAt)
+	assert.Equal(t, "http://localhost:8181", a.GeneratorURL)
+
+	// the labels don't depend on the status
+	up := newDummyResult("dummy", probe.StatusUp, probe.StatusDown)
+	assert.Equal(t, a.Labels, conf.NewAlert(&up).Labels)
+}
+
+func TestUpdate(t *testing.T) {
+	conf := &NotifyConfig{URL: "http://localhost:9093"}
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+	now := time.Date(2022, 1, 1, 0, 1, 0, 0, time.UTC)
+
+	r := newDummyResult("dummy", probe.StatusUp, probe.StatusInit)
+	assert.Nil(t, conf.update(&r, now))
+	r = newDummyResult("dummy", probe.StatusInit, probe.StatusInit)
+	assert.Nil(t, conf.update(&r, now))
+
+	r = newDummyResult("dummy", probe.StatusDown, probe.StatusUp)
+	alerts := conf.update(&r, now)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "2022-01-01T00:05:00Z", alerts[0].EndsAt)
+	assert.Len(t, conf.state.firing, 1)
+
+	r = newDummyResult("dummy", probe.StatusUp, probe.StatusDown)
+	alerts = conf.update(&r, now)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "2022-01-01T00:01:00Z", alerts[0].EndsAt)
+	// the starting time of the firing alert is kept
+	assert.Equal(t, "2022-01-01T00:00:00Z", alerts[0].StartsAt)
+	assert.Len(t, conf.state.firing, 0)
+
+	// the alert is resolved even it's not tracked, e.g. after restart
+	alerts = conf.update(&r, now)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "2022-01-01T00:01:00Z", alerts[0].EndsAt)
+}
+
+func TestRefresh(t *testing.T) {
+	conf := &NotifyConfig{URL: "http://localhost:9093"}
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+	now := time.Date(2022, 1, 1, 0, 1, 0, 0, time.UTC)
+
+	down := newDummyResult("am-down", probe.StatusDown, probe.StatusUp)
+	probe.SetResultData(down.Name, &down)
+	conf.update(&down, now)
+	// the recovery notification is missed
+	recovered := newDummyResult("am-recovered", probe.StatusDown, probe.StatusUp)
+	conf.update(&recovered, now)
+	up := newDummyResult("am-recovered", probe.StatusUp, probe.StatusDown)
+	probe.SetResultData(up.Name, &up)
+	// the probe is removed
+	removed := newDummyResult("am-removed", probe.StatusDown, probe.StatusUp)
+	conf.update(&removed, now)
+
+	alerts := conf.refresh(now.Add(time.Minute))
+	assert.Len(t, alerts, 3)
+	for _, a := range alerts {
+		if a.Labels["name"] == "am-down" {
+			assert.Equal(t, "2022-01-01T00:06:00Z", a.EndsAt)
+		} else {
+			assert.Equal(t, "2022-01-01T00:02:00Z", a.EndsAt)
+		}
+	}
+	assert.Len(t, conf.state.firing, 1)
+	assert.Contains(t, conf.state.firing, "am-down")
+}
+
+func TestNotify(t *testing.T) {
+	s, ch := newServer(t, http.StatusOK)
+	defer s.Close()
+
+	conf := &NotifyConfig{URL: s.URL, RepeatInterval: 50 * time.Millisecond}
+	conf.NotifyName = "dummy"
+	conf.Retry.Times = 1
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+
+	down := newDummyResult("am-notify", probe.StatusDown, probe.StatusUp)
+	probe.SetResultData(down.Name, &down)
+	conf.Notify(down)
+	alerts := <-ch
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "am-notify", alerts[0].Labels["name"])
+	assert.Contains(t, buf.String(), "successfully sent")
+
+	// the firing alert is re-posted by the ticker
+	alerts = <-ch
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "am-notify", alerts[0].Labels["name"])
+
+	up := newDummyResult("am-notify", probe.StatusUp, probe.StatusDown)
+	probe.SetResultData(up.Name, &up)
+	conf.Notify(up)
+	conf.Stop()
+	conf.Stop()
+	// skip the alerts re-posted before the recovery
+	for alerts = <-ch; alerts[0].Annotations["status"] != "up"; alerts = <-ch {
+	}
+	assert.Equal(t, "2022-01-01T00:00:00Z", alerts[0].StartsAt)
+
+	// nothing is sent
+	conf.Notify(newDummyResult("am-notify", probe.StatusUp, probe.StatusInit))
+	conf.NotifyStat([]probe.Prober{})
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, ch, 0)
+}
+
+func TestTakeOver(t *testing.T) {
+	s, ch := newServer(t, http.StatusOK)
+	defer s.Close()
+
+	old := &NotifyConfig{URL: s.URL}
+	old.NotifyName = "dummy"
+	assert.Nil(t, old.Config(global.NotifySettings{}))
+	down := newDummyResult("am-takeover", probe.StatusDown, probe.StatusUp)
+	probe.SetResultData(down.Name, &down)
+	old.update(&down, time.Now())
+	old.Stop()
+
+	// the changed notification re-posts the firing alert with the new labels
+	conf := &NotifyConfig{URL: s.URL, Labels: map[string]string{"team": "ops"}, RepeatInterval: 100 * time.Millisecond}
+	conf.NotifyName = "dummy"
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+	conf.TakeOver(nil)
+	assert.False(t, conf.state.started)
+	conf.TakeOver(old)
+	defer conf.Stop()
+	conf.state.mutex.Lock()
+	assert.True(t, conf.state.started)
+	assert.Contains(t, conf.state.firing, "am-takeover")
+	conf.state.mutex.Unlock()
+
+	alerts := <-ch
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "am-takeover", alerts[0].Labels["name"])
+	assert.Equal(t, "ops", alerts[0].Labels["team"])
+	assert.Equal(t, "2022-01-01T00:00:00Z", alerts[0].StartsAt)
+}
+
+func TestNotifyFailed(t *testing.T) {
+	s, ch := newServer(t, http.StatusBadRequest)
+	defer s.Close()
+
+	conf := &NotifyConfig{URL: s.URL, Headers: map[string]string{"Authorization": "Bearer token"}}
+	conf.NotifyName = "dummy"
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	err := conf.SendAlertmanager("title", `[]`)
+	assert.IsType(t, &global.ErrNoRetry{}, err)
+	<-ch
+
+	s2, ch2 := newServer(t, http.StatusInternalServerError)
+	defer s2.Close()
+	conf.URL = s2.URL
+	err = conf.SendAlertmanager("title", `[]`)
+	assert.NotNil(t, err)
+	_, ok := err.(*global.ErrNoRetry)
+	assert.False(t, ok)
+	<-ch2
+
+	conf.URL = "://invalid"
+	assert.NotNil(t, conf.SendAlertmanager("title", `[]`))
+}
+
+func TestDryNotify(t *testing.T) {
+	conf := &NotifyConfig{URL: "http://localhost:9093"}
+	conf.NotifyName = "dummy"
+	conf.Dry = true
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+
+	conf.Notify(newDummyResult("dummy", probe.StatusDown, probe.StatusUp))
+	assert.Contains(t, buf.String(), "[alertmanager / dummy] Dry notify")
+	assert.Len(t, conf.state.firing, 0)
+	assert.False(t, conf.state.started)
+
+	buf.Reset()
+	conf.DryNotifyStat([]probe.Prober{})
+	assert.NotContains(t, buf.String(), "Dry notify")
+}
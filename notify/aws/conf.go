@@ -46,7 +46,9 @@ type Options struct {
 // Config config a AWS configuration
 func (conf *Options) Config(gConf global.NotifySettings) error {
 
-	conf.DefaultNotify.Config(gConf)
+	if err := conf.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
 
 	session, err := session.NewSessionWithOptions(
 		session.Options{
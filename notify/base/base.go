@@ -19,6 +19,9 @@
 package base
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -37,6 +40,17 @@ type DefaultNotify struct {
 	Dry            bool                       `yaml:"dry,omitempty" json:"dry,omitempty" jsonschema:"title=Dry Run,description=If true the notification will not send the message"`
 	Timeout        time.Duration              `yaml:"timeout,omitempty" json:"timeout,omitempty" jsonschema:"format=duration,title=Timeout,description=The timeout of the notification"`
 	Retry          global.Retry               `yaml:"retry,omitempty" json:"retry,omitempty" jsonschema:"title=Retry,description=The retry of the notification"`
+
+	TitleTemplate       string `yaml:"title_template,omitempty" json:"title_template,omitempty" jsonschema:"title=Title Template,description=The Go template of the title for the probe result"`
+	MessageTemplate     string `yaml:"message_template,omitempty" json:"message_template,omitempty" jsonschema:"title=Message Template,description=The Go template of the message for the probe result"`
+	StatTitleTemplate   string `yaml:"stat_title_template,omitempty" json:"stat_title_template,omitempty" jsonschema:"title=SLA Title Template,description=The Go template of the title for the SLA report"`
+	StatMessageTemplate string `yaml:"stat_message_template,omitempty" json:"stat_message_template,omitempty" jsonschema:"title=SLA Message Template,description=The Go template of the message for the SLA report"`
+
+	GroupBy       []string      `yaml:"group_by,omitempty" json:"group_by,omitempty" jsonschema:"title=Group By,description=The probe kind or the label names to group the events by - all of the events are in one group if it's empty,example=kind,example=env"`
+	GroupWait     time.Duration `yaml:"group_wait,omitempty" json:"group_wait,omitempty" jsonschema:"format=duration,title=Group Wait,description=The time to buffer the events of a new group before sending - the grouping is disabled if both group_by and group_wait are not set,default=30s"`
+	GroupInterval time.Duration `yaml:"group_interval,omitempty" json:"group_interval,omitempty" jsonschema:"format=duration,title=Group Interval,description=The interval of the follow-up digests of a group,default=5m"`
+
+	templates *templates
 }
 
 // Kind returns the kind of the notification
@@ -58,6 +72,25 @@ func (c *DefaultNotify) Config(gConf global.NotifySettings) error {
 		c.NotifyChannels = append(c.NotifyChannels, global.DefaultChannelName)
 	}
 
+	if err := c.parseTemplates(); err != nil {
+		return c.templateError(err)
+	}
+
+	if c.GroupWait < 0 || c.GroupInterval < 0 {
+		return fmt.Errorf("[%s / %s] - invalid group_wait [%s] or group_interval [%s]",
+			c.NotifyKind, c.NotifyName, c.GroupWait, c.GroupInterval)
+	}
+	if len(c.GroupBy) > 0 && c.GroupWait == 0 {
+		c.GroupWait = global.DefaultGroupWait
+	}
+	if c.GroupWait > 0 {
+		if c.GroupInterval == 0 {
+			c.GroupInterval = global.DefaultGroupInterval
+		}
+		log.Infof("Notification [%s] - [%s] groups the events by %v, wait=%s, interval=%s",
+			c.NotifyKind, c.NotifyName, c.GroupBy, c.GroupWait, c.GroupInterval)
+	}
+
 	log.Infof("Notification [%s] - [%s] is configured!", c.NotifyKind, c.NotifyName)
 	return nil
 }
@@ -78,8 +111,8 @@ func (c *DefaultNotify) Notify(result probe.Result) {
 		c.DryNotify(result)
 		return
 	}
-	title := result.Title()
-	message := report.FormatFuncs[c.NotifyFormat].ResultFn(result)
+	title := c.ResultTitle(result)
+	message := c.ResultMessage(result)
 
 	c.SendWithRetry(title, message, "Notification")
 }
@@ -90,11 +123,33 @@ func (c *DefaultNotify) NotifyStat(probers []probe.Prober) {
 		c.DryNotifyStat(probers)
 		return
 	}
-	title := "Overall SLA Report"
-	message := report.FormatFuncs[c.NotifyFormat].StatFn(probers)
+	title := c.StatTitle(probers)
+	message := c.StatMessage(probers)
 	c.SendWithRetry(title, message, "SLA")
 }
 
+// Grouping returns the alert grouping settings, the grouping is disabled if the wait is zero
+func (c *DefaultNotify) Grouping() (by []string, wait, interval time.Duration) {
+	return c.GroupBy, c.GroupWait, c.GroupInterval
+}
+
+// NotifyBatch sends the grouped results in one message, it falls back to send
+// the results one by one if the format or the message template cannot render them.
+func (c *DefaultNotify) NotifyBatch(results []probe.Result) {
+	if c.Dry {
+		c.DryNotifyBatch(results)
+		return
+	}
+	fn := report.FormatFuncs[c.NotifyFormat].BatchFn
+	if len(results) == 1 || fn == nil || c.HasMessageTemplate() {
+		for _, r := range results {
+			c.Notify(r)
+		}
+		return
+	}
+	c.SendWithRetry(report.BatchTitle(results), fn(results), "Notification")
+}
+
 // SendWithRetry sends the notification with retry if got error
 func (c *DefaultNotify) SendWithRetry(title string, message string, tag string) {
 	fn := func() error {
@@ -111,12 +166,30 @@ func (c *DefaultNotify) SendWithRetry(title string, message string, tag string)
 
 // DryNotify just log the notification message
 func (c *DefaultNotify) DryNotify(result probe.Result) {
-	log.Infof("[%s / %s / dry_notify] - %s", c.NotifyKind, c.NotifyName,
-		report.FormatFuncs[c.NotifyFormat].ResultFn(result))
+	log.Infof("[%s / %s / dry_notify] - %s", c.NotifyKind, c.NotifyName, c.ResultMessage(result))
+}
+
+// DryNotifyBatch just log the grouped notification message
+func (c *DefaultNotify) DryNotifyBatch(results []probe.Result) {
+	fn := report.FormatFuncs[c.NotifyFormat].BatchFn
+	if len(results) == 1 || fn == nil || c.HasMessageTemplate() {
+		for _, r := range results {
+			c.DryNotify(r)
+		}
+		return
+	}
+	log.Infof("[%s / %s / dry_notify] - %s", c.NotifyKind, c.NotifyName, fn(results))
 }
 
 // DryNotifyStat just log the notification message
 func (c *DefaultNotify) DryNotifyStat(probers []probe.Prober) {
-	log.Infof("[%s / %s / dry_notify] - %s", c.NotifyKind, c.NotifyName,
-		report.FormatFuncs[c.NotifyFormat].StatFn(probers))
+	log.Infof("[%s / %s / dry_notify] - %s", c.NotifyKind, c.NotifyName, c.StatMessage(probers))
+}
+
+// AlertID returns the stable identity of the probe for the incident management
+// tools, it's derived from the probe name and endpoint, so the failure and the
+// recovery of a probe refer to the same alert.
+func AlertID(r *probe.Result) string {
+	sum := sha256.Sum256([]byte(r.Name + "\n" + r.Endpoint))
+	return "easeprobe-" + hex.EncodeToString(sum[:16])
 }
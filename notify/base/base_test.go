@@ -162,3 +162,71 @@ func TestDefaultNotify(t *testing.T) {
 
 	logrus.SetOutput(os.Stdout)
 }
+
+func TestNotifyBatch(t *testing.T) {
+	sent := []string{}
+	d := DefaultNotify{
+		NotifyKind:   "TestKind",
+		NotifyFormat: report.Markdown,
+		NotifySendFunc: func(title, msg string) error {
+			sent = append(sent, title)
+			return nil
+		},
+		NotifyName: "TestName",
+		GroupBy:    []string{"kind"},
+	}
+	assert.Nil(t, d.Config(global.NotifySettings{}))
+	by, wait, interval := d.Grouping()
+	assert.Equal(t, []string{"kind"}, by)
+	assert.Equal(t, global.DefaultGroupWait, wait)
+	assert.Equal(t, global.DefaultGroupInterval, interval)
+
+	results := []probe.Result{newDummyResult("dummy-1"), newDummyResult("dummy-2")}
+	d.NotifyBatch(results)
+	assert.Equal(t, []string{"2 Probes Changed (2 up)"}, sent)
+
+	// the format cannot render the grouped results
+	sent = []string{}
+	d.NotifyFormat = report.JSON
+	d.NotifyBatch(results)
+	assert.Len(t, sent, 2)
+
+	// the message template renders a single result
+	sent = []string{}
+	d.NotifyFormat = report.Markdown
+	d.MessageTemplate = "{{.Name}}"
+	assert.Nil(t, d.Config(global.NotifySettings{}))
+	d.NotifyBatch(results)
+	assert.Len(t, sent, 2)
+
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+	d.MessageTemplate = ""
+	assert.Nil(t, d.Config(global.NotifySettings{}))
+	d.DryNotifyBatch(results)
+	assert.Contains(t, buf.String(), "**2 Probes Changed (2 up)**")
+	logrus.SetOutput(os.Stdout)
+
+	// the grouping is disabled by default
+	d = DefaultNotify{NotifyKind: "TestKind", NotifyName: "TestName"}
+	assert.Nil(t, d.Config(global.NotifySettings{}))
+	_, wait, _ = d.Grouping()
+	assert.Equal(t, time.Duration(0), wait)
+
+	d.GroupWait = -time.Second
+	assert.NotNil(t, d.Config(global.NotifySettings{}))
+}
+
+func TestAlertID(t *testing.T) {
+	r := newDummyResult("dummy")
+	id := AlertID(&r)
+	assert.Regexp(t, "^easeprobe-[0-9a-f]{32}$", id)
+
+	// the id is stable across the status changes
+	r.Status = probe.StatusDown
+	r.Message = "another message"
+	assert.Equal(t, id, AlertID(&r))
+
+	r.Endpoint = "http://another:8080"
+	assert.NotEqual(t, id, AlertID(&r))
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package base
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"text/template"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/report"
+)
+
+// the default title of the SLA report
+const statTitle = "Overall SLA Report"
+
+// executor is the common interface of the text/template and the html/template
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// templates are the parsed notification templates, nil if it's not set
+type templates struct {
+	title       executor
+	message     executor
+	statTitle   executor
+	statMessage executor
+}
+
+// parseTemplates parses the notification templates. The titles are always parsed
+// as text/template, the messages are parsed as html/template if the format of the
+// notification is HTML, so that the values are escaped.
+func (c *DefaultNotify) parseTemplates() error {
+	c.templates = &templates{}
+	text := func(name, tpl string) (executor, error) {
+		if tpl == "" {
+			return nil, nil
+		}
+		return template.New(name).Funcs(report.TemplateFuncs()).Parse(tpl)
+	}
+	message := text
+	if c.NotifyFormat == report.HTML {
+		message = func(name, tpl string) (executor, error) {
+			if tpl == "" {
+				return nil, nil
+			}
+			return htmltemplate.New(name).Funcs(htmltemplate.FuncMap(report.TemplateFuncs())).Parse(tpl)
+		}
+	}
+
+	var err error
+	if c.templates.title, err = text("title_template", c.TitleTemplate); err != nil {
+		return err
+	}
+	if c.templates.message, err = message("message_template", c.MessageTemplate); err != nil {
+		return err
+	}
+	if c.templates.statTitle, err = text("stat_title_template", c.StatTitleTemplate); err != nil {
+		return err
+	}
+	if c.templates.statMessage, err = message("stat_message_template", c.StatMessageTemplate); err != nil {
+		return err
+	}
+	return nil
+}
+
+// render executes the template, the fallback is used if the template is not set or failed
+func (c *DefaultNotify) render(t executor, data interface{}, fallback func() string) string {
+	if t == nil {
+		return fallback()
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		log.Errorf("[%s / %s] Template Error : %v", c.NotifyKind, c.NotifyName, err)
+		return fallback()
+	}
+	return buf.String()
+}
+
+// HasMessageTemplate returns true if the message_template is set
+func (c *DefaultNotify) HasMessageTemplate() bool {
+	return c.templates != nil && c.templates.message != nil
+}
+
+// HasStatMessageTemplate returns true if the stat_message_template is set
+func (c *DefaultNotify) HasStatMessageTemplate() bool {
+	return c.templates != nil && c.templates.statMessage != nil
+}
+
+// ResultTitle returns the title of the result, it's rendered by the title_template if it's set
+func (c *DefaultNotify) ResultTitle(r probe.Result) string {
+	if c.templates == nil {
+		return r.Title()
+	}
+	return c.render(c.templates.title, &r, r.Title)
+}
+
+// ResultMessage returns the message of the result, it's rendered by the message_template
+// if it's set, otherwise the built-in format of the notification is used.
+func (c *DefaultNotify) ResultMessage(r probe.Result) string {
+	fallback := func() string {
+		return report.FormatFuncs[c.NotifyFormat].ResultFn(r)
+	}
+	if c.templates == nil {
+		return fallback()
+	}
+	return c.render(c.templates.message, &r, fallback)
+}
+
+// StatTitle returns the title of the SLA report, it's rendered by the stat_title_template if it's set
+func (c *DefaultNotify) StatTitle(probers []probe.Prober) string {
+	fallback := func() string {
+		return statTitle
+	}
+	if c.templates == nil || c.templates.statTitle == nil {
+		return fallback()
+	}
+	return c.render(c.templates.statTitle, report.NewStatData(statTitle, probers), fallback)
+}
+
+// StatMessage returns the message of the SLA report, it's rendered by the stat_message_template
+// if it's set, otherwise the built-in format of the notification is used.
+func (c *DefaultNotify) StatMessage(probers []probe.Prober) string {
+	fallback := func() string {
+		return report.FormatFuncs[c.NotifyFormat].StatFn(probers)
+	}
+	if c.templates == nil || c.templates.statMessage == nil {
+		return fallback()
+	}
+	return c.render(c.templates.statMessage, report.NewStatData(statTitle, probers), fallback)
+}
+
+// templateError returns the error of the invalid template
+func (c *DefaultNotify) templateError(err error) error {
+	return fmt.Errorf("[%s / %s] - invalid template: %v", c.NotifyKind, c.NotifyName, err)
+}
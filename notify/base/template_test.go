@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package base
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/report"
+)
+
+func TestTemplate(t *testing.T) {
+	var title, message string
+	d := DefaultNotify{
+		NotifyKind:   "TestKind",
+		NotifyFormat: report.Markdown,
+		NotifySendFunc: func(t, m string) error {
+			title, message = t, m
+			return nil
+		},
+		NotifyName: "TestName",
+
+		TitleTemplate:       `[{{upper .Kind}}] {{.Name}} is {{.Status}}`,
+		MessageTemplate:     `{{emoji .Status}} {{.Endpoint}} - {{duration .RecoveryDuration}} - owner: {{.Labels.owner}} - runbook: https://wiki/{{index .Labels "team"}}`,
+		StatTitleTemplate:   `{{.Title}} - {{len .Results}} probes`,
+		StatMessageTemplate: `{{range .Results}}{{.Name}}={{percent .SLAPercent}};{{end}}`,
+	}
+	assert.NoError(t, d.Config(global.NotifySettings{}))
+	assert.True(t, d.HasMessageTemplate())
+	assert.True(t, d.HasStatMessageTemplate())
+
+	r := newDummyResult("dummy")
+	r.Kind = "http"
+	r.Labels = map[string]string{"owner": "alice", "team": "web"}
+	d.Notify(r)
+	assert.Equal(t, "[HTTP] dummy is up", title)
+	assert.Equal(t, "✅ http://endpoint:8080 - 20s - owner: alice - runbook: https://wiki/web", message)
+
+	p := getProbers()
+	d.NotifyStat(p)
+	assert.Equal(t, "Overall SLA Report - 4 probes", title)
+	assert.Contains(t, message, "probe1=")
+	assert.Contains(t, message, "probe4=")
+
+	// the built-in format is used if the template is failed
+	d.MessageTemplate = `{{.NoSuchField}}`
+	assert.NoError(t, d.Config(global.NotifySettings{}))
+	d.Notify(r)
+	assert.Equal(t, report.ToMarkdown(r), message)
+
+	// the built-in formats are the default
+	d = DefaultNotify{NotifyKind: "TestKind", NotifyFormat: report.Markdown, NotifyName: "TestName"}
+	assert.NoError(t, d.Config(global.NotifySettings{}))
+	assert.False(t, d.HasMessageTemplate())
+	assert.False(t, d.HasStatMessageTemplate())
+	assert.Equal(t, r.Title(), d.ResultTitle(r))
+	assert.Equal(t, report.ToMarkdown(r), d.ResultMessage(r))
+	assert.Equal(t, "Overall SLA Report", d.StatTitle(p))
+	assert.Equal(t, report.SLAMarkdown(p), d.StatMessage(p))
+
+	// the message is escaped for the HTML format
+	d = DefaultNotify{
+		NotifyKind:      "TestKind",
+		NotifyFormat:    report.HTML,
+		NotifyName:      "TestName",
+		TitleTemplate:   `<{{.Name}}>`,
+		MessageTemplate: `<b>{{.Message}}</b>`,
+	}
+	assert.NoError(t, d.Config(global.NotifySettings{}))
+	r.Name = "a&b"
+	r.Message = "<script>"
+	assert.Equal(t, "<a&b>", d.ResultTitle(r))
+	assert.Equal(t, "<b>&lt;script&gt;</b>", d.ResultMessage(r))
+
+	// the invalid template
+	d = DefaultNotify{NotifyKind: "TestKind", NotifyName: "TestName", TitleTemplate: `{{.Name`}
+	err := d.Config(global.NotifySettings{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "[TestKind / TestName] - invalid template")
+	d = DefaultNotify{NotifyKind: "TestKind", NotifyName: "TestName", StatMessageTemplate: `{{end}}`}
+	assert.Error(t, d.Config(global.NotifySettings{}))
+}
@@ -48,7 +48,9 @@ func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
 	c.NotifyKind = "dingtalk"
 	c.NotifyFormat = report.Markdown
 	c.NotifySendFunc = c.SendDingtalkNotification
-	c.DefaultNotify.Config(gConf)
+	if err := c.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
 	log.Debugf("Notification [%s] - [%s] configuration: %+v", c.NotifyKind, c.NotifyName, c)
 	return nil
 }
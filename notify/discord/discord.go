@@ -114,7 +114,9 @@ type NotifyConfig struct {
 // Config configures the log files
 func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
 	c.NotifyKind = "discord"
-	c.DefaultNotify.Config(gConf)
+	if err := c.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
 
 	if len(strings.TrimSpace(c.Username)) <= 0 {
 		c.Username = global.GetEaseProbe().Name
@@ -134,38 +136,87 @@ func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
 
 // NewDiscord new a discord object from a result
 func (c *NotifyConfig) NewDiscord(result probe.Result) Discord {
-	discord := Discord{
+	return Discord{
 		Username:  c.Username,
 		AvatarURL: c.Avatar,
 		Content:   "",
-		Embeds:    []Embed{},
+		Embeds:    []Embed{c.NewResultEmbed(result)},
 	}
+}
 
+// NewResultEmbed new an embed object from a result
+func (c *NotifyConfig) NewResultEmbed(result probe.Result) Embed {
 	// using https://www.spycolor.com/ to pick color
 	color := 1091331 //"#10a703" - green
-	if result.Status != probe.StatusUp {
+	if result.Status == probe.StatusWarning {
+		color = 15105570 // "#e67e22" - orange
+	} else if result.Status != probe.StatusUp {
 		color = 10945283 // "#a70303" - red
 	}
 
 	rtt := result.RoundTripTime.Round(time.Millisecond)
 	description := fmt.Sprintf("%s %s - ⏱ %s\n```%s```",
 		result.Status.Emoji(), result.Endpoint, rtt, result.Message)
+	if c.HasMessageTemplate() {
+		description = c.ResultMessage(result)
+	}
 
-	discord.Embeds = append(discord.Embeds, Embed{
+	// the webhook cannot send the buttons, so the title links to the acknowledgement
+	fields := []Fields{}
+	if len(result.AckURL) > 0 {
+		fields = append(fields, Fields{
+			Name:  "Acknowledge",
+			Value: report.AckLink(result, report.Markdown),
+		})
+	}
+
+	return Embed{
 		Author:      Author{},
-		Title:       result.Title(),
-		URL:         "",
+		Title:       c.ResultTitle(result),
+		URL:         result.AckURL,
 		Color:       color,
 		Description: description,
 		Timestamp:   result.StartTime.UTC().Format(time.RFC3339),
 		Thumbnail:   Thumbnail{URL: c.Thumbnail},
-		Fields:      []Fields{},
+		Fields:      fields,
 		Footer: Footer{
 			Text:    global.FooterString(),
 			IconURL: global.GetEaseProbe().IconURL,
 		},
-	})
-	return discord
+	}
+}
+
+// maxEmbeds is the max number of the embeds in a message
+const maxEmbeds = 10
+
+// NewBatch return the discord messages of the grouped results, every result is an embed,
+// and a message has 10 embeds at most
+func (c *NotifyConfig) NewBatch(results []probe.Result) []Discord {
+	var discords []Discord
+
+	title := report.BatchTitle(results)
+	pages := (len(results) + maxEmbeds - 1) / maxEmbeds
+	for p := 0; p < pages; p++ {
+		content := fmt.Sprintf("**%s**", title)
+		if pages > 1 {
+			content = fmt.Sprintf("**%s (%d/%d)**", title, p+1, pages)
+		}
+		discord := Discord{
+			Username:  c.Username,
+			AvatarURL: c.Avatar,
+			Content:   content,
+			Embeds:    []Embed{},
+		}
+		end := (p + 1) * maxEmbeds
+		if len(results) < end {
+			end = len(results)
+		}
+		for _, r := range results[p*maxEmbeds : end] {
+			discord.Embeds = append(discord.Embeds, c.NewResultEmbed(r))
+		}
+		discords = append(discords, discord)
+	}
+	return discords
 }
 
 // Notify write the message into the slack
@@ -237,6 +288,17 @@ func (c *NotifyConfig) NewField(result probe.Result, inline bool) Fields {
 func (c *NotifyConfig) NewEmbeds(probers []probe.Prober) []Discord {
 	var discords []Discord
 
+	title := c.StatTitle(probers)
+	// the whole SLA report is rendered by the template
+	if c.HasStatMessageTemplate() {
+		return append(discords, Discord{
+			Username:  c.Username,
+			AvatarURL: c.Avatar,
+			Content:   fmt.Sprintf("**%s**\n%s", title, c.StatMessage(probers)),
+			Embeds:    []Embed{},
+		})
+	}
+
 	//every page has 12 probe result
 	const pageCnt = 12
 	total := len(probers)
@@ -250,7 +312,7 @@ func (c *NotifyConfig) NewEmbeds(probers []probe.Prober) []Discord {
 		discord := Discord{
 			Username:  c.Username,
 			AvatarURL: c.Avatar,
-			Content:   fmt.Sprintf("**Overall SLA Report (%d/%d)**", p+1, pages),
+			Content:   fmt.Sprintf("**%s (%d/%d)**", title, p+1, pages),
 			Embeds:    []Embed{},
 		}
 
@@ -273,6 +335,27 @@ func (c *NotifyConfig) NewEmbeds(probers []probe.Prober) []Discord {
 	return discords
 }
 
+// NotifyBatch sends the grouped results in one message, each result is an embed
+func (c *NotifyConfig) NotifyBatch(results []probe.Result) {
+	if c.Dry {
+		c.DryNotifyBatch(results)
+		return
+	}
+	if len(results) == 1 {
+		c.Notify(results[0])
+		return
+	}
+	tag := "Notification"
+	title := report.BatchTitle(results)
+	for _, discord := range c.NewBatch(results) {
+		fn := func() error {
+			return c.SendDiscordNotification(discord, tag)
+		}
+		err := global.DoRetry(c.Kind(), c.NotifyName, tag, c.Retry, fn)
+		report.LogSend(c.Kind(), c.NotifyName, tag, title, err)
+	}
+}
+
 // NotifyStat write the all probe stat message to slack
 func (c *NotifyConfig) NotifyStat(probers []probe.Prober) {
 	if c.Dry {
@@ -309,6 +392,21 @@ func (c *NotifyConfig) DryNotify(result probe.Result) {
 	log.Infof("[%s / %s] Dry notify - %s", c.Kind(), c.NotifyName, string(json))
 }
 
+// DryNotifyBatch just log the grouped notification messages
+func (c *NotifyConfig) DryNotifyBatch(results []probe.Result) {
+	if len(results) == 1 {
+		c.DryNotify(results[0])
+		return
+	}
+	discord := c.NewBatch(results)
+	json, err := json.Marshal(discord)
+	if err != nil {
+		log.Errorf("[%s / %s] JSON Marshal Error : %v", c.Kind(), c.NotifyName, err)
+		return
+	}
+	log.Infof("[%s / %s] Dry notify - %s", c.Kind(), c.NotifyName, string(json))
+}
+
 // DryNotifyStat just log the notification message
 func (c *NotifyConfig) DryNotifyStat(probers []probe.Prober) {
 	discord := c.NewEmbeds(probers)
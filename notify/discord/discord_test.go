@@ -35,6 +35,7 @@ import (
 	"github.com/wfusion/easeprobe/global"
 	"github.com/wfusion/easeprobe/probe"
 	"github.com/wfusion/easeprobe/probe/base"
+	"github.com/wfusion/easeprobe/report"
 	"github.com/wfusion/gofusion/common/utils/gomonkey"
 )
 
@@ -244,3 +245,54 @@ func TestNewField(t *testing.T) {
 	f = conf.NewField(r, false)
 	assert.Equal(t, "-------------------- dummy --------------------", f.Name)
 }
+
+func TestDiscordBatch(t *testing.T) {
+	conf := &NotifyConfig{}
+	conf.NotifyName = "dummyDiscord"
+	err := conf.Config(global.NotifySettings{})
+	assert.NoError(t, err)
+
+	results := []probe.Result{}
+	for i := 1; i <= 12; i++ {
+		r := newDummyResult(fmt.Sprintf("probe-%02d", i))
+		r.Status = probe.StatusDown
+		results = append(results, r)
+	}
+
+	// a message has 10 embeds at most
+	discords := conf.NewBatch(results)
+	assert.Equal(t, 2, len(discords))
+	assert.Equal(t, 10, len(discords[0].Embeds))
+	assert.Equal(t, 2, len(discords[1].Embeds))
+	assert.Contains(t, discords[0].Content, "(1/2)")
+	assert.Equal(t, conf.ResultTitle(results[10]), discords[1].Embeds[0].Title)
+	discords = conf.NewBatch(results[:3])
+	assert.Equal(t, 1, len(discords))
+	assert.Equal(t, "**"+report.BatchTitle(results[:3])+"**", discords[0].Content)
+
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+
+	requests := 0
+	var client *http.Client
+	defer gomonkey.ApplyMethod(reflect.TypeOf(client), "Do", func(_ *http.Client, req *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{
+			StatusCode: 204,
+			Body:       io.NopCloser(strings.NewReader(``)),
+		}, nil
+	}).Reset()
+
+	conf.NotifyBatch(results[:3])
+	assert.Equal(t, 1, requests)
+	assert.Contains(t, buf.String(), "[discord / dummyDiscord / Notification] - "+report.BatchTitle(results[:3])+" - successfully sent!")
+	conf.NotifyBatch(results)
+	assert.Equal(t, 3, requests)
+
+	buf.Reset()
+	conf.Dry = true
+	conf.NotifyBatch(results[:3])
+	assert.Equal(t, 3, requests)
+	assert.Contains(t, buf.String(), "[discord / dummyDiscord] Dry notify")
+	assert.Contains(t, buf.String(), "probe-03")
+}
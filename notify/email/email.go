@@ -45,7 +45,9 @@ func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
 	c.NotifyKind = "email"
 	c.NotifyFormat = report.HTML
 	c.NotifySendFunc = c.SendMail
-	c.DefaultNotify.Config(gConf)
+	if err := c.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
 	log.Debugf("Notification [%s] - [%s] configuration: %+v", c.NotifyKind, c.NotifyName, c)
 	return nil
 }
@@ -42,7 +42,9 @@ func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
 	c.NotifyKind = "lark"
 	c.NotifyFormat = report.Lark
 	c.NotifySendFunc = c.SendLark
-	c.DefaultNotify.Config(gConf)
+	if err := c.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
 	log.Debugf("Notification [%s] - [%s] configuration: %+v", c.NotifyKind, c.NotifyName, c)
 	return nil
 }
@@ -0,0 +1,335 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package matrix is the Matrix notification package, it sends the message
+// to a room of the Matrix homeserver via the client-server API.
+package matrix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmlpkg "html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/notify/base"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/report"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Refer to:
+// - Client-Server API: https://spec.matrix.org/latest/client-server-api/
+// - Message format: https://spec.matrix.org/latest/client-server-api/#mroommessage-msgtypes
+
+// the message types of the Matrix room message
+const (
+	MsgTypeText   = "m.text"
+	MsgTypeNotice = "m.notice"
+)
+
+// Message is the m.room.message event of the Matrix room, the body is the
+// plain text fallback for the clients which couldn't render the HTML.
+type Message struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format,omitempty"`
+	FormattedBody string `json:"formatted_body,omitempty"`
+}
+
+// Error is the standard error response of the Matrix API
+type Error struct {
+	ErrCode string `json:"errcode"`
+	Error   string `json:"error"`
+}
+
+// NotifyConfig is the Matrix notification configuration
+type NotifyConfig struct {
+	base.DefaultNotify `yaml:",inline"`
+
+	HomeServer  string `yaml:"homeserver" json:"homeserver" jsonschema:"required,format=uri,title=Homeserver,description=The URL of the Matrix homeserver. e.g. https://matrix.example.com"`
+	AccessToken string `yaml:"access_token" json:"access_token" jsonschema:"required,title=Access Token,description=The access token of the Matrix user who sends the message"`
+	Room        string `yaml:"room" json:"room" jsonschema:"required,title=Room,description=The room ID (!id:server) or the room alias (#alias:server)"`
+	MsgType     string `yaml:"msgtype,omitempty" json:"msgtype,omitempty" jsonschema:"enum=m.text,enum=m.notice,title=Message Type,description=The message type of the Matrix message,default=m.text"`
+
+	room *room
+}
+
+// room is the resolved room ID of the room alias
+type room struct {
+	mu sync.Mutex
+	id string
+}
+
+// the transaction ID counter, the transaction ID must be unique for the access token
+var txnCounter uint64
+
+// Config configures the Matrix notification
+func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
+	c.NotifyKind = "matrix"
+	c.NotifyFormat = report.HTML
+	if err := c.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
+
+	if u, err := url.Parse(strings.TrimSpace(c.HomeServer)); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("[%s / %s] - invalid homeserver [%s]", c.NotifyKind, c.NotifyName, c.HomeServer)
+	}
+	c.HomeServer = strings.TrimSuffix(strings.TrimSpace(c.HomeServer), "/")
+	if len(strings.TrimSpace(c.AccessToken)) == 0 {
+		return fmt.Errorf("[%s / %s] - the access_token is required", c.NotifyKind, c.NotifyName)
+	}
+	c.Room = strings.TrimSpace(c.Room)
+	c.room = &room{}
+	switch {
+	case strings.HasPrefix(c.Room, "!"):
+		c.room.id = c.Room
+	case strings.HasPrefix(c.Room, "#"):
+		// the room alias is resolved when the first message is sent
+	default:
+		return fmt.Errorf("[%s / %s] - invalid room [%s], it should be the room ID (!id:server) or the room alias (#alias:server)",
+			c.NotifyKind, c.NotifyName, c.Room)
+	}
+	switch c.MsgType {
+	case "":
+		c.MsgType = MsgTypeText
+	case MsgTypeText, MsgTypeNotice:
+	default:
+		return fmt.Errorf("[%s / %s] - invalid msgtype [%s], it should be %s or %s",
+			c.NotifyKind, c.NotifyName, c.MsgType, MsgTypeText, MsgTypeNotice)
+	}
+
+	log.Debugf("Notification [%s] - [%s] configuration: %+v", c.NotifyKind, c.NotifyName, c)
+	return nil
+}
+
+var (
+	// the document wrapper of the report.HTML, the Matrix clients only support the HTML fragment
+	htmlHead = regexp.MustCompile(`(?is)^.*<body[^>]*>`)
+	htmlTail = regexp.MustCompile(`(?is)</body>.*$`)
+	htmlTag  = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// HTMLFragment strips the html, head and body tags of the HTML document,
+// the styles and the title in the head are not supported by the Matrix clients.
+func HTMLFragment(html string) string {
+	html = htmlHead.ReplaceAllString(html, "")
+	html = htmlTail.ReplaceAllString(html, "")
+	return strings.TrimSpace(html)
+}
+
+// PlainText strips the HTML tags, it's the plain text fallback of the HTML message template
+func PlainText(html string) string {
+	return strings.TrimSpace(htmlpkg.UnescapeString(htmlTag.ReplaceAllString(html, "")))
+}
+
+// NewMessage returns the Matrix message with the HTML body and the Markdown plain text fallback
+func (c *NotifyConfig) NewMessage(html, text string) Message {
+	return Message{
+		MsgType:       c.MsgType,
+		Body:          text,
+		Format:        "org.matrix.custom.html",
+		FormattedBody: HTMLFragment(html),
+	}
+}
+
+// resultMessage returns the message of the result, the plain text of the message template
+// is the fallback if it's set, otherwise the Markdown format is the fallback.
+func (c *NotifyConfig) resultMessage(result probe.Result) Message {
+	if c.HasMessageTemplate() {
+		html := c.ResultMessage(result)
+		return c.NewMessage(html, PlainText(html))
+	}
+	return c.NewMessage(report.ToHTML(result), report.ToMarkdown(result))
+}
+
+// statMessage returns the message of the SLA report
+func (c *NotifyConfig) statMessage(probers []probe.Prober) Message {
+	if c.HasStatMessageTemplate() {
+		html := c.StatMessage(probers)
+		return c.NewMessage(html, PlainText(html))
+	}
+	return c.NewMessage(report.SLAHTML(probers), report.SLAMarkdown(probers))
+}
+
+// Notify sends the result message to the Matrix room
+func (c *NotifyConfig) Notify(result probe.Result) {
+	if c.Dry {
+		c.DryNotify(result)
+		return
+	}
+	c.send(c.resultMessage(result), "Notification", c.ResultTitle(result))
+}
+
+// NotifyBatch sends the grouped results in one message, the results are sent
+// one by one if the message template is set
+func (c *NotifyConfig) NotifyBatch(results []probe.Result) {
+	if c.Dry {
+		c.DryNotifyBatch(results)
+		return
+	}
+	if len(results) == 1 || c.HasMessageTemplate() {
+		for _, r := range results {
+			c.Notify(r)
+		}
+		return
+	}
+	c.send(c.NewMessage(report.BatchHTML(results), report.BatchMarkdown(results)), "Notification", report.BatchTitle(results))
+}
+
+// NotifyStat sends the SLA report to the Matrix room
+func (c *NotifyConfig) NotifyStat(probers []probe.Prober) {
+	if c.Dry {
+		c.DryNotifyStat(probers)
+		return
+	}
+	c.send(c.statMessage(probers), "SLA", c.StatTitle(probers))
+}
+
+// send sends the message with retry, the transaction ID is kept for the retries,
+// so that the homeserver could deduplicate the message.
+func (c *NotifyConfig) send(msg Message, tag, title string) {
+	txnID := fmt.Sprintf("easeprobe-%d-%d", time.Now().UnixNano(), atomic.AddUint64(&txnCounter, 1))
+	fn := func() error {
+		return c.SendMatrix(txnID, msg)
+	}
+	err := global.DoRetry(c.Kind(), c.NotifyName, tag, c.Retry, fn)
+	report.LogSend(c.Kind(), c.NotifyName, tag, title, err)
+}
+
+// DryNotify just log the Matrix message
+func (c *NotifyConfig) DryNotify(result probe.Result) {
+	c.dryLog(c.resultMessage(result))
+}
+
+// DryNotifyBatch just log the grouped Matrix message
+func (c *NotifyConfig) DryNotifyBatch(results []probe.Result) {
+	if len(results) == 1 || c.HasMessageTemplate() {
+		for _, r := range results {
+			c.DryNotify(r)
+		}
+		return
+	}
+	c.dryLog(c.NewMessage(report.BatchHTML(results), report.BatchMarkdown(results)))
+}
+
+// DryNotifyStat just log the Matrix message
+func (c *NotifyConfig) DryNotifyStat(probers []probe.Prober) {
+	c.dryLog(c.statMessage(probers))
+}
+
+func (c *NotifyConfig) dryLog(msg Message) {
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		log.Errorf("[%s / %s] JSON Marshal Error : %v", c.NotifyKind, c.NotifyName, err)
+		return
+	}
+	log.Infof("[%s / %s] Dry notify - %s", c.NotifyKind, c.NotifyName, string(buf))
+}
+
+// RoomID returns the room ID, the room alias is resolved by the homeserver at the first time
+func (c *NotifyConfig) RoomID() (string, error) {
+	c.room.mu.Lock()
+	defer c.room.mu.Unlock()
+	if c.room.id != "" {
+		return c.room.id, nil
+	}
+
+	var resp struct {
+		RoomID string `json:"room_id"`
+	}
+	path := "/_matrix/client/v3/directory/room/" + url.PathEscape(c.Room)
+	if err := c.request(http.MethodGet, path, nil, &resp); err != nil {
+		return "", err
+	}
+	if resp.RoomID == "" {
+		return "", &global.ErrNoRetry{Message: fmt.Sprintf("the room alias [%s] is not found", c.Room)}
+	}
+	log.Infof("[%s / %s] - the room alias [%s] is resolved to [%s]", c.NotifyKind, c.NotifyName, c.Room, resp.RoomID)
+	c.room.id = resp.RoomID
+	return c.room.id, nil
+}
+
+// SendMatrix sends the message to the Matrix room with the transaction ID
+func (c *NotifyConfig) SendMatrix(txnID string, msg Message) error {
+	roomID, err := c.RoomID()
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		url.PathEscape(roomID), url.PathEscape(txnID))
+	return c.request(http.MethodPut, path, msg, nil)
+}
+
+// request calls the Matrix client-server API, the response is decoded into the result if it's not nil
+func (c *NotifyConfig) request(method, path string, body interface{}, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return &global.ErrNoRetry{Message: err.Error()}
+		}
+		reader = bytes.NewBuffer(buf)
+	}
+	req, err := http.NewRequest(method, c.HomeServer+path, reader)
+	if err != nil {
+		return &global.ErrNoRetry{Message: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+	req.Close = true
+
+	client := &http.Client{Timeout: c.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusOK {
+		if result != nil {
+			return json.Unmarshal(buf, result)
+		}
+		return nil
+	}
+
+	msg := string(buf)
+	var e Error
+	if json.Unmarshal(buf, &e) == nil && e.ErrCode != "" {
+		msg = e.ErrCode + ": " + e.Error
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return fmt.Errorf("Error response from Matrix - code [%d] - msg [%s]", resp.StatusCode, msg)
+	}
+	// the invalid token or the forbidden room could not be fixed by retrying
+	return &global.ErrNoRetry{
+		Message: fmt.Sprintf("Error response from Matrix - code [%d] - msg [%s]", resp.StatusCode, msg),
+	}
+}
@@ -0,0 +1,245 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package matrix
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/probe/base"
+)
+
+func newDummyResult(name string) probe.Result {
+	r := probe.NewResult()
+	r.Name = name
+	r.Endpoint = "http://endpoint:8080"
+	r.Status = probe.StatusDown
+	r.Message = "dummy message"
+	r.RoundTripTime = 100 * time.Millisecond
+	return *r
+}
+
+type dummyProber struct {
+	base.DefaultProbe
+}
+
+func (d *dummyProber) Config(g global.ProbeSettings) error {
+	return d.DefaultProbe.Config(g, d.ProbeKind, d.ProbeTag, d.ProbeName, "endpoint", d.DoProbe)
+}
+
+func (d *dummyProber) DoProbe() (bool, string) {
+	return true, "dummy"
+}
+
+func newDummyProber(name string) probe.Prober {
+	r := newDummyResult(name)
+	probe.SetResultData(name, &r)
+	return &dummyProber{
+		DefaultProbe: base.DefaultProbe{
+			ProbeKind:   "dummy",
+			ProbeName:   name,
+			ProbeResult: &r,
+		},
+	}
+}
+
+type request struct {
+	method string
+	path   string
+	auth   string
+	msg    Message
+}
+
+// homeserver is a local mock of the Matrix client-server API
+type homeserver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []request
+	codes    []int // the response codes of the send requests in order
+}
+
+func newHomeserver() *homeserver {
+	h := &homeserver{}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		req := request{method: r.Method, path: r.URL.EscapedPath(), auth: r.Header.Get("Authorization")}
+		buf, _ := io.ReadAll(r.Body)
+		json.Unmarshal(buf, &req.msg)
+		h.requests = append(h.requests, req)
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/_matrix/client/v3/directory/room/#ops:example.com":
+			w.Write([]byte(`{"room_id":"!abc:example.com","servers":["example.com"]}`))
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Room alias not found"}`))
+		default:
+			code := http.StatusOK
+			if len(h.codes) > 0 {
+				code, h.codes = h.codes[0], h.codes[1:]
+			}
+			w.WriteHeader(code)
+			if code == http.StatusOK {
+				w.Write([]byte(`{"event_id":"$event"}`))
+			} else {
+				w.Write([]byte(`{"errcode":"M_FORBIDDEN","error":"not in the room"}`))
+			}
+		}
+	}))
+	return h
+}
+
+func (h *homeserver) take() []request {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r := h.requests
+	h.requests = nil
+	return r
+}
+
+func newConfig(server, room string) *NotifyConfig {
+	conf := &NotifyConfig{HomeServer: server + "/", AccessToken: "token", Room: room}
+	conf.NotifyName = "dummy"
+	conf.Retry = global.Retry{Times: 3, Interval: time.Millisecond}
+	return conf
+}
+
+func TestConfig(t *testing.T) {
+	conf := newConfig("http://localhost", "!abc:example.com")
+	assert.NoError(t, conf.Config(global.NotifySettings{}))
+	assert.Equal(t, "matrix", conf.Kind())
+	assert.Equal(t, "http://localhost", conf.HomeServer)
+	assert.Equal(t, MsgTypeText, conf.MsgType)
+
+	conf = newConfig("", "!abc:example.com")
+	assert.Error(t, conf.Config(global.NotifySettings{}))
+	conf = newConfig("http://localhost", "!abc:example.com")
+	conf.AccessToken = ""
+	assert.Error(t, conf.Config(global.NotifySettings{}))
+	conf = newConfig("http://localhost", "abc:example.com")
+	assert.Error(t, conf.Config(global.NotifySettings{}))
+	conf = newConfig("http://localhost", "#ops:example.com")
+	conf.MsgType = "m.image"
+	assert.Error(t, conf.Config(global.NotifySettings{}))
+}
+
+func TestHTMLFragment(t *testing.T) {
+	html := "<html><head><style>.a{}</style><title>T</title></head>\n<BODY style=\"x\">\n<h1>T</h1></body></html>"
+	assert.Equal(t, "<h1>T</h1>", HTMLFragment(html))
+	assert.Equal(t, "<b>T</b>", HTMLFragment("<b>T</b>"))
+	assert.Equal(t, "Down & <out>", PlainText("<p><b>Down</b> &amp; &lt;out&gt;</p>"))
+}
+
+func TestNotify(t *testing.T) {
+	h := newHomeserver()
+	defer h.Close()
+
+	conf := newConfig(h.URL, "#ops:example.com")
+	conf.MsgType = MsgTypeNotice
+	assert.NoError(t, conf.Config(global.NotifySettings{}))
+
+	// the room alias is resolved at the first time
+	conf.Notify(newDummyResult("dummy"))
+	reqs := h.take()
+	assert.Len(t, reqs, 2)
+	assert.Equal(t, http.MethodGet, reqs[0].method)
+	assert.Equal(t, "Bearer token", reqs[0].auth)
+	assert.Equal(t, http.MethodPut, reqs[1].method)
+	assert.True(t, strings.HasPrefix(reqs[1].path, "/_matrix/client/v3/rooms/%21abc:example.com/send/m.room.message/easeprobe-"))
+	msg := reqs[1].msg
+	assert.Equal(t, MsgTypeNotice, msg.MsgType)
+	assert.Equal(t, "org.matrix.custom.html", msg.Format)
+	assert.Contains(t, msg.Body, "**dummy Failure**")
+	assert.Contains(t, msg.FormattedBody, "dummy Failure")
+	assert.NotContains(t, msg.FormattedBody, "<html>")
+	assert.NotContains(t, msg.FormattedBody, "<style>")
+
+	// the rate limit is retried with the same transaction ID
+	h.codes = []int{http.StatusTooManyRequests}
+	conf.NotifyStat([]probe.Prober{newDummyProber("p1"), newDummyProber("p2")})
+	reqs = h.take()
+	assert.Len(t, reqs, 2)
+	assert.Equal(t, reqs[0].path, reqs[1].path)
+	assert.Contains(t, reqs[1].msg.Body, "Overall SLA Report")
+	assert.Contains(t, reqs[1].msg.FormattedBody, "p2")
+
+	// the forbidden room is not retried
+	h.codes = []int{http.StatusForbidden}
+	conf.Notify(newDummyResult("dummy"))
+	assert.Len(t, h.take(), 1)
+	err := conf.SendMatrix("txn", Message{MsgType: MsgTypeText, Body: "hi"})
+	assert.NoError(t, err)
+	h.codes = []int{http.StatusForbidden}
+	err = conf.SendMatrix("txn", Message{MsgType: MsgTypeText, Body: "hi"})
+	assert.Equal(t, "Error response from Matrix - code [403] - msg [M_FORBIDDEN: not in the room]", err.Error())
+	_, ok := err.(*global.ErrNoRetry)
+	assert.True(t, ok)
+	h.take()
+
+	// the unknown room alias
+	conf = newConfig(h.URL, "#unknown:example.com")
+	assert.NoError(t, conf.Config(global.NotifySettings{}))
+	conf.Notify(newDummyResult("dummy"))
+	reqs = h.take()
+	assert.Len(t, reqs, 1)
+	assert.Equal(t, http.MethodGet, reqs[0].method)
+
+	// the room ID is used directly
+	conf = newConfig(h.URL, "!abc:example.com")
+	assert.NoError(t, conf.Config(global.NotifySettings{}))
+	conf.Notify(newDummyResult("dummy"))
+	reqs = h.take()
+	assert.Len(t, reqs, 1)
+	assert.Equal(t, http.MethodPut, reqs[0].method)
+}
+
+func TestDryNotify(t *testing.T) {
+	conf := newConfig("http://localhost", "!abc:example.com")
+	conf.Dry = true
+	assert.NoError(t, conf.Config(global.NotifySettings{}))
+
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+	defer logrus.SetOutput(io.Discard)
+
+	conf.Notify(newDummyResult("dummy"))
+	assert.Contains(t, buf.String(), "[matrix / dummy] Dry notify - ")
+	assert.Contains(t, buf.String(), "org.matrix.custom.html")
+
+	buf.Reset()
+	conf.NotifyStat([]probe.Prober{newDummyProber("p1")})
+	assert.Contains(t, buf.String(), "Overall SLA Report")
+
+	// the grouped results are sent in one message
+	buf.Reset()
+	conf.NotifyBatch([]probe.Result{newDummyResult("d1"), newDummyResult("d2")})
+	assert.Equal(t, 1, strings.Count(buf.String(), "Dry notify"))
+	assert.Contains(t, buf.String(), "2 Probes Changed")
+}
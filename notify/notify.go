@@ -20,37 +20,47 @@ package notify
 
 import (
 	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/notify/alertmanager"
 	"github.com/wfusion/easeprobe/notify/aws"
 	"github.com/wfusion/easeprobe/notify/dingtalk"
 	"github.com/wfusion/easeprobe/notify/discord"
 	"github.com/wfusion/easeprobe/notify/email"
 	"github.com/wfusion/easeprobe/notify/lark"
 	"github.com/wfusion/easeprobe/notify/log"
+	"github.com/wfusion/easeprobe/notify/matrix"
+	"github.com/wfusion/easeprobe/notify/opsgenie"
+	"github.com/wfusion/easeprobe/notify/pagerduty"
 	"github.com/wfusion/easeprobe/notify/ringcentral"
 	"github.com/wfusion/easeprobe/notify/shell"
 	"github.com/wfusion/easeprobe/notify/slack"
 	"github.com/wfusion/easeprobe/notify/sms"
 	"github.com/wfusion/easeprobe/notify/teams"
 	"github.com/wfusion/easeprobe/notify/telegram"
+	"github.com/wfusion/easeprobe/notify/webhook"
 	"github.com/wfusion/easeprobe/notify/wecom"
 	"github.com/wfusion/easeprobe/probe"
 )
 
 // Config is the notify configuration
 type Config struct {
-	Log         []log.NotifyConfig         `yaml:"log,omitempty" json:"log,omitempty" jsonschema:"title=Log Notification,description=Log Notification Configuration"`
-	Email       []email.NotifyConfig       `yaml:"email,omitempty" json:"email,omitempty" jsonschema:"title=Email Notification,description=Email Notification Configuration"`
-	Slack       []slack.NotifyConfig       `yaml:"slack,omitempty" json:"slack,omitempty" jsonschema:"title=Slack Notification,description=Slack Notification Configuration"`
-	Discord     []discord.NotifyConfig     `yaml:"discord,omitempty" json:"discord,omitempty" jsonschema:"title=Discord Notification,description=Discord Notification Configuration"`
-	Telegram    []telegram.NotifyConfig    `yaml:"telegram,omitempty" json:"telegram,omitempty" jsonschema:"title=Telegram Notification,description=Telegram Notification Configuration"`
-	AwsSNS      []aws.NotifyConfig         `yaml:"aws_sns,omitempty" json:"aws_sns,omitempty" jsonschema:"title=AWS SNS Notification,description=AWS SNS Notification Configuration"`
-	Wecom       []wecom.NotifyConfig       `yaml:"wecom,omitempty" json:"wecom,omitempty" jsonschema:"title=WeCom Notification,description=WeCom Notification Configuration"`
-	Dingtalk    []dingtalk.NotifyConfig    `yaml:"dingtalk,omitempty" json:"dingtalk,omitempty" jsonschema:"title=DingTalk Notification,description=DingTalk Notification Configuration"`
-	Lark        []lark.NotifyConfig        `yaml:"lark,omitempty" json:"lark,omitempty" jsonschema:"title=Lark Notification,description=Lark Notification Configuration"`
-	Sms         []sms.NotifyConfig         `yaml:"sms,omitempty" json:"sms,omitempty" jsonschema:"title=SMS Notification,description=SMS Notification Configuration"`
-	Teams       []teams.NotifyConfig       `yaml:"teams,omitempty" json:"teams,omitempty" jsonschema:"title=Teams Notification,description=Teams Notification Configuration"`
-	Shell       []shell.NotifyConfig       `yaml:"shell,omitempty" json:"shell,omitempty" jsonschema:"title=Shell Notification,description=Shell Notification Configuration"`
-	RingCentral []ringcentral.NotifyConfig `yaml:"ringcentral,omitempty" json:"ringcentral,omitempty" jsonschema:"title=RingCentral Notification,description=RingCentral Notification Configuration"`
+	Log          []log.NotifyConfig          `yaml:"log,omitempty" json:"log,omitempty" jsonschema:"title=Log Notification,description=Log Notification Configuration"`
+	Email        []email.NotifyConfig        `yaml:"email,omitempty" json:"email,omitempty" jsonschema:"title=Email Notification,description=Email Notification Configuration"`
+	Slack        []slack.NotifyConfig        `yaml:"slack,omitempty" json:"slack,omitempty" jsonschema:"title=Slack Notification,description=Slack Notification Configuration"`
+	Discord      []discord.NotifyConfig      `yaml:"discord,omitempty" json:"discord,omitempty" jsonschema:"title=Discord Notification,description=Discord Notification Configuration"`
+	Telegram     []telegram.NotifyConfig     `yaml:"telegram,omitempty" json:"telegram,omitempty" jsonschema:"title=Telegram Notification,description=Telegram Notification Configuration"`
+	AwsSNS       []aws.NotifyConfig          `yaml:"aws_sns,omitempty" json:"aws_sns,omitempty" jsonschema:"title=AWS SNS Notification,description=AWS SNS Notification Configuration"`
+	Wecom        []wecom.NotifyConfig        `yaml:"wecom,omitempty" json:"wecom,omitempty" jsonschema:"title=WeCom Notification,description=WeCom Notification Configuration"`
+	Dingtalk     []dingtalk.NotifyConfig     `yaml:"dingtalk,omitempty" json:"dingtalk,omitempty" jsonschema:"title=DingTalk Notification,description=DingTalk Notification Configuration"`
+	Lark         []lark.NotifyConfig         `yaml:"lark,omitempty" json:"lark,omitempty" jsonschema:"title=Lark Notification,description=Lark Notification Configuration"`
+	Sms          []sms.NotifyConfig          `yaml:"sms,omitempty" json:"sms,omitempty" jsonschema:"title=SMS Notification,description=SMS Notification Configuration"`
+	Teams        []teams.NotifyConfig        `yaml:"teams,omitempty" json:"teams,omitempty" jsonschema:"title=Teams Notification,description=Teams Notification Configuration"`
+	Shell        []shell.NotifyConfig        `yaml:"shell,omitempty" json:"shell,omitempty" jsonschema:"title=Shell Notification,description=Shell Notification Configuration"`
+	RingCentral  []ringcentral.NotifyConfig  `yaml:"ringcentral,omitempty" json:"ringcentral,omitempty" jsonschema:"title=RingCentral Notification,description=RingCentral Notification Configuration"`
+	Webhook      []webhook.NotifyConfig      `yaml:"webhook,omitempty" json:"webhook,omitempty" jsonschema:"title=Webhook Notification,description=Generic Webhook Notification Configuration"`
+	PagerDuty    []pagerduty.NotifyConfig    `yaml:"pagerduty,omitempty" json:"pagerduty,omitempty" jsonschema:"title=PagerDuty Notification,description=PagerDuty Events API v2 Notification Configuration"`
+	Opsgenie     []opsgenie.NotifyConfig     `yaml:"opsgenie,omitempty" json:"opsgenie,omitempty" jsonschema:"title=Opsgenie Notification,description=Opsgenie Notification Configuration"`
+	Alertmanager []alertmanager.NotifyConfig `yaml:"alertmanager,omitempty" json:"alertmanager,omitempty" jsonschema:"title=Alertmanager Notification,description=Prometheus Alertmanager Notification Configuration"`
+	Matrix       []matrix.NotifyConfig       `yaml:"matrix,omitempty" json:"matrix,omitempty" jsonschema:"title=Matrix Notification,description=Matrix Notification Configuration"`
 }
 
 // Notify is the configuration of the Notify
@@ -61,7 +71,9 @@ type Notify interface {
 	Config(global.NotifySettings) error
 	Notify(probe.Result)
 	NotifyStat([]probe.Prober)
+	NotifyBatch([]probe.Result)
 
 	DryNotify(probe.Result)
 	DryNotifyStat([]probe.Prober)
+	DryNotifyBatch([]probe.Result)
 }
@@ -0,0 +1,311 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package opsgenie is the Opsgenie notification package.
+package opsgenie
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/notify/base"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/report"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// the base URLs of the Opsgenie API
+const (
+	DefaultURL = "https://api.opsgenie.com"
+	EUURL      = "https://api.eu.opsgenie.com"
+)
+
+// the default priorities of the alerts
+const (
+	DefaultPriority        = "P2"
+	DefaultWarningPriority = "P3"
+)
+
+// the max length of the fields in the Opsgenie API
+const (
+	maxMessageLen     = 130
+	maxDescriptionLen = 15000
+	maxTagLen         = 50
+)
+
+// Responder is the responder of the Opsgenie alert, the type could be
+// "team", "user", "escalation" or "schedule", and it's identified by the
+// id, the name, or the username (only for the "user" type).
+type Responder struct {
+	Type     string `yaml:"type" json:"type" jsonschema:"required,enum=team,enum=user,enum=escalation,enum=schedule,title=Type,description=The type of the responder"`
+	ID       string `yaml:"id,omitempty" json:"id,omitempty" jsonschema:"title=ID,description=The id of the responder"`
+	Name     string `yaml:"name,omitempty" json:"name,omitempty" jsonschema:"title=Name,description=The name of the team, escalation or schedule"`
+	Username string `yaml:"username,omitempty" json:"username,omitempty" jsonschema:"title=Username,description=The username of the user"`
+}
+
+// Alert is the request of creating the Opsgenie alert
+type Alert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Responders  []Responder       `json:"responders,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	Entity      string            `json:"entity,omitempty"`
+	Source      string            `json:"source,omitempty"`
+	Priority    string            `json:"priority,omitempty"`
+	Note        string            `json:"note,omitempty"`
+}
+
+// Close is the request of closing the Opsgenie alert
+type Close struct {
+	Source string `json:"source,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+// NotifyConfig is the Opsgenie notification configuration
+type NotifyConfig struct {
+	base.DefaultNotify `yaml:",inline"`
+
+	APIKey          string      `yaml:"api_key" json:"api_key" jsonschema:"required,title=API Key,description=The API key of the Opsgenie API integration"`
+	URL             string      `yaml:"url,omitempty" json:"url,omitempty" jsonschema:"format=uri,title=API URL,description=The base URL of the Opsgenie API. e.g. https://api.eu.opsgenie.com for EU,default=https://api.opsgenie.com"`
+	Responders      []Responder `yaml:"responders,omitempty" json:"responders,omitempty" jsonschema:"title=Responders,description=The responders of the alert"`
+	Tags            []string    `yaml:"tags,omitempty" json:"tags,omitempty" jsonschema:"title=Tags,description=The tags of the alert besides the probe labels"`
+	Priority        string      `yaml:"priority,omitempty" json:"priority,omitempty" jsonschema:"enum=P1,enum=P2,enum=P3,enum=P4,enum=P5,title=Priority,description=The priority of the down alert,default=P2"`
+	WarningPriority string      `yaml:"warning_priority,omitempty" json:"warning_priority,omitempty" jsonschema:"enum=P1,enum=P2,enum=P3,enum=P4,enum=P5,title=Warning Priority,description=The priority of the warning alert,default=P3"`
+}
+
+// Config configures the Opsgenie notification
+func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
+	c.NotifyKind = "opsgenie"
+	c.NotifyFormat = report.Text
+	if err := c.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(c.APIKey)) == 0 {
+		return fmt.Errorf("[%s / %s] - the api_key is required", c.NotifyKind, c.NotifyName)
+	}
+	if c.URL == "" {
+		c.URL = DefaultURL
+	}
+	c.URL = strings.TrimSuffix(c.URL, "/")
+
+	var err error
+	if c.Priority, err = priority(c.Priority, DefaultPriority); err != nil {
+		return fmt.Errorf("[%s / %s] - %v", c.NotifyKind, c.NotifyName, err)
+	}
+	if c.WarningPriority, err = priority(c.WarningPriority, DefaultWarningPriority); err != nil {
+		return fmt.Errorf("[%s / %s] - %v", c.NotifyKind, c.NotifyName, err)
+	}
+	for _, r := range c.Responders {
+		switch r.Type {
+		case "team", "user", "escalation", "schedule":
+		default:
+			return fmt.Errorf("[%s / %s] - invalid responder type [%s]", c.NotifyKind, c.NotifyName, r.Type)
+		}
+		if r.ID == "" && r.Name == "" && r.Username == "" {
+			return fmt.Errorf("[%s / %s] - the responder requires id, name or username", c.NotifyKind, c.NotifyName)
+		}
+	}
+	log.Debugf("Notification [%s] - [%s] configuration: %+v", c.NotifyKind, c.NotifyName, c)
+	return nil
+}
+
+func priority(p, def string) (string, error) {
+	p = strings.ToUpper(strings.TrimSpace(p))
+	switch p {
+	case "":
+		return def, nil
+	case "P1", "P2", "P3", "P4", "P5":
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid priority [%s]", p)
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// NewAlert returns the alert of the failed result
+func (c *NotifyConfig) NewAlert(r *probe.Result) *Alert {
+	a := &Alert{
+		Message:     truncate(c.ResultTitle(*r), maxMessageLen),
+		Alias:       base.AlertID(r),
+		Description: truncate(c.ResultMessage(*r), maxDescriptionLen),
+		Responders:  c.Responders,
+		Entity:      r.Endpoint,
+		Source:      global.GetEaseProbe().Name,
+		Priority:    c.Priority,
+		Note:        fmt.Sprintf("%s - %s", report.FormatTime(r.StartTime), r.Message),
+		Details: map[string]string{
+			"name":     r.Name,
+			"endpoint": r.Endpoint,
+			"kind":     r.Kind,
+			"status":   r.Status.String(),
+			"message":  r.Message,
+			"rtt":      r.RoundTripTime.Round(time.Millisecond).String(),
+			"sla":      fmt.Sprintf("%.2f%%", r.SLAPercent()),
+		},
+	}
+	if r.Status == probe.StatusWarning {
+		a.Priority = c.WarningPriority
+	}
+
+	// the labels are the tags in the "key:value" format
+	a.Tags = append(a.Tags, c.Tags...)
+	keys := make([]string, 0, len(r.Labels))
+	for k := range r.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		a.Tags = append(a.Tags, truncate(k+":"+r.Labels[k], maxTagLen))
+	}
+	return a
+}
+
+// NewClose returns the close request of the recovered result
+func (c *NotifyConfig) NewClose(r *probe.Result) *Close {
+	return &Close{
+		Source: global.GetEaseProbe().Name,
+		Note:   fmt.Sprintf("%s - %s", report.FormatTime(r.StartTime), c.ResultTitle(*r)),
+	}
+}
+
+// request returns the API path and the body of the result,
+// the path is empty if the result should not be sent.
+func (c *NotifyConfig) request(r *probe.Result) (string, interface{}) {
+	switch r.Status {
+	case probe.StatusUp:
+		// nothing to close if the probe is just started
+		if r.PreStatus == probe.StatusInit {
+			return "", nil
+		}
+		return "/v2/alerts/" + url.PathEscape(base.AlertID(r)) + "/close?identifierType=alias", c.NewClose(r)
+	case probe.StatusInit:
+		return "", nil
+	}
+	return "/v2/alerts", c.NewAlert(r)
+}
+
+// Notify creates the alert for the failed result, and closes it when the probe is recovered
+func (c *NotifyConfig) Notify(result probe.Result) {
+	if c.Dry {
+		c.DryNotify(result)
+		return
+	}
+	tag := "Notification"
+	path, body := c.request(&result)
+	if path == "" {
+		log.Debugf("[%s / %s] - %s is not sent to Opsgenie", c.NotifyKind, c.NotifyName, result.Title())
+		return
+	}
+	fn := func() error {
+		return c.SendOpsgenie(path, body)
+	}
+	err := global.DoRetry(c.Kind(), c.NotifyName, tag, c.Retry, fn)
+	report.LogSend(c.Kind(), c.NotifyName, tag, result.Title(), err)
+}
+
+// NotifyBatch sends the grouped results one by one, each probe is a separate alert
+func (c *NotifyConfig) NotifyBatch(results []probe.Result) {
+	for _, r := range results {
+		c.Notify(r)
+	}
+}
+
+// NotifyStat does nothing, the SLA report is not an alert
+func (c *NotifyConfig) NotifyStat(probers []probe.Prober) {
+	log.Debugf("[%s / %s] - the SLA report is not sent to Opsgenie", c.NotifyKind, c.NotifyName)
+}
+
+// DryNotify just log the Opsgenie request
+func (c *NotifyConfig) DryNotify(result probe.Result) {
+	path, body := c.request(&result)
+	if path == "" {
+		log.Infof("[%s / %s] Dry notify - %s is not sent", c.NotifyKind, c.NotifyName, result.Title())
+		return
+	}
+	buf, err := json.Marshal(body)
+	if err != nil {
+		log.Errorf("[%s / %s] JSON Marshal Error : %v", c.NotifyKind, c.NotifyName, err)
+		return
+	}
+	log.Infof("[%s / %s] Dry notify - POST %s - %s", c.NotifyKind, c.NotifyName, path, string(buf))
+}
+
+// DryNotifyBatch just log the Opsgenie requests of the grouped results
+func (c *NotifyConfig) DryNotifyBatch(results []probe.Result) {
+	for _, r := range results {
+		c.DryNotify(r)
+	}
+}
+
+// DryNotifyStat does nothing, the SLA report is not an alert
+func (c *NotifyConfig) DryNotifyStat(probers []probe.Prober) {
+	c.NotifyStat(probers)
+}
+
+// SendOpsgenie posts the request to the Opsgenie API
+func (c *NotifyConfig) SendOpsgenie(path string, body interface{}) error {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return &global.ErrNoRetry{Message: err.Error()}
+	}
+	req, err := http.NewRequest(http.MethodPost, c.URL+path, bytes.NewBuffer(buf))
+	if err != nil {
+		return &global.ErrNoRetry{Message: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+c.APIKey)
+	req.Close = true
+
+	client := &http.Client{Timeout: c.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	msg, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("Error response from Opsgenie - code [%d] - msg [%s]", resp.StatusCode, string(msg))
+	}
+	// the invalid request could not be fixed by retrying
+	return &global.ErrNoRetry{
+		Message: fmt.Sprintf("Error response from Opsgenie - code [%d] - msg [%s]", resp.StatusCode, string(msg)),
+	}
+}
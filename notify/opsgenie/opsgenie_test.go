@@ -0,0 +1,254 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opsgenie
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/notify/base"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func newDummyResult(status, pre probe.Status) probe.Result {
+	r := probe.NewResult()
+	r.Name = "dummy"
+	r.Endpoint = "http://endpoint:8080"
+	r.Kind = "http"
+	r.Labels = map[string]string{"team": "web", "env": "prod"}
+	r.Status = status
+	r.PreStatus = pre
+	r.Message = "dummy message"
+	r.RoundTripTime = 120 * time.Millisecond
+	return *r
+}
+
+type request struct {
+	path string
+	auth string
+	body map[string]interface{}
+}
+
+// newServer is a local mock of the Opsgenie API
+func newServer(t *testing.T, codes ...int) (*httptest.Server, chan request) {
+	ch := make(chan request, 10)
+	i := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		b, err := io.ReadAll(r.Body)
+		assert.Nil(t, err)
+		req := request{path: r.URL.RequestURI(), auth: r.Header.Get("Authorization")}
+		assert.Nil(t, json.Unmarshal(b, &req.body))
+		ch <- req
+
+		code := http.StatusAccepted
+		if i < len(codes) {
+			code = codes[i]
+		}
+		i++
+		w.WriteHeader(code)
+		w.Write([]byte(`{"result":"Request will be processed","took":0.1,"requestId":"id"}`))
+	}))
+	return s, ch
+}
+
+func TestConfig(t *testing.T) {
+	conf := &NotifyConfig{}
+	conf.NotifyName = "dummy"
+	assert.NotNil(t, conf.Config(global.NotifySettings{}))
+
+	conf.APIKey = "key"
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+	assert.Equal(t, "opsgenie", conf.Kind())
+	assert.Equal(t, DefaultURL, conf.URL)
+	assert.Equal(t, DefaultPriority, conf.Priority)
+	assert.Equal(t, DefaultWarningPriority, conf.WarningPriority)
+
+	conf.URL = EUURL + "/"
+	conf.Priority = "p1"
+	conf.WarningPriority = "P4"
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+	assert.Equal(t, EUURL, conf.URL)
+	assert.Equal(t, "P1", conf.Priority)
+	assert.Equal(t, "P4", conf.WarningPriority)
+
+	conf.Priority = "P6"
+	assert.NotNil(t, conf.Config(global.NotifySettings{}))
+	conf.Priority = ""
+	conf.WarningPriority = "high"
+	assert.NotNil(t, conf.Config(global.NotifySettings{}))
+	conf.WarningPriority = ""
+
+	conf.Responders = []Responder{{Type: "team", Name: "ops"}}
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+	conf.Responders = []Responder{{Type: "group", Name: "ops"}}
+	assert.NotNil(t, conf.Config(global.NotifySettings{}))
+	conf.Responders = []Responder{{Type: "user"}}
+	assert.NotNil(t, conf.Config(global.NotifySettings{}))
+}
+
+func TestNewAlert(t *testing.T) {
+	conf := &NotifyConfig{
+		APIKey:     "key",
+		Responders: []Responder{{Type: "team", Name: "ops"}},
+		Tags:       []string{"easeprobe"},
+	}
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	r := newDummyResult(probe.StatusDown, probe.StatusUp)
+	a := conf.NewAlert(&r)
+	assert.Equal(t, "dummy Failure", a.Message)
+	assert.Equal(t, base.AlertID(&r), a.Alias)
+	assert.Equal(t, conf.Responders, a.Responders)
+	assert.Equal(t, []string{"easeprobe", "env:prod", "team:web"}, a.Tags)
+	assert.Equal(t, "http://endpoint:8080", a.Entity)
+	assert.Equal(t, DefaultPriority, a.Priority)
+	assert.Contains(t, a.Note, "dummy message")
+	assert.Contains(t, a.Description, "dummy message")
+	assert.Equal(t, "120ms", a.Details["rtt"])
+	assert.Equal(t, "http", a.Details["kind"])
+
+	r = newDummyResult(probe.StatusWarning, probe.StatusUp)
+	r.Name = strings.Repeat("x", 200)
+	r.Labels = map[string]string{"key": strings.Repeat("v", 100)}
+	a = conf.NewAlert(&r)
+	assert.Equal(t, DefaultWarningPriority, a.Priority)
+	assert.Len(t, a.Message, maxMessageLen)
+	assert.Len(t, a.Tags[1], maxTagLen)
+}
+
+func TestRequest(t *testing.T) {
+	conf := &NotifyConfig{APIKey: "key"}
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	r := newDummyResult(probe.StatusDown, probe.StatusUp)
+	path, body := conf.request(&r)
+	assert.Equal(t, "/v2/alerts", path)
+	assert.IsType(t, &Alert{}, body)
+
+	r = newDummyResult(probe.StatusUp, probe.StatusDown)
+	path, body = conf.request(&r)
+	assert.Equal(t, "/v2/alerts/"+base.AlertID(&r)+"/close?identifierType=alias", path)
+	assert.IsType(t, &Close{}, body)
+
+	r = newDummyResult(probe.StatusUp, probe.StatusInit)
+	path, _ = conf.request(&r)
+	assert.Empty(t, path)
+	r = newDummyResult(probe.StatusInit, probe.StatusInit)
+	path, _ = conf.request(&r)
+	assert.Empty(t, path)
+}
+
+func TestNotify(t *testing.T) {
+	s, ch := newServer(t)
+	defer s.Close()
+
+	conf := &NotifyConfig{APIKey: "key", URL: s.URL}
+	conf.NotifyName = "dummy"
+	conf.Retry.Times = 1
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+
+	down := newDummyResult(probe.StatusDown, probe.StatusUp)
+	conf.Notify(down)
+	req := <-ch
+	assert.Equal(t, "/v2/alerts", req.path)
+	assert.Equal(t, "GenieKey key", req.auth)
+	assert.Equal(t, base.AlertID(&down), req.body["alias"])
+	assert.Contains(t, buf.String(), "successfully sent")
+
+	up := newDummyResult(probe.StatusUp, probe.StatusDown)
+	conf.Notify(up)
+	req = <-ch
+	assert.Equal(t, "/v2/alerts/"+base.AlertID(&up)+"/close?identifierType=alias", req.path)
+	assert.Contains(t, req.body["note"], "dummy Recovery")
+
+	// nothing is sent
+	conf.Notify(newDummyResult(probe.StatusUp, probe.StatusInit))
+	conf.NotifyStat([]probe.Prober{})
+	assert.Len(t, ch, 0)
+}
+
+func TestNotifyRetry(t *testing.T) {
+	s, ch := newServer(t, http.StatusInternalServerError, http.StatusTooManyRequests)
+	defer s.Close()
+
+	conf := &NotifyConfig{APIKey: "key", URL: s.URL}
+	conf.NotifyName = "dummy"
+	conf.Retry.Times = 3
+	conf.Retry.Interval = time.Millisecond
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+
+	conf.Notify(newDummyResult(probe.StatusDown, probe.StatusUp))
+	assert.Len(t, ch, 3)
+	assert.Contains(t, buf.String(), "successfully sent")
+}
+
+func TestNotifyFailed(t *testing.T) {
+	s, ch := newServer(t, http.StatusUnauthorized)
+	defer s.Close()
+
+	conf := &NotifyConfig{APIKey: "key", URL: s.URL}
+	conf.NotifyName = "dummy"
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	// the unauthorized request is not retried
+	err := conf.SendOpsgenie("/v2/alerts", &Alert{})
+	assert.IsType(t, &global.ErrNoRetry{}, err)
+	<-ch
+
+	conf.URL = "://invalid"
+	assert.NotNil(t, conf.SendOpsgenie("/v2/alerts", &Alert{}))
+	assert.NotNil(t, conf.SendOpsgenie("/v2/alerts", func() {}))
+}
+
+func TestDryNotify(t *testing.T) {
+	conf := &NotifyConfig{APIKey: "key"}
+	conf.NotifyName = "dummy"
+	conf.Dry = true
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+
+	conf.Notify(newDummyResult(probe.StatusDown, probe.StatusUp))
+	assert.Contains(t, buf.String(), "[opsgenie / dummy] Dry notify - POST /v2/alerts")
+
+	buf.Reset()
+	conf.Notify(newDummyResult(probe.StatusUp, probe.StatusInit))
+	assert.Contains(t, buf.String(), "is not sent")
+
+	buf.Reset()
+	conf.DryNotifyStat([]probe.Prober{})
+	assert.NotContains(t, buf.String(), "Dry notify")
+}
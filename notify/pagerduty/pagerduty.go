@@ -0,0 +1,287 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package pagerduty is the PagerDuty Events API v2 notification package.
+package pagerduty
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/notify/base"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/report"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultEventsURL is the URL of the PagerDuty Events API v2
+const DefaultEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// the event actions of the PagerDuty Events API v2
+const (
+	ActionTrigger = "trigger"
+	ActionResolve = "resolve"
+)
+
+// the severities of the PagerDuty Events API v2
+const (
+	SeverityCritical = "critical"
+	SeverityError    = "error"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// the default label keys of the component and the group
+const (
+	DefaultComponentLabel = "component"
+	DefaultGroupLabel     = "group"
+)
+
+// the max length of the summary in the PagerDuty Events API v2
+const maxSummaryLen = 1024
+
+// Payload is the payload of the trigger event
+type Payload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp,omitempty"`
+	Component     string                 `json:"component,omitempty"`
+	Group         string                 `json:"group,omitempty"`
+	Class         string                 `json:"class,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+// Event is the event of the PagerDuty Events API v2
+type Event struct {
+	RoutingKey  string   `json:"routing_key"`
+	EventAction string   `json:"event_action"`
+	DedupKey    string   `json:"dedup_key"`
+	Payload     *Payload `json:"payload,omitempty"`
+	Client      string   `json:"client,omitempty"`
+	ClientURL   string   `json:"client_url,omitempty"`
+}
+
+// NotifyConfig is the PagerDuty notification configuration
+type NotifyConfig struct {
+	base.DefaultNotify `yaml:",inline"`
+
+	RoutingKey     string `yaml:"routing_key" json:"routing_key" jsonschema:"required,title=Routing Key,description=The integration key of the PagerDuty Events API v2 integration"`
+	URL            string `yaml:"url,omitempty" json:"url,omitempty" jsonschema:"format=uri,title=Events API URL,description=The URL of the PagerDuty Events API v2,default=https://events.pagerduty.com/v2/enqueue"`
+	Severity       string `yaml:"severity,omitempty" json:"severity,omitempty" jsonschema:"enum=critical,enum=error,enum=warning,enum=info,title=Severity,description=The severity of the down event,default=critical"`
+	ComponentLabel string `yaml:"component_label,omitempty" json:"component_label,omitempty" jsonschema:"title=Component Label,description=The probe label used as the component,default=component"`
+	GroupLabel     string `yaml:"group_label,omitempty" json:"group_label,omitempty" jsonschema:"title=Group Label,description=The probe label used as the group,default=group"`
+	ClientURL      string `yaml:"client_url,omitempty" json:"client_url,omitempty" jsonschema:"format=uri,title=Client URL,description=The URL shown in the PagerDuty incident, e.g. the SLA live report"`
+}
+
+// Config configures the PagerDuty notification
+func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
+	c.NotifyKind = "pagerduty"
+	c.NotifyFormat = report.JSON
+	c.NotifySendFunc = c.SendPagerDuty
+	if err := c.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(c.RoutingKey)) == 0 {
+		return fmt.Errorf("[%s / %s] - the routing_key is required", c.NotifyKind, c.NotifyName)
+	}
+	if c.URL == "" {
+		c.URL = DefaultEventsURL
+	}
+	switch c.Severity = strings.ToLower(strings.TrimSpace(c.Severity)); c.Severity {
+	case "":
+		c.Severity = SeverityCritical
+	case SeverityCritical, SeverityError, SeverityWarning, SeverityInfo:
+	default:
+		return fmt.Errorf("[%s / %s] - invalid severity [%s]", c.NotifyKind, c.NotifyName, c.Severity)
+	}
+	if c.ComponentLabel == "" {
+		c.ComponentLabel = DefaultComponentLabel
+	}
+	if c.GroupLabel == "" {
+		c.GroupLabel = DefaultGroupLabel
+	}
+	log.Debugf("Notification [%s] - [%s] configuration: %+v", c.NotifyKind, c.NotifyName, c)
+	return nil
+}
+
+// DedupKey returns the stable deduplication key of the probe, so the trigger and
+// the resolve events of a probe are in the same PagerDuty alert.
+func DedupKey(r *probe.Result) string {
+	return base.AlertID(r)
+}
+
+// NewEvent maps the result to the PagerDuty event, it returns nil if the result
+// should not be sent, e.g. the first up result after the probe started.
+func (c *NotifyConfig) NewEvent(r *probe.Result) *Event {
+	e := &Event{
+		RoutingKey: c.RoutingKey,
+		DedupKey:   DedupKey(r),
+		Client:     global.GetEaseProbe().Name,
+		ClientURL:  c.ClientURL,
+	}
+	switch r.Status {
+	case probe.StatusUp:
+		// nothing to resolve if the probe is just started
+		if r.PreStatus == probe.StatusInit {
+			return nil
+		}
+		e.EventAction = ActionResolve
+		return e
+	case probe.StatusInit:
+		return nil
+	}
+
+	severity := c.Severity
+	if r.Status == probe.StatusWarning {
+		severity = SeverityWarning
+	}
+	// the title template is the whole summary if it's set
+	summary := c.ResultTitle(*r)
+	if c.TitleTemplate == "" {
+		summary += " - " + r.Message
+	}
+	if len(summary) > maxSummaryLen {
+		summary = summary[:maxSummaryLen]
+	}
+	e.EventAction = ActionTrigger
+	e.Payload = &Payload{
+		Summary:   summary,
+		Source:    r.Endpoint,
+		Severity:  severity,
+		Timestamp: r.StartTime.UTC().Format(time.RFC3339),
+		Component: r.Labels[c.ComponentLabel],
+		Group:     r.Labels[c.GroupLabel],
+		Class:     r.Kind,
+		CustomDetails: map[string]interface{}{
+			"name":     r.Name,
+			"endpoint": r.Endpoint,
+			"status":   r.Status.String(),
+			"message":  r.Message,
+			"rtt":      r.RoundTripTime.Round(time.Millisecond).String(),
+			"sla":      fmt.Sprintf("%.2f%%", r.SLAPercent()),
+		},
+	}
+	if c.HasMessageTemplate() {
+		e.Payload.CustomDetails["details"] = c.ResultMessage(*r)
+	}
+	if e.Payload.Component == "" {
+		e.Payload.Component = r.Name
+	}
+	if len(r.Labels) > 0 {
+		e.Payload.CustomDetails["labels"] = r.Labels
+	}
+	return e
+}
+
+// Notify sends the trigger or resolve event of the result to PagerDuty
+func (c *NotifyConfig) Notify(result probe.Result) {
+	if c.Dry {
+		c.DryNotify(result)
+		return
+	}
+	e := c.NewEvent(&result)
+	if e == nil {
+		log.Debugf("[%s / %s] - %s is not sent to PagerDuty", c.NotifyKind, c.NotifyName, result.Title())
+		return
+	}
+	buf, err := json.Marshal(e)
+	if err != nil {
+		report.LogSend(c.NotifyKind, c.NotifyName, "Notification", result.Title(), err)
+		return
+	}
+	c.SendWithRetry(result.Title(), string(buf), "Notification")
+}
+
+// NotifyBatch sends the events of the grouped results one by one, each probe is a separate incident
+func (c *NotifyConfig) NotifyBatch(results []probe.Result) {
+	for _, r := range results {
+		c.Notify(r)
+	}
+}
+
+// NotifyStat does nothing, the SLA report is not an incident
+func (c *NotifyConfig) NotifyStat(probers []probe.Prober) {
+	log.Debugf("[%s / %s] - the SLA report is not sent to PagerDuty", c.NotifyKind, c.NotifyName)
+}
+
+// DryNotify just log the PagerDuty event
+func (c *NotifyConfig) DryNotify(result probe.Result) {
+	e := c.NewEvent(&result)
+	if e == nil {
+		log.Infof("[%s / %s] Dry notify - %s is not sent", c.NotifyKind, c.NotifyName, result.Title())
+		return
+	}
+	buf, err := json.Marshal(e)
+	if err != nil {
+		log.Errorf("[%s / %s] JSON Marshal Error : %v", c.NotifyKind, c.NotifyName, err)
+		return
+	}
+	log.Infof("[%s / %s] Dry notify - %s", c.NotifyKind, c.NotifyName, string(buf))
+}
+
+// DryNotifyBatch just log the PagerDuty events of the grouped results
+func (c *NotifyConfig) DryNotifyBatch(results []probe.Result) {
+	for _, r := range results {
+		c.DryNotify(r)
+	}
+}
+
+// DryNotifyStat does nothing, the SLA report is not an incident
+func (c *NotifyConfig) DryNotifyStat(probers []probe.Prober) {
+	c.NotifyStat(probers)
+}
+
+// SendPagerDuty posts the event to the PagerDuty Events API v2
+func (c *NotifyConfig) SendPagerDuty(title, msg string) error {
+	req, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewBufferString(msg))
+	if err != nil {
+		return &global.ErrNoRetry{Message: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Close = true
+
+	client := &http.Client{Timeout: c.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("Error response from PagerDuty [%s] - code [%d] - msg [%s]", title, resp.StatusCode, string(buf))
+	}
+	// the invalid event could not be fixed by retrying
+	return &global.ErrNoRetry{
+		Message: fmt.Sprintf("Error response from PagerDuty [%s] - code [%d] - msg [%s]", title, resp.StatusCode, string(buf)),
+	}
+}
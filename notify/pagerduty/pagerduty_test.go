@@ -0,0 +1,236 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pagerduty
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func newDummyResult(status, pre probe.Status) probe.Result {
+	r := probe.NewResult()
+	r.Name = "dummy"
+	r.Endpoint = "http://endpoint:8080"
+	r.Kind = "http"
+	r.Labels = map[string]string{"component": "api", "group": "team-a"}
+	r.Status = status
+	r.PreStatus = pre
+	r.Message = "dummy message"
+	r.RoundTripTime = 120 * time.Millisecond
+	return *r
+}
+
+// newServer is a local stand-in for the PagerDuty Events API v2
+func newServer(t *testing.T, codes ...int) (*httptest.Server, chan Event) {
+	ch := make(chan Event, 10)
+	i := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		b, err := io.ReadAll(r.Body)
+		assert.Nil(t, err)
+		var e Event
+		assert.Nil(t, json.Unmarshal(b, &e))
+		ch <- e
+
+		code := http.StatusAccepted
+		if i < len(codes) {
+			code = codes[i]
+		}
+		i++
+		w.WriteHeader(code)
+		w.Write([]byte(`{"status":"success","message":"Event processed","dedup_key":"` + e.DedupKey + `"}`))
+	}))
+	return s, ch
+}
+
+func TestConfig(t *testing.T) {
+	conf := &NotifyConfig{}
+	conf.NotifyName = "dummy"
+	assert.NotNil(t, conf.Config(global.NotifySettings{}))
+
+	conf.RoutingKey = "key"
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+	assert.Equal(t, "pagerduty", conf.Kind())
+	assert.Equal(t, DefaultEventsURL, conf.URL)
+	assert.Equal(t, SeverityCritical, conf.Severity)
+	assert.Equal(t, DefaultComponentLabel, conf.ComponentLabel)
+	assert.Equal(t, DefaultGroupLabel, conf.GroupLabel)
+
+	conf.Severity = "Error"
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+	assert.Equal(t, SeverityError, conf.Severity)
+
+	conf.Severity = "fatal"
+	assert.NotNil(t, conf.Config(global.NotifySettings{}))
+}
+
+func TestDedupKey(t *testing.T) {
+	r := newDummyResult(probe.StatusDown, probe.StatusUp)
+	key := DedupKey(&r)
+	assert.True(t, strings.HasPrefix(key, "easeprobe-"))
+	// the key is stable across the status changes
+	r.Status = probe.StatusUp
+	r.Message = "another message"
+	assert.Equal(t, key, DedupKey(&r))
+	// but it's different for another endpoint
+	r.Endpoint = "http://another:8080"
+	assert.NotEqual(t, key, DedupKey(&r))
+}
+
+func TestNewEvent(t *testing.T) {
+	conf := &NotifyConfig{RoutingKey: "key"}
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	r := newDummyResult(probe.StatusDown, probe.StatusUp)
+	e := conf.NewEvent(&r)
+	assert.Equal(t, ActionTrigger, e.EventAction)
+	assert.Equal(t, "key", e.RoutingKey)
+	assert.Equal(t, DedupKey(&r), e.DedupKey)
+	assert.Equal(t, "dummy Failure - dummy message", e.Payload.Summary)
+	assert.Equal(t, "http://endpoint:8080", e.Payload.Source)
+	assert.Equal(t, SeverityCritical, e.Payload.Severity)
+	assert.Equal(t, "api", e.Payload.Component)
+	assert.Equal(t, "team-a", e.Payload.Group)
+	assert.Equal(t, "http", e.Payload.Class)
+	assert.Equal(t, "120ms", e.Payload.CustomDetails["rtt"])
+	assert.Equal(t, "dummy message", e.Payload.CustomDetails["message"])
+	assert.Contains(t, e.Payload.CustomDetails, "sla")
+
+	r = newDummyResult(probe.StatusWarning, probe.StatusUp)
+	r.Labels = nil
+	e = conf.NewEvent(&r)
+	assert.Equal(t, ActionTrigger, e.EventAction)
+	assert.Equal(t, SeverityWarning, e.Payload.Severity)
+	assert.Equal(t, "dummy", e.Payload.Component)
+	assert.Empty(t, e.Payload.Group)
+	assert.NotContains(t, e.Payload.CustomDetails, "labels")
+
+	r = newDummyResult(probe.StatusDown, probe.StatusUp)
+	r.Message = strings.Repeat("x", 2*maxSummaryLen)
+	e = conf.NewEvent(&r)
+	assert.Len(t, e.Payload.Summary, maxSummaryLen)
+
+	r = newDummyResult(probe.StatusUp, probe.StatusDown)
+	e = conf.NewEvent(&r)
+	assert.Equal(t, ActionResolve, e.EventAction)
+	assert.Equal(t, DedupKey(&r), e.DedupKey)
+	assert.Nil(t, e.Payload)
+
+	// the first up result and the init result are not sent
+	r = newDummyResult(probe.StatusUp, probe.StatusInit)
+	assert.Nil(t, conf.NewEvent(&r))
+	r = newDummyResult(probe.StatusInit, probe.StatusInit)
+	assert.Nil(t, conf.NewEvent(&r))
+}
+
+func TestNotify(t *testing.T) {
+	s, ch := newServer(t)
+	defer s.Close()
+
+	conf := &NotifyConfig{RoutingKey: "key", URL: s.URL}
+	conf.NotifyName = "dummy"
+	conf.Retry.Times = 1
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+
+	down := newDummyResult(probe.StatusDown, probe.StatusUp)
+	conf.Notify(down)
+	e := <-ch
+	assert.Equal(t, ActionTrigger, e.EventAction)
+	assert.Contains(t, buf.String(), "successfully sent")
+
+	up := newDummyResult(probe.StatusUp, probe.StatusDown)
+	conf.Notify(up)
+	e2 := <-ch
+	assert.Equal(t, ActionResolve, e2.EventAction)
+	assert.Equal(t, e.DedupKey, e2.DedupKey)
+
+	// nothing is sent
+	conf.Notify(newDummyResult(probe.StatusUp, probe.StatusInit))
+	conf.NotifyStat([]probe.Prober{})
+	assert.Len(t, ch, 0)
+}
+
+func TestNotifyFailed(t *testing.T) {
+	s, ch := newServer(t, http.StatusBadRequest, http.StatusTooManyRequests, http.StatusInternalServerError)
+	defer s.Close()
+
+	conf := &NotifyConfig{RoutingKey: "key", URL: s.URL}
+	conf.NotifyName = "dummy"
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	// the bad request is not retried
+	err := conf.SendPagerDuty("title", `{}`)
+	assert.NotNil(t, err)
+	assert.IsType(t, &global.ErrNoRetry{}, err)
+	<-ch
+
+	// the rate limit and the server error are retried
+	err = conf.SendPagerDuty("title", `{}`)
+	assert.NotNil(t, err)
+	assert.NotContains(t, err.Error(), "ErrNoRetry")
+	_, ok := err.(*global.ErrNoRetry)
+	assert.False(t, ok)
+	<-ch
+	err = conf.SendPagerDuty("title", `{}`)
+	_, ok = err.(*global.ErrNoRetry)
+	assert.False(t, ok)
+	<-ch
+
+	assert.Nil(t, conf.SendPagerDuty("title", `{}`))
+	<-ch
+
+	conf.URL = "://invalid"
+	assert.NotNil(t, conf.SendPagerDuty("title", `{}`))
+}
+
+func TestDryNotify(t *testing.T) {
+	conf := &NotifyConfig{RoutingKey: "key"}
+	conf.NotifyName = "dummy"
+	conf.Dry = true
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+
+	conf.Notify(newDummyResult(probe.StatusDown, probe.StatusUp))
+	assert.Contains(t, buf.String(), "[pagerduty / dummy] Dry notify")
+	assert.Contains(t, buf.String(), ActionTrigger)
+
+	buf.Reset()
+	conf.Notify(newDummyResult(probe.StatusUp, probe.StatusInit))
+	assert.Contains(t, buf.String(), "is not sent")
+
+	buf.Reset()
+	conf.DryNotifyStat([]probe.Prober{})
+	assert.NotContains(t, buf.String(), "Dry notify")
+}
@@ -43,7 +43,9 @@ func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
 	c.NotifyKind = "ringcentral"
 	c.NotifyFormat = report.Text
 	c.NotifySendFunc = c.SendRingCentral
-	c.DefaultNotify.Config(gConf)
+	if err := c.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
 	log.Debugf("Notification [%s] - [%s] configuration: %+v", c.NotifyKind, c.NotifyName, c)
 	return nil
 }
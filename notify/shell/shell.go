@@ -49,7 +49,9 @@ func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
 	c.NotifyKind = "shell"
 	c.NotifyFormat = report.Shell
 	c.NotifySendFunc = c.RunShell
-	c.DefaultNotify.Config(gConf)
+	if err := c.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -42,7 +42,9 @@ type NotifyConfig struct {
 func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
 	c.NotifyKind = conf.ProviderMap[c.ProviderType]
 	c.NotifyFormat = report.SMS
-	c.DefaultNotify.Config(gConf)
+	if err := c.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
 	c.configSMSDriver()
 	c.NotifySendFunc = c.DoNotify
 
@@ -0,0 +1,275 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package teams
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/report"
+)
+
+// Refer to:
+// - Adaptive Card: https://adaptivecards.io/explorer/
+// - Workflows webhook: https://learn.microsoft.com/en-us/connectors/teams/?tabs=text1#microsoft-teams-webhook
+// - Using https://adaptivecards.io/designer/ to preview
+
+// the Adaptive Card size limit of Teams is 28 KB, so the SLA report is split into pages
+const tablePageCnt = 30
+
+// Message is the payload of the Workflows webhook, it wraps the Adaptive Card as an attachment
+type Message struct {
+	Type        string       `json:"type"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+// Attachment is the Adaptive Card attachment of the message
+type Attachment struct {
+	ContentType string       `json:"contentType"`
+	Content     AdaptiveCard `json:"content"`
+}
+
+// AdaptiveCard is the Adaptive Card content
+type AdaptiveCard struct {
+	Schema  string                   `json:"$schema"`
+	Type    string                   `json:"type"`
+	Version string                   `json:"version"`
+	MSTeams map[string]string        `json:"msteams,omitempty"`
+	Body    []map[string]interface{} `json:"body"`
+	Actions []Action                 `json:"actions,omitempty"`
+}
+
+// Action is the button of the Adaptive Card which opens an URL
+type Action struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// Fact is the key-value pair of the FactSet
+type Fact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// statusColor returns the Adaptive Card color of the status, it is used as the container style as well
+func statusColor(s probe.Status) string {
+	switch s {
+	case probe.StatusUp:
+		return "good"
+	case probe.StatusWarning:
+		return "warning"
+	case probe.StatusInit:
+		return "default"
+	}
+	return "attention"
+}
+
+func textBlock(text string, attrs map[string]interface{}) map[string]interface{} {
+	block := map[string]interface{}{
+		"type": "TextBlock",
+		"text": text,
+		"wrap": true,
+	}
+	for k, v := range attrs {
+		block[k] = v
+	}
+	return block
+}
+
+func newMessage(card AdaptiveCard) Message {
+	return Message{
+		Type: "message",
+		Attachments: []Attachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content:     card,
+		}},
+	}
+}
+
+func newCard(version string) AdaptiveCard {
+	return AdaptiveCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: version,
+		MSTeams: map[string]string{"width": "Full"},
+		Body:    []map[string]interface{}{},
+	}
+}
+
+// webLink returns the URL of the path in the EaseProbe web UI, empty if the web URL is not set
+func (c *NotifyConfig) webLink(path string) string {
+	if len(c.WebURL) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(c.WebURL, "/") + path
+}
+
+// ResultFacts returns the facts of the result which are shown in the card
+func ResultFacts(r probe.Result) []Fact {
+	facts := []Fact{
+		{Title: "Kind", Value: r.Kind},
+		{Title: "Endpoint", Value: r.Endpoint},
+		{Title: "Status", Value: r.Status.Emoji() + " " + r.Status.String()},
+		{Title: "RTT", Value: report.DurationStr(r.RoundTripTime)},
+		{Title: "Time", Value: report.FormatTime(r.StartTime)},
+	}
+	if r.Status == probe.StatusUp && r.RecoveryDuration > 0 {
+		facts = append(facts, Fact{Title: "Downtime", Value: report.DurationStr(r.RecoveryDuration)})
+	} else if r.Status != probe.StatusUp && !r.LatestDownTime.IsZero() {
+		facts = append(facts, Fact{Title: "Down Since", Value: report.FormatTime(r.LatestDownTime)})
+	}
+	return facts
+}
+
+// NewResultCard returns the Adaptive Card message of the probe result
+func (c *NotifyConfig) NewResultCard(r probe.Result) Message {
+	message := r.Message
+	if c.HasMessageTemplate() {
+		message = c.ResultMessage(r)
+	}
+	card := newCard("1.4")
+	card.Body = append(card.Body,
+		map[string]interface{}{
+			"type":  "Container",
+			"style": statusColor(r.Status),
+			"bleed": true,
+			"items": []interface{}{
+				textBlock(c.ResultTitle(r), map[string]interface{}{
+					"size":   "Medium",
+					"weight": "Bolder",
+					"color":  statusColor(r.Status),
+				}),
+			},
+		},
+		textBlock(message, nil),
+		map[string]interface{}{
+			"type":  "FactSet",
+			"facts": ResultFacts(r),
+		},
+		textBlock(global.FooterString(), map[string]interface{}{
+			"size":     "Small",
+			"isSubtle": true,
+		}),
+	)
+	if link := c.webLink("/probes/" + url.PathEscape(r.Name)); len(link) > 0 {
+		card.Actions = append(card.Actions,
+			Action{Type: "Action.OpenUrl", Title: "View Probe", URL: link},
+			Action{Type: "Action.OpenUrl", Title: "SLA Report", URL: c.webLink("/")},
+		)
+	}
+	return newMessage(card)
+}
+
+func tableCell(text string, attrs map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":  "TableCell",
+		"items": []interface{}{textBlock(text, attrs)},
+	}
+}
+
+// NewSLACards returns the Adaptive Card messages of the SLA report,
+// the probers are rendered as a table which is split into pages.
+func (c *NotifyConfig) NewSLACards(probers []probe.Prober) []Message {
+	total := len(probers)
+	pages := total / tablePageCnt
+	if total%tablePageCnt > 0 || pages == 0 {
+		pages++
+	}
+
+	var messages []Message
+	statTitle := c.StatTitle(probers)
+	for p := 0; p < pages; p++ {
+		// the Table element is supported since Adaptive Card 1.5
+		card := newCard("1.5")
+		title := statTitle
+		if pages > 1 {
+			title = fmt.Sprintf("%s (%d/%d)", title, p+1, pages)
+		}
+		card.Body = append(card.Body, textBlock(title, map[string]interface{}{
+			"size":   "Medium",
+			"weight": "Bolder",
+		}))
+		if p == 0 && c.HasStatMessageTemplate() {
+			card.Body = append(card.Body, textBlock(c.StatMessage(probers), nil))
+		}
+
+		header := map[string]interface{}{
+			"type":  "TableRow",
+			"style": "accent",
+			"cells": []interface{}{
+				tableCell("Name", map[string]interface{}{"weight": "Bolder"}),
+				tableCell("Kind", map[string]interface{}{"weight": "Bolder"}),
+				tableCell("Status", map[string]interface{}{"weight": "Bolder"}),
+				tableCell("SLA", map[string]interface{}{"weight": "Bolder"}),
+				tableCell("Up / Down", map[string]interface{}{"weight": "Bolder"}),
+				tableCell("Latest Probe", map[string]interface{}{"weight": "Bolder"}),
+			},
+		}
+		rows := []interface{}{header}
+
+		start := p * tablePageCnt
+		end := start + tablePageCnt
+		if end > total {
+			end = total
+		}
+		for _, prober := range probers[start:end] {
+			r := prober.Result()
+			rows = append(rows, map[string]interface{}{
+				"type": "TableRow",
+				"cells": []interface{}{
+					tableCell(r.Name, nil),
+					tableCell(prober.Kind(), nil),
+					tableCell(r.Status.Emoji()+" "+r.Status.String(), map[string]interface{}{"color": statusColor(r.Status)}),
+					tableCell(fmt.Sprintf("%.2f%%", r.SLAPercent()), nil),
+					tableCell(report.DurationStr(r.Stat.UpTime)+" / "+report.DurationStr(r.Stat.DownTime), nil),
+					tableCell(report.FormatTime(r.StartTime), nil),
+				},
+			})
+		}
+
+		card.Body = append(card.Body,
+			map[string]interface{}{
+				"type": "Table",
+				"columns": []interface{}{
+					map[string]interface{}{"width": 3},
+					map[string]interface{}{"width": 1},
+					map[string]interface{}{"width": 2},
+					map[string]interface{}{"width": 1},
+					map[string]interface{}{"width": 2},
+					map[string]interface{}{"width": 2},
+				},
+				"firstRowAsHeaders": true,
+				"showGridLines":     true,
+				"rows":              rows,
+			},
+			textBlock(global.FooterString(), map[string]interface{}{
+				"size":     "Small",
+				"isSubtle": true,
+			}),
+		)
+		if link := c.webLink("/"); len(link) > 0 {
+			card.Actions = append(card.Actions, Action{Type: "Action.OpenUrl", Title: "SLA Report", URL: link})
+		}
+		messages = append(messages, newMessage(card))
+	}
+	return messages
+}
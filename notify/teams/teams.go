@@ -24,18 +24,30 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/wfusion/easeprobe/global"
 	"github.com/wfusion/easeprobe/notify/base"
+	"github.com/wfusion/easeprobe/probe"
 	"github.com/wfusion/easeprobe/report"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// The payload styles of the Teams notification
+const (
+	// StyleMessageCard is the legacy MessageCard of the Office 365 connector webhook
+	StyleMessageCard = "message_card"
+	// StyleAdaptiveCard is the Adaptive Card of the Power Automate workflow webhook
+	StyleAdaptiveCard = "adaptive_card"
+)
+
 // NotifyConfig is the teams notification configuration
 type NotifyConfig struct {
 	base.DefaultNotify `yaml:",inline"`
 	WebhookURL         string `yaml:"webhook"  json:"webhook" jsonschema:"required,format=url,title=Webhook URL,description=The Microsoft Teams Robot Webhook URL"`
+	Style              string `yaml:"style,omitempty" json:"style,omitempty" jsonschema:"enum=message_card,enum=adaptive_card,title=Payload Style,description=The payload style - message_card for the Office 365 connector webhook or adaptive_card for the Power Automate workflow webhook,default=message_card"`
+	WebURL             string `yaml:"web_url,omitempty" json:"web_url,omitempty" jsonschema:"format=url,title=Web URL,description=The URL of the EaseProbe web UI which the action buttons of the Adaptive Card link to"`
 }
 
 // Config configures the teams notification
@@ -43,7 +55,19 @@ func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
 	c.NotifyKind = "teams"
 	c.NotifyFormat = report.MarkdownSocial
 	c.NotifySendFunc = c.SendTeamsMessage
-	c.DefaultNotify.Config(gConf)
+	if err := c.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
+
+	c.Style = strings.ToLower(strings.TrimSpace(c.Style))
+	switch c.Style {
+	case "":
+		c.Style = StyleMessageCard
+	case StyleMessageCard, StyleAdaptiveCard:
+	default:
+		return fmt.Errorf("[%s / %s] - invalid style [%s], it should be %s or %s",
+			c.NotifyKind, c.NotifyName, c.Style, StyleMessageCard, StyleAdaptiveCard)
+	}
 
 	log.Debugf("Notification [%s] - [%s] configuration: %+v", c.NotifyKind, c.NotifyName, c)
 	return nil
@@ -69,6 +93,21 @@ func (c *NotifyConfig) SendTeamsMessage(title, msg string) error {
 	if err != nil {
 		return err
 	}
+	return c.post(json)
+}
+
+// SendTeamsCard sends the Adaptive Card message to the teams workflow webhook
+func (c *NotifyConfig) SendTeamsCard(msg Message, tag string) error {
+	json, err := json.Marshal(msg)
+	if err != nil {
+		log.Errorf("[%s / %s / %s] - %v, err - %s", c.Kind(), c.Name(), tag, msg, err)
+		return &global.ErrNoRetry{Message: err.Error()}
+	}
+	log.Debugf("[%s / %s / %s] - %s", c.Kind(), c.Name(), tag, string(json))
+	return c.post(json)
+}
+
+func (c *NotifyConfig) post(json []byte) error {
 	req, err := http.NewRequest(http.MethodPost, c.WebhookURL, bytes.NewBuffer([]byte(json)))
 	if err != nil {
 		return err
@@ -87,8 +126,102 @@ func (c *NotifyConfig) SendTeamsMessage(title, msg string) error {
 	if err != nil {
 		return err
 	}
-	if resp.StatusCode != 200 && string(buf) != "1" {
+	// the connector webhook returns 200, and the workflow webhook returns 202
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted && string(buf) != "1" {
 		return fmt.Errorf("error response from Teams Webhook - code [%d] - msg [%s]", resp.StatusCode, string(buf))
 	}
 	return nil
 }
+
+// Notify sends the result to teams, the Adaptive Card is sent if the style is adaptive_card
+func (c *NotifyConfig) Notify(result probe.Result) {
+	if c.Style != StyleAdaptiveCard {
+		c.DefaultNotify.Notify(result)
+		return
+	}
+	if c.Dry {
+		c.DryNotify(result)
+		return
+	}
+	tag := "Notification"
+	msg := c.NewResultCard(result)
+	err := global.DoRetry(c.Kind(), c.NotifyName, tag, c.Retry, func() error {
+		return c.SendTeamsCard(msg, tag)
+	})
+	report.LogSend(c.Kind(), c.NotifyName, tag, result.Name, err)
+}
+
+// NotifyBatch sends the grouped results to teams, the Adaptive Cards are sent one by one
+func (c *NotifyConfig) NotifyBatch(results []probe.Result) {
+	if c.Style != StyleAdaptiveCard {
+		c.DefaultNotify.NotifyBatch(results)
+		return
+	}
+	for _, r := range results {
+		c.Notify(r)
+	}
+}
+
+// NotifyStat sends the SLA report to teams, it's a table card if the style is adaptive_card
+func (c *NotifyConfig) NotifyStat(probers []probe.Prober) {
+	if c.Style != StyleAdaptiveCard {
+		c.DefaultNotify.NotifyStat(probers)
+		return
+	}
+	if c.Dry {
+		c.DryNotifyStat(probers)
+		return
+	}
+	tag := "SLA"
+	messages := c.NewSLACards(probers)
+	total := len(messages)
+	for idx, msg := range messages {
+		msg := msg
+		err := global.DoRetry(c.Kind(), c.NotifyName, tag, c.Retry, func() error {
+			return c.SendTeamsCard(msg, tag)
+		})
+		if err != nil {
+			log.Errorf("[%s / %s / %s] - failed to send part [%d/%d]! (%v)", c.Kind(), c.Name(), tag, idx+1, total, err)
+		} else {
+			log.Infof("[%s / %s / %s] - successfully sent part [%d/%d]!", c.Kind(), c.Name(), tag, idx+1, total)
+		}
+	}
+}
+
+// DryNotify just log the notification message
+func (c *NotifyConfig) DryNotify(result probe.Result) {
+	if c.Style != StyleAdaptiveCard {
+		c.DefaultNotify.DryNotify(result)
+		return
+	}
+	c.dryLog(c.NewResultCard(result))
+}
+
+// DryNotifyBatch just log the grouped notification message
+func (c *NotifyConfig) DryNotifyBatch(results []probe.Result) {
+	if c.Style != StyleAdaptiveCard {
+		c.DefaultNotify.DryNotifyBatch(results)
+		return
+	}
+	for _, r := range results {
+		c.DryNotify(r)
+	}
+}
+
+// DryNotifyStat just log the notification message
+func (c *NotifyConfig) DryNotifyStat(probers []probe.Prober) {
+	if c.Style != StyleAdaptiveCard {
+		c.DefaultNotify.DryNotifyStat(probers)
+		return
+	}
+	c.dryLog(c.NewSLACards(probers))
+}
+
+func (c *NotifyConfig) dryLog(v interface{}) {
+	json, err := json.Marshal(v)
+	if err != nil {
+		log.Errorf("[%s / %s] JSON Marshal Error : %v", c.Kind(), c.NotifyName, err)
+		return
+	}
+	log.Infof("[%s / %s] Dry notify - %s", c.Kind(), c.NotifyName, string(json))
+}
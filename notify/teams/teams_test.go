@@ -20,14 +20,19 @@ package teams
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/probe/base"
 	"github.com/wfusion/easeprobe/report"
 	"github.com/wfusion/gofusion/common/utils/gomonkey"
 )
@@ -91,3 +96,129 @@ func TestSlack(t *testing.T) {
 	assertError(t, err, "marshal error")
 
 }
+
+func newDummyResult(name string) probe.Result {
+	r := probe.NewResult()
+	r.Name = name
+	r.Kind = "http"
+	r.Endpoint = "http://endpoint:8080"
+	r.Status = probe.StatusDown
+	r.Message = "dummy message"
+	r.RoundTripTime = 100 * time.Millisecond
+	r.LatestDownTime = time.Now()
+	return *r
+}
+
+type dummyProber struct {
+	base.DefaultProbe
+}
+
+func (d *dummyProber) Config(g global.ProbeSettings) error {
+	return d.DefaultProbe.Config(g, d.ProbeKind, d.ProbeTag, d.ProbeName, "endpoint", d.DoProbe)
+}
+
+func (d *dummyProber) DoProbe() (bool, string) {
+	return true, "dummy"
+}
+
+func newDummyProber(name string) probe.Prober {
+	r := newDummyResult(name)
+	probe.SetResultData(name, &r)
+	return &dummyProber{
+		DefaultProbe: base.DefaultProbe{
+			ProbeKind:   "http",
+			ProbeName:   name,
+			ProbeResult: &r,
+		},
+	}
+}
+
+func content(t *testing.T, body []byte) map[string]interface{} {
+	var msg map[string]interface{}
+	assert.NoError(t, json.Unmarshal(body, &msg))
+	assert.Equal(t, "message", msg["type"])
+	attachments := msg["attachments"].([]interface{})
+	assert.Len(t, attachments, 1)
+	attachment := attachments[0].(map[string]interface{})
+	assert.Equal(t, "application/vnd.microsoft.card.adaptive", attachment["contentType"])
+	return attachment["content"].(map[string]interface{})
+}
+
+func TestAdaptiveCard(t *testing.T) {
+	conf := &NotifyConfig{Style: "invalid"}
+	conf.NotifyName = "dummy"
+	assert.Error(t, conf.Config(global.NotifySettings{}))
+
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		buf, _ := io.ReadAll(req.Body)
+		bodies <- buf
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	conf = &NotifyConfig{WebhookURL: server.URL, Style: "Adaptive_Card", WebURL: "http://easeprobe:8181/"}
+	conf.NotifyName = "dummy"
+	assert.NoError(t, conf.Config(global.NotifySettings{}))
+	assert.Equal(t, StyleAdaptiveCard, conf.Style)
+
+	conf.Notify(newDummyResult("dummy probe"))
+	card := content(t, <-bodies)
+	assert.Equal(t, "AdaptiveCard", card["type"])
+	buf, _ := json.Marshal(card)
+	assert.Contains(t, string(buf), `"color":"attention"`)
+	assert.Contains(t, string(buf), `{"title":"Kind","value":"http"}`)
+	assert.Contains(t, string(buf), `{"title":"Endpoint","value":"http://endpoint:8080"}`)
+	assert.Contains(t, string(buf), `"Down Since"`)
+	actions := card["actions"].([]interface{})
+	assert.Len(t, actions, 2)
+	assert.Equal(t, "http://easeprobe:8181/probes/dummy%20probe", actions[0].(map[string]interface{})["url"])
+	assert.Equal(t, "http://easeprobe:8181/", actions[1].(map[string]interface{})["url"])
+
+	// recovery shows the downtime
+	r := newDummyResult("dummy")
+	r.Status = probe.StatusUp
+	r.PreStatus = probe.StatusDown
+	r.RecoveryDuration = 5 * time.Minute
+	conf.Notify(r)
+	buf, _ = json.Marshal(content(t, <-bodies))
+	assert.Contains(t, string(buf), `"color":"good"`)
+	assert.Contains(t, string(buf), `{"title":"Downtime","value":"5m0s"}`)
+
+	// the SLA report is split into table cards
+	probers := []probe.Prober{}
+	for i := 0; i < tablePageCnt+1; i++ {
+		probers = append(probers, newDummyProber(fmt.Sprintf("p%d", i)))
+	}
+	conf.NotifyStat(probers)
+	for i := 0; i < 2; i++ {
+		card = content(t, <-bodies)
+		assert.Equal(t, "1.5", card["version"])
+		body := card["body"].([]interface{})
+		assert.Equal(t, fmt.Sprintf("Overall SLA Report (%d/2)", i+1), body[0].(map[string]interface{})["text"])
+		table := body[1].(map[string]interface{})
+		assert.Equal(t, "Table", table["type"])
+		rows := len(table["rows"].([]interface{}))
+		if i == 0 {
+			assert.Equal(t, tablePageCnt+1, rows)
+		} else {
+			assert.Equal(t, 2, rows)
+		}
+	}
+
+	// no action buttons without the web URL
+	conf.WebURL = ""
+	conf.Notify(newDummyResult("dummy"))
+	card = content(t, <-bodies)
+	assert.Nil(t, card["actions"])
+
+	// the legacy message card is still the default
+	conf = &NotifyConfig{WebhookURL: server.URL}
+	conf.NotifyName = "dummy"
+	assert.NoError(t, conf.Config(global.NotifySettings{}))
+	assert.Equal(t, StyleMessageCard, conf.Style)
+	conf.Notify(newDummyResult("dummy"))
+	var legacy map[string]interface{}
+	assert.NoError(t, json.Unmarshal(<-bodies, &legacy))
+	assert.Equal(t, "MessageCard", legacy["@type"])
+}
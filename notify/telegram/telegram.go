@@ -19,14 +19,17 @@
 package telegram
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/ack"
 	"github.com/wfusion/easeprobe/global"
 	"github.com/wfusion/easeprobe/notify/base"
+	"github.com/wfusion/easeprobe/probe"
 	"github.com/wfusion/easeprobe/report"
 )
 
@@ -47,7 +50,9 @@ func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
 	c.NotifyKind = "telegram"
 	c.NotifyFormat = report.Markdown
 	c.NotifySendFunc = c.SendTelegram
-	c.DefaultNotify.Config(gConf)
+	if err := c.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
 	log.Debugf("Notification [%s] - [%s] configuration: %+v", c.NotifyKind, c.NotifyName, c)
 	return nil
 }
@@ -79,12 +84,117 @@ func (c *NotifyConfig) SendTelegram(title, text string) error {
 	return nil
 }
 
+// Notify sends the result with the inline keyboard to acknowledge the failure
+func (c *NotifyConfig) Notify(result probe.Result) {
+	if c.Dry {
+		c.DryNotify(result)
+		return
+	}
+	markup := ackKeyboard(result)
+	if len(markup) <= 0 {
+		c.DefaultNotify.Notify(result)
+		return
+	}
+
+	title := c.ResultTitle(result)
+	parts := splitMessage(c.ResultMessage(result))
+	tag := "Notification"
+	fn := func() error {
+		for i, part := range parts {
+			// the keyboard is attached to the last part of the message
+			m := ""
+			if i == len(parts)-1 {
+				m = markup
+			}
+			if err := c.sendMessage(part, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err := global.DoRetry(c.Kind(), c.NotifyName, tag, c.Retry, fn)
+	report.LogSend(c.Kind(), c.NotifyName, tag, title, err)
+}
+
+// NotifyBatch sends the grouped results in one message, the results are sent one by one
+// with the inline keyboard if the grouped message cannot be rendered
+func (c *NotifyConfig) NotifyBatch(results []probe.Result) {
+	if c.Dry {
+		c.DryNotifyBatch(results)
+		return
+	}
+	if !c.oneByOne(results) {
+		c.DefaultNotify.NotifyBatch(results)
+		return
+	}
+	for _, r := range results {
+		c.Notify(r)
+	}
+}
+
+// DryNotify just log the notification message and the inline keyboard
+func (c *NotifyConfig) DryNotify(result probe.Result) {
+	c.DefaultNotify.DryNotify(result)
+	if markup := ackKeyboard(result); len(markup) > 0 {
+		log.Infof("[%s / %s / dry_notify] - reply_markup: %s", c.NotifyKind, c.NotifyName, markup)
+	}
+}
+
+// DryNotifyBatch just log the grouped notification message
+func (c *NotifyConfig) DryNotifyBatch(results []probe.Result) {
+	if !c.oneByOne(results) {
+		c.DefaultNotify.DryNotifyBatch(results)
+		return
+	}
+	for _, r := range results {
+		c.DryNotify(r)
+	}
+}
+
+// oneByOne returns true if the grouped results are sent one by one, the same as the DefaultNotify
+func (c *NotifyConfig) oneByOne(results []probe.Result) bool {
+	return len(results) == 1 || report.FormatFuncs[c.NotifyFormat].BatchFn == nil || c.HasMessageTemplate()
+}
+
+// ackKeyboard returns the inline keyboard to acknowledge the failure, empty if the failure cannot be
+// acknowledged. The button is handled by the bot webhook if it's configured, otherwise it opens the
+// acknowledgement link.
+func ackKeyboard(r probe.Result) string {
+	if r.Status != probe.StatusDown || r.IsAcked() {
+		return ""
+	}
+	button := map[string]string{"text": "✋ Acknowledge"}
+	if data, ok := ack.TelegramCallbackData(r.Name); ok && ack.TelegramEnabled() {
+		button["callback_data"] = data
+	} else if len(r.AckURL) > 0 {
+		button["url"] = r.AckURL
+	} else {
+		return ""
+	}
+	buf, err := json.Marshal(map[string]interface{}{
+		"inline_keyboard": [][]map[string]string{{button}},
+	})
+	if err != nil {
+		log.Errorf("[telegram] Failed to marshal the inline keyboard - %v", err)
+		return ""
+	}
+	return string(buf)
+}
+
 // SendTelegramNotification will send the notification to telegram.
 func (c *NotifyConfig) SendTelegramNotification(text string) error {
+	return c.sendMessage(text, "")
+}
+
+// sendMessage sends the message with the optional reply markup to telegram.
+func (c *NotifyConfig) sendMessage(text, markup string) error {
 	api := "https://api.telegram.org/bot" + c.Token +
 		"/sendMessage?&chat_id=" + c.ChatID +
 		"&parse_mode=markdown" +
 		"&text=" + url.QueryEscape(text)
+	if len(markup) > 0 {
+		api += "&reply_markup=" + url.QueryEscape(markup)
+	}
 	log.Debugf("[%s] - API %s", c.Kind(), api)
 	req, err := http.NewRequest(http.MethodPost, api, nil)
 	if err != nil {
@@ -28,7 +28,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/ack"
 	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
 	"github.com/wfusion/easeprobe/report"
 	"github.com/wfusion/gofusion/common/utils/gomonkey"
 )
@@ -112,3 +114,57 @@ func TestSplitMessage(t *testing.T) {
 	assert.Equal(t, msg[:4096], msgs[0])
 	assert.Equal(t, msg[4096:], msgs[1])
 }
+
+func TestAckKeyboard(t *testing.T) {
+	r := probe.Result{Name: "web", Status: probe.StatusDown}
+	assert.Empty(t, ackKeyboard(r))
+
+	r.AckURL = "https://probe.example.com/ack/web?token=abc"
+	assert.Equal(t, `{"inline_keyboard":[[{"text":"✋ Acknowledge","url":"`+r.AckURL+`"}]]}`, ackKeyboard(r))
+
+	ack.SetSettings(ack.Settings{TelegramSecretToken: "secret"}, "")
+	defer ack.SetSettings(ack.Settings{}, "")
+	assert.Equal(t, `{"inline_keyboard":[[{"callback_data":"ack:web","text":"✋ Acknowledge"}]]}`, ackKeyboard(r))
+
+	r.Stat.Ack = &probe.AckData{By: "alice"}
+	assert.Empty(t, ackKeyboard(r))
+	r.Stat.Ack = nil
+	r.Status = probe.StatusUp
+	assert.Empty(t, ackKeyboard(r))
+}
+
+func TestNotifyBatch(t *testing.T) {
+	conf := &NotifyConfig{}
+	conf.NotifyName = "dummy"
+	err := conf.Config(global.NotifySettings{})
+	assert.NoError(t, err)
+
+	markups := []string{}
+	var client http.Client
+	defer gomonkey.ApplyMethod(reflect.TypeOf(&client), "Do", func(_ *http.Client, req *http.Request) (*http.Response, error) {
+		markups = append(markups, req.URL.Query().Get("reply_markup"))
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(strings.NewReader(`ok`)),
+		}, nil
+	}).Reset()
+
+	r := probe.Result{Name: "web", Status: probe.StatusDown, AckURL: "https://probe.example.com/ack/web?token=abc"}
+	o := probe.Result{Name: "db", Status: probe.StatusDown}
+
+	// the single result keeps the inline keyboard
+	conf.NotifyBatch([]probe.Result{r})
+	assert.Equal(t, []string{ackKeyboard(r)}, markups)
+
+	// the grouped message has the acknowledgement links
+	markups = markups[:0]
+	conf.NotifyBatch([]probe.Result{r, o})
+	assert.Equal(t, []string{""}, markups)
+
+	// the results are sent one by one with the message template
+	markups = markups[:0]
+	conf.MessageTemplate = "{{.Name}} is {{.Status}}"
+	assert.NoError(t, conf.Config(global.NotifySettings{}))
+	conf.NotifyBatch([]probe.Result{r, o})
+	assert.Equal(t, []string{ackKeyboard(r), ""}, markups)
+}
@@ -0,0 +1,240 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package webhook is the generic webhook notification package.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/notify/base"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/report"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// the default settings of the webhook
+const (
+	DefaultMethod          = http.MethodPost
+	DefaultContentType     = "application/json"
+	DefaultSignatureHeader = "X-EaseProbe-Signature"
+)
+
+// DefaultTemplate is the default body template of the result notification
+const DefaultTemplate = `{"title":"{{json .Title}}","name":"{{json .Name}}","endpoint":"{{json .Endpoint}}",` +
+	`"status":"{{.Status}}","message":"{{json .Message}}","time":"{{time .StartTime}}",` +
+	`"rtt":{{.RoundTripTime.Milliseconds}}}`
+
+// DefaultStatTemplate is the default body template of the SLA report
+const DefaultStatTemplate = `{"title":"{{json .Title}}","summary":"{{json .Summary}}","sla":{{toJSON .SLAs}}}`
+
+// NotifyConfig is the generic webhook notification configuration
+type NotifyConfig struct {
+	base.DefaultNotify `yaml:",inline"`
+
+	URL             string            `yaml:"url" json:"url" jsonschema:"required,format=uri,title=URL,description=The URL of the webhook"`
+	Method          string            `yaml:"method,omitempty" json:"method,omitempty" jsonschema:"title=Method,description=The HTTP method of the webhook,default=POST"`
+	Headers         map[string]string `yaml:"headers,omitempty" json:"headers,omitempty" jsonschema:"title=Headers,description=The HTTP headers of the webhook"`
+	ContentType     string            `yaml:"content_type,omitempty" json:"content_type,omitempty" jsonschema:"title=Content Type,description=The content type of the body,default=application/json"`
+	Template        string            `yaml:"template,omitempty" json:"template,omitempty" jsonschema:"title=Template,description=The Go text/template of the body for the probe result"`
+	StatTemplate    string            `yaml:"stat_template,omitempty" json:"stat_template,omitempty" jsonschema:"title=SLA Template,description=The Go text/template of the body for the SLA report"`
+	SuccessCodes    []int             `yaml:"success_codes,omitempty" json:"success_codes,omitempty" jsonschema:"title=Success Codes,description=The HTTP status codes of the success response. default: 2xx"`
+	Secret          string            `yaml:"secret,omitempty" json:"secret,omitempty" jsonschema:"title=Secret,description=The secret to sign the body with HMAC-SHA256"`
+	SignatureHeader string            `yaml:"signature_header,omitempty" json:"signature_header,omitempty" jsonschema:"title=Signature Header,description=The header of the HMAC signature,default=X-EaseProbe-Signature"`
+
+	resultTmpl *template.Template `yaml:"-" json:"-"`
+	statTmpl   *template.Template `yaml:"-" json:"-"`
+}
+
+// Config configures the webhook notification
+func (c *NotifyConfig) Config(gConf global.NotifySettings) error {
+	c.NotifyKind = "webhook"
+	c.NotifyFormat = report.JSON
+	c.NotifySendFunc = c.SendWebhook
+	if err := c.DefaultNotify.Config(gConf); err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(c.URL)) == 0 {
+		return fmt.Errorf("[%s / %s] - the url is required", c.NotifyKind, c.NotifyName)
+	}
+	c.Method = strings.ToUpper(strings.TrimSpace(c.Method))
+	if c.Method == "" {
+		c.Method = DefaultMethod
+	}
+	if c.ContentType == "" {
+		c.ContentType = DefaultContentType
+	}
+	if c.SignatureHeader == "" {
+		c.SignatureHeader = DefaultSignatureHeader
+	}
+	if c.Template == "" {
+		c.Template = DefaultTemplate
+	}
+	if c.StatTemplate == "" {
+		c.StatTemplate = DefaultStatTemplate
+	}
+
+	var err error
+	if c.resultTmpl, err = template.New("template").Funcs(report.TemplateFuncs()).Parse(c.Template); err != nil {
+		return fmt.Errorf("[%s / %s] - invalid template: %v", c.NotifyKind, c.NotifyName, err)
+	}
+	if c.statTmpl, err = template.New("stat_template").Funcs(report.TemplateFuncs()).Parse(c.StatTemplate); err != nil {
+		return fmt.Errorf("[%s / %s] - invalid stat_template: %v", c.NotifyKind, c.NotifyName, err)
+	}
+
+	log.Debugf("Notification [%s] - [%s] configuration: %+v", c.NotifyKind, c.NotifyName, c)
+	return nil
+}
+
+// Notify renders the result with the template and sends it to the webhook
+func (c *NotifyConfig) Notify(result probe.Result) {
+	if c.Dry {
+		c.DryNotify(result)
+		return
+	}
+	body, err := render(c.resultTmpl, &result)
+	if err != nil {
+		report.LogSend(c.NotifyKind, c.NotifyName, "Notification", c.ResultTitle(result), err)
+		return
+	}
+	c.SendWithRetry(c.ResultTitle(result), body, "Notification")
+}
+
+// NotifyBatch renders and sends the grouped results one by one, the template renders a single result
+func (c *NotifyConfig) NotifyBatch(results []probe.Result) {
+	for _, r := range results {
+		c.Notify(r)
+	}
+}
+
+// NotifyStat renders the SLA report with the stat template and sends it to the webhook
+func (c *NotifyConfig) NotifyStat(probers []probe.Prober) {
+	if c.Dry {
+		c.DryNotifyStat(probers)
+		return
+	}
+	data := report.NewStatData(c.StatTitle(probers), probers)
+	body, err := render(c.statTmpl, data)
+	if err != nil {
+		report.LogSend(c.NotifyKind, c.NotifyName, "SLA", data.Title, err)
+		return
+	}
+	c.SendWithRetry(data.Title, body, "SLA")
+}
+
+// DryNotify just log the rendered body
+func (c *NotifyConfig) DryNotify(result probe.Result) {
+	body, err := render(c.resultTmpl, &result)
+	if err != nil {
+		log.Errorf("[%s / %s] Template Error : %v", c.NotifyKind, c.NotifyName, err)
+		return
+	}
+	log.Infof("[%s / %s] Dry notify - %s %s - %s", c.NotifyKind, c.NotifyName, c.Method, c.URL, body)
+}
+
+// DryNotifyBatch just log the rendered bodies of the grouped results
+func (c *NotifyConfig) DryNotifyBatch(results []probe.Result) {
+	for _, r := range results {
+		c.DryNotify(r)
+	}
+}
+
+// DryNotifyStat just log the rendered body
+func (c *NotifyConfig) DryNotifyStat(probers []probe.Prober) {
+	body, err := render(c.statTmpl, report.NewStatData(c.StatTitle(probers), probers))
+	if err != nil {
+		log.Errorf("[%s / %s] Template Error : %v", c.NotifyKind, c.NotifyName, err)
+		return
+	}
+	log.Infof("[%s / %s] Dry notify - %s %s - %s", c.NotifyKind, c.NotifyName, c.Method, c.URL, body)
+}
+
+func render(t *template.Template, data interface{}) (string, error) {
+	if t == nil {
+		return "", &global.ErrNoRetry{Message: "the template is not configured"}
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Sign returns the HMAC-SHA256 signature of the body, e.g. "sha256=<hex>"
+func Sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsSuccess returns true if the status code is one of the success codes,
+// any 2xx status code is success if the success codes are not configured.
+func (c *NotifyConfig) IsSuccess(code int) bool {
+	if len(c.SuccessCodes) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, s := range c.SuccessCodes {
+		if s == code {
+			return true
+		}
+	}
+	return false
+}
+
+// SendWebhook sends the rendered body to the webhook
+func (c *NotifyConfig) SendWebhook(title, body string) error {
+	req, err := http.NewRequest(c.Method, c.URL, bytes.NewBufferString(body))
+	if err != nil {
+		return &global.ErrNoRetry{Message: err.Error()}
+	}
+	req.Close = true
+	req.Header.Set("Content-Type", c.ContentType)
+	req.Header.Set("User-Agent", global.OrgProgVer)
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+	if c.Secret != "" {
+		req.Header.Set(c.SignatureHeader, Sign(c.Secret, body))
+	}
+
+	client := &http.Client{Timeout: c.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if !c.IsSuccess(resp.StatusCode) {
+		return fmt.Errorf("Error response from Webhook [%s] - code [%d] - msg [%s]", title, resp.StatusCode, string(buf))
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/probe/base"
+)
+
+func newDummyResult(name string) probe.Result {
+	r := probe.NewResult()
+	r.Name = name
+	r.Endpoint = "http://endpoint:8080"
+	r.Status = probe.StatusDown
+	r.Message = `dummy "message"`
+	r.RoundTripTime = 100 * time.Millisecond
+	return *r
+}
+
+type dummyProber struct {
+	base.DefaultProbe
+}
+
+func (d *dummyProber) Config(g global.ProbeSettings) error {
+	return d.DefaultProbe.Config(g, d.ProbeKind, d.ProbeTag, d.ProbeName, "endpoint", d.DoProbe)
+}
+
+func (d *dummyProber) DoProbe() (bool, string) {
+	return true, "dummy"
+}
+
+func newDummyProber(name string) probe.Prober {
+	r := newDummyResult(name)
+	probe.SetResultData(name, &r)
+	return &dummyProber{
+		DefaultProbe: base.DefaultProbe{
+			ProbeKind:   "dummy",
+			ProbeName:   name,
+			ProbeResult: &r,
+		},
+	}
+}
+
+type request struct {
+	method string
+	header http.Header
+	body   string
+}
+
+func newServer(t *testing.T, code int) (*httptest.Server, chan request) {
+	ch := make(chan request, 10)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		assert.Nil(t, err)
+		ch <- request{method: r.Method, header: r.Header, body: string(b)}
+		w.WriteHeader(code)
+	}))
+	return s, ch
+}
+
+func TestConfig(t *testing.T) {
+	conf := &NotifyConfig{}
+	conf.NotifyName = "dummy"
+	assert.NotNil(t, conf.Config(global.NotifySettings{}))
+
+	conf.URL = "http://localhost"
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+	assert.Equal(t, "webhook", conf.Kind())
+	assert.Equal(t, DefaultMethod, conf.Method)
+	assert.Equal(t, DefaultContentType, conf.ContentType)
+	assert.Equal(t, DefaultSignatureHeader, conf.SignatureHeader)
+	assert.Equal(t, DefaultTemplate, conf.Template)
+	assert.Equal(t, DefaultStatTemplate, conf.StatTemplate)
+
+	conf = &NotifyConfig{URL: "http://localhost", Method: "put", Template: "{{.Name"}
+	assert.NotNil(t, conf.Config(global.NotifySettings{}))
+	assert.Equal(t, http.MethodPut, conf.Method)
+
+	conf = &NotifyConfig{URL: "http://localhost", StatTemplate: "{{unknown .}}"}
+	assert.NotNil(t, conf.Config(global.NotifySettings{}))
+}
+
+func TestIsSuccess(t *testing.T) {
+	conf := &NotifyConfig{}
+	assert.True(t, conf.IsSuccess(200))
+	assert.True(t, conf.IsSuccess(204))
+	assert.False(t, conf.IsSuccess(302))
+	assert.False(t, conf.IsSuccess(500))
+
+	conf.SuccessCodes = []int{200, 302}
+	assert.True(t, conf.IsSuccess(302))
+	assert.False(t, conf.IsSuccess(204))
+}
+
+func TestSign(t *testing.T) {
+	// echo -n 'hello' | openssl dgst -sha256 -hmac 'secret'
+	assert.Equal(t, "sha256=88aab3ede8d3adf94d26ab90d3bafd4a2083070c3bcce9c014ee04a443847c0b", Sign("secret", "hello"))
+}
+
+func TestNotify(t *testing.T) {
+	s, ch := newServer(t, http.StatusOK)
+	defer s.Close()
+
+	conf := &NotifyConfig{
+		URL:     s.URL,
+		Headers: map[string]string{"X-Token": "token"},
+		Secret:  "secret",
+	}
+	conf.NotifyName = "dummy"
+	conf.Retry.Times = 1
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+
+	r := newDummyResult("dummy")
+	conf.Notify(r)
+	req := <-ch
+	assert.Equal(t, http.MethodPost, req.method)
+	assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+	assert.Equal(t, "token", req.header.Get("X-Token"))
+	assert.Equal(t, Sign("secret", req.body), req.header.Get(DefaultSignatureHeader))
+	body := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(req.body), &body))
+	assert.Equal(t, "dummy Failure", body["title"])
+	assert.Equal(t, `dummy "message"`, body["message"])
+	assert.Equal(t, "down", body["status"])
+	assert.Equal(t, float64(100), body["rtt"])
+	assert.Contains(t, buf.String(), "successfully sent")
+
+	conf.NotifyStat([]probe.Prober{newDummyProber("p1"), newDummyProber("p2")})
+	req = <-ch
+	stat := struct {
+		Title string        `json:"title"`
+		SLA   []interface{} `json:"sla"`
+	}{}
+	assert.Nil(t, json.Unmarshal([]byte(req.body), &stat))
+	assert.Equal(t, "Overall SLA Report", stat.Title)
+	assert.Len(t, stat.SLA, 2)
+
+	// customized template
+	conf = &NotifyConfig{
+		URL:          s.URL,
+		Method:       "PUT",
+		ContentType:  "text/plain",
+		Template:     `{{emoji .Status}} {{upper .Name}} - {{.Message}}`,
+		StatTemplate: `{{range .Results}}{{.Name}}: {{percent .SLAPercent}}% {{end}}`,
+	}
+	conf.Retry.Times = 1
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+	conf.Notify(r)
+	req = <-ch
+	assert.Equal(t, http.MethodPut, req.method)
+	assert.Equal(t, "text/plain", req.header.Get("Content-Type"))
+	assert.Empty(t, req.header.Get(DefaultSignatureHeader))
+	assert.Equal(t, `❌ DUMMY - dummy "message"`, req.body)
+
+	conf.NotifyStat([]probe.Prober{newDummyProber("p1")})
+	req = <-ch
+	assert.Equal(t, "p1: 0.00% ", req.body)
+
+	// the template failed to execute
+	conf.Template = `{{.Unknown}}`
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+	buf.Reset()
+	conf.Notify(r)
+	assert.Contains(t, buf.String(), "failed to send")
+	assert.Len(t, ch, 0)
+}
+
+func TestNotifyFailed(t *testing.T) {
+	s, ch := newServer(t, http.StatusForbidden)
+	defer s.Close()
+
+	conf := &NotifyConfig{URL: s.URL}
+	conf.NotifyName = "dummy"
+	conf.Retry.Times = 1
+	conf.Retry.Interval = time.Millisecond
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+	err := conf.SendWebhook("title", "body")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "403")
+	<-ch
+
+	// the forbidden code is accepted
+	conf.SuccessCodes = []int{http.StatusForbidden}
+	assert.Nil(t, conf.SendWebhook("title", "body"))
+	<-ch
+
+	conf.URL = "://invalid"
+	assert.NotNil(t, conf.SendWebhook("title", "body"))
+}
+
+func TestDryNotify(t *testing.T) {
+	conf := &NotifyConfig{URL: "http://localhost"}
+	conf.NotifyName = "dummy"
+	conf.Dry = true
+	assert.Nil(t, conf.Config(global.NotifySettings{}))
+
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+
+	conf.Notify(newDummyResult("dummy"))
+	assert.Contains(t, buf.String(), "[webhook / dummy] Dry notify - POST http://localhost")
+
+	buf.Reset()
+	conf.NotifyStat([]probe.Prober{newDummyProber("p1")})
+	assert.Contains(t, buf.String(), "Overall SLA Report")
+
+	conf.resultTmpl = nil
+	conf.statTmpl = nil
+	buf.Reset()
+	conf.Notify(newDummyResult("dummy"))
+	assert.Contains(t, buf.String(), "Template Error")
+	buf.Reset()
+	conf.NotifyStat([]probe.Prober{newDummyProber("p1")})
+	assert.Contains(t, buf.String(), "Template Error")
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package probe
+
+import "time"
+
+// AckData is the acknowledgement of the ongoing failure, the repeat notification
+// and the escalation stop until the probe recovers or the acknowledgement expires.
+type AckData struct {
+	// the person who acknowledges the failure
+	By string `yaml:"by" json:"by"`
+	// the comment of the acknowledgement
+	Comment string `yaml:"comment,omitempty" json:"comment,omitempty"`
+	// the time of the acknowledgement
+	Time time.Time `yaml:"time" json:"time"`
+	// the acknowledgement expires after the duration, zero means until the probe recovers
+	Expire time.Duration `yaml:"expire,omitempty" json:"expire,omitempty"`
+}
+
+// Clone returns a copy of the AckData, it returns nil if the acknowledgement is nil
+func (a *AckData) Clone() *AckData {
+	if a == nil {
+		return nil
+	}
+	dst := *a
+	return &dst
+}
+
+// ExpireTime returns the expiry time of the acknowledgement, zero if it never expires
+func (a *AckData) ExpireTime() time.Time {
+	if a.Expire <= 0 {
+		return time.Time{}
+	}
+	return a.Time.Add(a.Expire)
+}
+
+// Expired returns true if the acknowledgement is expired at the time now
+func (a *AckData) Expired(now time.Time) bool {
+	return a.Expire > 0 && !now.Before(a.ExpireTime())
+}
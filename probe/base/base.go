@@ -24,40 +24,53 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/proxy"
 
+	"github.com/wfusion/easeprobe/ack"
+	"github.com/wfusion/easeprobe/escalation"
 	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/maintenance"
 	"github.com/wfusion/easeprobe/metric"
 	"github.com/wfusion/easeprobe/probe"
 )
 
 // Probe Simple Status
 const (
-	ServiceUp   int = 1
-	ServiceDown int = 0
+	ServiceUp      int = 1
+	ServiceDown    int = 0
+	ServiceWarning int = 2
 )
 
 // ProbeFuncType is the probe function type
 type ProbeFuncType func() (bool, string)
 
+// ProbeStatusFuncType is the probe function type which returns the probe status,
+// so that the probe could report the warning status besides up and down.
+type ProbeStatusFuncType func() (probe.Status, string)
+
 // DefaultProbe is the default options for all probe
 type DefaultProbe struct {
 	ProbeKind                            string            `yaml:"-" json:"-"`
 	ProbeTag                             string            `yaml:"-" json:"-"`
 	ProbeName                            string            `yaml:"name" json:"name" jsonschema:"required,title=Probe Name,description=the name of probe must be unique"`
 	ProbeChannels                        []string          `yaml:"channels" json:"channels,omitempty" jsonschema:"title=Probe Channels,description=the channels of probe message need to send to"`
+	ProbeDependsOn                       []string          `yaml:"depends_on,omitempty" json:"depends_on,omitempty" jsonschema:"title=Probe Dependencies,description=the names of the probes which this probe depends on"`
 	ProbeTimeout                         time.Duration     `yaml:"timeout,omitempty" json:"timeout,omitempty" jsonschema:"type=string,format=duration,title=Probe Timeout,description=the timeout of probe"`
 	ProbeTimeInterval                    time.Duration     `yaml:"interval,omitempty" json:"interval,omitempty" jsonschema:"type=string,format=duration,title=Probe Interval,description=the interval of probe"`
 	Labels                               prometheus.Labels `yaml:"labels,omitempty" json:"labels,omitempty" jsonschema:"title=Probe LabelMap,description=the labels of probe"`
+	ProbeSLO                             float64           `yaml:"slo,omitempty" json:"slo,omitempty" jsonschema:"title=SLO,description=the SLO target of probe in percentage,example=99.9,default=99.9"`
 	global.StatusChangeThresholdSettings `yaml:",inline" json:",inline"`
 	global.NotificationStrategySettings  `yaml:"alert" json:"alert" jsonschema:"title=Probe Alert,description=the alert strategy of probe"`
-	ProbeFunc                            ProbeFuncType `yaml:"-" json:"-"`
-	ProbeResult                          *probe.Result `yaml:"-" json:"-"`
-	metrics                              *metrics      `yaml:"-" json:"-"`
+	ProbeFunc                            ProbeFuncType       `yaml:"-" json:"-"`
+	ProbeStatusFunc                      ProbeStatusFuncType `yaml:"-" json:"-"`
+	ProbeResult                          *probe.Result       `yaml:"-" json:"-"`
+	metrics                              *metrics            `yaml:"-" json:"-"`
+	registry                             *metric.Registry    `yaml:"-" json:"-"`
 }
 
 // LabelMap return the const metric labels  for a probe in the configuration.
@@ -72,6 +85,22 @@ func (d *DefaultProbe) SetLabelMap(labels prometheus.Labels) {
 	d.Labels = labels
 }
 
+// MetricRegistry return the metric registry of the probe, it's the global registry by default.
+func (d *DefaultProbe) MetricRegistry() *metric.Registry {
+	if d.registry == nil {
+		return metric.DefaultRegistry()
+	}
+	return d.registry
+}
+
+// SetMetricRegistry set a dedicated metric registry for a transient probe,
+// the metrics and the result of the transient probe are not kept globally.
+//
+//	Note: This method takes effect before Probe.Config() only
+func (d *DefaultProbe) SetMetricRegistry(registry *metric.Registry) {
+	d.registry = registry
+}
+
 // Kind return the probe kind
 func (d *DefaultProbe) Kind() string {
 	return d.ProbeKind
@@ -87,6 +116,11 @@ func (d *DefaultProbe) Channels() []string {
 	return d.ProbeChannels
 }
 
+// DependsOn return the names of the probes which the probe depends on
+func (d *DefaultProbe) DependsOn() []string {
+	return d.ProbeDependsOn
+}
+
 // Timeout get the probe timeout
 func (d *DefaultProbe) Timeout() time.Duration {
 	return d.ProbeTimeout
@@ -119,7 +153,8 @@ func (d *DefaultProbe) CheckStatusThreshold() probe.Status {
 		title, c.CurrentStatus, c.StatusCount, s.Failure, s.Success)
 
 	if c.CurrentStatus == true && c.StatusCount >= s.Success {
-		if d.ProbeResult.Status != probe.StatusUp {
+		// the warning is held by the warning override, it's not a status change
+		if d.ProbeResult.Status != probe.StatusUp && d.ProbeResult.Status != probe.StatusWarning {
 			cnt := math.Max(float64(c.StatusCount), float64(s.Success))
 			log.Infof("%s - Status is UP! Threshold reached for success [%d/%d]", title, int(cnt), s.Success)
 		}
@@ -155,10 +190,18 @@ func (d *DefaultProbe) Config(gConf global.ProbeSettings,
 	d.ProbeTimeInterval = gConf.NormalizeInterval(d.ProbeTimeInterval)
 	d.StatusChangeThresholdSettings = gConf.NormalizeThreshold(d.StatusChangeThresholdSettings)
 	d.NotificationStrategySettings = gConf.NormalizeNotificationStrategy(d.NotificationStrategySettings)
+	d.ProbeSLO = gConf.NormalizeSLO(d.ProbeSLO)
 
-	d.ProbeResult = probe.NewResultWithName(name)
+	if d.registry != nil {
+		d.ProbeResult = probe.NewResult()
+	} else {
+		d.ProbeResult = probe.NewResultWithName(name)
+	}
 	d.ProbeResult.Name = name
 	d.ProbeResult.Endpoint = endpoint
+	d.ProbeResult.SLO = d.ProbeSLO
+	d.ProbeResult.Kind = kind
+	d.ProbeResult.Labels = d.Labels
 
 	// update the notification strategy settings
 	d.ProbeResult.Stat.NotificationStrategyData.Strategy = d.NotificationStrategySettings.Strategy
@@ -183,14 +226,26 @@ func (d *DefaultProbe) Config(gConf global.ProbeSettings,
 		log.Infof("Probe %s Status Threshold are configured! failure[%d], success[%d]", d.LogTitle(), d.Failure, d.Success)
 	}
 
-	d.metrics = newMetrics(kind, tag, d.Labels)
+	d.metrics = newMetrics(d.MetricRegistry(), kind, tag, d.Labels)
 
 	return nil
 }
 
+// doProbe calls the probe function, the ProbeStatusFunc is preferred if it is set
+func (d *DefaultProbe) doProbe() (probe.Status, string) {
+	if d.ProbeStatusFunc != nil {
+		return d.ProbeStatusFunc()
+	}
+	stat, msg := d.ProbeFunc()
+	if !stat {
+		return probe.StatusDown, msg
+	}
+	return probe.StatusUp, msg
+}
+
 // Probe return the checking result
 func (d *DefaultProbe) Probe() probe.Result {
-	if d.ProbeFunc == nil {
+	if d.ProbeFunc == nil && d.ProbeStatusFunc == nil {
 		return *d.ProbeResult
 	}
 
@@ -198,17 +253,37 @@ func (d *DefaultProbe) Probe() probe.Result {
 	d.ProbeResult.StartTime = now
 	d.ProbeResult.StartTimestamp = now.UnixMilli()
 
-	stat, msg := d.ProbeFunc()
+	current, msg := d.doProbe()
 
 	d.ProbeResult.RoundTripTime = time.Since(now)
 
-	// check the status threshold
-	d.ProbeResult.Stat.StatusCounter.AppendStatus(stat, msg)
+	// check the maintenance window
+	d.ProbeResult.Maintenance = ""
+	if w := maintenance.Find(d.ProbeName, d.ProbeKind, d.Labels, now); w != nil {
+		log.Debugf("%s - in the maintenance window [%s]", d.LogTitle(), w.Name)
+		d.ProbeResult.Maintenance = w.Name
+	}
+
+	// check the status threshold, the warning is counted as success
+	d.ProbeResult.Stat.StatusCounter.AppendStatus(current.IsAvailable(), msg)
 	status := d.CheckStatusThreshold()
+	if status == probe.StatusUp && current == probe.StatusWarning {
+		status = probe.StatusWarning
+	}
 	title := status.Title()
 
 	// process the notification strategy
-	d.ProbeResult.Stat.NotificationStrategyData.ProcessStatus(status == probe.StatusUp)
+	d.ProbeResult.Stat.NotificationStrategyData.ProcessStatus(status.IsAvailable())
+
+	// check the dependencies
+	suppressedBy, msg := d.checkDependency(status, msg)
+	d.ProbeResult.SuppressedBy = suppressedBy
+
+	// process the acknowledgement
+	ack.Process(d.ProbeResult, status, now)
+
+	// process the escalation policy
+	escalation.Process(d.ProbeResult, status, d.ProbeChannels, now)
 
 	if len(d.ProbeTag) > 0 {
 		d.ProbeResult.Message = fmt.Sprintf("%s (%s/%s): %s", title, d.ProbeKind, d.ProbeTag, msg)
@@ -231,13 +306,52 @@ func (d *DefaultProbe) Probe() probe.Result {
 	return result
 }
 
+// checkDependency check the dependencies of the probe,
+// it returns the parent probe name if the failure is suppressed, and the message with the dependency information.
+func (d *DefaultProbe) checkDependency(status probe.Status, msg string) (string, string) {
+	g := probe.GetDependencyGraph()
+	if g == nil {
+		return "", msg
+	}
+
+	if status.IsAvailable() {
+		// the recovery of the suppressed failure is suppressed as well
+		if d.ProbeResult.Status == probe.StatusDown {
+			return d.ProbeResult.SuppressedBy, msg
+		}
+		return "", msg
+	}
+
+	if status != probe.StatusDown {
+		return "", msg
+	}
+
+	if d.ProbeResult.Status == probe.StatusDown {
+		// the suppression is decided once when the failure begins,
+		// so the failure which has been alerted is not suppressed by the parent going down later
+		if parent := d.ProbeResult.SuppressedBy; len(parent) > 0 {
+			return parent, fmt.Sprintf("Unreachable - the parent [%s] is down. %s", parent, msg)
+		}
+	} else if parent := g.DownParent(d.ProbeName); len(parent) > 0 {
+		// the parent is down, the failure is caused by the parent
+		log.Infof("%s - the parent [%s] is down, the failure is suppressed", d.LogTitle(), parent)
+		return parent, fmt.Sprintf("Unreachable - the parent [%s] is down. %s", parent, msg)
+	}
+
+	// list the probes which depend on this probe, they might be affected by the failure
+	if dependents := g.Dependents(d.ProbeName); len(dependents) > 0 {
+		msg = fmt.Sprintf("%s (Dependent probes: %s)", msg, strings.Join(dependents, ", "))
+	}
+	return "", msg
+}
+
 // ExportMetrics export the metrics
 func (d *DefaultProbe) ExportMetrics() {
 	cnt := int64(0)
 	time := time.Duration(0)
 
-	if d.ProbeResult.Status == probe.StatusUp {
-		cnt = d.ProbeResult.Stat.Status[probe.StatusUp]
+	if d.ProbeResult.Status.IsAvailable() {
+		cnt = d.ProbeResult.Stat.Status[d.ProbeResult.Status]
 		time = d.ProbeResult.Stat.UpTime
 	} else {
 		cnt = d.ProbeResult.Stat.Status[probe.StatusDown]
@@ -264,7 +378,9 @@ func (d *DefaultProbe) ExportMetrics() {
 	}, d.Labels)).Set(float64(d.ProbeResult.RoundTripTime.Milliseconds()))
 
 	status := ServiceUp // up
-	if d.ProbeResult.Status != probe.StatusUp {
+	if d.ProbeResult.Status == probe.StatusWarning {
+		status = ServiceWarning // warning
+	} else if d.ProbeResult.Status != probe.StatusUp {
 		status = ServiceDown // down
 	}
 	d.metrics.Status.With(metric.AddConstLabels(prometheus.Labels{
@@ -286,8 +402,8 @@ func (d *DefaultProbe) DownTimeCalculation(status probe.Status) {
 		d.ProbeResult.LatestDownTime = time.Now().UTC()
 	}
 
-	// Status from DOWN to UP - Recovery
-	if d.ProbeResult.PreStatus == probe.StatusDown && status == probe.StatusUp {
+	// Status from DOWN to UP or WARNING - Recovery
+	if d.ProbeResult.PreStatus == probe.StatusDown && status.IsAvailable() {
 		d.ProbeResult.RecoveryDuration = time.Since(d.ProbeResult.LatestDownTime)
 	}
 }
@@ -26,11 +26,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/wfusion/gofusion/common/utils/gomonkey"
 	"golang.org/x/net/proxy"
 
 	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/maintenance"
 	"github.com/wfusion/easeprobe/probe"
 )
 
@@ -83,6 +86,17 @@ func TestBase(t *testing.T) {
 	assert.Equal(t, global.DefaultTimeOut, p.Timeout())
 	assert.Equal(t, global.DefaultProbeInterval, p.Interval())
 	assert.Equal(t, probe.StatusInit, p.Result().Status)
+	assert.Equal(t, global.DefaultSLO, p.Result().SLO)
+
+	p.ProbeSLO = 99.5
+	p.Config(global.ProbeSettings{SLO: 99})
+	assert.Equal(t, 99.5, p.Result().SLO)
+
+	p.SetLabelMap(prometheus.Labels{"env": "prod"})
+	p.Config(global.ProbeSettings{})
+	assert.Equal(t, "dummy", p.Result().Kind)
+	assert.Equal(t, "prod", p.Result().Labels["env"])
+	p.SetLabelMap(nil)
 
 	p.ProbeTag = ""
 	p.Config(global.ProbeSettings{})
@@ -238,3 +252,152 @@ func TestStatusThreshold2(t *testing.T) {
 	p.Probe()
 	assert.Equal(t, probe.StatusUp, p.Result().Status)
 }
+
+func TestStatusWarning(t *testing.T) {
+	p := newDummyProber("probe")
+	p.Failure = 2
+	p.Success = 1
+	p.Config(global.ProbeSettings{})
+	p.ProbeResult.Status = probe.StatusUp
+
+	status := probe.StatusWarning
+	p.ProbeStatusFunc = func() (probe.Status, string) {
+		return status, status.String()
+	}
+	r := p.Probe()
+	assert.Equal(t, probe.StatusWarning, r.Status)
+	assert.Equal(t, probe.StatusUp, r.PreStatus)
+	assert.Contains(t, r.Message, "Warning")
+	assert.Equal(t, int64(1), r.Stat.Status[probe.StatusWarning])
+	assert.Equal(t, 0, r.Stat.NotificationStrategyData.Failed)
+
+	// the warning is counted as success for the failure threshold
+	status = probe.StatusDown
+	r = p.Probe()
+	assert.NotEqual(t, probe.StatusDown, r.Status)
+	r = p.Probe()
+	assert.Equal(t, probe.StatusDown, r.Status)
+
+	// recovery to the warning status
+	status = probe.StatusWarning
+	r = p.Probe()
+	assert.Equal(t, probe.StatusWarning, r.Status)
+	assert.Equal(t, probe.StatusDown, r.PreStatus)
+
+	status = probe.StatusUp
+	r = p.Probe()
+	assert.Equal(t, probe.StatusUp, r.Status)
+	assert.Equal(t, int64(2), r.Stat.Status[probe.StatusWarning])
+
+	// the ProbeStatusFunc is preferred
+	p.ProbeFunc = func() (bool, string) {
+		return false, "failure"
+	}
+	status = probe.StatusWarning
+	r = p.Probe()
+	assert.Equal(t, probe.StatusWarning, r.Status)
+
+	// the held warning doesn't log the status change
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+	r = p.Probe()
+	assert.Equal(t, probe.StatusWarning, r.Status)
+	for _, e := range hook.AllEntries() {
+		assert.NotContains(t, e.Message, "Status is UP")
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	p := newDummyProber("probe")
+	p.Config(global.ProbeSettings{})
+	p.ProbeFunc = func() (bool, string) {
+		return false, "failure"
+	}
+
+	now := time.Now()
+	maintenance.SetWindows([]maintenance.Window{
+		{Name: "deploy", Start: now.Add(-time.Minute), End: now.Add(time.Hour), Probes: []string{"probe"}},
+	})
+	defer maintenance.SetWindows(nil)
+
+	r := p.Probe()
+	assert.Equal(t, probe.StatusDown, r.Status)
+	assert.Equal(t, "deploy", r.Maintenance)
+	assert.Equal(t, p.Interval(), r.Stat.MaintenanceTime)
+	assert.Equal(t, time.Duration(0), r.Stat.DownTime)
+
+	maintenance.SetWindows(nil)
+	r = p.Probe()
+	assert.Equal(t, "", r.Maintenance)
+	assert.Equal(t, p.Interval(), r.Stat.DownTime)
+}
+
+func TestDependency(t *testing.T) {
+	parent := newDummyProber("parent")
+	parent.Config(global.ProbeSettings{})
+	child := newDummyProber("child")
+	child.ProbeDependsOn = []string{"parent"}
+	child.Config(global.ProbeSettings{})
+	assert.Equal(t, []string{"parent"}, child.DependsOn())
+
+	g, err := probe.NewDependencyGraph([]probe.Prober{parent, child})
+	assert.Nil(t, err)
+	probe.SetDependencyGraph(g)
+	defer probe.SetDependencyGraph(nil)
+	defer probe.CleanData(nil)
+
+	parentUp, childUp := false, false
+	parent.ProbeFunc = func() (bool, string) { return parentUp, "parent" }
+	child.ProbeFunc = func() (bool, string) { return childUp, "child" }
+	// the result data is updated as the saving goroutine does
+	probeParent := func() probe.Result {
+		r := parent.Probe()
+		probe.SetResultData(r.Name, &r)
+		return r
+	}
+
+	// the parent alert lists the dependent probes
+	r := probeParent()
+	assert.Equal(t, probe.StatusDown, r.Status)
+	assert.Contains(t, r.Message, "Dependent probes: child")
+	assert.Equal(t, "", r.SuppressedBy)
+
+	// the child failure is suppressed
+	r = child.Probe()
+	assert.Equal(t, probe.StatusDown, r.Status)
+	assert.Equal(t, "parent", r.SuppressedBy)
+	assert.Contains(t, r.Message, "Unreachable - the parent [parent] is down")
+	assert.Equal(t, int64(1), r.Stat.Suppressed)
+
+	// the recovery of the suppressed failure is suppressed as well
+	parentUp, childUp = true, true
+	r = probeParent()
+	assert.Equal(t, probe.StatusUp, r.Status)
+	r = child.Probe()
+	assert.Equal(t, probe.StatusUp, r.Status)
+	assert.Equal(t, "parent", r.SuppressedBy)
+	r = child.Probe()
+	assert.Equal(t, "", r.SuppressedBy)
+
+	// the child failure is not suppressed if the parent is up
+	childUp = false
+	r = child.Probe()
+	assert.Equal(t, probe.StatusDown, r.Status)
+	assert.Equal(t, "", r.SuppressedBy)
+	assert.NotContains(t, r.Message, "Unreachable")
+
+	// the child failure has been alerted, it is not suppressed by the parent going down later
+	parentUp = false
+	r = probeParent()
+	assert.Equal(t, probe.StatusDown, r.Status)
+	r = child.Probe()
+	assert.Equal(t, probe.StatusDown, r.Status)
+	assert.Equal(t, "", r.SuppressedBy)
+	assert.NotContains(t, r.Message, "Unreachable")
+
+	// so the recovery of the child is not suppressed either
+	childUp = true
+	r = child.Probe()
+	assert.Equal(t, probe.StatusUp, r.Status)
+	assert.Equal(t, "", r.SuppressedBy)
+}
@@ -33,18 +33,18 @@ type metrics struct {
 }
 
 // newMetrics create the metrics
-func newMetrics(subsystem, name string, constLabels prometheus.Labels) *metrics {
+func newMetrics(registry *metric.Registry, subsystem, name string, constLabels prometheus.Labels) *metrics {
 	namespace := global.GetEaseProbe().Name
 	return &metrics{
-		TotalCnt: metric.NewGauge(namespace, subsystem, name, "total",
+		TotalCnt: registry.NewGauge(namespace, subsystem, name, "total",
 			"Total Probed Counts", []string{"name", "status", "endpoint"}, constLabels),
-		TotalTime: metric.NewGauge(namespace, subsystem, name, "total_time",
+		TotalTime: registry.NewGauge(namespace, subsystem, name, "total_time",
 			"Total Time(Seconds) of Status", []string{"name", "status", "endpoint"}, constLabels),
-		Duration: metric.NewGauge(namespace, subsystem, name, "duration",
+		Duration: registry.NewGauge(namespace, subsystem, name, "duration",
 			"Probe Duration", []string{"name", "status", "endpoint"}, constLabels),
-		Status: metric.NewGauge(namespace, subsystem, name, "status",
+		Status: registry.NewGauge(namespace, subsystem, name, "status",
 			"Probe Status", []string{"name", "endpoint"}, constLabels),
-		SLA: metric.NewGauge(namespace, subsystem, name, "sla",
+		SLA: registry.NewGauge(namespace, subsystem, name, "sla",
 			"Probe SLA", []string{"name", "endpoint"}, constLabels),
 	}
 }
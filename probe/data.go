@@ -89,6 +89,19 @@ func GetResultData(name string) *Result {
 	return nil
 }
 
+// GetAllResultData get the results of all probes
+// Note: the function would be called by Data Saving
+func GetAllResultData() map[string]*Result {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	data := make(map[string]*Result, len(resultData))
+	for k, v := range resultData {
+		r := v.Clone()
+		data[k] = &r
+	}
+	return data
+}
+
 // CleanData removes the items in resultData not in []Prober
 // Note: No need to consider the thread-safe, because this function is only called once during the startup
 func CleanData(p []Prober) {
@@ -345,6 +345,7 @@ type DummyProbe struct {
 	MyName     string
 	MyResult   *Result
 	MyChannels []string
+	MyDepends  []string
 	MyTimeout  time.Duration
 	MyInterval time.Duration
 }
@@ -365,6 +366,9 @@ func (d *DummyProbe) Name() string {
 func (d *DummyProbe) Channels() []string {
 	return d.MyChannels
 }
+func (d *DummyProbe) DependsOn() []string {
+	return d.MyDepends
+}
 func (d *DummyProbe) Timeout() time.Duration {
 	return d.MyTimeout
 }
@@ -406,3 +410,13 @@ func TestCleanData(t *testing.T) {
 	CleanData(p)
 	assert.Equal(t, len(resultData), 3)
 }
+
+func TestGetAllResultData(t *testing.T) {
+	SetResultData("all-data", &Result{Name: "all-data", Message: "origin"})
+	data := GetAllResultData()
+	assert.Equal(t, "origin", data["all-data"].Message)
+
+	// the results are copied
+	data["all-data"].Message = "changed"
+	assert.Equal(t, "origin", GetResultData("all-data").Message)
+}
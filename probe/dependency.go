@@ -0,0 +1,189 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package probe
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DependencyGraph is the dependency graph of the probes,
+// the child probe depends on the parent probes by `depends_on`.
+type DependencyGraph struct {
+	probers  map[string]Prober
+	parents  map[string][]string
+	children map[string][]string
+}
+
+// DependencyCycleError is the error of the dependency cycle
+type DependencyCycleError struct {
+	Cycle []string
+}
+
+func (e *DependencyCycleError) Error() string {
+	return fmt.Sprintf("probe dependency cycle is found: %s", strings.Join(e.Cycle, " -> "))
+}
+
+var (
+	graphLock       sync.RWMutex
+	dependencyGraph *DependencyGraph
+)
+
+// NewDependencyGraph build the dependency graph of the probes.
+// The unknown dependencies are ignored, and it returns the DependencyCycleError if there is a dependency cycle.
+func NewDependencyGraph(probers []Prober) (*DependencyGraph, error) {
+	g := &DependencyGraph{
+		probers:  map[string]Prober{},
+		parents:  map[string][]string{},
+		children: map[string][]string{},
+	}
+	for _, p := range probers {
+		g.probers[p.Name()] = p
+	}
+	for _, p := range probers {
+		for _, dep := range p.DependsOn() {
+			dep = strings.TrimSpace(dep)
+			if _, ok := g.probers[dep]; !ok {
+				log.Warnf("[%s / %s] the dependency [%s] is not found, ignored!", p.Kind(), p.Name(), dep)
+				continue
+			}
+			if contains(g.parents[p.Name()], dep) {
+				continue
+			}
+			g.parents[p.Name()] = append(g.parents[p.Name()], dep)
+			g.children[dep] = append(g.children[dep], p.Name())
+		}
+	}
+	if cycle := g.findCycle(); len(cycle) > 0 {
+		return nil, &DependencyCycleError{Cycle: cycle}
+	}
+	return g, nil
+}
+
+// findCycle return the probe names of the first dependency cycle, or nil if no cycle
+func (g *DependencyGraph) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		path = append(path, name)
+		for _, parent := range g.parents[name] {
+			switch state[parent] {
+			case visiting:
+				// the cycle is the path from the parent to the current probe
+				for i, n := range path {
+					if n == parent {
+						cycle := append([]string{}, path[i:]...)
+						return append(cycle, parent)
+					}
+				}
+			case unvisited:
+				if cycle := visit(parent); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	// visit the probes in order to make the result stable
+	names := make([]string, 0, len(g.probers))
+	for name := range g.probers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if state[name] == unvisited {
+			if cycle := visit(name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Parents return the probe names which the probe depends on directly
+func (g *DependencyGraph) Parents(name string) []string {
+	return g.parents[name]
+}
+
+// Dependents return all of the probe names which depend on the probe directly or indirectly
+func (g *DependencyGraph) Dependents(name string) []string {
+	result := []string{}
+	seen := map[string]bool{name: true}
+	queue := append([]string{}, g.children[name]...)
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		result = append(result, n)
+		queue = append(queue, g.children[n]...)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// DownParent return the name of the parent probe which is down, or empty if all of the parents are available.
+// The status is read from the result data, because the parent result is updated by its own goroutine.
+func (g *DependencyGraph) DownParent(name string) string {
+	for _, parent := range g.parents[name] {
+		if r := GetResultData(parent); r != nil && r.Status == StatusDown {
+			return parent
+		}
+	}
+	return ""
+}
+
+// SetDependencyGraph set the global dependency graph
+func SetDependencyGraph(g *DependencyGraph) {
+	graphLock.Lock()
+	defer graphLock.Unlock()
+	dependencyGraph = g
+}
+
+// GetDependencyGraph return the global dependency graph, it could be nil
+func GetDependencyGraph() *DependencyGraph {
+	graphLock.RLock()
+	defer graphLock.RUnlock()
+	return dependencyGraph
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package probe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newDependProbe(name string, depends ...string) *DummyProbe {
+	return &DummyProbe{
+		MyName:    name,
+		MyResult:  &Result{Name: name, Status: StatusUp},
+		MyDepends: depends,
+	}
+}
+
+func TestDependencyGraph(t *testing.T) {
+	sw := newDependProbe("switch")
+	bastion := newDependProbe("bastion", "switch")
+	web := newDependProbe("web", "bastion", "switch", "switch", "not-exist")
+	db := newDependProbe("db", "bastion")
+	other := newDependProbe("other")
+
+	g, err := NewDependencyGraph([]Prober{sw, bastion, web, db, other})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bastion", "switch"}, g.Parents("web"))
+	assert.Equal(t, []string{"bastion", "db", "web"}, g.Dependents("switch"))
+	assert.Equal(t, []string{"db", "web"}, g.Dependents("bastion"))
+	assert.Empty(t, g.Dependents("other"))
+	assert.Empty(t, g.Parents("other"))
+
+	defer CleanData(nil)
+	assert.Equal(t, "", g.DownParent("web"))
+	SetResultData("switch", &Result{Name: "switch", Status: StatusDown})
+	assert.Equal(t, "switch", g.DownParent("bastion"))
+	assert.Equal(t, "switch", g.DownParent("web"))
+	assert.Equal(t, "", g.DownParent("db"))
+	SetResultData("bastion", &Result{Name: "bastion", Status: StatusDown})
+	assert.Equal(t, "bastion", g.DownParent("db"))
+
+	SetDependencyGraph(g)
+	assert.Equal(t, g, GetDependencyGraph())
+	SetDependencyGraph(nil)
+	assert.Nil(t, GetDependencyGraph())
+}
+
+func TestDependencyCycle(t *testing.T) {
+	a := newDependProbe("a", "c")
+	b := newDependProbe("b", "a")
+	c := newDependProbe("c", "b")
+	d := newDependProbe("d", "a")
+
+	_, err := NewDependencyGraph([]Prober{a, b, c, d})
+	assert.NotNil(t, err)
+	cycleErr, ok := err.(*DependencyCycleError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a", "c", "b", "a"}, cycleErr.Cycle)
+	assert.Contains(t, err.Error(), "a -> c -> b -> a")
+
+	self := newDependProbe("self", "self")
+	_, err = NewDependencyGraph([]Prober{self})
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"self", "self"}, err.(*DependencyCycleError).Cycle)
+
+	// the diamond is not a cycle
+	top := newDependProbe("top")
+	left := newDependProbe("left", "top")
+	right := newDependProbe("right", "top")
+	bottom := newDependProbe("bottom", "left", "right")
+	_, err = NewDependencyGraph([]Prober{bottom, left, right, top})
+	assert.Nil(t, err)
+}
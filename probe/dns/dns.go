@@ -0,0 +1,308 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dns is the DNS probe package
+package dns
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/metric"
+	"github.com/wfusion/easeprobe/probe/base"
+)
+
+// The supported transport protocols
+const (
+	ProtocolUDP = "udp"
+	ProtocolTCP = "tcp"
+	ProtocolTLS = "tls"
+)
+
+// The default ports of the resolver
+const (
+	DefaultPort    = "53"
+	DefaultTLSPort = "853"
+)
+
+// DefaultResolvConf is the resolver configuration used when no server is set
+const DefaultResolvConf = "/etc/resolv.conf"
+
+var queryTypes = map[string]uint16{
+	"A":     dns.TypeA,
+	"AAAA":  dns.TypeAAAA,
+	"CNAME": dns.TypeCNAME,
+	"MX":    dns.TypeMX,
+	"TXT":   dns.TypeTXT,
+	"SRV":   dns.TypeSRV,
+	"NS":    dns.TypeNS,
+	"SOA":   dns.TypeSOA,
+}
+
+// DNS implements a config for DNS
+type DNS struct {
+	base.DefaultProbe `yaml:",inline"`
+	Domain            string   `yaml:"domain" json:"domain" jsonschema:"required,format=hostname,title=Domain,description=The domain name to query"`
+	Server            string   `yaml:"server,omitempty" json:"server,omitempty" jsonschema:"title=Resolver,description=The resolver address (host:port) to query; the system resolver is used if not set,example=8.8.8.8:53"`
+	Protocol          string   `yaml:"protocol,omitempty" json:"protocol,omitempty" jsonschema:"enum=udp,enum=tcp,enum=tls,title=Protocol,description=The transport protocol to the resolver,default=udp"`
+	QueryType         string   `yaml:"type,omitempty" json:"type,omitempty" jsonschema:"enum=A,enum=AAAA,enum=CNAME,enum=MX,enum=TXT,enum=SRV,enum=NS,enum=SOA,title=Query Type,description=The record type to query,default=A"`
+	Expect            []string `yaml:"expect,omitempty" json:"expect,omitempty" jsonschema:"title=Expected Answers,description=The values must be found in the answers"`
+	RCode             string   `yaml:"rcode,omitempty" json:"rcode,omitempty" jsonschema:"title=Response Code,description=The expected response code such as NOERROR or NXDOMAIN,default=NOERROR"`
+	MinTTL            uint32   `yaml:"min_ttl,omitempty" json:"min_ttl,omitempty" jsonschema:"title=Minimum TTL,description=The minimum TTL (seconds) of every answer,default=0"`
+	MinAnswers        int      `yaml:"min_answers,omitempty" json:"min_answers,omitempty" jsonschema:"title=Minimum Answers,description=The minimum number of the answers,default=0"`
+	MaxAnswers        int      `yaml:"max_answers,omitempty" json:"max_answers,omitempty" jsonschema:"title=Maximum Answers,description=The maximum number of the answers (0 means no limit),default=0"`
+
+	// Option - TLS Config for DNS over TLS
+	global.TLS `yaml:",inline"`
+
+	qType  uint16      `yaml:"-" json:"-"`
+	rCode  int         `yaml:"-" json:"-"`
+	client *dns.Client `yaml:"-" json:"-"`
+
+	metrics *metrics `yaml:"-" json:"-"`
+}
+
+// Config DNS Config Object
+func (d *DNS) Config(gConf global.ProbeSettings) error {
+	kind := "dns"
+	tag := ""
+	name := d.ProbeName
+
+	d.Domain = strings.TrimSpace(d.Domain)
+	d.Protocol = strings.ToLower(strings.TrimSpace(d.Protocol))
+	if d.Protocol == "" {
+		d.Protocol = ProtocolUDP
+	}
+	d.QueryType = strings.ToUpper(strings.TrimSpace(d.QueryType))
+	if d.QueryType == "" {
+		d.QueryType = "A"
+	}
+	d.RCode = strings.ToUpper(strings.TrimSpace(d.RCode))
+	if d.RCode == "" {
+		d.RCode = dns.RcodeToString[dns.RcodeSuccess]
+	}
+
+	server, err := d.resolver()
+	d.Server = server
+	endpoint := fmt.Sprintf("%s://%s/%s/%s", d.Protocol, d.Server, d.Domain, d.QueryType)
+	d.DefaultProbe.Config(gConf, kind, tag, name, endpoint, d.DoProbe)
+	if err != nil {
+		log.Errorf("[%s / %s] resolver error - %v", d.ProbeKind, d.ProbeName, err)
+		return err
+	}
+
+	if len(d.Domain) <= 0 {
+		return fmt.Errorf("the domain is empty")
+	}
+
+	network := ""
+	switch d.Protocol {
+	case ProtocolUDP:
+		network = "udp"
+	case ProtocolTCP:
+		network = "tcp"
+	case ProtocolTLS:
+		network = "tcp-tls"
+	default:
+		return fmt.Errorf("the protocol [%s] is not supported", d.Protocol)
+	}
+
+	qType, ok := queryTypes[d.QueryType]
+	if !ok {
+		return fmt.Errorf("the query type [%s] is not supported", d.QueryType)
+	}
+	d.qType = qType
+
+	rCode, ok := dns.StringToRcode[d.RCode]
+	if !ok {
+		return fmt.Errorf("the response code [%s] is not supported", d.RCode)
+	}
+	d.rCode = rCode
+
+	if d.MaxAnswers > 0 && d.MinAnswers > d.MaxAnswers {
+		return fmt.Errorf("the min_answers(%d) is greater than max_answers(%d)", d.MinAnswers, d.MaxAnswers)
+	}
+
+	d.client = &dns.Client{
+		Net:     network,
+		Timeout: d.Timeout(),
+	}
+	if d.Protocol == ProtocolTLS {
+		tls, err := d.TLS.Config()
+		if err != nil {
+			log.Errorf("[%s / %s] TLS configuration error - %s", d.ProbeKind, d.ProbeName, err)
+			return err
+		}
+		d.client.TLSConfig = tls
+	}
+
+	d.metrics = newMetrics(d.MetricRegistry(), kind, tag, d.Labels)
+
+	log.Debugf("[%s / %s] configuration: %+v", d.ProbeKind, d.ProbeName, *d)
+	return nil
+}
+
+// resolver return the resolver address with the port
+func (d *DNS) resolver() (string, error) {
+	port := DefaultPort
+	if d.Protocol == ProtocolTLS {
+		port = DefaultTLSPort
+	}
+
+	server := strings.TrimSpace(d.Server)
+	if server == "" {
+		conf, err := dns.ClientConfigFromFile(DefaultResolvConf)
+		if err != nil || len(conf.Servers) <= 0 {
+			return "", fmt.Errorf("the resolver is not set and cannot be read from %s - %v", DefaultResolvConf, err)
+		}
+		return net.JoinHostPort(conf.Servers[0], port), nil
+	}
+
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		// the server has no port, or it is an IPv6 address without brackets
+		server = net.JoinHostPort(strings.Trim(server, "[]"), port)
+	}
+	return server, nil
+}
+
+// DoProbe return the checking result
+func (d *DNS) DoProbe() (bool, string) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(d.Domain), d.qType)
+	msg.RecursionDesired = true
+
+	resp, rtt, err := d.client.Exchange(msg, d.Server)
+	d.ExportMetrics(resp, rtt)
+	if err != nil {
+		log.Errorf("[%s / %s] query error: %v", d.ProbeKind, d.ProbeName, err)
+		return false, fmt.Sprintf("Error: %v", err)
+	}
+
+	rCode := dns.RcodeToString[resp.Rcode]
+	if resp.Rcode != d.rCode {
+		return false, fmt.Sprintf("DNS response code is %s, expected %s", rCode, d.RCode)
+	}
+
+	answers := d.answers(resp)
+	if len(answers) < d.MinAnswers {
+		return false, fmt.Sprintf("DNS got %d %s answer(s), expected at least %d", len(answers), d.QueryType, d.MinAnswers)
+	}
+	if d.MaxAnswers > 0 && len(answers) > d.MaxAnswers {
+		return false, fmt.Sprintf("DNS got %d %s answer(s), expected at most %d", len(answers), d.QueryType, d.MaxAnswers)
+	}
+
+	for _, rr := range answers {
+		if rr.Header().Ttl < d.MinTTL {
+			return false, fmt.Sprintf("DNS answer [%s] TTL is %d, expected at least %d", value(rr), rr.Header().Ttl, d.MinTTL)
+		}
+	}
+
+	values := make([]string, 0, len(answers))
+	for _, rr := range answers {
+		values = append(values, value(rr))
+	}
+	for _, e := range d.Expect {
+		if !contains(values, e) {
+			return false, fmt.Sprintf("DNS answers %v do not contain [%s]", values, e)
+		}
+	}
+
+	log.Debugf("[%s / %s] - %s %s answers: %v (%s)", d.ProbeKind, d.ProbeName, d.Domain, d.QueryType, values, rtt)
+	return true, fmt.Sprintf("DNS response code is %s, %d %s answer(s) in %s", rCode, len(answers), d.QueryType, rtt.Round(time.Microsecond))
+}
+
+// answers return the answers which match the query type
+func (d *DNS) answers(resp *dns.Msg) []dns.RR {
+	var rrs []dns.RR
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype == d.qType {
+			rrs = append(rrs, rr)
+		}
+	}
+	return rrs
+}
+
+// value return the comparable value of the record
+func value(rr dns.RR) string {
+	switch r := rr.(type) {
+	case *dns.A:
+		return r.A.String()
+	case *dns.AAAA:
+		return r.AAAA.String()
+	case *dns.CNAME:
+		return r.Target
+	case *dns.MX:
+		return r.Mx
+	case *dns.TXT:
+		return strings.Join(r.Txt, "")
+	case *dns.SRV:
+		return net.JoinHostPort(r.Target, strconv.Itoa(int(r.Port)))
+	case *dns.NS:
+		return r.Ns
+	case *dns.SOA:
+		return r.Ns
+	}
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
+
+// contains checks the expected value in the answers
+// the domain names are compared in case-insensitive, and the tailing dot is optional.
+func contains(values []string, expect string) bool {
+	expect = strings.TrimSpace(expect)
+	for _, v := range values {
+		if v == expect || strings.EqualFold(dns.Fqdn(v), dns.Fqdn(expect)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportMetrics export DNS metrics
+func (d *DNS) ExportMetrics(resp *dns.Msg, rtt time.Duration) {
+	rCode := "NONE" // no response
+	answers := 0
+	if resp != nil {
+		rCode = dns.RcodeToString[resp.Rcode]
+		answers = len(d.answers(resp))
+	}
+
+	d.metrics.RCode.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     d.ProbeName,
+		"rcode":    rCode,
+		"endpoint": d.ProbeResult.Endpoint,
+	}, d.Labels)).Inc()
+
+	d.metrics.QueryDuration.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     d.ProbeName,
+		"rcode":    rCode,
+		"endpoint": d.ProbeResult.Endpoint,
+	}, d.Labels)).Set(float64(rtt.Milliseconds()))
+
+	d.metrics.AnswerCount.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     d.ProbeName,
+		"rcode":    rCode,
+		"endpoint": d.ProbeResult.Endpoint,
+	}, d.Labels)).Set(float64(answers))
+}
@@ -0,0 +1,219 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe/base"
+)
+
+var records = map[uint16][]string{
+	dns.TypeA:     {"example.com. 300 IN A 10.0.0.1", "example.com. 300 IN A 10.0.0.2"},
+	dns.TypeAAAA:  {"example.com. 300 IN AAAA 2001:db8::1"},
+	dns.TypeCNAME: {"example.com. 60 IN CNAME www.example.net."},
+	dns.TypeMX:    {"example.com. 300 IN MX 10 mail.example.com."},
+	dns.TypeTXT:   {`example.com. 300 IN TXT "v=spf1 -all"`},
+	dns.TypeSRV:   {"example.com. 300 IN SRV 10 5 5060 sip.example.com."},
+	dns.TypeNS:    {"example.com. 300 IN NS ns1.example.com."},
+	dns.TypeSOA:   {"example.com. 300 IN SOA ns1.example.com. admin.example.com. 1 7200 3600 1209600 3600"},
+}
+
+func handler(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	q := r.Question[0]
+	if q.Name != "example.com." {
+		m.Rcode = dns.RcodeNameError
+		w.WriteMsg(m)
+		return
+	}
+	for _, s := range records[q.Qtype] {
+		rr, _ := dns.NewRR(s)
+		m.Answer = append(m.Answer, rr)
+	}
+	w.WriteMsg(m)
+}
+
+func startServer(t *testing.T, network string) (string, func()) {
+	started := make(chan struct{})
+	server := &dns.Server{
+		Handler:           dns.HandlerFunc(handler),
+		NotifyStartedFunc: func() { close(started) },
+	}
+	addr := ""
+	if network == "udp" {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		assert.Nil(t, err)
+		server.PacketConn = pc
+		addr = pc.LocalAddr().String()
+	} else {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.Nil(t, err)
+		server.Listener = l
+		addr = l.Addr().String()
+	}
+	go server.ActivateAndServe()
+	<-started
+	return addr, func() { server.Shutdown() }
+}
+
+func newDNS(server, protocol, domain, qtype string) *DNS {
+	return &DNS{
+		DefaultProbe: base.DefaultProbe{ProbeName: "dummy dns"},
+		Server:       server,
+		Protocol:     protocol,
+		Domain:       domain,
+		QueryType:    qtype,
+	}
+}
+
+func TestDNS(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	addr, stop := startServer(t, "udp")
+	defer stop()
+
+	d := newDNS(addr, "", "example.com", "")
+	d.ProbeTimeout = 2 * time.Second
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	assert.Equal(t, "dns", d.ProbeKind)
+	assert.Equal(t, ProtocolUDP, d.Protocol)
+	assert.Equal(t, "A", d.QueryType)
+	assert.Equal(t, "NOERROR", d.RCode)
+	assert.Equal(t, "udp://"+addr+"/example.com/A", d.Result().Endpoint)
+
+	s, m := d.DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "2 A answer(s)")
+
+	// expected answers
+	d.Expect = []string{"10.0.0.2"}
+	s, m = d.DoProbe()
+	assert.True(t, s)
+	d.Expect = []string{"10.0.0.3"}
+	s, m = d.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "do not contain [10.0.0.3]")
+	d.Expect = nil
+
+	// minimum TTL
+	d.MinTTL = 600
+	s, m = d.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "TTL is 300")
+	d.MinTTL = 300
+	s, _ = d.DoProbe()
+	assert.True(t, s)
+
+	// answer count
+	d.MinAnswers = 3
+	s, m = d.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "expected at least 3")
+	d.MinAnswers = 0
+	d.MaxAnswers = 1
+	s, m = d.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "expected at most 1")
+
+	// response code
+	d = newDNS(addr, ProtocolUDP, "nonexistent.com", "A")
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	s, m = d.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "NXDOMAIN")
+	d = newDNS(addr, ProtocolUDP, "nonexistent.com", "A")
+	d.RCode = "nxdomain"
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	s, _ = d.DoProbe()
+	assert.True(t, s)
+}
+
+func TestDNSRecordTypes(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	addr, stop := startServer(t, "tcp")
+	defer stop()
+
+	expects := map[string]string{
+		"A":     "10.0.0.1",
+		"AAAA":  "2001:db8::1",
+		"CNAME": "www.example.net",
+		"MX":    "mail.example.com.",
+		"TXT":   "v=spf1 -all",
+		"SRV":   "sip.example.com.:5060",
+		"NS":    "NS1.example.com",
+		"SOA":   "ns1.example.com.",
+	}
+	for qtype, expect := range expects {
+		d := newDNS(addr, ProtocolTCP, "example.com", qtype)
+		d.Expect = []string{expect}
+		d.MinAnswers = 1
+		assert.Nil(t, d.Config(global.ProbeSettings{}))
+		s, m := d.DoProbe()
+		assert.True(t, s, "%s: %s", qtype, m)
+	}
+}
+
+func TestDNSConfig(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	d := newDNS("8.8.8.8", ProtocolTLS, "example.com", "mx")
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	assert.Equal(t, "8.8.8.8:853", d.Server)
+	assert.Equal(t, "MX", d.QueryType)
+	assert.Equal(t, "tcp-tls", d.client.Net)
+
+	d = newDNS("2001:4860:4860::8888", "", "example.com", "")
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	assert.Equal(t, "[2001:4860:4860::8888]:53", d.Server)
+
+	d = newDNS("8.8.8.8", "", "", "")
+	assert.NotNil(t, d.Config(global.ProbeSettings{}))
+
+	d = newDNS("8.8.8.8", "quic", "example.com", "")
+	assert.NotNil(t, d.Config(global.ProbeSettings{}))
+
+	d = newDNS("8.8.8.8", "", "example.com", "PTR")
+	assert.NotNil(t, d.Config(global.ProbeSettings{}))
+
+	d = newDNS("8.8.8.8", "", "example.com", "")
+	d.RCode = "BADCODE"
+	assert.NotNil(t, d.Config(global.ProbeSettings{}))
+
+	d = newDNS("8.8.8.8", "", "example.com", "")
+	d.MinAnswers = 3
+	d.MaxAnswers = 2
+	assert.NotNil(t, d.Config(global.ProbeSettings{}))
+
+	d = newDNS("8.8.8.8", ProtocolTLS, "example.com", "")
+	d.CA = "/path/not/exist"
+	assert.NotNil(t, d.Config(global.ProbeSettings{}))
+
+	// the resolver cannot be reached
+	d = newDNS("127.0.0.1:1", ProtocolTCP, "example.com", "")
+	d.ProbeTimeout = time.Second
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	s, m := d.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Error")
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/metric"
+)
+
+// metrics is the metrics for dns probe
+type metrics struct {
+	RCode         *prometheus.CounterVec
+	QueryDuration *prometheus.GaugeVec
+	AnswerCount   *prometheus.GaugeVec
+}
+
+// newMetrics create the DNS metrics
+func newMetrics(registry *metric.Registry, subsystem, name string, constLabels prometheus.Labels) *metrics {
+	namespace := global.GetEaseProbe().Name
+	return &metrics{
+		RCode: registry.NewCounter(namespace, subsystem, name, "rcode",
+			"DNS Response Code", []string{"name", "rcode", "endpoint"}, constLabels),
+		QueryDuration: registry.NewGauge(namespace, subsystem, name, "query_duration",
+			"DNS Query Duration", []string{"name", "rcode", "endpoint"}, constLabels),
+		AnswerCount: registry.NewGauge(namespace, subsystem, name, "answer_count",
+			"DNS Answer Count", []string{"name", "rcode", "endpoint"}, constLabels),
+	}
+}
@@ -0,0 +1,40 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package probe
+
+import "time"
+
+// EscalationData is the escalation state of the ongoing failure,
+// it's kept in the data file, so that the escalation survives the restart.
+type EscalationData struct {
+	// the name of the escalation policy
+	Policy string `yaml:"policy" json:"policy"`
+	// the number of the stages which have been notified
+	Stage int `yaml:"stage" json:"stage"`
+	// the start time of the failure
+	Since time.Time `yaml:"since" json:"since"`
+}
+
+// Clone returns a copy of the EscalationData, it returns nil if the escalation is nil
+func (e *EscalationData) Clone() *EscalationData {
+	if e == nil {
+		return nil
+	}
+	dst := *e
+	return &dst
+}
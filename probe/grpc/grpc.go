@@ -0,0 +1,232 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package grpc is the gRPC probe package
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/gofusion/common/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/wfusion/easeprobe/eval"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/metric"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/probe/base"
+)
+
+// GRPC implements a config for gRPC
+type GRPC struct {
+	base.DefaultProbe `yaml:",inline"`
+	Host              string            `yaml:"host" json:"host" jsonschema:"required,title=Host,description=The gRPC server address (host:port) to probe,example=localhost:50051"`
+	Service           string            `yaml:"service,omitempty" json:"service,omitempty" jsonschema:"title=Health Service,description=The service name to check with the standard health service; empty means the overall server health"`
+	Metadata          map[string]string `yaml:"metadata,omitempty" json:"metadata,omitempty" jsonschema:"title=Metadata,description=The metadata headers sent with the request"`
+	Secure            bool              `yaml:"tls,omitempty" json:"tls,omitempty" jsonschema:"title=TLS,description=Use TLS with the system root CAs even if no CA file is set,default=false"`
+
+	// Option - Invoke an arbitrary unary method by the server reflection instead of the health checking
+	Method  string `yaml:"method,omitempty" json:"method,omitempty" jsonschema:"title=Method,description=The full unary method name to invoke by the server reflection,example=helloworld.Greeter/SayHello"`
+	Request string `yaml:"request,omitempty" json:"request,omitempty" jsonschema:"title=Request,description=The JSON request message of the method"`
+
+	// Output Text Checker
+	probe.TextChecker `yaml:",inline"`
+
+	// Evaluator for the JSON response of the method
+	Evaluator eval.Evaluator `yaml:"eval,omitempty" json:"eval,omitempty" jsonschema:"title=Evaluator,description=The evaluator of the JSON response"`
+
+	// Option - TLS Config
+	global.TLS `yaml:",inline"`
+
+	creds credentials.TransportCredentials `yaml:"-" json:"-"`
+
+	metrics *metrics `yaml:"-" json:"-"`
+}
+
+// Config gRPC Config Object
+func (g *GRPC) Config(gConf global.ProbeSettings) error {
+	kind := "grpc"
+	tag := ""
+	name := g.ProbeName
+	g.DefaultProbe.Config(gConf, kind, tag, name, g.Host, g.DoProbe)
+
+	if len(strings.TrimSpace(g.Host)) <= 0 {
+		return fmt.Errorf("the host is empty")
+	}
+
+	tlsConfig, err := g.TLS.Config()
+	if err != nil {
+		log.Errorf("[%s / %s] TLS configuration error - %s", g.ProbeKind, g.ProbeName, err)
+		return err
+	}
+	if tlsConfig == nil && g.Secure {
+		tlsConfig = &tls.Config{}
+	}
+	if tlsConfig != nil {
+		g.creds = credentials.NewTLS(tlsConfig)
+	} else {
+		g.creds = insecure.NewCredentials()
+	}
+
+	g.Method = strings.Trim(strings.TrimSpace(g.Method), "/")
+	if len(g.Method) > 0 {
+		if _, _, err := splitMethod(g.Method); err != nil {
+			return err
+		}
+	}
+
+	if err := g.TextChecker.Config(); err != nil {
+		return err
+	}
+
+	// if the evaluator is set, config it
+	if g.Evaluator.DocType != eval.Unsupported && len(strings.TrimSpace(g.Evaluator.Expression)) > 0 {
+		if err := g.Evaluator.Config(); err != nil {
+			return err
+		}
+	}
+
+	g.metrics = newMetrics(g.MetricRegistry(), kind, tag, g.Labels)
+
+	log.Debugf("[%s / %s] configuration: %+v", g.ProbeKind, g.ProbeName, *g)
+	return nil
+}
+
+// DoProbe return the checking result
+func (g *GRPC) DoProbe() (bool, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.Timeout())
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, g.Host,
+		grpc.WithTransportCredentials(g.creds),
+		grpc.WithUserAgent(global.OrgProgVer),
+		grpc.WithBlock())
+	if err != nil {
+		g.ExportMetrics(err, -1)
+		log.Errorf("[%s / %s] dial error: %v", g.ProbeKind, g.ProbeName, err)
+		return false, fmt.Sprintf("Error: %v", err)
+	}
+	defer conn.Close()
+
+	if len(g.Metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(g.Metadata))
+	}
+
+	if len(g.Method) > 0 {
+		return g.invoke(ctx, conn)
+	}
+	return g.checkHealth(ctx, conn)
+}
+
+// checkHealth calls the standard health checking service
+func (g *GRPC) checkHealth(ctx context.Context, conn *grpc.ClientConn) (bool, string) {
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx,
+		&grpc_health_v1.HealthCheckRequest{Service: g.Service})
+	if err != nil {
+		g.ExportMetrics(err, -1)
+		log.Errorf("[%s / %s] health check error: %v", g.ProbeKind, g.ProbeName, err)
+		return false, fmt.Sprintf("Error: %v", err)
+	}
+	g.ExportMetrics(nil, resp.GetStatus())
+
+	service := g.Service
+	if len(service) <= 0 {
+		service = "server"
+	}
+	if resp.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		return false, fmt.Sprintf("gRPC health status of %s is %s", service, resp.GetStatus())
+	}
+	return true, fmt.Sprintf("gRPC health status of %s is %s", service, resp.GetStatus())
+}
+
+// invoke calls the unary method and checks the JSON response
+func (g *GRPC) invoke(ctx context.Context, conn *grpc.ClientConn) (bool, string) {
+	response, err := invokeByReflection(ctx, conn, g.Method, g.Request)
+	g.ExportMetrics(err, -1)
+	if err != nil {
+		log.Errorf("[%s / %s] invoke %s error: %v", g.ProbeKind, g.ProbeName, g.Method, err)
+		return false, fmt.Sprintf("Error: %v", err)
+	}
+
+	result := true
+	message := fmt.Sprintf("gRPC method %s is invoked successfully", g.Method)
+
+	log.Debugf("[%s / %s] - %s", g.ProbeKind, g.ProbeName, g.TextChecker.String())
+	if err := g.Check(response); err != nil {
+		log.Errorf("[%s / %s] - %v", g.ProbeKind, g.ProbeName, err)
+		message += fmt.Sprintf(". Error: %v", err)
+		result = false
+	}
+
+	if g.Evaluator.DocType != eval.Unsupported && g.Evaluator.Extractor != nil &&
+		len(strings.TrimSpace(g.Evaluator.Expression)) > 0 {
+
+		log.Debugf("[%s / %s] - Evaluator expression: %s", g.ProbeKind, g.ProbeName, g.Evaluator.Expression)
+		g.Evaluator.SetDocument(g.Evaluator.DocType, response)
+		result, err := g.Evaluator.Evaluate()
+		if err != nil {
+			log.Errorf("[%s / %s] - %v", g.ProbeKind, g.ProbeName, err)
+			message += fmt.Sprintf(". Evaluation Error: %v", err)
+			message = message[:utils.Min(len(message), 256)]
+			return false, message
+		}
+		if !result {
+			log.Errorf("[%s / %s] - expression is evaluated to false!", g.ProbeKind, g.ProbeName)
+			message += ". Expression is evaluated to false!"
+			for k, v := range g.Evaluator.ExtractedValues {
+				message += fmt.Sprintf(" [%s = %v]", k, v)
+				log.Debugf("[%s / %s] - Expression Value: [%s] = [%v]", g.ProbeKind, g.ProbeName, k, v)
+			}
+			message = message[:utils.Min(len(message), 256)]
+			return false, message
+		}
+		log.Debugf("[%s / %s] - expression is evaluated to true!", g.ProbeKind, g.ProbeName)
+	}
+
+	return result, message
+}
+
+// ExportMetrics export gRPC metrics
+// the serving status is negative if the health service is not called.
+func (g *GRPC) ExportMetrics(err error, serving grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	code := status.Code(err)
+
+	g.metrics.StatusCode.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     g.ProbeName,
+		"status":   code.String(),
+		"endpoint": g.ProbeResult.Endpoint,
+	}, g.Labels)).Inc()
+
+	if serving < 0 {
+		return
+	}
+	g.metrics.ServingStatus.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     g.ProbeName,
+		"service":  g.Service,
+		"endpoint": g.ProbeResult.Endpoint,
+	}, g.Labels)).Set(float64(serving))
+}
@@ -0,0 +1,216 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"github.com/wfusion/easeprobe/eval"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe/base"
+)
+
+func startServer(t *testing.T, opts ...grpc.ServerOption) (string, *health.Server, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	server := grpc.NewServer(opts...)
+	hs := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, hs)
+	reflection.Register(server)
+	go server.Serve(l)
+
+	return l.Addr().String(), hs, server.Stop
+}
+
+func newGRPC(host string) *GRPC {
+	return &GRPC{
+		DefaultProbe: base.DefaultProbe{
+			ProbeName:    "dummy grpc",
+			ProbeTimeout: 2 * time.Second,
+		},
+		Host: host,
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	addr, hs, stop := startServer(t)
+	defer stop()
+
+	g := newGRPC(addr)
+	assert.Nil(t, g.Config(global.ProbeSettings{}))
+	assert.Equal(t, "grpc", g.ProbeKind)
+	assert.Equal(t, addr, g.Result().Endpoint)
+
+	s, m := g.DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "SERVING")
+
+	hs.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	s, m = g.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "NOT_SERVING")
+
+	// the service health status
+	hs.SetServingStatus("app.Service", grpc_health_v1.HealthCheckResponse_SERVING)
+	g.Service = "app.Service"
+	s, m = g.DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "app.Service")
+
+	g.Service = "unknown.Service"
+	s, m = g.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "NotFound")
+
+	// the server cannot be reached
+	g = newGRPC("127.0.0.1:1")
+	g.ProbeTimeout = time.Second
+	assert.Nil(t, g.Config(global.ProbeSettings{}))
+	s, m = g.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Error")
+}
+
+func TestGRPCMetadata(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	auth := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if v := md.Get("authorization"); len(v) <= 0 || v[0] != "Bearer token" {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return handler(ctx, req)
+	}
+	addr, _, stop := startServer(t, grpc.UnaryInterceptor(auth))
+	defer stop()
+
+	g := newGRPC(addr)
+	assert.Nil(t, g.Config(global.ProbeSettings{}))
+	s, m := g.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "invalid token")
+
+	g.Metadata = map[string]string{"authorization": "Bearer token"}
+	s, m = g.DoProbe()
+	assert.True(t, s, m)
+}
+
+func TestGRPCMethod(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	addr, hs, stop := startServer(t)
+	defer stop()
+
+	g := newGRPC(addr)
+	g.Method = "/grpc.health.v1.Health/Check"
+	g.Request = `{"service": ""}`
+	g.Contain = "SERVING"
+	g.Evaluator = eval.Evaluator{
+		DocType:    eval.JSON,
+		Expression: "x_str('//status') == 'SERVING'",
+	}
+	assert.Nil(t, g.Config(global.ProbeSettings{}))
+	assert.Equal(t, "grpc.health.v1.Health/Check", g.Method)
+
+	s, m := g.DoProbe()
+	assert.True(t, s, m)
+
+	hs.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	s, m = g.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Expression is evaluated to false")
+
+	// the text checker
+	g.Contain = "UNKNOWN"
+	s, m = g.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "UNKNOWN")
+	g.Contain = ""
+
+	// invalid request
+	g.Request = `{"unknown": 1}`
+	s, m = g.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "invalid request")
+
+	// the method is not found
+	g.Method = "grpc.health.v1.Health/Unknown"
+	s, m = g.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "not found")
+
+	// the streaming method is not supported
+	g.Method = "grpc.health.v1.Health/Watch"
+	g.Request = ""
+	s, m = g.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "not a unary method")
+
+	// the service is not found
+	g.Method = "unknown.Service/Check"
+	s, m = g.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Error")
+}
+
+func TestGRPCConfig(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	g := newGRPC("")
+	assert.NotNil(t, g.Config(global.ProbeSettings{}))
+
+	g = newGRPC("localhost:50051")
+	g.Method = "Check"
+	assert.NotNil(t, g.Config(global.ProbeSettings{}))
+
+	g = newGRPC("localhost:50051")
+	g.CA = "/path/not/exist"
+	assert.NotNil(t, g.Config(global.ProbeSettings{}))
+
+	g = newGRPC("localhost:50051")
+	g.Secure = true
+	assert.Nil(t, g.Config(global.ProbeSettings{}))
+	assert.Equal(t, "tls", g.creds.Info().SecurityProtocol)
+
+	g = newGRPC("localhost:50051")
+	assert.Nil(t, g.Config(global.ProbeSettings{}))
+	assert.Equal(t, "insecure", g.creds.Info().SecurityProtocol)
+
+	service, method, err := splitMethod("pkg.Service/Method")
+	assert.Nil(t, err)
+	assert.Equal(t, "pkg.Service", service)
+	assert.Equal(t, "Method", method)
+	service, method, err = splitMethod("pkg.Service.Method")
+	assert.Nil(t, err)
+	assert.Equal(t, "pkg.Service", service)
+	assert.Equal(t, "Method", method)
+	_, _, err = splitMethod("pkg.Service.")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// splitMethod splits the full method name "package.Service/Method" into service and method
+func splitMethod(fullMethod string) (string, string, error) {
+	fullMethod = strings.Trim(fullMethod, "/")
+	pos := strings.LastIndexAny(fullMethod, "/.")
+	if pos <= 0 || pos >= len(fullMethod)-1 {
+		return "", "", fmt.Errorf("the method [%s] is invalid, it must be \"package.Service/Method\"", fullMethod)
+	}
+	return fullMethod[:pos], fullMethod[pos+1:], nil
+}
+
+// invokeByReflection resolves the method descriptor by the server reflection,
+// invokes the unary method with the JSON request and returns the JSON response
+func invokeByReflection(ctx context.Context, conn *grpc.ClientConn, fullMethod, request string) (string, error) {
+	service, method, err := splitMethod(fullMethod)
+	if err != nil {
+		return "", err
+	}
+
+	files, err := resolveFiles(ctx, conn, service)
+	if err != nil {
+		return "", err
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return "", fmt.Errorf("service [%s] is not found: %v", service, err)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return "", fmt.Errorf("[%s] is not a service", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return "", fmt.Errorf("method [%s] is not found in service [%s]", method, service)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return "", fmt.Errorf("method [%s] is not a unary method", fullMethod)
+	}
+
+	req := dynamicpb.NewMessage(md.Input())
+	if len(strings.TrimSpace(request)) > 0 {
+		if err := protojson.Unmarshal([]byte(request), req); err != nil {
+			return "", fmt.Errorf("invalid request for %s: %v", md.Input().FullName(), err)
+		}
+	}
+	resp := dynamicpb.NewMessage(md.Output())
+
+	if err := conn.Invoke(ctx, "/"+service+"/"+method, req, resp); err != nil {
+		return "", err
+	}
+
+	buf, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(resp)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// resolveFiles fetches the file descriptors of the symbol and all of its dependencies
+func resolveFiles(ctx context.Context, conn *grpc.ClientConn, symbol string) (*protoregistry.Files, error) {
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+
+	fetch := func(req *rpb.ServerReflectionRequest) ([]*descriptorpb.FileDescriptorProto, error) {
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return nil, fmt.Errorf("server reflection error: %s", e.GetErrorMessage())
+		}
+		var fds []*descriptorpb.FileDescriptorProto
+		for _, buf := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(buf, fd); err != nil {
+				return nil, err
+			}
+			fds = append(fds, fd)
+		}
+		return fds, nil
+	}
+
+	fds, err := fetch(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	all := map[string]*descriptorpb.FileDescriptorProto{}
+	for len(fds) > 0 {
+		fd := fds[0]
+		fds = fds[1:]
+		if _, ok := all[fd.GetName()]; ok {
+			continue
+		}
+		all[fd.GetName()] = fd
+		for _, dep := range fd.GetDependency() {
+			if _, ok := all[dep]; ok {
+				continue
+			}
+			// the well-known types could be resolved locally
+			if d, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+				all[dep] = protodesc.ToFileDescriptorProto(d)
+				continue
+			}
+			deps, err := fetch(&rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+			})
+			if err != nil {
+				return nil, err
+			}
+			fds = append(fds, deps...)
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range all {
+		set.File = append(set.File, fd)
+	}
+	return protodesc.NewFiles(set)
+}
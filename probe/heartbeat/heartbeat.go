@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package heartbeat is the passive heartbeat probe package.
+// The monitored jobs ping the heartbeat URL, and the probe is down if no ping arrives in time.
+package heartbeat
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/gofusion/common/utils"
+
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/metric"
+	"github.com/wfusion/easeprobe/probe/base"
+)
+
+// PathPrefix is the URL path prefix of the heartbeat API
+const PathPrefix = "/api/v1/heartbeat/"
+
+// Event is the heartbeat event type
+type Event int
+
+// The heartbeat events
+const (
+	EventSuccess Event = iota
+	EventStart
+	EventFail
+)
+
+// String convert the event to string
+func (e Event) String() string {
+	switch e {
+	case EventStart:
+		return "start"
+	case EventFail:
+		return "fail"
+	}
+	return "success"
+}
+
+// ParseEvent parse the suffix of the heartbeat URL,
+// it could be empty, "start", "fail" or an exit code.
+func ParseEvent(suffix string) (Event, int, error) {
+	suffix = strings.ToLower(strings.Trim(strings.TrimSpace(suffix), "/"))
+	switch suffix {
+	case "", "success":
+		return EventSuccess, 0, nil
+	case "start":
+		return EventStart, 0, nil
+	case "fail":
+		return EventFail, 0, nil
+	}
+	code, err := strconv.Atoi(suffix)
+	if err != nil || code < 0 || code > 255 {
+		return EventSuccess, 0, fmt.Errorf("invalid heartbeat suffix [%s]", suffix)
+	}
+	if code != 0 {
+		return EventFail, code, nil
+	}
+	return EventSuccess, 0, nil
+}
+
+// Heartbeat implements a config for the passive heartbeat
+type Heartbeat struct {
+	base.DefaultProbe `yaml:",inline"`
+	Grace             time.Duration `yaml:"grace,omitempty" json:"grace,omitempty" jsonschema:"type=string,format=duration,title=Grace Period,description=the extra time to wait for the ping after the interval,default=1m"`
+
+	mu        sync.Mutex `yaml:"-" json:"-"`
+	lastPing  time.Time  `yaml:"-" json:"-"`
+	lastStart time.Time  `yaml:"-" json:"-"`
+	event     Event      `yaml:"-" json:"-"`
+	exitCode  int        `yaml:"-" json:"-"`
+	detail    string     `yaml:"-" json:"-"`
+
+	metrics *metrics `yaml:"-" json:"-"`
+}
+
+// Config Heartbeat Config Object
+func (h *Heartbeat) Config(gConf global.ProbeSettings) error {
+	kind := "heartbeat"
+	tag := ""
+	name := h.ProbeName
+	h.DefaultProbe.Config(gConf, kind, tag, name, h.URL(), h.DoProbe)
+
+	if h.Grace < 0 {
+		return fmt.Errorf("the grace period is negative")
+	}
+	if h.Grace == 0 {
+		h.Grace = global.DefaultHeartbeatGrace
+	}
+
+	// the first ping is expected within the interval and grace period after started
+	h.mu.Lock()
+	h.lastPing = time.Now()
+	h.mu.Unlock()
+
+	h.metrics = newMetrics(h.MetricRegistry(), kind, tag, h.Labels)
+
+	log.Debugf("[%s / %s] configuration: %+v", h.ProbeKind, h.ProbeName, h)
+	return nil
+}
+
+// URL return the path of the heartbeat URL
+func (h *Heartbeat) URL() string {
+	return PathPrefix + url.PathEscape(h.ProbeName)
+}
+
+// Ping receive a heartbeat event from the monitored job
+func (h *Heartbeat) Ping(event Event, code int, detail string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	h.lastPing = now
+	if event == EventStart {
+		h.lastStart = now
+	} else {
+		h.event = event
+		h.exitCode = code
+		h.detail = detail[:utils.Min(len(detail), 256)]
+	}
+
+	log.Debugf("[%s / %s] received the heartbeat [%s], exit code: %d", h.ProbeKind, h.ProbeName, event, code)
+	h.ExportMetrics(event)
+}
+
+// DoProbe return the checking result
+func (h *Heartbeat) DoProbe() (bool, string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	deadline := h.Interval() + h.Grace
+	since := time.Since(h.lastPing)
+
+	// the job started but has not finished in time
+	if !h.lastStart.IsZero() && h.lastStart.Equal(h.lastPing) {
+		if since > deadline {
+			return false, fmt.Sprintf("Job started %s ago but has not finished", since.Round(time.Second))
+		}
+		return true, fmt.Sprintf("Job is running, started %s ago", since.Round(time.Second))
+	}
+
+	if since > deadline {
+		return false, fmt.Sprintf("No heartbeat received in %s (interval %s + grace %s)",
+			since.Round(time.Second), h.Interval(), h.Grace)
+	}
+
+	if h.event == EventFail {
+		message := "Job reported failure"
+		if h.exitCode != 0 {
+			message += fmt.Sprintf(" with exit code %d", h.exitCode)
+		}
+		if len(h.detail) > 0 {
+			message += ": " + h.detail
+		}
+		return false, message
+	}
+
+	message := fmt.Sprintf("Heartbeat received %s ago", since.Round(time.Second))
+	if !h.lastStart.IsZero() {
+		message += fmt.Sprintf(", the job took %s", h.lastPing.Sub(h.lastStart).Round(time.Millisecond))
+	}
+	return true, message
+}
+
+// ExportMetrics export heartbeat metrics
+func (h *Heartbeat) ExportMetrics(event Event) {
+	h.metrics.PingTotal.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     h.ProbeName,
+		"event":    event.String(),
+		"endpoint": h.ProbeResult.Endpoint,
+	}, h.Labels)).Inc()
+
+	h.metrics.LastPing.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     h.ProbeName,
+		"endpoint": h.ProbeResult.Endpoint,
+	}, h.Labels)).Set(float64(h.lastPing.Unix()))
+}
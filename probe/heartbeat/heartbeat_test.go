@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package heartbeat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/probe/base"
+)
+
+func newHeartbeat(name string) *Heartbeat {
+	return &Heartbeat{
+		DefaultProbe: base.DefaultProbe{
+			ProbeName:         name,
+			ProbeTimeInterval: time.Minute,
+		},
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	cases := []struct {
+		suffix string
+		event  Event
+		code   int
+		err    bool
+	}{
+		{"", EventSuccess, 0, false},
+		{"/", EventSuccess, 0, false},
+		{"start", EventStart, 0, false},
+		{"/START/", EventStart, 0, false},
+		{"fail", EventFail, 0, false},
+		{"0", EventSuccess, 0, false},
+		{"1", EventFail, 1, false},
+		{"255", EventFail, 255, false},
+		{"256", EventSuccess, 0, true},
+		{"-1", EventSuccess, 0, true},
+		{"unknown", EventSuccess, 0, true},
+	}
+	for _, c := range cases {
+		e, code, err := ParseEvent(c.suffix)
+		assert.Equal(t, c.event, e, c.suffix)
+		assert.Equal(t, c.code, code, c.suffix)
+		assert.Equal(t, c.err, err != nil, c.suffix)
+	}
+
+	assert.Equal(t, "success", EventSuccess.String())
+	assert.Equal(t, "start", EventStart.String())
+	assert.Equal(t, "fail", EventFail.String())
+}
+
+func TestHeartbeat(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	h := newHeartbeat("nightly backup")
+	assert.Nil(t, h.Config(global.ProbeSettings{}))
+	assert.Equal(t, "heartbeat", h.ProbeKind)
+	assert.Equal(t, global.DefaultHeartbeatGrace, h.Grace)
+	assert.Equal(t, "/api/v1/heartbeat/nightly%20backup", h.Result().Endpoint)
+
+	// waiting for the first ping
+	s, m := h.DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "Heartbeat received")
+
+	h.Ping(EventSuccess, 0, "")
+	s, _ = h.DoProbe()
+	assert.True(t, s)
+
+	// the job reports the failure
+	h.Ping(EventFail, 2, "disk is full")
+	s, m = h.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "exit code 2: disk is full")
+
+	// the job is running
+	h.Ping(EventStart, 0, "")
+	s, m = h.DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "Job is running")
+
+	// the job is finished
+	h.Ping(EventSuccess, 0, "")
+	s, m = h.DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "the job took")
+
+	// the job started but has not finished in time
+	h.Ping(EventStart, 0, "")
+	h.lastStart = time.Now().Add(-3 * time.Minute)
+	h.lastPing = h.lastStart
+	s, m = h.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "has not finished")
+
+	// no ping is received in time
+	h.Ping(EventSuccess, 0, "")
+	h.lastPing = time.Now().Add(-2*time.Minute - time.Second)
+	s, m = h.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "No heartbeat received")
+
+	// the probe result goes through the status accounting
+	r := h.Probe()
+	assert.Equal(t, probe.StatusDown, r.Status)
+	h.Ping(EventSuccess, 0, "")
+	r = h.Probe()
+	assert.Equal(t, probe.StatusUp, r.Status)
+}
+
+func TestHeartbeatConfig(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	h := newHeartbeat("job")
+	h.Grace = -time.Second
+	assert.NotNil(t, h.Config(global.ProbeSettings{}))
+
+	h = newHeartbeat("job")
+	h.Grace = 10 * time.Second
+	assert.Nil(t, h.Config(global.ProbeSettings{}))
+	assert.Equal(t, 10*time.Second, h.Grace)
+	assert.Equal(t, "/api/v1/heartbeat/job", h.URL())
+}
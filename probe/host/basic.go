@@ -85,6 +85,11 @@ func (b *Basic) CheckThreshold() (bool, string) {
 	return true, ""
 }
 
+// CheckWarning has no warning threshold for the basic info
+func (b *Basic) CheckWarning() (bool, string) {
+	return true, ""
+}
+
 // CreateMetrics create the cpu metrics
 func (b *Basic) CreateMetrics(subsystem, name string) {
 	namespace := global.GetEaseProbe().Name
@@ -32,6 +32,7 @@ type IMetrics interface {
 	Parse(s []string) error         // Parse a string to a metrics struct
 	UsageInfo() string              // UsageInfo returns the usage info of the metrics
 	CheckThreshold() (bool, string) // CheckThreshold check the metrics usage
+	CheckWarning() (bool, string)   // CheckWarning check the metrics usage with the warning threshold
 	CreateMetrics(kind, tag string) // CreateMetrics creates the metrics
 	ExportMetrics(name string)      // ExportMetrics export the metrics
 }
@@ -43,6 +43,7 @@ type CPU struct {
 	Steal             float64 `yaml:"steal"`
 
 	Threshold float64 `yaml:"threshold"`
+	Warning   float64 `yaml:"warning"`
 	metrics   *prometheus.GaugeVec
 }
 
@@ -74,6 +75,7 @@ func (c *CPU) Config(s *Server) {
 // SetThreshold set the cpu threshold
 func (c *CPU) SetThreshold(t *Threshold) {
 	c.Threshold = t.CPU
+	c.Warning = t.Warning.CPU
 }
 
 // Parse a string to a CPU struct
@@ -109,6 +111,14 @@ func (c *CPU) CheckThreshold() (bool, string) {
 	return true, ""
 }
 
+// CheckWarning check the cpu usage with the warning threshold
+func (c *CPU) CheckWarning() (bool, string) {
+	if c.Warning > 0 && c.Warning <= (100-c.Idle)/100 {
+		return false, "CPU threshold warning!"
+	}
+	return true, ""
+}
+
 // CreateMetrics create the cpu metrics
 func (c *CPU) CreateMetrics(subsystem, name string) {
 	namespace := global.GetEaseProbe().Name
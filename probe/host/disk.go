@@ -36,6 +36,7 @@ type Disks struct {
 	Usage []ResourceUsage
 
 	Threshold float64
+	Warning   float64
 	metrics   *prometheus.GaugeVec
 }
 
@@ -73,6 +74,7 @@ func (d *Disks) Config(s *Server) {
 // SetThreshold set the threshold of the disk
 func (d *Disks) SetThreshold(t *Threshold) {
 	d.Threshold = t.Disk
+	d.Warning = t.Warning.Disk
 }
 
 // Parse a string to a CPU struct
@@ -119,6 +121,20 @@ func (d *Disks) CheckThreshold() (bool, string) {
 	return true, ""
 }
 
+// CheckWarning check the disk usage with the warning threshold
+func (d *Disks) CheckWarning() (bool, string) {
+	lowDisks := []string{}
+	for _, disk := range d.Usage {
+		if d.Warning > 0 && d.Warning <= disk.Usage/100 {
+			lowDisks = append(lowDisks, disk.Tag)
+		}
+	}
+	if len(lowDisks) > 0 {
+		return false, fmt.Sprintf("Disk Space threshold warning! - [%s]", strings.Join(lowDisks, ", "))
+	}
+	return true, ""
+}
+
 // CreateMetrics create the disk metrics
 func (d *Disks) CreateMetrics(subsystem, name string) {
 	namespace := global.GetEaseProbe().Name
@@ -74,18 +74,28 @@ func (s *Server) Config(gConf global.ProbeSettings) error {
 
 	endpoint := s.Threshold.String()
 	err := s.Configure(gConf, kind, tag, name, endpoint, &BastionMap, s.DoProbe)
+	s.ProbeStatusFunc = nil
+	if s.Threshold.Warning.IsEnabled() {
+		s.ProbeStatusFunc = s.DoProbeStatus
+	}
 	log.Debugf("[%s / %s] configuration: %+v", s.ProbeKind, s.ProbeName, *s)
 	return err
 }
 
 // DoProbe return the checking result
 func (s *Server) DoProbe() (bool, string) {
+	status, message := s.DoProbeStatus()
+	return status.IsAvailable(), message
+}
+
+// DoProbeStatus return the checking result with the warning status
+func (s *Server) DoProbeStatus() (probe.Status, string) {
 
 	output, err := s.RunSSHCmd()
 
 	if err != nil {
 		log.Errorf("[%s / %s] %v", s.ProbeKind, s.ProbeName, err)
-		return false, err.Error() + " - " + output
+		return probe.StatusDown, err.Error() + " - " + output
 	}
 
 	log.Debugf("[%s / %s] - %s", s.ProbeKind, s.ProbeName, global.CommandLine(s.Command, s.Args))
@@ -94,11 +104,14 @@ func (s *Server) DoProbe() (bool, string) {
 	info, err := s.ParseHostInfo(string(output))
 	if err != nil {
 		log.Errorf("[%s / %s] %v", s.ProbeKind, s.ProbeName, err)
-		return false, fmt.Sprintf("Prase the output failed: %v", err)
+		return probe.StatusDown, fmt.Sprintf("Prase the output failed: %v", err)
 	}
 	log.Debugf("[%s / %s] - %+v", s.ProbeKind, s.ProbeName, info)
 	s.ExportMetrics()
-	return s.CheckThreshold(info)
+	if status, message := s.CheckThreshold(info); status == false {
+		return probe.StatusDown, message
+	}
+	return s.CheckWarning(info)
 }
 
 // Usage return all of the resources usage
@@ -134,6 +147,26 @@ func (s *Server) CheckThreshold(info Info) (bool, string) {
 	return status, message + s.Usage(info)
 }
 
+// CheckWarning check the warning threshold
+func (s *Server) CheckWarning(info Info) (probe.Status, string) {
+	status := probe.StatusUp
+	message := ""
+
+	for _, metric := range s.hostMetrics {
+		s, m := metric.CheckWarning()
+		if s == false {
+			status = probe.StatusWarning
+			message = addMessage(message, m)
+		}
+	}
+
+	if message == "" {
+		message = "Fine!"
+	}
+
+	return status, message + s.Usage(info)
+}
+
 // ParseHostInfo parse the host info
 func (s *Server) ParseHostInfo(str string) (Info, error) {
 	line := strings.Split(str, "\n")
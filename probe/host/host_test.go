@@ -25,6 +25,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
 	"github.com/wfusion/easeprobe/probe/base"
 	"github.com/wfusion/easeprobe/probe/ssh"
 	"github.com/wfusion/gofusion/common/utils/gomonkey"
@@ -287,3 +288,47 @@ func TestBadParse(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid load average output")
 }
+
+func TestHostWarning(t *testing.T) {
+	host := newHost(t)
+	server := &host.Servers[0]
+	server.Config(global.ProbeSettings{})
+	assert.Nil(t, server.ProbeStatusFunc)
+
+	var s *ssh.Server
+	defer gomonkey.ApplyMethod(reflect.TypeOf(s), "RunSSHCmd", func(_ *ssh.Server) (string, error) {
+		return hostInfo, nil
+	}).Reset()
+
+	server.Threshold.Warning.CPU = 0.5
+	server.Config(global.ProbeSettings{})
+	assert.NotNil(t, server.ProbeStatusFunc)
+	status, message := server.DoProbeStatus()
+	assert.Equal(t, probe.StatusWarning, status)
+	assert.Contains(t, message, "CPU threshold warning!")
+	s2, _ := server.DoProbe()
+	assert.True(t, s2)
+
+	server.Threshold.Warning.Mem = 0.2
+	server.Threshold.Warning.Disk = 0.2
+	server.Config(global.ProbeSettings{})
+	status, message = server.DoProbeStatus()
+	assert.Equal(t, probe.StatusWarning, status)
+	assert.Contains(t, message, "Memory threshold warning!")
+	assert.Contains(t, message, "Disk Space threshold warning!")
+
+	// the threshold alert takes precedence over the warning
+	server.Threshold.CPU = 0.5
+	server.Config(global.ProbeSettings{})
+	status, message = server.DoProbeStatus()
+	assert.Equal(t, probe.StatusDown, status)
+	assert.Contains(t, message, "CPU threshold alert!")
+	assert.NotContains(t, message, "warning")
+
+	// no warning if the usage is below the warning threshold
+	server.Threshold = Threshold{Warning: WarningThreshold{CPU: 0.9, Mem: 0.9, Disk: 0.9}}
+	server.Config(global.ProbeSettings{})
+	status, message = server.DoProbeStatus()
+	assert.Equal(t, probe.StatusUp, status)
+	assert.Contains(t, message, "Fine")
+}
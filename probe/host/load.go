@@ -127,6 +127,11 @@ func (l *Load) CheckThreshold() (bool, string) {
 	return true, ""
 }
 
+// CheckWarning has no warning threshold for the load average
+func (l *Load) CheckWarning() (bool, string) {
+	return true, ""
+}
+
 // CreateMetrics create the load average metrics
 func (l *Load) CreateMetrics(subsystem, name string) {
 	namespace := global.GetEaseProbe().Name
@@ -35,6 +35,7 @@ type Mem struct {
 	ResourceUsage     `yaml:",inline"`
 
 	Threshold float64 `yaml:"threshold"`
+	Warning   float64 `yaml:"warning"`
 	metrics   *prometheus.GaugeVec
 }
 
@@ -66,6 +67,7 @@ func (m *Mem) Config(s *Server) {
 // SetThreshold set the threshold of the memory
 func (m *Mem) SetThreshold(t *Threshold) {
 	m.Threshold = t.Mem
+	m.Warning = t.Warning.Mem
 }
 
 // Parse a string to a Memory struct
@@ -96,6 +98,14 @@ func (m *Mem) CheckThreshold() (bool, string) {
 	return true, ""
 }
 
+// CheckWarning check the memory usage with the warning threshold
+func (m *Mem) CheckWarning() (bool, string) {
+	if m.Warning > 0 && m.Warning <= m.Usage/100 {
+		return false, "Memory threshold warning!"
+	}
+	return true, ""
+}
+
 // CreateMetrics create the memory metrics
 func (m *Mem) CreateMetrics(subsystem, name string) {
 	namespace := global.GetEaseProbe().Name
@@ -36,6 +36,22 @@ type Threshold struct {
 	Mem  float64            `yaml:"mem,omitempty" json:"mem,omitempty" jsonschema:"title=Memory threshold,description=Memory threshold (default: 0.8)"`
 	Disk float64            `yaml:"disk,omitempty" json:"disk,omitempty" jsonschema:"title=Disk threshold,description=Disk threshold (default: 0.95)"`
 	Load map[string]float64 `yaml:"load,omitempty" json:"load,omitempty" jsonschema:"title=Load average threshold,description=Load Average M1/M5/M15 threshold (default: 0.8)"`
+
+	Warning WarningThreshold `yaml:"warning,omitempty" json:"warning,omitempty" jsonschema:"title=Warning threshold,description=the soft threshold of the probe for cpu/memory/disk"`
+}
+
+// WarningThreshold is the soft threshold of a probe,
+// the probe is in warning status if the usage reaches it but not the threshold.
+// The zero value means the warning is disabled.
+type WarningThreshold struct {
+	CPU  float64 `yaml:"cpu,omitempty" json:"cpu,omitempty" jsonschema:"title=CPU warning threshold,description=CPU warning threshold"`
+	Mem  float64 `yaml:"mem,omitempty" json:"mem,omitempty" jsonschema:"title=Memory warning threshold,description=Memory warning threshold"`
+	Disk float64 `yaml:"disk,omitempty" json:"disk,omitempty" jsonschema:"title=Disk warning threshold,description=Disk warning threshold"`
+}
+
+// IsEnabled return true if any of the warning threshold is set
+func (w *WarningThreshold) IsEnabled() bool {
+	return w.CPU > 0 || w.Mem > 0 || w.Disk > 0
 }
 
 func (t *Threshold) String() string {
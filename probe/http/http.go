@@ -29,6 +29,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
@@ -68,6 +69,9 @@ type HTTP struct {
 	// Option - TLS Config
 	global.TLS `yaml:",inline"`
 
+	// Option - the probe is in warning status if the response is slower than it
+	WarningLatency time.Duration `yaml:"warning_latency,omitempty" json:"warning_latency,omitempty" jsonschema:"type=string,format=duration,title=Warning Latency,description=the probe is in warning status if the response time is longer than it"`
+
 	client *http.Client `yaml:"-" json:"-"`
 
 	traceStats *TraceStats `yaml:"-" json:"-"`
@@ -173,7 +177,14 @@ func (h *HTTP) Config(gConf global.ProbeSettings) error {
 		}
 	}
 
-	h.metrics = newMetrics(kind, tag, h.Labels)
+	if h.WarningLatency < 0 {
+		return fmt.Errorf("the warning latency is negative")
+	}
+	if h.WarningLatency > 0 {
+		h.ProbeStatusFunc = h.DoProbeStatus
+	}
+
+	h.metrics = newMetrics(h.MetricRegistry(), kind, tag, h.Labels)
 
 	log.Debugf("[%s / %s] configuration: %+v", h.ProbeKind, h.ProbeName, *h)
 	return nil
@@ -275,6 +286,21 @@ func (h *HTTP) DoProbe() (bool, string) {
 	return result, message
 }
 
+// DoProbeStatus return the checking result with the warning status,
+// the probe is in warning status if the response time exceeds the warning latency.
+func (h *HTTP) DoProbeStatus() (probe.Status, string) {
+	stat, message := h.DoProbe()
+	if !stat {
+		return probe.StatusDown, message
+	}
+	if h.WarningLatency > 0 && h.traceStats != nil && h.traceStats.totalTook >= h.WarningLatency {
+		message += fmt.Sprintf(". Response time %s exceeds the warning latency %s",
+			h.traceStats.totalTook.Round(time.Millisecond), h.WarningLatency)
+		return probe.StatusWarning, message
+	}
+	return probe.StatusUp, message
+}
+
 // ExportMetrics export HTTP metrics
 func (h *HTTP) ExportMetrics(resp *http.Response) {
 	code := 0 // no response
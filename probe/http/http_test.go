@@ -25,9 +25,11 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -281,3 +283,45 @@ func TestHTTPDoProbe(t *testing.T) {
 	assert.NotContains(t, m, "200")
 
 }
+
+func TestHTTPWarningLatency(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	h := createSimpleHTTP()
+	h.WarningLatency = -time.Second
+	assert.Error(t, h.Config(global.ProbeSettings{}))
+
+	h = createSimpleHTTP()
+	h.URL = server.URL + "/slow"
+	h.Headers = nil
+	h.WarningLatency = 20 * time.Millisecond
+	assert.NoError(t, h.Config(global.ProbeSettings{}))
+	assert.NotNil(t, h.ProbeStatusFunc)
+
+	s, m := h.DoProbeStatus()
+	assert.Equal(t, probe.StatusWarning, s)
+	assert.Contains(t, m, "exceeds the warning latency")
+	r := h.Probe()
+	assert.Equal(t, probe.StatusWarning, r.Status)
+
+	h.URL = server.URL + "/fast"
+	h.WarningLatency = time.Second
+	s, _ = h.DoProbeStatus()
+	assert.Equal(t, probe.StatusUp, s)
+
+	h.URL = "http://127.0.0.1:1/not-exist"
+	s, _ = h.DoProbeStatus()
+	assert.Equal(t, probe.StatusDown, s)
+
+	// no warning latency, the ProbeStatusFunc is not set
+	h = createSimpleHTTP()
+	assert.NoError(t, h.Config(global.ProbeSettings{}))
+	assert.Nil(t, h.ProbeStatusFunc)
+}
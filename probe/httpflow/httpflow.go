@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package httpflow is the multi-step HTTP transaction probe package.
+package httpflow
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/gofusion/common/utils"
+
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/metric"
+	"github.com/wfusion/easeprobe/probe/base"
+)
+
+// HTTPFlow implements a config for the multi-step HTTP transaction
+type HTTPFlow struct {
+	base.DefaultProbe `yaml:",inline"`
+	Proxy             string `yaml:"proxy,omitempty" json:"proxy,omitempty" jsonschema:"format=url,title=Proxy Server,description=proxy to use for all of the HTTP requests"`
+	Steps             []Step `yaml:"steps" json:"steps" jsonschema:"required,title=Steps,description=the HTTP requests which are sent in order"`
+
+	// Option - TLS Config
+	global.TLS `yaml:",inline"`
+
+	transport *http.Transport `yaml:"-" json:"-"`
+
+	metrics *metrics `yaml:"-" json:"-"`
+}
+
+// Config HTTPFlow Config Object
+func (f *HTTPFlow) Config(gConf global.ProbeSettings) error {
+	kind := "http_flow"
+	tag := ""
+	name := f.ProbeName
+	endpoint := ""
+	if len(f.Steps) > 0 {
+		endpoint = f.Steps[0].URL
+	}
+	f.DefaultProbe.Config(gConf, kind, tag, name, endpoint, f.DoProbe)
+
+	if len(f.Steps) <= 0 {
+		return fmt.Errorf("the steps are empty")
+	}
+
+	tls, err := f.TLS.Config()
+	if err != nil {
+		log.Errorf("[%s / %s] TLS configuration error - %s", f.ProbeKind, f.ProbeName, err)
+		return err
+	}
+	f.transport = &http.Transport{
+		TLSClientConfig: tls,
+		Proxy:           http.ProxyFromEnvironment,
+	}
+	if len(strings.TrimSpace(f.Proxy)) > 0 {
+		proxyURL, err := url.Parse(f.Proxy)
+		if err != nil {
+			log.Errorf("[%s / %s] proxy URL is not valid - %+v", f.ProbeKind, f.ProbeName, err)
+			return err
+		}
+		f.transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	for i := range f.Steps {
+		if err := f.Steps[i].Config(i); err != nil {
+			log.Errorf("[%s / %s] step configuration error - %v", f.ProbeKind, f.ProbeName, err)
+			return err
+		}
+	}
+
+	f.metrics = newMetrics(f.MetricRegistry(), kind, tag, f.Labels)
+
+	log.Debugf("[%s / %s] configuration: %+v", f.ProbeKind, f.ProbeName, *f)
+	return nil
+}
+
+// DoProbe return the checking result
+func (f *HTTPFlow) DoProbe() (bool, string) {
+	// every run has its own cookies and variables
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{
+		Timeout:   f.Timeout(),
+		Transport: f.transport,
+		Jar:       jar,
+	}
+	vars := map[string]string{}
+
+	start := time.Now()
+	for i := range f.Steps {
+		s := &f.Steps[i]
+		code, took, err := s.Do(client, vars)
+		f.ExportMetrics(s, code, took)
+		if err != nil {
+			log.Errorf("[%s / %s] step [%d: %s] failed - %v", f.ProbeKind, f.ProbeName, i+1, s.Name, err)
+			message := fmt.Sprintf("Step [%d: %s] failed - %v", i+1, s.Name, err)
+			return false, message[:utils.Min(len(message), 256)]
+		}
+		log.Debugf("[%s / %s] step [%d: %s] passed in %s", f.ProbeKind, f.ProbeName, i+1, s.Name, took)
+	}
+
+	return true, fmt.Sprintf("All %d steps passed in %s", len(f.Steps), time.Since(start).Round(time.Millisecond))
+}
+
+// ExportMetrics export the metrics of the step
+func (f *HTTPFlow) ExportMetrics(s *Step, code int, took time.Duration) {
+	f.metrics.StatusCode.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     f.ProbeName,
+		"step":     s.Name,
+		"status":   fmt.Sprintf("%d", code),
+		"endpoint": f.ProbeResult.Endpoint,
+	}, f.Labels)).Inc()
+
+	f.metrics.StepDuration.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     f.ProbeName,
+		"step":     s.Name,
+		"endpoint": f.ProbeResult.Endpoint,
+	}, f.Labels)).Set(float64(took.Milliseconds()))
+}
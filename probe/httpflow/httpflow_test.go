@@ -0,0 +1,241 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpflow
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/eval"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/probe/base"
+)
+
+func newServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || string(body) != `{"user":"easeprobe"}` {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s-123", Path: "/"})
+		w.Header().Set("X-Request-Id", "req-1")
+		w.Write([]byte(`{"data": {"token": "t-456", "user": "easeprobe"}}`))
+	})
+	mux.HandleFunc("/api/items", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t-456" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if c, err := r.Cookie("session"); err != nil || c.Value != "s-123" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"items": [{"id": 1}, {"id": 2}], "owner": "easeprobe"}`))
+	})
+	mux.HandleFunc("/logout/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/logout/s-123" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("bye"))
+	})
+	return httptest.NewServer(mux)
+}
+
+func newFlow(url string) *HTTPFlow {
+	return &HTTPFlow{
+		DefaultProbe: base.DefaultProbe{
+			ProbeName:    "dummy flow",
+			ProbeTimeout: 2 * time.Second,
+		},
+		Steps: []Step{
+			{
+				Name:   "login",
+				URL:    url + "/login",
+				Method: "post",
+				Body:   `{"user":"easeprobe"}`,
+				Capture: []Capture{
+					{Name: "token", Query: "//data/token"},
+					{Name: "session", From: "cookie", Key: "session"},
+					{Name: "request_id", From: "header", Key: "X-Request-Id"},
+				},
+			},
+			{
+				Name:        "list items",
+				URL:         url + "/api/items",
+				Headers:     map[string]string{"Authorization": "Bearer {{token}}"},
+				SuccessCode: [][]int{{200, 299}},
+				TextChecker: probe.TextChecker{Contain: "items"},
+				Evaluator: eval.Evaluator{
+					DocType:    eval.JSON,
+					Expression: "x_len('//items') == 2 && x_str('//owner') == '{{ user }}'",
+				},
+			},
+			{
+				URL:         url + "/logout/{{session}}",
+				SuccessCode: [][]int{{200, 299}},
+			},
+		},
+	}
+}
+
+func TestHTTPFlow(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	server := newServer()
+	defer server.Close()
+
+	f := newFlow(server.URL)
+	f.Steps[0].Capture = append(f.Steps[0].Capture, Capture{Name: "user", Query: "//data/user"})
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	assert.Equal(t, "http_flow", f.ProbeKind)
+	assert.Equal(t, server.URL+"/login", f.Result().Endpoint)
+	assert.Equal(t, "POST", f.Steps[0].Method)
+	assert.Equal(t, "step-3", f.Steps[2].Name)
+	assert.Equal(t, FromBody, f.Steps[0].Capture[0].From)
+	assert.Equal(t, eval.JSON, f.Steps[0].Capture[0].Doc)
+
+	s, m := f.DoProbe()
+	assert.True(t, s, m)
+	assert.Contains(t, m, "All 3 steps passed")
+
+	// the variables are not shared between the runs
+	s, m = f.DoProbe()
+	assert.True(t, s, m)
+
+	// the text checker of the step
+	f.Steps[1].Contain = "nothing"
+	s, m = f.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Step [2: list items] failed")
+	f.Steps[1].Contain = ""
+
+	// the evaluator of the step
+	f.Steps[0].Capture[3].Query = "//data/token"
+	s, m = f.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Step [2: list items] failed - expression is evaluated to false")
+}
+
+func TestHTTPFlowFailure(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	server := newServer()
+	defer server.Close()
+
+	// the status code of the first step
+	f := newFlow(server.URL)
+	f.Steps[0].Body = `{"user":"nobody"}`
+	f.Steps[0].SuccessCode = [][]int{{200, 299}}
+	f.Steps[1].Evaluator = eval.Evaluator{}
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	s, m := f.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Step [1: login] failed - HTTP Status Code is 401")
+
+	// the captured value is not found
+	f = newFlow(server.URL)
+	f.Steps[0].Capture[2].Key = "X-Not-Exist"
+	f.Steps[1].Evaluator = eval.Evaluator{}
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	s, m = f.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "capture [request_id] error")
+
+	f = newFlow(server.URL)
+	f.Steps[0].Capture[1].Key = "not_exist"
+	f.Steps[1].Evaluator = eval.Evaluator{}
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	s, m = f.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "capture [session] error")
+
+	// the server is down
+	f = newFlow(server.URL)
+	f.Steps[1].Evaluator = eval.Evaluator{}
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	server.Close()
+	s, m = f.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Step [1: login] failed")
+}
+
+func TestHTTPFlowConfig(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	f := &HTTPFlow{DefaultProbe: base.DefaultProbe{ProbeName: "dummy flow"}}
+	assert.NotNil(t, f.Config(global.ProbeSettings{}))
+
+	cases := []func(f *HTTPFlow){
+		func(f *HTTPFlow) { f.Steps[0].URL = "not a url" },
+		func(f *HTTPFlow) { f.Steps[0].Method = "CONNECT" },
+		func(f *HTTPFlow) { f.Steps[0].SuccessCode = [][]int{{200}} },
+		func(f *HTTPFlow) { f.Steps[0].Capture[0].Name = "" },
+		func(f *HTTPFlow) { f.Steps[0].Capture[0].Query = "" },
+		func(f *HTTPFlow) { f.Steps[0].Capture[1].Key = "" },
+		func(f *HTTPFlow) { f.Steps[0].Capture[1].From = "query" },
+		func(f *HTTPFlow) { f.Steps[1].RegExp = true; f.Steps[1].Contain = "[" },
+		func(f *HTTPFlow) { f.CA = "/path/not/exist" },
+		func(f *HTTPFlow) { f.Proxy = "://invalid" },
+	}
+	for i, c := range cases {
+		f := newFlow("http://localhost")
+		c(f)
+		assert.NotNil(t, f.Config(global.ProbeSettings{}), fmt.Sprintf("case %d", i))
+	}
+
+	// the URL references the variable is not validated
+	f = newFlow("http://localhost")
+	f.Steps[1].URL = "{{host}}/api"
+	f.Proxy = "socks5://localhost:1080"
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+
+	vars := map[string]string{"a": "1", "b_2": "x"}
+	assert.Equal(t, "1-x-{{c}}", render("{{a}}-{{ b_2 }}-{{c}}", vars))
+}
+
+func TestHTTPFlowConcurrent(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	server := newServer()
+	defer server.Close()
+
+	f := newFlow(server.URL)
+	f.Steps[0].Capture = append(f.Steps[0].Capture, Capture{Name: "user", Query: "//data/user"})
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	expression := f.Steps[1].Evaluator.Expression
+
+	// the scheduled probe and the immediate run share the steps
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, m := f.DoProbe()
+			assert.True(t, s, m)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, expression, f.Steps[1].Evaluator.Expression)
+}
@@ -0,0 +1,283 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpflow
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/wfusion/easeprobe/eval"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+// The sources of the captured value
+const (
+	FromBody   = "body"
+	FromHeader = "header"
+	FromCookie = "cookie"
+)
+
+// varRegex matches the variable reference - {{name}}
+// Note: the "${name}" form is not used, because it is expanded as environment variable in the configuration file.
+var varRegex = regexp.MustCompile(`{{\s*([A-Za-z_][A-Za-z0-9_]*)\s*}}`)
+
+// Capture extracts a value from the response as a variable for the later steps
+type Capture struct {
+	Name  string       `yaml:"name" json:"name" jsonschema:"required,title=Variable Name,description=the variable name referenced by {{name}} in the later steps"`
+	From  string       `yaml:"from,omitempty" json:"from,omitempty" jsonschema:"enum=body,enum=header,enum=cookie,title=From,description=where the value is extracted from,default=body"`
+	Key   string       `yaml:"key,omitempty" json:"key,omitempty" jsonschema:"title=Key,description=the header or cookie name"`
+	Doc   eval.DocType `yaml:"doc,omitempty" json:"doc,omitempty" jsonschema:"type=string,enum=html,enum=xml,enum=json,enum=text,title=Document Type,description=the document type of the body,default=json"`
+	Query string       `yaml:"query,omitempty" json:"query,omitempty" jsonschema:"title=Query,description=XPath/Regex expression to extract the value from the body"`
+}
+
+// Step is one HTTP request of the flow
+type Step struct {
+	Name            string            `yaml:"name,omitempty" json:"name,omitempty" jsonschema:"title=Step Name,description=the name of the step"`
+	URL             string            `yaml:"url" json:"url" jsonschema:"required,title=HTTP URL,description=HTTP URL of the step, could reference the variables"`
+	Method          string            `yaml:"method,omitempty" json:"method,omitempty" jsonschema:"enum=GET,enum=POST,enum=DELETE,enum=PUT,enum=HEAD,enum=OPTIONS,enum=PATCH,title=HTTP Method,description=HTTP method of the step"`
+	ContentEncoding string            `yaml:"content_encoding,omitempty" json:"content_encoding,omitempty" jsonschema:"title=Content Encoding,description=content encoding of the step request"`
+	Headers         map[string]string `yaml:"headers,omitempty" json:"headers,omitempty" jsonschema:"title=HTTP Headers,description=HTTP headers of the step, could reference the variables"`
+	Body            string            `yaml:"body,omitempty" json:"body,omitempty" jsonschema:"title=HTTP Body,description=HTTP body of the step, could reference the variables"`
+	User            string            `yaml:"username,omitempty" json:"username,omitempty" jsonschema:"title=HTTP Basic Auth Username,description=HTTP Basic Auth Username"`
+	Pass            string            `yaml:"password,omitempty" json:"password,omitempty" jsonschema:"title=HTTP Basic Auth Password,description=HTTP Basic Auth Password"`
+
+	// Option - Preferred HTTP response code ranges
+	// If not set, default is [0, 499].
+	SuccessCode [][]int `yaml:"success_code,omitempty" json:"success_code,omitempty" jsonschema:"title=HTTP Success Code Range,description=Preferred HTTP response code ranges.  If not set the default is [0\\, 499]."`
+
+	// Output Text Checker
+	probe.TextChecker `yaml:",inline"`
+
+	// Evaluator, the expression could reference the variables
+	Evaluator eval.Evaluator `yaml:"eval,omitempty" json:"eval,omitempty" jsonschema:"title=HTTP Evaluator,description=HTTP evaluator of the step response"`
+
+	// Capture the variables from the response
+	Capture []Capture `yaml:"capture,omitempty" json:"capture,omitempty" jsonschema:"title=Capture,description=the variables captured from the response"`
+}
+
+func checkHTTPMethod(m string) bool {
+	methods := [...]string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	for _, method := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Config the step, the index is used for the default name
+func (s *Step) Config(index int) error {
+	s.Name = strings.TrimSpace(s.Name)
+	if len(s.Name) <= 0 {
+		s.Name = fmt.Sprintf("step-%d", index+1)
+	}
+
+	// the URL could not be validated if it references the variables
+	if !varRegex.MatchString(s.URL) {
+		if _, err := url.ParseRequestURI(s.URL); err != nil {
+			return fmt.Errorf("step [%s] URL is not valid - %v", s.Name, err)
+		}
+	}
+
+	if len(s.Method) <= 0 {
+		s.Method = "GET"
+	}
+	if !checkHTTPMethod(s.Method) {
+		return fmt.Errorf("step [%s] method [%s] is not supported", s.Name, s.Method)
+	}
+	s.Method = strings.ToUpper(s.Method)
+
+	var codeRange [][]int
+	for _, r := range s.SuccessCode {
+		if len(r) != 2 {
+			return fmt.Errorf("step [%s] success code range is not valid - %v", s.Name, r)
+		}
+		codeRange = append(codeRange, []int{r[0], r[1]})
+	}
+	if len(codeRange) == 0 {
+		codeRange = [][]int{{0, 499}}
+	}
+	s.SuccessCode = codeRange
+
+	if err := s.TextChecker.Config(); err != nil {
+		return err
+	}
+
+	// if the evaluator is set, config it
+	if s.Evaluator.DocType != eval.Unsupported && len(strings.TrimSpace(s.Evaluator.Expression)) > 0 {
+		if err := s.Evaluator.Config(); err != nil {
+			return err
+		}
+	}
+
+	for i := range s.Capture {
+		c := &s.Capture[i]
+		if len(strings.TrimSpace(c.Name)) <= 0 {
+			return fmt.Errorf("step [%s] capture name is empty", s.Name)
+		}
+		c.From = strings.ToLower(strings.TrimSpace(c.From))
+		switch c.From {
+		case "", FromBody:
+			c.From = FromBody
+			if len(c.Query) <= 0 {
+				return fmt.Errorf("step [%s] capture [%s] query is empty", s.Name, c.Name)
+			}
+			if c.Doc == eval.Unsupported {
+				c.Doc = eval.JSON
+			}
+		case FromHeader, FromCookie:
+			if len(c.Key) <= 0 {
+				return fmt.Errorf("step [%s] capture [%s] key is empty", s.Name, c.Name)
+			}
+		default:
+			return fmt.Errorf("step [%s] capture [%s] from [%s] is not supported", s.Name, c.Name, c.From)
+		}
+	}
+	return nil
+}
+
+// render replaces the variable references with the captured values
+func render(str string, vars map[string]string) string {
+	return varRegex.ReplaceAllStringFunc(str, func(m string) string {
+		name := varRegex.FindStringSubmatch(m)[1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+// Do send the request of the step, check the response and capture the variables.
+// it returns the status code and the duration of the request.
+func (s *Step) Do(client *http.Client, vars map[string]string) (int, time.Duration, error) {
+	req, err := http.NewRequest(s.Method, render(s.URL, vars), bytes.NewBufferString(render(s.Body, vars)))
+	if err != nil {
+		return 0, 0, fmt.Errorf("HTTP request error - %v", err)
+	}
+	if len(s.User) > 0 && len(s.Pass) > 0 {
+		req.SetBasicAuth(render(s.User, vars), render(s.Pass, vars))
+	}
+	if len(s.ContentEncoding) > 0 {
+		req.Header.Set("Content-Type", s.ContentEncoding)
+	}
+	req.Header.Set("User-Agent", global.OrgProgVer)
+	for k, v := range s.Headers {
+		if strings.EqualFold(k, "host") {
+			req.Host = render(v, vars)
+		} else {
+			req.Header.Set(k, render(v, vars))
+		}
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, time.Since(start), err
+	}
+	defer resp.Body.Close()
+	response, err := io.ReadAll(resp.Body)
+	took := time.Since(start)
+	if err != nil {
+		return resp.StatusCode, took, err
+	}
+
+	var valid bool
+	for _, r := range s.SuccessCode {
+		if r[0] <= resp.StatusCode && resp.StatusCode <= r[1] {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return resp.StatusCode, took, fmt.Errorf("HTTP Status Code is %d. It missed in %v", resp.StatusCode, s.SuccessCode)
+	}
+
+	if err := s.Check(string(response)); err != nil {
+		return resp.StatusCode, took, err
+	}
+
+	if s.Evaluator.DocType != eval.Unsupported && s.Evaluator.Extractor != nil &&
+		len(strings.TrimSpace(s.Evaluator.Expression)) > 0 {
+		// the step is shared by the concurrent runs, so every run has its own evaluator
+		ev := eval.NewEvaluator(string(response), s.Evaluator.DocType, render(s.Evaluator.Expression, vars))
+		for _, v := range s.Evaluator.Variables {
+			ev.AddVariable(&v)
+		}
+		result, err := ev.Evaluate()
+		if err != nil {
+			return resp.StatusCode, took, fmt.Errorf("evaluation error: %v", err)
+		}
+		if !result {
+			message := "expression is evaluated to false!"
+			for k, v := range ev.ExtractedValues {
+				message += fmt.Sprintf(" [%s = %v]", k, v)
+			}
+			return resp.StatusCode, took, errors.New(message)
+		}
+	}
+
+	for _, c := range s.Capture {
+		v, err := c.capture(client, req, resp, string(response))
+		if err != nil {
+			return resp.StatusCode, took, fmt.Errorf("capture [%s] error: %v", c.Name, err)
+		}
+		vars[c.Name] = v
+	}
+
+	return resp.StatusCode, took, nil
+}
+
+// capture extracts the value from the response
+func (c *Capture) capture(client *http.Client, req *http.Request, resp *http.Response, body string) (string, error) {
+	switch c.From {
+	case FromHeader:
+		if v := resp.Header.Get(c.Key); len(v) > 0 {
+			return v, nil
+		}
+		return "", fmt.Errorf("header [%s] is not found", c.Key)
+	case FromCookie:
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == c.Key {
+				return cookie.Value, nil
+			}
+		}
+		if client.Jar != nil {
+			for _, cookie := range client.Jar.Cookies(req.URL) {
+				if cookie.Name == c.Key {
+					return cookie.Value, nil
+				}
+			}
+		}
+		return "", fmt.Errorf("cookie [%s] is not found", c.Key)
+	}
+
+	e := eval.NewEvaluator(body, c.Doc, "")
+	v := eval.NewVariable(c.Name, eval.String, c.Query)
+	if err := e.ExtractValue(v); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%v", v.Value), nil
+}
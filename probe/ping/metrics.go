@@ -34,22 +34,22 @@ type metrics struct {
 }
 
 // newMetrics create the metrics
-func newMetrics(subsystem, name string, constLabels prometheus.Labels) *metrics {
+func newMetrics(registry *metric.Registry, subsystem, name string, constLabels prometheus.Labels) *metrics {
 	namespace := global.GetEaseProbe().Name
 	return &metrics{
-		PacketsSent: metric.NewCounter(namespace, subsystem, name, "sent",
+		PacketsSent: registry.NewCounter(namespace, subsystem, name, "sent",
 			"Total Package Sent", []string{"name", "endpoint"}, constLabels),
-		PacketsRecv: metric.NewCounter(namespace, subsystem, name, "recv",
+		PacketsRecv: registry.NewCounter(namespace, subsystem, name, "recv",
 			"Total Package Received", []string{"name", "endpoint"}, constLabels),
-		PacketLoss: metric.NewGauge(namespace, subsystem, name, "loss",
+		PacketLoss: registry.NewGauge(namespace, subsystem, name, "loss",
 			"Package Loss Percentage", []string{"name", "endpoint"}, constLabels),
-		MinRtt: metric.NewGauge(namespace, subsystem, name, "min_rtt",
+		MinRtt: registry.NewGauge(namespace, subsystem, name, "min_rtt",
 			"Minimum Round Trip Time", []string{"name", "endpoint"}, constLabels),
-		MaxRtt: metric.NewGauge(namespace, subsystem, name, "max_rtt",
+		MaxRtt: registry.NewGauge(namespace, subsystem, name, "max_rtt",
 			"Maximum Round Trip Time", []string{"name", "endpoint"}, constLabels),
-		AvgRtt: metric.NewGauge(namespace, subsystem, name, "avg_rtt",
+		AvgRtt: registry.NewGauge(namespace, subsystem, name, "avg_rtt",
 			"Average Round Trip Time", []string{"name", "endpoint"}, constLabels),
-		StdDevRtt: metric.NewGauge(namespace, subsystem, name, "stddev_rtt",
+		StdDevRtt: registry.NewGauge(namespace, subsystem, name, "stddev_rtt",
 			"Standard Deviation of Round Trip Time", []string{"name", "endpoint"}, constLabels),
 	}
 }
@@ -33,6 +33,7 @@ type Prober interface {
 	Kind() string
 	Name() string
 	Channels() []string
+	DependsOn() []string
 	Timeout() time.Duration
 	Interval() time.Duration
 	Result() *Result
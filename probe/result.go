@@ -33,8 +33,16 @@ type Stat struct {
 	Status   map[Status]int64 `json:"status" yaml:"status"`
 	UpTime   time.Duration    `json:"uptime" yaml:"uptime"`
 	DownTime time.Duration    `json:"downtime" yaml:"downtime"`
+	// MaintenanceTime is the downtime during the maintenance windows, it is not counted in the SLA
+	MaintenanceTime time.Duration `json:"maintenance,omitempty" yaml:"maintenance,omitempty"`
+	// Suppressed is the number of the failures which are suppressed because the parent probe is down
+	Suppressed int64 `json:"suppressed,omitempty" yaml:"suppressed,omitempty"`
 	StatusCounter
 	NotificationStrategyData `json:"alert" yaml:"alert"`
+	// Escalation is the escalation state of the ongoing failure, nil if the failure is not escalated
+	Escalation *EscalationData `json:"escalation,omitempty" yaml:"escalation,omitempty"`
+	// Ack is the acknowledgement of the ongoing failure, nil if the failure is not acknowledged
+	Ack *AckData `json:"ack,omitempty" yaml:"ack,omitempty"`
 }
 
 // Result is the status of health check
@@ -50,6 +58,20 @@ type Result struct {
 	LatestDownTime   time.Time     `json:"latestdowntime" yaml:"latestdowntime"`
 	RecoveryDuration time.Duration `json:"recoverytime" yaml:"recoverytime"`
 	Stat             Stat          `json:"stat" yaml:"stat"`
+	// Maintenance is the name of the active maintenance window, empty if not in maintenance
+	Maintenance string `json:"maintenance,omitempty" yaml:"maintenance,omitempty"`
+	// SuppressedBy is the name of the parent probe which is down, empty if the failure is not suppressed
+	SuppressedBy string `json:"suppressed_by,omitempty" yaml:"suppressed_by,omitempty"`
+	// SLO is the SLO target of the probe in percentage
+	SLO float64 `json:"slo,omitempty" yaml:"slo,omitempty"`
+	// Kind is the kind of the probe
+	Kind string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	// Labels is the labels of the probe
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	// Escalated is the notifiers of the escalation stages which are reached or recovered by the result
+	Escalated []string `json:"-" yaml:"-"`
+	// AckURL is the link to acknowledge the failure, empty if the failure cannot be acknowledged
+	AckURL string `json:"-" yaml:"-"`
 }
 
 // NewResult return a Result object
@@ -112,6 +134,20 @@ func (r *Result) Clone() Result {
 	dst.LatestDownTime = r.LatestDownTime
 	dst.RecoveryDuration = r.RecoveryDuration
 	dst.Stat = r.Stat.Clone()
+	dst.Maintenance = r.Maintenance
+	dst.SuppressedBy = r.SuppressedBy
+	dst.SLO = r.SLO
+	dst.Kind = r.Kind
+	if r.Labels != nil {
+		dst.Labels = make(map[string]string, len(r.Labels))
+		for k, v := range r.Labels {
+			dst.Labels[k] = v
+		}
+	}
+	if r.Escalated != nil {
+		dst.Escalated = append([]string{}, r.Escalated...)
+	}
+	dst.AckURL = r.AckURL
 	return dst
 }
 
@@ -126,8 +162,12 @@ func (s *Stat) Clone() Stat {
 	}
 	dst.UpTime = s.UpTime
 	dst.DownTime = s.DownTime
+	dst.MaintenanceTime = s.MaintenanceTime
+	dst.Suppressed = s.Suppressed
 	dst.StatusCounter = s.StatusCounter.Clone()
 	dst.NotificationStrategyData = s.NotificationStrategyData.Clone()
+	dst.Escalation = s.Escalation.Clone()
+	dst.Ack = s.Ack.Clone()
 	return dst
 }
 
@@ -135,20 +175,46 @@ func (s *Stat) Clone() Stat {
 func (r *Result) DoStat(d time.Duration) {
 	r.Stat.Total++
 	r.Stat.Status[r.Status]++
-	if r.Status == StatusUp {
+	if r.IsSuppressed() {
+		r.Stat.Suppressed++
+	}
+	// the warning status is degraded but still available,
+	// and the downtime in the maintenance window is not counted in the SLA
+	if r.Status.IsAvailable() {
 		r.Stat.UpTime += d
+	} else if r.InMaintenance() {
+		r.Stat.MaintenanceTime += d
 	} else {
 		r.Stat.DownTime += d
 	}
 }
 
+// InMaintenance return true if the probe is in a maintenance window
+func (r *Result) InMaintenance() bool {
+	return len(r.Maintenance) > 0
+}
+
+// IsAcked return true if the failure is acknowledged
+func (r *Result) IsAcked() bool {
+	return r.Stat.Ack != nil
+}
+
+// IsSuppressed return true if the failure is suppressed because the parent probe is down
+func (r *Result) IsSuppressed() bool {
+	return len(r.SuppressedBy) > 0 && !r.Status.IsAvailable()
+}
+
 // Title return the title for notification
 func (r *Result) Title() string {
 	t := ""
 	if r.PreStatus == StatusInit && r.Status == StatusUp {
 		t = "Monitoring %s"
+	} else if r.Status == StatusWarning {
+		t = "%s Warning"
 	} else if r.Status != StatusUp {
 		t = "%s Failure"
+	} else if r.PreStatus == StatusWarning {
+		t = "%s Recovery"
 	} else {
 		t = "%s Recovery - ( " + r.RecoveryDuration.Round(time.Second).String() + " Downtime )"
 	}
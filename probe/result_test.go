@@ -155,6 +155,20 @@ func TestTitle(t *testing.T) {
 	if r.Title() != expected {
 		t.Errorf("%s != %s", r.Title(), expected)
 	}
+
+	r.PreStatus = StatusUp
+	r.Status = StatusWarning
+	expected = "Test Name Warning"
+	if r.Title() != expected {
+		t.Errorf("%s != %s", r.Title(), expected)
+	}
+
+	r.PreStatus = StatusWarning
+	r.Status = StatusUp
+	expected = "Test Name Recovery"
+	if r.Title() != expected {
+		t.Errorf("%s != %s", r.Title(), expected)
+	}
 }
 
 func TestDebug(t *testing.T) {
@@ -262,3 +276,71 @@ func TestSLAPercent(t *testing.T) {
 	r.Status = StatusUp
 	assert.Equal(t, float64(100), r.SLAPercent())
 }
+
+func TestMaintenanceStat(t *testing.T) {
+	r := NewResult()
+	assert.False(t, r.InMaintenance())
+
+	r.Status = StatusDown
+	r.Maintenance = "deploy"
+	assert.True(t, r.InMaintenance())
+	r.DoStat(time.Minute)
+	assert.Equal(t, time.Minute, r.Stat.MaintenanceTime)
+	assert.Equal(t, time.Duration(0), r.Stat.DownTime)
+	assert.Equal(t, int64(1), r.Stat.Status[StatusDown])
+
+	// the uptime is still counted in the maintenance window
+	r.Status = StatusUp
+	r.DoStat(time.Minute)
+	assert.Equal(t, time.Minute, r.Stat.UpTime)
+	assert.Equal(t, float64(100), r.SLAPercent())
+
+	r.Status = StatusDown
+	r.Maintenance = ""
+	r.DoStat(time.Minute)
+	assert.Equal(t, time.Minute, r.Stat.DownTime)
+	assert.Equal(t, time.Minute, r.Stat.MaintenanceTime)
+
+	r.Maintenance = "deploy"
+	c := r.Clone()
+	assert.Equal(t, "deploy", c.Maintenance)
+	assert.Equal(t, time.Minute, c.Stat.MaintenanceTime)
+}
+
+func TestSuppressedStat(t *testing.T) {
+	r := NewResult()
+	r.Status = StatusDown
+	r.SuppressedBy = "switch"
+	assert.True(t, r.IsSuppressed())
+	r.DoStat(time.Minute)
+	assert.Equal(t, int64(1), r.Stat.Suppressed)
+
+	// the recovery is not counted
+	r.Status = StatusUp
+	assert.False(t, r.IsSuppressed())
+	r.DoStat(time.Minute)
+	assert.Equal(t, int64(1), r.Stat.Suppressed)
+
+	c := r.Clone()
+	assert.Equal(t, "switch", c.SuppressedBy)
+	assert.Equal(t, int64(1), c.Stat.Suppressed)
+}
+
+func TestSLOClone(t *testing.T) {
+	r := NewResult()
+	r.SLO = 99.5
+	c := r.Clone()
+	assert.Equal(t, 99.5, c.SLO)
+}
+
+func TestKindLabelsClone(t *testing.T) {
+	r := NewResult()
+	r.Kind = "http"
+	r.Labels = map[string]string{"env": "prod"}
+	c := r.Clone()
+	assert.Equal(t, "http", c.Kind)
+	assert.Equal(t, r.Labels, c.Labels)
+	// the labels are deep copied
+	c.Labels["env"] = "test"
+	assert.Equal(t, "prod", r.Labels["env"])
+}
@@ -62,7 +62,7 @@ func (s *Shell) Config(gConf global.ProbeSettings) error {
 		return err
 	}
 
-	s.metrics = newMetrics(kind, tag, s.Labels)
+	s.metrics = newMetrics(s.MetricRegistry(), kind, tag, s.Labels)
 
 	log.Debugf("[%s / %s] configuration: %+v", s.ProbeKind, s.ProbeName, *s)
 	return nil
@@ -33,6 +33,7 @@ const (
 	StatusDown
 	StatusUnknown
 	StatusBad
+	StatusWarning
 )
 
 var (
@@ -42,6 +43,7 @@ var (
 		StatusDown:    "Error",
 		StatusUnknown: "Unknown",
 		StatusBad:     "Bad",
+		StatusWarning: "Warning",
 	}
 	toString = map[Status]string{
 		StatusInit:    "init",
@@ -49,6 +51,7 @@ var (
 		StatusDown:    "down",
 		StatusUnknown: "unknown",
 		StatusBad:     "bad",
+		StatusWarning: "warning",
 	}
 
 	toStatus = global.ReverseMap(toString)
@@ -59,6 +62,7 @@ var (
 		StatusDown:    "❌",
 		StatusUnknown: "⛔️",
 		StatusBad:     "🚫",
+		StatusWarning: "⚠️",
 	}
 )
 
@@ -87,6 +91,11 @@ func (s *Status) Status(status string) {
 	}
 }
 
+// IsAvailable return true if the service is available, the warning status is available but degraded
+func (s Status) IsAvailable() bool {
+	return s == StatusUp || s == StatusWarning
+}
+
 // Emoji convert the status to emoji
 func (s *Status) Emoji() string {
 	if val, ok := toEmoji[*s]; ok {
@@ -66,6 +66,7 @@ func TestYamlJSON(t *testing.T) {
 	testYamlJSON(t, "down", StatusDown, true)
 	testYamlJSON(t, "unknown", StatusUnknown, true)
 	testYamlJSON(t, "bad", StatusBad, true)
+	testYamlJSON(t, "warning", StatusWarning, true)
 
 	testYamlJSON(t, "xxx", 10, false)
 
@@ -105,6 +106,11 @@ func TestStatus(t *testing.T) {
 
 	err = yaml.Unmarshal([]byte{1, 2}, &s)
 	assert.NotNil(t, err)
+
+	assert.True(t, StatusUp.IsAvailable())
+	assert.True(t, StatusWarning.IsAvailable())
+	assert.False(t, StatusDown.IsAvailable())
+	assert.False(t, StatusUnknown.IsAvailable())
 }
 
 func TestStatusTitle(t *testing.T) {
@@ -123,6 +129,10 @@ func TestStatusTitle(t *testing.T) {
 	s = StatusBad
 	assert.Equal(t, "Bad", s.Title())
 
+	s = StatusWarning
+	assert.Equal(t, "Warning", s.Title())
+	assert.Equal(t, "⚠️", s.Emoji())
+
 	s = -1
 	assert.Equal(t, "Unknown", s.Title())
 }
@@ -33,6 +33,7 @@ import (
 
 	"github.com/wfusion/easeprobe/global"
 	"github.com/wfusion/easeprobe/metric"
+	"github.com/wfusion/easeprobe/probe"
 	"github.com/wfusion/easeprobe/probe/base"
 )
 
@@ -49,6 +50,9 @@ type TLS struct {
 
 	ExpireSkipVerify  bool          `yaml:"expire_skip_verify" json:"expire_skip_verify,omitempty" jsonschema:"title=Expire Skip Verify,description=Whether to skip verifying the certificate expire time"`
 	AlertExpireBefore time.Duration `yaml:"alert_expire_before" json:"alert_expire_before,omitempty" jsonschema:"title=Alert Expire Before,description=The alert expire before time"`
+	WarnExpireBefore  time.Duration `yaml:"warn_expire_before" json:"warn_expire_before,omitempty" jsonschema:"title=Warn Expire Before,description=The warning expire before time"`
+
+	earliestExpiry time.Time `yaml:"-" json:"-"`
 
 	metrics *metrics
 }
@@ -77,7 +81,11 @@ func (t *TLS) Config(gConf global.ProbeSettings) error {
 		}
 	}
 
-	t.metrics = newMetrics(kind, tag, t.Labels)
+	if t.WarnExpireBefore > 0 && !t.ExpireSkipVerify {
+		t.ProbeStatusFunc = t.DoProbeStatus
+	}
+
+	t.metrics = newMetrics(t.MetricRegistry(), kind, tag, t.Labels)
 
 	log.Debugf("[%s / %s] configuration: %+v", t.ProbeKind, t.ProbeName, *t)
 	return nil
@@ -85,6 +93,7 @@ func (t *TLS) Config(gConf global.ProbeSettings) error {
 
 // DoProbe return the checking result
 func (t *TLS) DoProbe() (bool, string) {
+	t.earliestExpiry = time.Time{}
 	addr := t.Host
 	conn, err := t.GetProxyConnection(t.Proxy, addr)
 	if err != nil {
@@ -137,13 +146,30 @@ func (t *TLS) DoProbe() (bool, string) {
 	}
 
 	state := tconn.ConnectionState()
+	t.earliestExpiry = getEarliestCertExpiry(&state)
 
 	t.metrics.EarliestCertExpiry.With(metric.AddConstLabels(prometheus.Labels{
 		"endpoint": t.ProbeResult.Endpoint,
-	}, t.Labels)).Set(float64(getEarliestCertExpiry(&state).Unix()))
+	}, t.Labels)).Set(float64(t.earliestExpiry.Unix()))
 	t.metrics.EarliestCertExpiry.With(metric.AddConstLabels(prometheus.Labels{
 		"endpoint": t.ProbeResult.Endpoint,
 	}, t.Labels)).Set(float64(getLastChainExpiry(&state).Unix()))
 
 	return true, "TLS Endpoint Verified Successfully!"
 }
+
+// DoProbeStatus return the checking result with the warning status,
+// the probe is in warning status if the certificate is expiring in the warning time.
+func (t *TLS) DoProbeStatus() (probe.Status, string) {
+	stat, message := t.DoProbe()
+	if !stat {
+		return probe.StatusDown, message
+	}
+	if t.WarnExpireBefore > 0 && !t.ExpireSkipVerify && !t.earliestExpiry.IsZero() {
+		durLeft := time.Until(t.earliestExpiry)
+		if durLeft < t.WarnExpireBefore {
+			return probe.StatusWarning, fmt.Sprintf("certificate is expiring in %v", durLeft.Round(time.Second))
+		}
+	}
+	return probe.StatusUp, message
+}
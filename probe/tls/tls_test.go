@@ -37,6 +37,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
 	"github.com/wfusion/easeprobe/probe/base"
 	"github.com/wfusion/gofusion/common/utils/gomonkey"
 )
@@ -348,6 +349,59 @@ func TestTlsAlertExpiredBefore(t *testing.T) {
 	})
 }
 
+func TestTlsWarnExpiredBefore(t *testing.T) {
+	mock, err := newTLSMockServer(&x509.Certificate{
+		DNSNames:              []string{"0.0.0.0"},
+		IPAddresses:           []net.IP{net.IPv4zero, net.IPv6loopback, net.IPv6unspecified},
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(time.Hour * 24 * -366),
+		NotAfter:              time.Now().Add(time.Hour * 24 * 10),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	})
+	if err != nil {
+		t.Errorf("newTlsMockServer error: %v", err)
+		return
+	}
+	defer mock.Close()
+
+	tls := &TLS{
+		Host:               mock.hostname,
+		InsecureSkipVerify: true,
+		AlertExpireBefore:  time.Hour * 24 * 2,
+		WarnExpireBefore:   time.Hour * 24 * 20,
+	}
+	tls.Config(global.ProbeSettings{
+		Timeout: time.Second * 10,
+	})
+	assert.NotNil(t, tls.ProbeStatusFunc)
+
+	s, msg := tls.DoProbeStatus()
+	assert.Equal(t, probe.StatusWarning, s)
+	assert.True(t, strings.HasPrefix(msg, "certificate is expiring in"))
+
+	// the alert takes precedence over the warning
+	tls.AlertExpireBefore = time.Hour * 24 * 15
+	s, _ = tls.DoProbeStatus()
+	assert.Equal(t, probe.StatusDown, s)
+
+	tls.AlertExpireBefore = 0
+	tls.WarnExpireBefore = time.Hour * 24 * 5
+	s, _ = tls.DoProbeStatus()
+	assert.Equal(t, probe.StatusUp, s)
+
+	// no warning if the expire time is not verified
+	tls = &TLS{
+		Host:               mock.hostname,
+		InsecureSkipVerify: true,
+		ExpireSkipVerify:   true,
+		WarnExpireBefore:   time.Hour * 24 * 20,
+	}
+	tls.Config(global.ProbeSettings{})
+	assert.Nil(t, tls.ProbeStatusFunc)
+}
+
 func TestTLSFail(t *testing.T) {
 	tlsConf := &TLS{
 		DefaultProbe:       base.DefaultProbe{ProbeName: "dummy tls"},
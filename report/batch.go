@@ -0,0 +1,319 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wfusion/easeprobe/ack"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// the limits of the Slack blocks
+const (
+	slackTextLimit    = 3000 // the max length of the text of a section block
+	slackButtonLimit  = 75   // the max length of the text of a button
+	slackActionsLimit = 25   // the max number of the elements of an actions block
+)
+
+// the order of the status in the batch title, the most severe first
+var batchStatusOrder = []probe.Status{
+	probe.StatusDown, probe.StatusBad, probe.StatusUnknown,
+	probe.StatusWarning, probe.StatusUp, probe.StatusInit,
+}
+
+// BatchTitle returns the title of the grouped results, e.g. "3 Probes Changed (2 down, 1 up)"
+func BatchTitle(results []probe.Result) string {
+	cnt := map[probe.Status]int{}
+	for _, r := range results {
+		cnt[r.Status]++
+	}
+	status := []string{}
+	for _, s := range batchStatusOrder {
+		if cnt[s] > 0 {
+			status = append(status, fmt.Sprintf("%d %s", cnt[s], s.String()))
+		}
+	}
+	return fmt.Sprintf("%d Probes Changed (%s)", len(results), strings.Join(status, ", "))
+}
+
+// batchStatus returns the most severe status of the results
+func batchStatus(results []probe.Result) probe.Status {
+	for _, s := range batchStatusOrder {
+		for _, r := range results {
+			if r.Status == s {
+				return s
+			}
+		}
+	}
+	return probe.StatusUnknown
+}
+
+// latestTime returns the latest probe time of the results
+func latestTime(results []probe.Result) time.Time {
+	t := time.Time{}
+	for _, r := range results {
+		if r.StartTime.After(t) {
+			t = r.StartTime
+		}
+	}
+	return t
+}
+
+// BatchText convert the grouped results to text
+func BatchText(results []probe.Result) string {
+	text := BatchTitle(results) + "\n"
+	for _, r := range results {
+		text += fmt.Sprintf("\n%s [%s] %s - %s", r.Status.Emoji(), r.Title(), r.Endpoint, r.Message)
+		if link := AckLink(r, Text); len(link) > 0 {
+			text += "\n   " + link
+		}
+	}
+	return text + "\n\n" + global.FooterString() + " at " + FormatTime(latestTime(results))
+}
+
+// BatchLog convert the grouped results to log lines, one line for each result
+func BatchLog(results []probe.Result) string {
+	lines := make([]string, 0, len(results))
+	for _, r := range results {
+		lines = append(lines, ToLog(r))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// BatchMarkdown convert the grouped results to markdown
+func BatchMarkdown(results []probe.Result) string {
+	return batchMarkdown(results, Markdown)
+}
+
+// BatchMarkdownSocial convert the grouped results to social markdown
+func BatchMarkdownSocial(results []probe.Result) string {
+	return batchMarkdown(results, MarkdownSocial)
+}
+
+func batchMarkdown(results []probe.Result, f Format) string {
+	bold := "**"
+	if f == MarkdownSocial {
+		bold = "*"
+	}
+	md := bold + BatchTitle(results) + bold + "\n"
+	for _, r := range results {
+		md += fmt.Sprintf("\n- %s %s%s%s %s - %s", r.Status.Emoji(), bold, r.Title(), bold, r.Endpoint, r.Message)
+		if link := AckLink(r, f); len(link) > 0 {
+			md += " " + link
+		}
+	}
+	return md + "\n\n> " + global.FooterString() + " at " + FormatTime(latestTime(results))
+}
+
+// BatchHTML convert the grouped results to HTML
+func BatchHTML(results []probe.Result) string {
+	page := HTMLHeader(BatchTitle(results)) + `
+			<table style="font-size: 16px; line-height: 20px;">
+				<tr><td class="head"><b> Service Name </b></td><td class="head"><b> Endpoint </b></td><td class="head"><b> Status </b></td><td class="head"><b> Probe Time </b></td><td class="head"><b> Round Trip Time </b></td><td class="head"><b> Message </b></td></tr>`
+	for _, r := range results {
+		message := html.EscapeString(r.Message)
+		if link := AckLink(r, HTML); len(link) > 0 {
+			message += "<br/>" + link
+		}
+		page += fmt.Sprintf(`
+				<tr><td class="data">%s</td><td class="data">%s</td><td class="data">%s %s</td><td class="data">%s</td><td class="data">%s</td><td class="data">%s</td></tr>`,
+			html.EscapeString(r.Name), html.EscapeString(r.Endpoint), r.Status.Emoji(), r.Status.String(),
+			FormatTime(r.StartTime), r.RoundTripTime.Round(time.Millisecond), message)
+	}
+	return page + `
+			</table>
+		` + HTMLFooter(FormatTime(latestTime(results)))
+}
+
+// BatchSummary convert the grouped results to a short summary, e.g. for SMS
+func BatchSummary(results []probe.Result) string {
+	names := make([]string, 0, len(results))
+	for _, r := range results {
+		names = append(names, r.Name)
+	}
+	return BatchTitle(results) + ": " + strings.Join(names, ", ") + "\n" + global.FooterString()
+}
+
+// truncate cuts the string to the max length in bytes without breaking the UTF-8 characters
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
+
+// BatchSlack convert the grouped results to the Slack blocks. The results are packed into
+// the sections as many as the text limit of Slack allows, and the acknowledgement buttons
+// of the failures are packed into the actions blocks.
+func BatchSlack(results []probe.Result) string {
+	title := BatchTitle(results)
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": title, "emoji": true},
+		},
+	}
+	section := func(text string) map[string]interface{} {
+		return map[string]interface{}{
+			"type": "section",
+			"text": map[string]interface{}{"type": "mrkdwn", "text": text},
+		}
+	}
+
+	text := ""
+	buttons := []interface{}{}
+	for _, r := range results {
+		rtt := r.RoundTripTime.Round(time.Millisecond)
+		line := truncate(fmt.Sprintf("%s *%s* %s - ⏱ %s\n>%s",
+			r.Status.Emoji(), r.Title(), r.Endpoint, rtt, r.Message), slackTextLimit)
+		if len(text)+len(line)+1 > slackTextLimit {
+			blocks = append(blocks, section(text))
+			text = ""
+		}
+		if len(text) > 0 {
+			text += "\n"
+		}
+		text += line
+
+		// the action ids must be unique in the actions block
+		actionID := fmt.Sprintf("%s_%d", ack.SlackActionID, len(buttons)%slackActionsLimit)
+		if b := slackAckButton(r, truncate("✋ "+r.Name, slackButtonLimit), actionID); b != nil {
+			buttons = append(buttons, b)
+		}
+	}
+	if len(text) > 0 {
+		blocks = append(blocks, section(text))
+	}
+	for i := 0; i < len(buttons); i += slackActionsLimit {
+		end := i + slackActionsLimit
+		if end > len(buttons) {
+			end = len(buttons)
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "actions", "elements": buttons[i:end]})
+	}
+
+	blocks = append(blocks, map[string]interface{}{
+		"type": "context",
+		"elements": []interface{}{
+			map[string]interface{}{"type": "image", "image_url": global.GetEaseProbe().IconURL, "alt_text": global.OrgProg},
+			map[string]interface{}{"type": "mrkdwn", "text": global.FooterString() + " " +
+				SlackTimeFormation(latestTime(results), " probed at ", global.GetTimeFormat())},
+		},
+	})
+
+	buf, err := json.Marshal(map[string]interface{}{"text": title, "blocks": blocks})
+	if err != nil {
+		log.Errorf("BatchSlack(): Failed to marshal the blocks to json: %s", err)
+		return ""
+	}
+	return string(buf)
+}
+
+// BatchLark convert the grouped results to the Lark card, one element for each result
+func BatchLark(results []probe.Result) string {
+	elements := []interface{}{}
+	for _, r := range results {
+		rtt := r.RoundTripTime.Round(time.Millisecond)
+		elements = append(elements, map[string]interface{}{
+			"tag": "div",
+			"text": map[string]interface{}{
+				"tag": "lark_md",
+				"content": fmt.Sprintf("**%s** %s\n%s - ⏱ %s\n%s",
+					r.Title(), r.Status.Emoji(), r.Endpoint, rtt, r.Message),
+			},
+		})
+	}
+	elements = append(elements,
+		map[string]interface{}{"tag": "hr"},
+		map[string]interface{}{
+			"tag": "note",
+			"elements": []interface{}{map[string]interface{}{
+				"tag":     "plain_text",
+				"content": global.FooterString() + " probed at " + FormatTime(latestTime(results)),
+			}},
+		},
+	)
+
+	card := map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"config": map[string]interface{}{"wide_screen_mode": true},
+			"header": map[string]interface{}{
+				"template": larkColor(batchStatus(results)),
+				"title":    map[string]interface{}{"tag": "plain_text", "content": BatchTitle(results)},
+			},
+			"elements": elements,
+		},
+	}
+	buf, err := json.Marshal(card)
+	if err != nil {
+		log.Errorf("BatchLark(): Failed to marshal the card to json: %s", err)
+		return ""
+	}
+	return string(buf)
+}
+
+// BatchShell convert the grouped results to the shell variables, the type is "Batch",
+// the JSON is the array of the results, and the CSV has one row for each result.
+func BatchShell(results []probe.Result) string {
+	env := make(map[string]string)
+
+	env["EASEPROBE_TYPE"] = "Batch"
+	env["EASEPROBE_TITLE"] = BatchTitle(results)
+
+	dto := make([]resultDTO, 0, len(results))
+	data := [][]string{csvHeader}
+	for _, r := range results {
+		dto = append(dto, newResultDTO(r))
+		data = append(data, csvRow(r))
+	}
+	j, err := json.Marshal(dto)
+	if err != nil {
+		log.Errorf("BatchShell(): Failed to marshal the results to json: %s", err)
+		return ""
+	}
+	env["EASEPROBE_JSON"] = string(j)
+
+	csvBuf := new(bytes.Buffer)
+	if err := csv.NewWriter(csvBuf).WriteAll(data); err != nil {
+		log.Errorf("BatchShell(): Failed to write to csv buffer: %v", err)
+		return ""
+	}
+	env["EASEPROBE_CSV"] = csvBuf.String()
+
+	buf, err := json.Marshal(env)
+	if err != nil {
+		log.Errorf("BatchShell(): Failed to marshal env to json: %s", err)
+		return ""
+	}
+	return string(buf)
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func TestBatch(t *testing.T) {
+	r1 := newDummyResult("probe-1")
+	r2 := newDummyResult("probe-2 <b>")
+	r2.Status = probe.StatusDown
+	r2.PreStatus = probe.StatusUp
+	r3 := newDummyResult("probe-3")
+	r3.Status = probe.StatusDown
+	r3.PreStatus = probe.StatusUp
+	results := []probe.Result{r1, r2, r3}
+
+	assert.Equal(t, "3 Probes Changed (2 down, 1 up)", BatchTitle(results))
+
+	text := BatchText(results)
+	assert.True(t, strings.HasPrefix(text, "3 Probes Changed"))
+	assert.Contains(t, text, "[probe-1 Recovery - ( 20s Downtime )]")
+	assert.Contains(t, text, "[probe-3 Failure]")
+
+	assert.Len(t, strings.Split(BatchLog(results), "\n"), 3)
+
+	md := BatchMarkdown(results)
+	assert.Contains(t, md, "**3 Probes Changed (2 down, 1 up)**")
+	assert.Contains(t, md, "- ❌ **probe-3 Failure**")
+	md = BatchMarkdownSocial(results)
+	assert.Contains(t, md, "*3 Probes Changed (2 down, 1 up)*")
+	assert.NotContains(t, md, "**")
+
+	html := BatchHTML(results)
+	assert.Contains(t, html, "<td class=\"data\">probe-2 &lt;b&gt;</td>")
+	assert.Equal(t, 3, strings.Count(html, "dummy message"))
+
+	assert.Contains(t, BatchSummary(results), "probe-1, probe-2 <b>, probe-3")
+
+	// the formats which cannot render the grouped results
+	assert.Nil(t, FormatFuncs[JSON].BatchFn)
+	for _, f := range []Format{HTML, Slack, Lark, Shell} {
+		assert.NotNil(t, FormatFuncs[f].BatchFn)
+	}
+}
+
+func TestBatchSlack(t *testing.T) {
+	results := []probe.Result{}
+	for i := 0; i < 40; i++ {
+		r := newDummyResult(fmt.Sprintf("probe-%d", i))
+		r.Status = probe.StatusDown
+		r.PreStatus = probe.StatusUp
+		r.Message = strings.Repeat("x", 100) + " \"quoted\"\nnext line"
+		r.AckURL = fmt.Sprintf("http://localhost:8181/ack/probe-%d", i)
+		results = append(results, r)
+	}
+	results[0].AckURL = "" // cannot be acknowledged
+
+	var msg struct {
+		Text   string `json:"text"`
+		Blocks []struct {
+			Type     string                `json:"type"`
+			Text     struct{ Text string } `json:"text"`
+			Elements []struct {
+				ActionID string `json:"action_id"`
+				Value    string `json:"value"`
+				URL      string `json:"url"`
+			} `json:"elements"`
+		} `json:"blocks"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(BatchSlack(results)), &msg))
+	assert.Equal(t, "40 Probes Changed (40 down)", msg.Text)
+	assert.Equal(t, "header", msg.Blocks[0].Type)
+	assert.Equal(t, "context", msg.Blocks[len(msg.Blocks)-1].Type)
+	assert.LessOrEqual(t, len(msg.Blocks), 50)
+
+	sections, buttons := 0, 0
+	for _, b := range msg.Blocks {
+		switch b.Type {
+		case "section":
+			sections++
+			assert.LessOrEqual(t, len(b.Text.Text), slackTextLimit)
+			assert.Contains(t, b.Text.Text, "probe-")
+		case "actions":
+			assert.LessOrEqual(t, len(b.Elements), slackActionsLimit)
+			ids := map[string]bool{}
+			for _, e := range b.Elements {
+				assert.False(t, ids[e.ActionID], "the action id is duplicated")
+				ids[e.ActionID] = true
+				assert.Equal(t, "http://localhost:8181/ack/"+e.Value, e.URL)
+			}
+			buttons += len(b.Elements)
+		}
+	}
+	assert.Greater(t, sections, 1)
+	assert.Equal(t, 39, buttons)
+}
+
+func TestBatchLarkShell(t *testing.T) {
+	r1 := newDummyResult("probe-1")
+	r2 := newDummyResult("probe-2")
+	r2.Status = probe.StatusDown
+	r2.Message = `"quoted"`
+	results := []probe.Result{r1, r2}
+
+	var card struct {
+		Card struct {
+			Header struct {
+				Template string `json:"template"`
+				Title    struct{ Content string }
+			} `json:"header"`
+			Elements []map[string]interface{} `json:"elements"`
+		} `json:"card"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(BatchLark(results)), &card))
+	assert.Equal(t, "red", card.Card.Header.Template)
+	assert.Equal(t, "2 Probes Changed (1 down, 1 up)", card.Card.Header.Title.Content)
+	assert.Len(t, card.Card.Elements, 4)
+
+	env := map[string]string{}
+	assert.Nil(t, json.Unmarshal([]byte(BatchShell(results)), &env))
+	assert.Equal(t, "Batch", env["EASEPROBE_TYPE"])
+	assert.Equal(t, "2 Probes Changed (1 down, 1 up)", env["EASEPROBE_TITLE"])
+	var dto []resultDTO
+	assert.Nil(t, json.Unmarshal([]byte(env["EASEPROBE_JSON"]), &dto))
+	assert.Len(t, dto, 2)
+	assert.Equal(t, `"quoted"`, dto[1].Message)
+	rows, err := csv.NewReader(strings.NewReader(env["EASEPROBE_CSV"])).ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, "probe-2", rows[2][1])
+
+	assert.Equal(t, "ab", truncate("ab", 3))
+	assert.Equal(t, "a", truncate("a✋", 3))
+}
@@ -38,8 +38,9 @@ type SLAFilter struct {
 	Message    string
 	PageNum    int
 	PageSize   int
-	total      int // the total number of probers
-	cnt        int // the number of probers that match the filter
+	ProbeLink  bool // link the probe name to its detail page
+	total      int  // the total number of probers
+	cnt        int  // the number of probers that match the filter
 }
 
 // NewEmptyFilter create a new SLAFilter
@@ -0,0 +1,322 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/store"
+)
+
+// HistoryPoint is a point of the status and latency series of the probe history
+type HistoryPoint struct {
+	Time        time.Time     `json:"time"`
+	Status      probe.Status  `json:"status"`
+	RTT         time.Duration `json:"rtt"`
+	MaxRTT      time.Duration `json:"max_rtt"`
+	Count       int64         `json:"count"`
+	Up          int64         `json:"up"`
+	Down        int64         `json:"down"`
+	Maintenance int64         `json:"maintenance"`
+	SLA         float64       `json:"sla"`
+	Message     string        `json:"message,omitempty"`
+}
+
+// Transition is the status change of the probe
+type Transition struct {
+	Time    time.Time    `json:"time"`
+	From    probe.Status `json:"from"`
+	To      probe.Status `json:"to"`
+	Message string       `json:"message,omitempty"`
+}
+
+// Incident is the period that the probe is unavailable out of the maintenance window
+type Incident struct {
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Duration time.Duration `json:"duration"`
+	Status   probe.Status  `json:"status"`
+	Message  string        `json:"message"`
+	Ongoing  bool          `json:"ongoing"`
+}
+
+// History is the history of the probe in the time range
+type History struct {
+	Name        string         `json:"name"`
+	Endpoint    string         `json:"endpoint"`
+	From        time.Time      `json:"from"`
+	To          time.Time      `json:"to"`
+	Step        time.Duration  `json:"step"`
+	SLA         float64        `json:"sla"`
+	Points      []HistoryPoint `json:"points"`
+	Transitions []Transition   `json:"transitions"`
+	Incidents   []Incident     `json:"incidents"`
+}
+
+// NewHistory builds the history from the records in [from, to) ordered by time.
+// The records are aggregated into the points by the step, the transitions and the
+// incidents are detected from the records as they are, so they are as accurate as
+// the records - the downsampled record is regarded as a whole.
+func NewHistory(name, endpoint string, records []store.Record, from, to time.Time, step time.Duration) History {
+	h := History{
+		Name:        name,
+		Endpoint:    endpoint,
+		From:        from,
+		To:          to,
+		Step:        step,
+		Points:      []HistoryPoint{},
+		Transitions: []Transition{},
+		Incidents:   []Incident{},
+	}
+
+	total := store.Record{}
+	for _, r := range records {
+		total.Merge(r)
+	}
+	h.SLA = total.SLA()
+
+	for _, r := range store.Aggregate(records, step) {
+		h.Points = append(h.Points, HistoryPoint{
+			Time:        r.Time,
+			Status:      r.Status,
+			RTT:         r.RTT,
+			MaxRTT:      r.MaxRTT,
+			Count:       r.Count,
+			Up:          r.Up,
+			Down:        r.Down(),
+			Maintenance: r.Maintenance,
+			SLA:         r.SLA(),
+			Message:     r.Message,
+		})
+	}
+
+	for i := 1; i < len(records); i++ {
+		if records[i].Status != records[i-1].Status {
+			h.Transitions = append(h.Transitions, Transition{
+				Time:    records[i].Time,
+				From:    records[i-1].Status,
+				To:      records[i].Status,
+				Message: records[i].Message,
+			})
+		}
+	}
+
+	var incident *Incident
+	for _, r := range records {
+		if r.Down() > 0 {
+			if incident == nil {
+				incident = &Incident{Start: r.Time, Status: r.Status, Message: r.Message}
+			}
+			continue
+		}
+		if incident != nil {
+			incident.End = r.Time
+			incident.Duration = incident.End.Sub(incident.Start)
+			h.Incidents = append(h.Incidents, *incident)
+			incident = nil
+		}
+	}
+	if incident != nil {
+		incident.End = to
+		if now := time.Now(); to.IsZero() || to.After(now) {
+			incident.End = now
+		}
+		incident.Duration = incident.End.Sub(incident.Start)
+		incident.Ongoing = true
+		h.Incidents = append(h.Incidents, *incident)
+	}
+	return h
+}
+
+// DayUptime is the availability of the probe in a day
+type DayUptime struct {
+	Date        time.Time    `json:"date"`
+	Status      probe.Status `json:"status"` // the worst status of the day
+	Count       int64        `json:"count"`
+	Up          int64        `json:"up"`
+	Down        int64        `json:"down"`
+	Maintenance int64        `json:"maintenance"`
+	SLA         float64      `json:"sla"`
+}
+
+// DailyUptime aggregates the records into the days until today in the location,
+// the days without any record have zero count.
+func DailyUptime(records []store.Record, days int, now time.Time, loc *time.Location) []DayUptime {
+	if loc == nil {
+		loc = time.UTC
+	}
+	today := truncateDay(now, loc)
+	daily := make([]store.Record, days)
+	for _, r := range records {
+		day := truncateDay(r.Time, loc)
+		i := days - 1 - int(math.Round(today.Sub(day).Hours()/24))
+		if i >= 0 && i < days {
+			daily[i].Merge(r)
+		}
+	}
+
+	result := make([]DayUptime, days)
+	for i, r := range daily {
+		result[i] = DayUptime{
+			Date:        today.AddDate(0, 0, i-days+1),
+			Status:      r.Status,
+			Count:       r.Count,
+			Up:          r.Up,
+			Down:        r.Down(),
+			Maintenance: r.Maintenance,
+			SLA:         r.SLA(),
+		}
+	}
+	return result
+}
+
+func truncateDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// uptimeColor returns the color of the uptime bar of the day
+func uptimeColor(d DayUptime) string {
+	switch {
+	case d.Count == 0:
+		return "#ddd"
+	case d.Down == 0:
+		return StatusColor(probe.StatusUp)
+	case d.SLA >= 99:
+		return StatusColor(probe.StatusWarning)
+	}
+	return StatusColor(probe.StatusDown)
+}
+
+// UptimeHTML returns the uptime bar of the days as SVG
+func UptimeHTML(days []DayUptime) string {
+	const w, gap, h = 8, 2, 32
+	total := store.Record{}
+	svg := fmt.Sprintf(`<svg width="%d" height="%d">`, len(days)*(w+gap), h)
+	for i, d := range days {
+		total.Merge(store.Record{Count: d.Count, Up: d.Up, Maintenance: d.Maintenance})
+		tip := fmt.Sprintf("%s - no data", d.Date.Format("2006-01-02"))
+		if d.Count > 0 {
+			tip = fmt.Sprintf("%s - %.2f%% (%d down of %d)", d.Date.Format("2006-01-02"), d.SLA, d.Down, d.Count)
+		}
+		svg += fmt.Sprintf(`<rect x="%d" y="0" width="%d" height="%d" rx="2" fill="%s"><title>%s</title></rect>`,
+			i*(w+gap), w, h, uptimeColor(d), tip)
+	}
+	svg += `</svg>`
+
+	return fmt.Sprintf(`
+	<h2 style="font-weight: normal; color: #3b3b3b;">Uptime - %d days <span style="font-size: 16px;">(%.3f%%)</span></h2>
+	%s
+	<div style="width: %dpx; font-size: 12px; color: #666;"><span>%d days ago</span><span style="float: right;">Today</span></div>
+	`, len(days), total.SLA(), svg, len(days)*(w+gap), len(days))
+}
+
+// LatencyHTML returns the latency chart of the history as SVG, the average round
+// trip time is the solid line, the max one is the dashed line, and the red marks
+// are the failures.
+func LatencyHTML(h History) string {
+	const w, ht, pad = 900, 200, 50
+	title := fmt.Sprintf(`<h2 style="font-weight: normal; color: #3b3b3b;">Latency <span style="font-size: 16px;">(%s - %s, SLA %.3f%%)</span></h2>`,
+		FormatTime(h.From), FormatTime(h.To), h.SLA)
+	span := h.To.Sub(h.From)
+	if len(h.Points) == 0 || span <= 0 {
+		return title + `<p style="color: #666;">No data</p>`
+	}
+
+	var max time.Duration
+	for _, p := range h.Points {
+		if p.MaxRTT > max {
+			max = p.MaxRTT
+		}
+	}
+	if max <= 0 {
+		max = time.Millisecond
+	}
+	x := func(t time.Time) float64 {
+		return pad + float64(t.Sub(h.From))/float64(span)*w
+	}
+	y := func(d time.Duration) float64 {
+		return ht - float64(d)/float64(max)*ht + 10
+	}
+
+	avg, top, marks := []string{}, []string{}, ""
+	for _, p := range h.Points {
+		avg = append(avg, fmt.Sprintf("%.1f,%.1f", x(p.Time), y(p.RTT)))
+		top = append(top, fmt.Sprintf("%.1f,%.1f", x(p.Time), y(p.MaxRTT)))
+		if p.Down > 0 {
+			marks += fmt.Sprintf(`<rect x="%.1f" y="%d" width="3" height="8" fill="%s"><title>%s - %s</title></rect>`,
+				x(p.Time)-1, ht+12, StatusColor(probe.StatusDown), FormatTime(p.Time), html.EscapeString(p.Message))
+		}
+	}
+
+	svg := fmt.Sprintf(`<svg width="%d" height="%d" style="font-size: 12px;">`, w+pad*2, ht+40)
+	svg += fmt.Sprintf(`<line x1="%d" y1="10" x2="%d" y2="%d" stroke="#ccc"/>`, pad, pad, ht+10)
+	svg += fmt.Sprintf(`<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#ccc"/>`, pad, ht+10, w+pad, ht+10)
+	svg += fmt.Sprintf(`<text x="%d" y="15" text-anchor="end" fill="#666">%dms</text>`, pad-4, max.Milliseconds())
+	svg += fmt.Sprintf(`<text x="%d" y="%d" text-anchor="end" fill="#666">0ms</text>`, pad-4, ht+10)
+	svg += fmt.Sprintf(`<polyline points="%s" fill="none" stroke="#2442bf" stroke-opacity="0.4" stroke-dasharray="4 2"/>`, strings.Join(top, " "))
+	svg += fmt.Sprintf(`<polyline points="%s" fill="none" stroke="#2442bf" stroke-width="2"/>`, strings.Join(avg, " "))
+	svg += marks
+	svg += `</svg>`
+	return title + svg
+}
+
+// IncidentsHTML returns the incidents table, the latest incident is the first
+func IncidentsHTML(incidents []Incident) string {
+	html := `<h2 style="font-weight: normal; color: #3b3b3b;">Incidents</h2>`
+	if len(incidents) == 0 {
+		return html + `<p style="color: #666;">No incidents</p>`
+	}
+	html += `<table style="font-size: 16px; line-height: 20px;">
+	<tr><td class="head">Start</td><td class="head">End</td><td class="head">Duration</td><td class="head">Status</td><td class="head">Message</td></tr>`
+	for i := len(incidents) - 1; i >= 0; i-- {
+		in := incidents[i]
+		end := FormatTime(in.End)
+		if in.Ongoing {
+			end = "<b>Ongoing</b>"
+		}
+		html += fmt.Sprintf(`
+	<tr><td class="data">%s</td><td class="data">%s</td><td class="data">%s</td><td class="data"><span style="color:%s">%s %s</span></td><td class="data">%s</td></tr>`,
+			FormatTime(in.Start), end, DurationStr(in.Duration.Round(time.Second)),
+			StatusColor(in.Status), in.Status.Emoji(), in.Status.String(), JSONEscape(in.Message))
+	}
+	return html + `</table>`
+}
+
+// ProbeHTML returns the detail page of the probe, the history is nil if it's not kept,
+// and the extra sections are appended at the end of the page
+func ProbeHTML(r *probe.Result, h *History, days []DayUptime, sections ...string) string {
+	page := HTMLHeader(html.EscapeString(r.Name))
+	page += `<p><a href="/">&larr; Overall SLA Report</a></p>`
+	page += `<table style="font-size: 16px; line-height: 20px;">` + SLAHTMLSection(r) + `</table>`
+	if h == nil {
+		page += `<p style="color: #666;">The history is not kept by the result store.</p>`
+	} else {
+		page += UptimeHTML(days) + LatencyHTML(*h) + IncidentsHTML(h.Incidents)
+	}
+	for _, s := range sections {
+		page += s
+	}
+	page += HTMLFooter(FormatTime(time.Now()))
+	return page
+}
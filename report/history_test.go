@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/store"
+)
+
+func historyRecords(base time.Time) []store.Record {
+	statuses := []probe.Status{
+		probe.StatusUp, probe.StatusUp, probe.StatusDown, probe.StatusDown,
+		probe.StatusUp, probe.StatusWarning, probe.StatusDown,
+	}
+	records := []store.Record{}
+	for i, s := range statuses {
+		r := store.NewRecord(probe.Result{
+			StartTime:     base.Add(time.Duration(i) * 10 * time.Minute),
+			Status:        s,
+			RoundTripTime: time.Duration(i+1) * 10 * time.Millisecond,
+			Message:       s.String(),
+		})
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestNewHistory(t *testing.T) {
+	base := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := historyRecords(base)
+	to := base.Add(2 * time.Hour)
+
+	h := NewHistory("test", "http://example.com", records, base, to, 30*time.Minute)
+	assert.Equal(t, "test", h.Name)
+	assert.InDelta(t, 4.0/7*100, h.SLA, 0.01)
+
+	assert.Len(t, h.Points, 3)
+	assert.Equal(t, probe.StatusDown, h.Points[0].Status)
+	assert.Equal(t, int64(3), h.Points[0].Count)
+	assert.Equal(t, int64(1), h.Points[0].Down)
+	assert.Equal(t, 20*time.Millisecond, h.Points[0].RTT)
+	assert.Equal(t, 30*time.Millisecond, h.Points[0].MaxRTT)
+	assert.Equal(t, "down", h.Points[0].Message)
+
+	assert.Len(t, h.Transitions, 4)
+	assert.Equal(t, probe.StatusUp, h.Transitions[0].From)
+	assert.Equal(t, probe.StatusDown, h.Transitions[0].To)
+	assert.Equal(t, base.Add(20*time.Minute), h.Transitions[0].Time)
+
+	assert.Len(t, h.Incidents, 2)
+	assert.Equal(t, base.Add(20*time.Minute), h.Incidents[0].Start)
+	assert.Equal(t, 20*time.Minute, h.Incidents[0].Duration)
+	assert.False(t, h.Incidents[0].Ongoing)
+	assert.Equal(t, "down", h.Incidents[0].Message)
+	assert.True(t, h.Incidents[1].Ongoing)
+	assert.Equal(t, to, h.Incidents[1].End)
+
+	h = NewHistory("empty", "", nil, base, to, time.Minute)
+	assert.Empty(t, h.Points)
+	assert.Empty(t, h.Incidents)
+	assert.Equal(t, float64(100), h.SLA)
+}
+
+func TestDailyUptime(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	now := time.Date(2022, 1, 10, 12, 0, 0, 0, loc)
+	records := []store.Record{
+		// 2022-01-08 in UTC+8
+		{Time: time.Date(2022, 1, 7, 20, 0, 0, 0, time.UTC), Status: probe.StatusUp, Count: 10, Up: 10},
+		{Time: time.Date(2022, 1, 8, 10, 0, 0, 0, time.UTC), Status: probe.StatusDown, Count: 10, Up: 9},
+		// 2022-01-10 in UTC+8
+		{Time: time.Date(2022, 1, 10, 1, 0, 0, 0, time.UTC), Status: probe.StatusDown, Count: 2, Maintenance: 2},
+		// too old
+		{Time: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Status: probe.StatusUp, Count: 1, Up: 1},
+	}
+	days := DailyUptime(records, 5, now, loc)
+	assert.Len(t, days, 5)
+	assert.Equal(t, time.Date(2022, 1, 6, 0, 0, 0, 0, loc), days[0].Date)
+	assert.Equal(t, time.Date(2022, 1, 10, 0, 0, 0, 0, loc), days[4].Date)
+	assert.Equal(t, int64(0), days[0].Count)
+	assert.Equal(t, int64(20), days[2].Count)
+	assert.Equal(t, int64(1), days[2].Down)
+	assert.Equal(t, probe.StatusDown, days[2].Status)
+	assert.Equal(t, float64(95), days[2].SLA)
+	assert.Equal(t, int64(2), days[4].Maintenance)
+	assert.Equal(t, float64(100), days[4].SLA)
+
+	html := UptimeHTML(days)
+	assert.Contains(t, html, "5 days")
+	assert.Contains(t, html, "#ddd")
+	assert.Contains(t, html, "2022-01-08 - 95.00% (1 down of 20)")
+}
+
+func TestProbeHTML(t *testing.T) {
+	base := time.Now().Add(-2 * time.Hour)
+	h := NewHistory("test", "http://example.com", historyRecords(base), base, time.Now(), 10*time.Minute)
+	r := &probe.Result{Name: "test", Endpoint: "http://example.com", Status: probe.StatusDown}
+	days := DailyUptime(nil, 90, time.Now(), nil)
+
+	html := ProbeHTML(r, &h, days)
+	assert.Contains(t, html, "Uptime - 90 days")
+	assert.Contains(t, html, "<polyline")
+	assert.Contains(t, html, "70ms")
+	assert.Contains(t, html, "Ongoing")
+
+	html = ProbeHTML(r, nil, nil)
+	assert.Contains(t, html, "The history is not kept")
+	assert.NotContains(t, html, "Uptime - 90 days")
+
+	empty := NewHistory("test", "", nil, base, time.Now(), time.Minute)
+	assert.Contains(t, LatencyHTML(empty), "No data")
+	assert.Contains(t, IncidentsHTML(nil), "No incidents")
+}
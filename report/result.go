@@ -22,8 +22,10 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"html"
 	"time"
 
+	"github.com/wfusion/easeprobe/ack"
 	"github.com/wfusion/easeprobe/global"
 	"github.com/wfusion/easeprobe/probe"
 
@@ -40,13 +42,35 @@ func ToLog(r probe.Result) string {
 
 // ToText convert the result object to ToText
 func ToText(r probe.Result) string {
-	tpl := "[%s] %s\n%s - ⏱ %s\n%s\n%s"
+	tpl := "[%s] %s\n%s - ⏱ %s\n%s%s\n%s"
 	rtt := r.RoundTripTime.Round(time.Millisecond)
 	return fmt.Sprintf(tpl,
-		r.Title(), r.Status.Emoji(), r.Endpoint, rtt, r.Message,
+		r.Title(), r.Status.Emoji(), r.Endpoint, rtt, r.Message, ackLine(r, Text),
 		global.FooterString()+" at "+FormatTime(r.StartTime))
 }
 
+// AckLink returns the link to acknowledge the failure in the format, empty if the failure cannot be acknowledged
+func AckLink(r probe.Result, f Format) string {
+	if len(r.AckURL) <= 0 {
+		return ""
+	}
+	switch f {
+	case Markdown, MarkdownSocial:
+		return "[✋ Acknowledge](" + r.AckURL + ")"
+	case HTML:
+		return `<a href="` + html.EscapeString(r.AckURL) + `">✋ Acknowledge</a>`
+	}
+	return "Acknowledge: " + r.AckURL
+}
+
+// ackLine returns the acknowledgement link in a new line, empty if the failure cannot be acknowledged
+func ackLine(r probe.Result, f Format) string {
+	if link := AckLink(r, f); len(link) > 0 {
+		return "\n" + link
+	}
+	return ""
+}
+
 // resultDTO only for JSON format notification
 type resultDTO struct {
 	Name           string        `json:"name"`
@@ -59,9 +83,8 @@ type resultDTO struct {
 	Message        string        `json:"message"`
 }
 
-// ToJSON convert the result object to ToJSON
-func ToJSON(r probe.Result) string {
-	ro := resultDTO{
+func newResultDTO(r probe.Result) resultDTO {
+	return resultDTO{
 		Name:           r.Title(),
 		Endpoint:       r.Endpoint,
 		StartTime:      r.StartTime,
@@ -71,6 +94,11 @@ func ToJSON(r probe.Result) string {
 		PreStatus:      r.PreStatus,
 		Message:        r.Message,
 	}
+}
+
+// ToJSON convert the result object to ToJSON
+func ToJSON(r probe.Result) string {
+	ro := newResultDTO(r)
 	j, err := json.Marshal(&ro)
 	if err != nil {
 		log.Errorf("error: %v", err)
@@ -81,16 +109,7 @@ func ToJSON(r probe.Result) string {
 
 // ToJSONIndent convert the object to indent JSON
 func ToJSONIndent(r probe.Result) string {
-	ro := resultDTO{
-		Name:           r.Title(),
-		Endpoint:       r.Endpoint,
-		StartTime:      r.StartTime,
-		StartTimestamp: r.StartTimestamp,
-		RoundTripTime:  r.RoundTripTime,
-		Status:         r.Status,
-		PreStatus:      r.PreStatus,
-		Message:        r.Message,
-	}
+	ro := newResultDTO(r)
 	j, err := json.MarshalIndent(&ro, "", "    ")
 	if err != nil {
 		log.Errorf("error: %v", err)
@@ -126,13 +145,21 @@ func ToHTML(r probe.Result) string {
 				<tr>
 					<td class="head right"><b> Message </b></td>
 					<td class="data">%s</td>
-				</tr>
+				</tr>%s
 			</table>
 		` + HTMLFooter(FormatTime(r.StartTime))
 
+	ack := ""
+	if link := AckLink(r, HTML); len(link) > 0 {
+		ack = `
+				<tr>
+					<td class="head right"><b> Acknowledge </b></td>
+					<td class="data">` + link + `</td>
+				</tr>`
+	}
 	rtt := r.RoundTripTime.Round(time.Millisecond)
 	return fmt.Sprintf(html, r.Name, r.Endpoint, r.Status.Emoji(), r.Status.String(),
-		FormatTime(r.StartTime), rtt, r.Message)
+		FormatTime(r.StartTime), rtt, r.Message, ack)
 }
 
 // ToMarkdown convert the object to ToMarkdown
@@ -146,13 +173,13 @@ func ToMarkdownSocial(r probe.Result) string {
 }
 
 func markdown(r probe.Result, f Format) string {
-	tpl := "**%s** %s\n%s - ⏱ %s\n%s\n> %s"
+	tpl := "**%s** %s\n%s - ⏱ %s\n%s%s\n> %s"
 	if f == MarkdownSocial {
-		tpl = "*%s* %s\n%s - ⏱ %s\n%s\n> %s"
+		tpl = "*%s* %s\n%s - ⏱ %s\n%s%s\n> %s"
 	}
 	rtt := r.RoundTripTime.Round(time.Millisecond)
 	return fmt.Sprintf(tpl,
-		r.Title(), r.Status.Emoji(), r.Endpoint, rtt, r.Message,
+		r.Title(), r.Status.Emoji(), r.Endpoint, rtt, r.Message, ackLine(r, f),
 		global.FooterString()+" at "+FormatTime(r.StartTime))
 }
 
@@ -170,7 +197,7 @@ func ToSlack(r probe.Result) string {
 					"type": "mrkdwn",
 					"text": "%s"
 				}
-			},
+			},%s
 			{
 				"type": "context",
 				"elements": [
@@ -193,7 +220,45 @@ func ToSlack(r probe.Result) string {
 		r.Title(), r.Status.Emoji(), r.Endpoint, rtt, JSONEscape(r.Message))
 	context := SlackTimeFormation(r.StartTime, " probed at ", global.GetTimeFormat())
 	summary := fmt.Sprintf("%s %s - %s", r.Title(), r.Status.Emoji(), JSONEscape(r.Message))
-	return fmt.Sprintf(json, summary, body, context)
+	return fmt.Sprintf(json, summary, body, slackAckBlock(r), context)
+}
+
+// slackAckBlock returns the actions block with the acknowledgement button, empty if the failure cannot be acknowledged.
+func slackAckBlock(r probe.Result) string {
+	button := slackAckButton(r, "✋ Acknowledge", ack.SlackActionID)
+	if button == nil {
+		return ""
+	}
+	buf, err := json.Marshal(map[string]interface{}{
+		"type":     "actions",
+		"elements": []interface{}{button},
+	})
+	if err != nil {
+		log.Errorf("error: %v", err)
+		return ""
+	}
+	return "\n\t\t\t" + string(buf) + ","
+}
+
+// slackAckButton returns the acknowledgement button, nil if the failure cannot be acknowledged.
+// The button is handled by the Slack app if it's configured, otherwise it opens the acknowledgement link.
+func slackAckButton(r probe.Result, text, actionID string) map[string]interface{} {
+	if r.Status != probe.StatusDown || r.IsAcked() {
+		return nil
+	}
+	button := map[string]interface{}{
+		"type":      "button",
+		"text":      map[string]interface{}{"type": "plain_text", "text": text, "emoji": true},
+		"action_id": actionID,
+		"value":     r.Name,
+	}
+	if !ack.SlackEnabled() {
+		if len(r.AckURL) <= 0 {
+			return nil
+		}
+		button["url"] = r.AckURL
+	}
+	return button
 }
 
 // ToLark convert the object to Lark notification
@@ -237,34 +302,42 @@ func ToLark(r probe.Result) string {
 		}
 	}`
 
-	headerColor := "gray"
-	switch r.Status {
-	case probe.StatusUp:
-		headerColor = "green"
-	case probe.StatusDown:
-		headerColor = "red"
-	case probe.StatusUnknown:
-		headerColor = "gray"
-	case probe.StatusInit:
-		headerColor = "blue"
-	}
-
 	title := fmt.Sprintf("%s %s", r.Title(), r.Status.Emoji())
 	rtt := r.RoundTripTime.Round(time.Millisecond)
 	content := fmt.Sprintf("%s - ⏱ %s\\n%s", r.Endpoint, rtt, JSONEscape(r.Message))
 	footer := global.FooterString() + " probed at " + FormatTime(r.StartTime)
-	return fmt.Sprintf(json, headerColor, title, content, footer)
+	return fmt.Sprintf(json, larkColor(r.Status), title, content, footer)
 }
 
-// ToCSV convert the object to CSV
-func ToCSV(r probe.Result) string {
+// larkColor returns the header color of the Lark card by the status
+func larkColor(s probe.Status) string {
+	switch s {
+	case probe.StatusUp:
+		return "green"
+	case probe.StatusWarning:
+		return "orange"
+	case probe.StatusDown:
+		return "red"
+	case probe.StatusInit:
+		return "blue"
+	}
+	return "gray"
+}
+
+// the CSV header of the results
+var csvHeader = []string{"Title", "Name", "Endpoint", "Status", "PreStatus", "RoundTripTime", "Time", "Timestamp", "Message"}
+
+// csvRow returns the CSV row of the result
+func csvRow(r probe.Result) []string {
 	rtt := fmt.Sprintf("%d", r.RoundTripTime.Round(time.Millisecond))
 	time := FormatTime(r.StartTime)
 	timestamp := fmt.Sprintf("%d", r.StartTimestamp)
-	data := [][]string{
-		{"Title", "Name", "Endpoint", "Status", "PreStatus", "RoundTripTime", "Time", "Timestamp", "Message"},
-		{r.Title(), r.Name, r.Endpoint, r.Status.String(), r.PreStatus.String(), rtt, time, timestamp, r.Message},
-	}
+	return []string{r.Title(), r.Name, r.Endpoint, r.Status.String(), r.PreStatus.String(), rtt, time, timestamp, r.Message}
+}
+
+// ToCSV convert the object to CSV
+func ToCSV(r probe.Result) string {
+	data := [][]string{csvHeader, csvRow(r)}
 
 	buf := new(bytes.Buffer)
 	w := csv.NewWriter(buf)
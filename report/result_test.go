@@ -256,3 +256,35 @@ func TestResultToShell(t *testing.T) {
 	assert.Empty(t, ToShell(r))
 
 }
+
+func TestResultAck(t *testing.T) {
+	global.InitEaseProbe("EaseProbe", "http://icon/url")
+	r := newDummyResult("dummy")
+	r.Status = probe.StatusDown
+	assert.Empty(t, AckLink(r, Markdown))
+	assert.NotContains(t, ToText(r), "Acknowledge")
+	assert.NotContains(t, ToSlack(r), `"actions"`)
+
+	r.AckURL = "https://probe.example.com/ack/dummy?token=abc"
+	assert.Equal(t, "[✋ Acknowledge]("+r.AckURL+")", AckLink(r, Markdown))
+	assert.Equal(t, `<a href="https://probe.example.com/ack/dummy?token=abc">✋ Acknowledge</a>`, AckLink(r, HTML))
+	assert.Equal(t, "Acknowledge: "+r.AckURL, AckLink(r, Text))
+	assert.Contains(t, ToText(r), "Acknowledge: "+r.AckURL)
+	assert.Contains(t, ToMarkdown(r), "[✋ Acknowledge]("+r.AckURL+")")
+	assert.Contains(t, ToHTML(r), AckLink(r, HTML))
+
+	block := slackAckBlock(r)
+	assert.True(t, json.Valid([]byte(strings.TrimSuffix(strings.TrimSpace(block), ","))))
+	assert.Contains(t, block, `"url":"`+r.AckURL+`"`)
+	assert.Contains(t, ToSlack(r), block)
+
+	// no button once the failure is acknowledged
+	r.Stat.Ack = &probe.AckData{By: "alice", Comment: "<on it>", Time: time.Now(), Expire: time.Hour}
+	assert.Empty(t, slackAckBlock(r))
+	s := SLAAckHTML(&r)
+	assert.Contains(t, s, "Acknowledged by <b>alice</b>")
+	assert.Contains(t, s, "expires at")
+	assert.Contains(t, s, "&lt;on it&gt;")
+	r.Stat.Ack = nil
+	assert.Empty(t, SLAAckHTML(&r))
+}
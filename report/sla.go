@@ -23,34 +23,43 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"html"
+	"net/url"
 	"sort"
 	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/maintenance"
 	"github.com/wfusion/easeprobe/probe"
 )
 
 // Availability is the Availability JSON structure
 type Availability struct {
-	UpTime   time.Duration `json:"up"`
-	DownTime time.Duration `json:"down"`
-	SLA      float64       `json:"sla"`
+	UpTime          time.Duration `json:"up"`
+	DownTime        time.Duration `json:"down"`
+	MaintenanceTime time.Duration `json:"maintenance"`
+	SLA             float64       `json:"sla"`
 }
 
 // Summary is the Summary JSON structure
 type Summary struct {
-	Total int64 `json:"total"`
-	Up    int64 `json:"up"`
-	Down  int64 `json:"down"`
+	Total      int64 `json:"total"`
+	Up         int64 `json:"up"`
+	Warning    int64 `json:"warning"`
+	Down       int64 `json:"down"`
+	Suppressed int64 `json:"suppressed"`
 }
 
 // LatestProbe is the LatestProbe JSON structure
 type LatestProbe struct {
-	Time    time.Time    `json:"time"`
-	Status  probe.Status `json:"status"`
-	Message string       `json:"message"`
+	Time         time.Time      `json:"time"`
+	Status       probe.Status   `json:"status"`
+	Message      string         `json:"message"`
+	Maintenance  string         `json:"maintenance,omitempty"`
+	SuppressedBy string         `json:"suppressed_by,omitempty"`
+	Ack          *probe.AckData `json:"ack,omitempty"`
 }
 
 // SLA is the SLA JSON structure
@@ -60,6 +69,8 @@ type SLA struct {
 	Availability Availability `json:"sla"`
 	ProbeTimes   Summary      `json:"probe_summary"`
 	LatestProbe  LatestProbe  `json:"latest_probe"`
+	SLO          float64      `json:"slo"`
+	Windows      []SLAWindow  `json:"windows,omitempty"`
 }
 
 // SLAObject covert the result to SLA struct
@@ -68,20 +79,28 @@ func SLAObject(r *probe.Result) SLA {
 		Name:     r.Name,
 		Endpoint: r.Endpoint,
 		Availability: Availability{
-			UpTime:   r.Stat.UpTime,
-			DownTime: r.Stat.DownTime,
-			SLA:      r.SLAPercent(),
+			UpTime:          r.Stat.UpTime,
+			DownTime:        r.Stat.DownTime,
+			MaintenanceTime: r.Stat.MaintenanceTime,
+			SLA:             r.SLAPercent(),
 		},
 		ProbeTimes: Summary{
-			Total: r.Stat.Total,
-			Up:    r.Stat.Status[probe.StatusUp],
-			Down:  r.Stat.Status[probe.StatusDown] + r.Stat.Status[probe.StatusUnknown],
+			Total:      r.Stat.Total,
+			Up:         r.Stat.Status[probe.StatusUp],
+			Warning:    r.Stat.Status[probe.StatusWarning],
+			Down:       r.Stat.Status[probe.StatusDown] + r.Stat.Status[probe.StatusUnknown],
+			Suppressed: r.Stat.Suppressed,
 		},
 		LatestProbe: LatestProbe{
-			Time:    r.StartTime,
-			Status:  r.Status,
-			Message: r.Message,
+			Time:         r.StartTime,
+			Status:       r.Status,
+			Message:      r.Message,
+			Maintenance:  r.Maintenance,
+			SuppressedBy: r.SuppressedBy,
+			Ack:          r.Stat.Ack.Clone(),
 		},
+		SLO:     r.SLO,
+		Windows: SLAWindows(r),
 	}
 
 }
@@ -114,12 +133,16 @@ func SLAJSON(probers []probe.Prober) string {
 
 // SLATextSection return the Text format string to stat
 func SLATextSection(r *probe.Result) string {
-	text := "Name: %s - %s, \n\tAvailability: Up - %s, Down - %s, SLA: %.2f%%\n\tProbe-Times: Total: %d ( %s ), \n\tLatest-Probe:%s - %s, Message:%s"
+	text := "Name: %s - %s, \n\tAvailability: Up - %s, Down - %s%s, SLA: %.2f%%%s\n\tProbe-Times: Total: %d ( %s ), \n\tLatest-Probe:%s - %s%s, Message:%s"
+	windows := SLAWindowsText(r, Text)
+	if windows != "" {
+		windows = "\n\t" + windows
+	}
 	return fmt.Sprintf(text, r.Name, r.Endpoint,
-		DurationStr(r.Stat.UpTime), DurationStr(r.Stat.DownTime), r.SLAPercent(),
+		DurationStr(r.Stat.UpTime), DurationStr(r.Stat.DownTime), SLAMaintenanceTime(r, Text), r.SLAPercent(), windows,
 		r.Stat.Total, SLAStatusText(r.Stat, Text),
 		FormatTime(r.StartTime),
-		r.Status.Emoji()+" "+r.Status.String(), JSONEscape(r.Message))
+		r.Status.Emoji()+" "+r.Status.String(), SLAMaintenanceWindow(r, Text), JSONEscape(r.Message))
 }
 
 // SLAText return a full stat report
@@ -135,11 +158,15 @@ func SLAText(probers []probe.Prober) string {
 // SLALogSection return the Log format string to stat
 func SLALogSection(r *probe.Result) string {
 	text := `name="%s"; endpoint="%s"; up="%s"; down="%s"; sla="%.2f%%"; total="%d(%s)"; latest_time="%s"; latest_status="%s"; message="%s"`
-	return fmt.Sprintf(text, r.Name, r.Endpoint,
+	text = fmt.Sprintf(text, r.Name, r.Endpoint,
 		DurationStr(r.Stat.UpTime), DurationStr(r.Stat.DownTime), r.SLAPercent(),
 		r.Stat.Total, SLAStatusText(r.Stat, Log),
 		FormatTime(r.StartTime),
 		r.Status.String(), r.Message)
+	if windows := SLAWindowsText(r, Log); windows != "" {
+		text += fmt.Sprintf(`; windows="%s"`, windows)
+	}
+	return text
 }
 
 // SLALog return a full stat report with Log format
@@ -161,16 +188,23 @@ func SLAMarkdownSection(r *probe.Result, f Format) string {
 		text = "\n*%s* - %s\n"
 	}
 
-	text += "- Availability: Up - `%s`, Down - `%s`, SLA: `%.2f%%` \n" +
+	text += "- Availability: Up - `%s`, Down - `%s`%s, SLA: `%.2f%%` \n" +
+		"%s" +
 		"- Probe-Times: Total: `%d` ( %s ) \n" +
-		"- Latest-Probe: %s - %s \n" +
+		"- Latest-Probe: %s - %s%s \n" +
 		"  ```%s```\n"
 
+	windows := SLAWindowsText(r, MarkdownSocial)
+	if windows != "" {
+		windows = "- " + windows + " \n"
+	}
+
 	return fmt.Sprintf(text, r.Name, r.Endpoint,
-		DurationStr(r.Stat.UpTime), DurationStr(r.Stat.DownTime), r.SLAPercent(),
+		DurationStr(r.Stat.UpTime), DurationStr(r.Stat.DownTime), SLAMaintenanceTime(r, MarkdownSocial), r.SLAPercent(),
+		windows,
 		r.Stat.Total, SLAStatusText(r.Stat, MarkdownSocial),
 		FormatTime(r.StartTime),
-		r.Status.Emoji()+" "+r.Status.String(), r.Message)
+		r.Status.Emoji()+" "+r.Status.String(), SLAMaintenanceWindow(r, MarkdownSocial), r.Message)
 }
 
 // SLAMarkdown return a full stat report with Markdown format
@@ -199,26 +233,114 @@ func slaMarkdown(probers []probe.Prober, f Format) string {
 
 // SLAHTMLSection return the HTML format string to stat
 func SLAHTMLSection(r *probe.Result) string {
+	return slaHTMLSection(r, "")
+}
+
+// slaHTMLSection return the HTML format string to stat, the name links to the page if it's not empty
+func slaHTMLSection(r *probe.Result, link string) string {
+	name := r.Name
+	if link != "" {
+		name = fmt.Sprintf(`<a href="%s">%s</a>`, link, r.Name)
+	}
 
 	htmlTpl := `
 	<tr>
 		<td class="head" colspan="3"><b>%s</b> - %s<td>
 	</tr>
 	<tr>
-		<td class="data"><b>Availability</b><br><b>Uptime: </b>%s,  <b>Downtime: </b>%s  </td>
+		<td class="data"><b>Availability</b><br><b>Uptime: </b>%s,  <b>Downtime: </b>%s%s  </td>
 		<td class="data"><b>SLA: </b>%.2f%%<br><b>RTT: </b>%dms</td>
 		<td class="data"><b>Probe-Times</b><br><b>Total</b>: %d ( %s )</td>
 	</tr>
-	<tr>
-		<td  class="data" colspan="3"><b>Latest Probe</b>: %s - %s<br>%s<td>
+	%s<tr>
+		<td  class="data" colspan="3"><b>Latest Probe</b>: %s - <span style="color:%s">%s</span>%s<br>%s<td>
+	</tr>
+	`
+	windows := SLAWindowsText(r, HTML)
+	if windows != "" {
+		windows = `<tr>
+		<td class="data" colspan="3">` + windows + `</td>
 	</tr>
 	`
-	return fmt.Sprintf(htmlTpl, r.Name, r.Endpoint,
-		DurationStr(r.Stat.UpTime), DurationStr(r.Stat.DownTime),
+	}
+	return fmt.Sprintf(htmlTpl, name, r.Endpoint,
+		DurationStr(r.Stat.UpTime), DurationStr(r.Stat.DownTime), SLAMaintenanceTime(r, HTML),
 		r.SLAPercent(), r.RoundTripTime.Milliseconds(),
-		r.Stat.Total, SLAStatusText(r.Stat, HTML),
-		FormatTime(r.StartTime),
-		r.Status.Emoji()+" "+r.Status.String(), JSONEscape(r.Message))
+		r.Stat.Total, SLAStatusText(r.Stat, HTML), windows,
+		FormatTime(r.StartTime), StatusColor(r.Status),
+		r.Status.Emoji()+" "+r.Status.String(), SLAMaintenanceWindow(r, HTML)+SLASuppressedHTML(r)+SLAAckHTML(r), JSONEscape(r.Message))
+}
+
+// SLAMaintenanceTime return the maintenance time of the probe, empty if no maintenance time
+func SLAMaintenanceTime(r *probe.Result, t Format) string {
+	if r.Stat.MaintenanceTime <= 0 {
+		return ""
+	}
+	format := ", Maintenance - %s"
+	switch t {
+	case MarkdownSocial, Markdown:
+		format = ", Maintenance - `%s`"
+	case HTML:
+		format = ",  <b>Maintenance: </b>%s"
+	}
+	return fmt.Sprintf(format, DurationStr(r.Stat.MaintenanceTime))
+}
+
+// SLAMaintenanceWindow return the active maintenance window of the probe, empty if not in maintenance
+func SLAMaintenanceWindow(r *probe.Result, t Format) string {
+	if !r.InMaintenance() {
+		return ""
+	}
+	format := " (🔧 Maintenance: %s)"
+	switch t {
+	case MarkdownSocial, Markdown:
+		format = " (🔧 Maintenance: `%s`)"
+	case HTML:
+		return fmt.Sprintf(` <span style="color:#666">(🔧 Maintenance: <b>%s</b>)</span>`, html.EscapeString(r.Maintenance))
+	}
+	return fmt.Sprintf(format, r.Maintenance)
+}
+
+// SLASuppressedHTML return the suppressed information of the probe, empty if the failure is not suppressed
+func SLASuppressedHTML(r *probe.Result) string {
+	html := ""
+	if r.IsSuppressed() {
+		html += fmt.Sprintf(` <span style="color:#666">(⛓ Unreachable: the parent <b>%s</b> is down)</span>`, r.SuppressedBy)
+	}
+	if r.Stat.Suppressed > 0 {
+		html += fmt.Sprintf(` <span style="color:#666">- <b>Suppressed</b>: %d</span>`, r.Stat.Suppressed)
+	}
+	return html
+}
+
+// SLAAckHTML return the acknowledgement of the probe, empty if the failure is not acknowledged
+func SLAAckHTML(r *probe.Result) string {
+	a := r.Stat.Ack
+	if a == nil {
+		return ""
+	}
+	s := fmt.Sprintf(` <span style="color:#666">(✅ Acknowledged by <b>%s</b> at %s`,
+		html.EscapeString(a.By), FormatTime(a.Time))
+	if a.Expire > 0 {
+		s += ", expires at " + FormatTime(a.ExpireTime())
+	}
+	if len(a.Comment) > 0 {
+		s += " - " + html.EscapeString(a.Comment)
+	}
+	return s + ")</span>"
+}
+
+// StatusColor return the HTML color of the status
+func StatusColor(s probe.Status) string {
+	switch s {
+	case probe.StatusUp:
+		return "#4E944F"
+	case probe.StatusWarning:
+		return "#E67E22"
+	case probe.StatusDown:
+		return "#c00"
+	}
+	return "#666"
 }
 
 // SLAHTML return a full stat report
@@ -238,11 +360,15 @@ func SLAHTMLFilter(probers []probe.Prober, filter *SLAFilter) string {
 	table := `<table style="font-size: 16px; line-height: 20px;">`
 	for _, p := range probers {
 		r := probe.GetResultData(p.Name())
-		table += SLAHTMLSection(r)
+		link := ""
+		if filter.ProbeLink {
+			link = "/probes/" + url.PathEscape(p.Name())
+		}
+		table += slaHTMLSection(r, link)
 	}
 	table += `</table>`
 
-	html = html + filter.HTML() + table
+	html = html + filter.HTML() + MaintenanceHTML(maintenance.All(), time.Now()) + table
 
 	html += HTMLFooter(FormatTime(time.Now()))
 	return html
@@ -256,6 +382,7 @@ func SLASlackSection(r *probe.Result) string {
 				"type": "mrkdwn",
 				"text": "*%s* - %s` +
 		`\n>*Availability*\n>\t` + " *Up*:  `%s`  *Down* `%s`  -  *SLA*: `%.2f %%`" +
+		`%s` +
 		`\n>*Probe Times*\n>\t*Total* : %d ( %s )` +
 		`\n>*Latest Probe*\n>\t%s | %s` +
 		`\n>\t%s"` + `
@@ -268,8 +395,13 @@ func SLASlackSection(r *probe.Result) string {
 		message = "`" + message + "`"
 	}
 
+	windows := SLAWindowsText(r, MarkdownSocial)
+	if windows != "" {
+		windows = `\n>*SLO*\n>\t` + JSONEscape(windows)
+	}
+
 	return fmt.Sprintf(json, r.Name, JSONEscape(r.Endpoint),
-		DurationStr(r.Stat.UpTime), DurationStr(r.Stat.DownTime), r.SLAPercent(),
+		DurationStr(r.Stat.UpTime), DurationStr(r.Stat.DownTime), r.SLAPercent(), windows,
 		r.Stat.Total, SLAStatusText(r.Stat, MarkdownSocial),
 		t, r.Status.Emoji()+" "+r.Status.String(), message)
 }
@@ -378,12 +510,16 @@ func SLALarkSection(r *probe.Result) string {
 	}, {
 		"tag": "div",
 		"text": {
-		  "content": "**Name:** %s - %s\n**Availability:** Up - %s, Down - %s\n**SLA:** %.2f%%\n**Probe-Times:** Total: %d ( %s )\n**Latest-Probe:** %s - %s\n**Message:**%s",
+		  "content": "**Name:** %s - %s\n**Availability:** Up - %s, Down - %s\n**SLA:** %.2f%%%s\n**Probe-Times:** Total: %d ( %s )\n**Latest-Probe:** %s - %s\n**Message:**%s",
 		  "tag": "lark_md"
 		}
 	},`
+	windows := SLAWindowsText(r, Lark)
+	if windows != "" {
+		windows = `\n**SLO:** ` + JSONEscape(windows)
+	}
 	return fmt.Sprintf(text, r.Name, r.Endpoint,
-		DurationStr(r.Stat.UpTime), DurationStr(r.Stat.DownTime), r.SLAPercent(),
+		DurationStr(r.Stat.UpTime), DurationStr(r.Stat.DownTime), r.SLAPercent(), windows,
 		r.Stat.Total, SLAStatusText(r.Stat, Lark),
 		FormatTime(r.StartTime),
 		r.Status.Emoji()+" "+r.Status.String(), JSONEscape(r.Message))
@@ -500,3 +636,25 @@ func SLAShell(probers []probe.Prober) string {
 	}
 	return string(buf)
 }
+
+// MaintenanceHTML return the HTML of the maintenance windows, empty if no window is configured
+func MaintenanceHTML(windows []maintenance.Window, now time.Time) string {
+	if len(windows) <= 0 {
+		return ""
+	}
+	span := `<span style="font-size:9pt; background-color:%s; color:white; padding:0 5px; margin-right:10px;border-radius: 3px;">`
+	s := `<div style="font-size:10pt; line-height: 24px; margin-bottom: 12px;"><b>Maintenance Windows</b>: `
+	for _, w := range windows {
+		color := "#666"
+		state := "scheduled"
+		if start, end, ok := w.Occurrence(now); ok {
+			color = "#E67E22"
+			state = fmt.Sprintf("active %s ~ %s", FormatTime(start), FormatTime(end))
+		} else if w.Expired(now) {
+			state = "expired"
+		}
+		s += fmt.Sprintf(span+"🔧 <b>%s</b> - %s</span>", color, html.EscapeString(w.Name), state)
+	}
+	s += "</div>"
+	return s
+}
@@ -22,11 +22,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"net/url"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/maintenance"
 	"github.com/wfusion/easeprobe/probe"
 	"github.com/wfusion/easeprobe/probe/base"
 	"github.com/wfusion/gofusion/common/utils/gomonkey"
@@ -124,6 +127,85 @@ func TestSLAStatusText(t *testing.T) {
 	assert.NotContains(t, str, "**")
 }
 
+func TestSLAWarning(t *testing.T) {
+	p := newDummyProber("probe1")
+	r := p.Result()
+	r.Status = probe.StatusWarning
+	r.Stat.Status[probe.StatusWarning] = 3
+	sla := SLAObject(r)
+	assert.Equal(t, int64(3), sla.ProbeTimes.Warning)
+	assert.Contains(t, SLAJSONSection(r), "\"warning\":3")
+	assert.Contains(t, SLAHTMLSection(r), StatusColor(probe.StatusWarning))
+	assert.Contains(t, SLAStatusText(r.Stat, Log), "warning:3")
+
+	assert.Equal(t, "#4E944F", StatusColor(probe.StatusUp))
+	assert.Equal(t, "#c00", StatusColor(probe.StatusDown))
+	assert.Equal(t, "#666", StatusColor(probe.StatusInit))
+}
+
+func TestSLAMaintenance(t *testing.T) {
+	p := newDummyProber("probe1")
+	r := p.Result()
+	assert.NotContains(t, SLATextSection(r), "Maintenance")
+	assert.Equal(t, "", SLAMaintenanceWindow(r, HTML))
+
+	r.Maintenance = "deploy"
+	r.Stat.MaintenanceTime = 30 * time.Minute
+	sla := SLAObject(r)
+	assert.Equal(t, 30*time.Minute, sla.Availability.MaintenanceTime)
+	assert.Equal(t, "deploy", sla.LatestProbe.Maintenance)
+	assert.Contains(t, SLATextSection(r), "Maintenance - 30m")
+	assert.Contains(t, SLATextSection(r), "Maintenance: deploy")
+	assert.Contains(t, SLAMarkdownSection(r, Markdown), "Maintenance: `deploy`")
+	assert.Contains(t, SLAHTMLSection(r), "<b>Maintenance: </b>30m")
+	assert.Contains(t, SLAHTMLSection(r), "Maintenance: <b>deploy</b>")
+
+	now := time.Now()
+	assert.Equal(t, "", MaintenanceHTML(nil, now))
+	windows := []maintenance.Window{
+		{Name: "active", Start: now.Add(-time.Minute), End: now.Add(time.Hour)},
+		{Name: "expired", Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)},
+		{Name: "weekly", Cron: "0 2 * * SAT", Duration: time.Hour},
+	}
+	for i := range windows {
+		assert.Nil(t, windows[i].Config())
+	}
+	html := MaintenanceHTML(windows, now)
+	assert.Contains(t, html, "<b>active</b> - active")
+	assert.Contains(t, html, "<b>expired</b> - expired")
+	assert.Contains(t, html, "<b>weekly</b>")
+
+	// the names are escaped
+	windows = []maintenance.Window{{Name: "<script>x</script>", Start: now.Add(-time.Minute), End: now.Add(time.Hour)}}
+	assert.Nil(t, windows[0].Config())
+	html = MaintenanceHTML(windows, now)
+	assert.NotContains(t, html, "<script>")
+	assert.Contains(t, html, "&lt;script&gt;x&lt;/script&gt;")
+	r.Maintenance = "<img src=x>"
+	assert.Contains(t, SLAMaintenanceWindow(r, HTML), "<b>&lt;img src=x&gt;</b>")
+	assert.Contains(t, SLAMaintenanceWindow(r, Text), "<img src=x>")
+}
+
+func TestSLASuppressed(t *testing.T) {
+	p := newDummyProber("probe1")
+	r := p.Result()
+	assert.Equal(t, "", SLASuppressedHTML(r))
+
+	r.Status = probe.StatusDown
+	r.SuppressedBy = "switch"
+	r.Stat.Suppressed = 5
+	sla := SLAObject(r)
+	assert.Equal(t, int64(5), sla.ProbeTimes.Suppressed)
+	assert.Equal(t, "switch", sla.LatestProbe.SuppressedBy)
+	assert.Contains(t, SLAJSONSection(r), `"suppressed_by":"switch"`)
+	html := SLAHTMLSection(r)
+	assert.Contains(t, html, "the parent <b>switch</b> is down")
+	assert.Contains(t, html, "<b>Suppressed</b>: 5")
+
+	r.Status = probe.StatusUp
+	assert.NotContains(t, SLASuppressedHTML(r), "Unreachable")
+}
+
 func TestFailed(t *testing.T) {
 	probes := getProbers()
 	var w *csv.Writer
@@ -158,6 +240,14 @@ func TestSLAFilter(t *testing.T) {
 	for _, p := range probes {
 		assert.Contains(t, html, p.Name())
 	}
+	assert.NotContains(t, html, `href="/probes/`)
+
+	filter.ProbeLink = true
+	html = SLAHTMLFilter(probes, filter)
+	for _, p := range probes {
+		assert.Contains(t, html, `href="/probes/`+url.PathEscape(p.Name())+`"`)
+	}
+	filter.ProbeLink = false
 
 	status := probe.StatusUp
 	filter.Status = &status
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/wfusion/easeprobe/probe"
+)
+
+// StatData is the data of the SLA report templates
+type StatData struct {
+	Title   string
+	Time    time.Time
+	Summary string
+	Results []*probe.Result
+	SLAs    []SLA
+}
+
+// NewStatData returns the template data of the SLA report
+func NewStatData(title string, probers []probe.Prober) StatData {
+	data := StatData{
+		Title:   title,
+		Time:    time.Now(),
+		Summary: SLASummary(probers),
+	}
+	for _, p := range probers {
+		r := p.Result()
+		data.Results = append(data.Results, r)
+		data.SLAs = append(data.SLAs, SLAObject(r))
+	}
+	return data
+}
+
+// TemplateFuncs returns the helper functions of the notification templates
+//   - json: escape the string to be embedded in the JSON string
+//   - toJSON: marshal the object to JSON
+//   - duration: format the duration, e.g. "1d2h3m4s"
+//   - time: format the time with the time zone and the time format of the settings
+//   - emoji: the emoji of the status
+//   - percent: format the percentage with two decimals
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"json": JSONEscape,
+		"toJSON": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"duration": DurationStr,
+		"time":     FormatTime,
+		"emoji": func(s probe.Status) string {
+			return s.Emoji()
+		},
+		"percent": func(f float64) string {
+			return fmt.Sprintf("%.2f", f)
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"join":  strings.Join,
+	}
+}
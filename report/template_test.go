@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStatData(t *testing.T) {
+	probers := getProbers()
+	data := NewStatData("title", probers)
+	assert.Equal(t, "title", data.Title)
+	assert.Equal(t, SLASummary(probers), data.Summary)
+	assert.Len(t, data.Results, len(probers))
+	assert.Len(t, data.SLAs, len(probers))
+	assert.Equal(t, probers[0].Name(), data.SLAs[0].Name)
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	r := newDummyResult("dummy")
+	r.Message = "line1\n\"line2\""
+	r.Stat.UpTime = 90 * time.Minute
+
+	tmpl := `{{json .Message}}|{{duration .Stat.UpTime}}|{{emoji .Status}}|{{percent .SLAPercent}}|` +
+		`{{upper .Name}}|{{lower "ABC"}}|{{toJSON .Status}}|{{time .StartTime}}`
+	tp, err := template.New("test").Funcs(TemplateFuncs()).Parse(tmpl)
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, tp.Execute(&buf, &r))
+	expected := fmt.Sprintf(`line1\n\"line2\"|%s|%s|%.2f|DUMMY|abc|"%s"|%s`,
+		DurationStr(r.Stat.UpTime), r.Status.Emoji(), r.SLAPercent(), r.Status.String(), FormatTime(r.StartTime))
+	assert.Equal(t, expected, buf.String())
+
+	tp, err = template.New("test").Funcs(TemplateFuncs()).Parse(`{{join . ", "}}`)
+	assert.Nil(t, err)
+	buf.Reset()
+	assert.Nil(t, tp.Execute(&buf, []string{"a", "b"}))
+	assert.Equal(t, "a, b", buf.String())
+}
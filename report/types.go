@@ -86,23 +86,28 @@ type FormatFuncType func(probe.Result) string
 // StatFormatFuncType is the format function for Stat
 type StatFormatFuncType func([]probe.Prober) string
 
+// BatchFormatFuncType is the format function for the grouped results
+type BatchFormatFuncType func([]probe.Result) string
+
 // FormatFuncStruct is the format function struct
+// The BatchFn is nil if the format cannot render the grouped results in one message
 type FormatFuncStruct struct {
 	ResultFn FormatFuncType
 	StatFn   StatFormatFuncType
+	BatchFn  BatchFormatFuncType
 }
 
 // FormatFuncs is the format function map
 var FormatFuncs = map[Format]FormatFuncStruct{
-	Unknown:        {ToText, SLAText},
-	Text:           {ToText, SLAText},
-	Log:            {ToLog, SLALog},
-	JSON:           {ToJSON, SLAJSON},
-	Markdown:       {ToMarkdown, SLAMarkdown},
-	MarkdownSocial: {ToMarkdownSocial, SLAMarkdownSocial},
-	HTML:           {ToHTML, SLAHTML},
-	Slack:          {ToSlack, SLASlack},
-	Lark:           {ToLark, SLALark},
-	SMS:            {ToText, SLASummary},
-	Shell:          {ToShell, SLAShell},
+	Unknown:        {ToText, SLAText, BatchText},
+	Text:           {ToText, SLAText, BatchText},
+	Log:            {ToLog, SLALog, BatchLog},
+	JSON:           {ToJSON, SLAJSON, nil},
+	Markdown:       {ToMarkdown, SLAMarkdown, BatchMarkdown},
+	MarkdownSocial: {ToMarkdownSocial, SLAMarkdownSocial, BatchMarkdownSocial},
+	HTML:           {ToHTML, SLAHTML, BatchHTML},
+	Slack:          {ToSlack, SLASlack, BatchSlack},
+	Lark:           {ToLark, SLALark, BatchLark},
+	SMS:            {ToText, SLASummary, BatchSummary},
+	Shell:          {ToShell, SLAShell, BatchShell},
 }
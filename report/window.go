@@ -0,0 +1,251 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/metric"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/store"
+)
+
+// The SLA windows
+const (
+	Window24h       = "24h"
+	Window7d        = "7d"
+	Window30d       = "30d"
+	WindowMonth     = "mtd"
+	WindowPrevMonth = "prev_month"
+)
+
+var windowTitles = map[string]string{
+	Window24h:       "24h",
+	Window7d:        "7d",
+	Window30d:       "30d",
+	WindowMonth:     "Month-to-date",
+	WindowPrevMonth: "Previous Month",
+}
+
+// windowCacheTTL is how long the SLA windows of a probe are cached,
+// so that the history is not scanned by every report and scrape
+const windowCacheTTL = time.Minute
+
+// SLAWindow is the SLA of the probe in the time window, the results in the
+// maintenance windows are not counted.
+type SLAWindow struct {
+	Window      string    `json:"window"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Total       int64     `json:"total"`
+	Up          int64     `json:"up"`
+	Down        int64     `json:"down"`
+	Maintenance int64     `json:"maintenance"`
+	SLA         float64   `json:"sla"`
+	// ErrorBudget is the remaining error budget in percentage, negative if the SLO is breached
+	ErrorBudget float64 `json:"error_budget"`
+	Met         bool    `json:"met"`
+}
+
+// Title returns the title of the window
+func (w *SLAWindow) Title() string {
+	if t, ok := windowTitles[w.Window]; ok {
+		return t
+	}
+	return w.Window
+}
+
+// ErrorBudget returns the remaining error budget in percentage for the SLA and the
+// SLO target. It's negative if the SLO is breached, and it's 0 if the SLO is 100%
+// and there is any failure.
+func ErrorBudget(sla, slo float64) float64 {
+	allowed := 100 - slo
+	if allowed <= 0 {
+		if sla >= 100 {
+			return 100
+		}
+		return 0
+	}
+	return (allowed - (100 - sla)) / allowed * 100
+}
+
+// NewSLAWindows returns the empty SLA windows at the time: the last 24 hours, 7 days,
+// 30 days, the month-to-date and the previous calendar month in the location.
+func NewSLAWindows(now time.Time, loc *time.Location) []SLAWindow {
+	if loc == nil {
+		loc = time.UTC
+	}
+	y, m, _ := now.In(loc).Date()
+	month := time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	return []SLAWindow{
+		{Window: Window24h, From: now.Add(-24 * time.Hour), To: now},
+		{Window: Window7d, From: now.Add(-7 * 24 * time.Hour), To: now},
+		{Window: Window30d, From: now.Add(-30 * 24 * time.Hour), To: now},
+		{Window: WindowMonth, From: month, To: now},
+		{Window: WindowPrevMonth, From: month.AddDate(0, -1, 0), To: month},
+	}
+}
+
+// ComputeSLAWindows computes the SLA of the windows from the history records
+func ComputeSLAWindows(windows []SLAWindow, records []store.Record, slo float64) []SLAWindow {
+	totals := make([]store.Record, len(windows))
+	for _, r := range records {
+		for i, w := range windows {
+			if !r.Time.Before(w.From) && r.Time.Before(w.To) {
+				totals[i].Merge(r)
+			}
+		}
+	}
+	for i := range windows {
+		windows[i].Total = totals[i].Count
+		windows[i].Up = totals[i].Up
+		windows[i].Down = totals[i].Down()
+		windows[i].Maintenance = totals[i].Maintenance
+		windows[i].SLA = totals[i].SLA()
+		windows[i].ErrorBudget = ErrorBudget(windows[i].SLA, slo)
+		windows[i].Met = windows[i].SLA >= slo
+	}
+	return windows
+}
+
+type windowCache struct {
+	time    time.Time
+	slo     float64
+	windows []SLAWindow
+}
+
+var (
+	windowCacheMap = map[string]windowCache{}
+	windowMutex    sync.Mutex
+)
+
+// SLAWindows returns the SLA windows of the probe from the history in the result
+// store, nil if the history is not kept.
+func SLAWindows(r *probe.Result) []SLAWindow {
+	s := store.Default()
+	if s == nil || r == nil {
+		return nil
+	}
+	now := time.Now()
+
+	windowMutex.Lock()
+	c, ok := windowCacheMap[r.Name]
+	windowMutex.Unlock()
+	if ok && c.slo == r.SLO && now.Sub(c.time) < windowCacheTTL {
+		return c.windows
+	}
+
+	windows := NewSLAWindows(now, global.GetTimeLocation())
+	from := now
+	for _, w := range windows {
+		if w.From.Before(from) {
+			from = w.From
+		}
+	}
+	records, err := s.Query(r.Name, from, now)
+	if err != nil {
+		log.Debugf("Cannot query the history of [%s] for the SLA windows: %v", r.Name, err)
+		return nil
+	}
+	windows = ComputeSLAWindows(windows, records, r.SLO)
+
+	windowMutex.Lock()
+	windowCacheMap[r.Name] = windowCache{time: now, slo: r.SLO, windows: windows}
+	windowMutex.Unlock()
+	return windows
+}
+
+// SLAWindowsText returns the SLO and the SLA windows of the probe, empty if the history is not kept
+func SLAWindowsText(r *probe.Result, t Format) string {
+	windows := SLAWindows(r)
+	if len(windows) == 0 {
+		return ""
+	}
+	format := "%s: %.2f%%"
+	budget := "Error Budget (%s): %.2f%%"
+	switch t {
+	case MarkdownSocial, Markdown:
+		format = "%s: `%.2f%%`"
+		budget = "Error Budget (%s): `%.2f%%`"
+	case HTML:
+		format = "<b>%s: </b>%.2f%%"
+		budget = "<b>Error Budget (%s): </b>%.2f%%"
+	}
+
+	items := []string{fmt.Sprintf(format, "SLO", r.SLO)}
+	for _, w := range windows {
+		items = append(items, fmt.Sprintf(format, w.Title(), w.SLA))
+	}
+	for _, w := range windows {
+		if w.Window == Window30d {
+			items = append(items, fmt.Sprintf(budget, w.Title(), w.ErrorBudget))
+		}
+	}
+	return strings.Join(items, ", ")
+}
+
+// slaCollector exports the SLO and the SLA windows of the probes as Prometheus gauges
+type slaCollector struct {
+	probers func() []probe.Prober
+	slo     *prometheus.Desc
+	sla     *prometheus.Desc
+	budget  *prometheus.Desc
+}
+
+// NewSLACollector returns the Prometheus collector of the SLO, the SLA and the error
+// budget of the windows, they are computed from the history when the metrics are collected.
+func NewSLACollector(probers func() []probe.Prober) prometheus.Collector {
+	namespace := global.GetEaseProbe().Name
+	return &slaCollector{
+		probers: probers,
+		slo: prometheus.NewDesc(metric.GetName(namespace, "sla", "slo"),
+			"The SLO target of the probe in percentage", []string{"name", "endpoint"}, nil),
+		sla: prometheus.NewDesc(metric.GetName(namespace, "sla", "window"),
+			"The SLA of the probe in the window in percentage", []string{"name", "endpoint", "window"}, nil),
+		budget: prometheus.NewDesc(metric.GetName(namespace, "sla", "error_budget"),
+			"The remaining error budget of the probe in the window in percentage", []string{"name", "endpoint", "window"}, nil),
+	}
+}
+
+// Describe implements the prometheus.Collector
+func (c *slaCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.slo
+	ch <- c.sla
+	ch <- c.budget
+}
+
+// Collect implements the prometheus.Collector
+func (c *slaCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, p := range c.probers() {
+		r := probe.GetResultData(p.Name())
+		if r == nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.slo, prometheus.GaugeValue, r.SLO, r.Name, r.Endpoint)
+		for _, w := range SLAWindows(r) {
+			ch <- prometheus.MustNewConstMetric(c.sla, prometheus.GaugeValue, w.SLA, r.Name, r.Endpoint, w.Window)
+			ch <- prometheus.MustNewConstMetric(c.budget, prometheus.GaugeValue, w.ErrorBudget, r.Name, r.Endpoint, w.Window)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package report
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/store"
+)
+
+func TestErrorBudget(t *testing.T) {
+	assert.InDelta(t, 100, ErrorBudget(100, 99.9), 0.0001)
+	assert.InDelta(t, 50, ErrorBudget(99.95, 99.9), 0.0001)
+	assert.InDelta(t, 0, ErrorBudget(99.9, 99.9), 0.0001)
+	assert.InDelta(t, -100, ErrorBudget(99.8, 99.9), 0.0001)
+	assert.Equal(t, float64(100), ErrorBudget(100, 100))
+	assert.Equal(t, float64(0), ErrorBudget(99.99, 100))
+}
+
+func TestNewSLAWindows(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	now := time.Date(2022, 3, 10, 12, 0, 0, 0, loc)
+	windows := NewSLAWindows(now, loc)
+	assert.Len(t, windows, 5)
+
+	expected := map[string][2]time.Time{
+		Window24h:       {now.Add(-24 * time.Hour), now},
+		Window7d:        {now.Add(-7 * 24 * time.Hour), now},
+		Window30d:       {now.Add(-30 * 24 * time.Hour), now},
+		WindowMonth:     {time.Date(2022, 3, 1, 0, 0, 0, 0, loc), now},
+		WindowPrevMonth: {time.Date(2022, 2, 1, 0, 0, 0, 0, loc), time.Date(2022, 3, 1, 0, 0, 0, 0, loc)},
+	}
+	for _, w := range windows {
+		assert.Equal(t, expected[w.Window][0], w.From, w.Window)
+		assert.Equal(t, expected[w.Window][1], w.To, w.Window)
+	}
+	assert.Equal(t, "Previous Month", windows[4].Title())
+}
+
+func TestComputeSLAWindows(t *testing.T) {
+	now := time.Date(2022, 3, 10, 12, 0, 0, 0, time.UTC)
+	records := []store.Record{
+		// previous month
+		{Time: time.Date(2022, 2, 10, 0, 0, 0, 0, time.UTC), Count: 100, Up: 90},
+		// month-to-date, and in the 30 days
+		{Time: time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC), Count: 100, Up: 100},
+		// the last 24 hours
+		{Time: now.Add(-time.Hour), Count: 100, Up: 99, Maintenance: 1},
+	}
+	windows := ComputeSLAWindows(NewSLAWindows(now, time.UTC), records, 99)
+	sla := map[string]SLAWindow{}
+	for _, w := range windows {
+		sla[w.Window] = w
+	}
+
+	assert.Equal(t, int64(100), sla[Window24h].Total)
+	assert.Equal(t, float64(100), sla[Window24h].SLA)
+	assert.Equal(t, int64(1), sla[Window24h].Maintenance)
+	assert.True(t, sla[Window24h].Met)
+
+	assert.Equal(t, int64(200), sla[WindowMonth].Total)
+	assert.Equal(t, int64(300), sla[Window30d].Total)
+	assert.InDelta(t, 289.0/299*100, sla[Window30d].SLA, 0.0001)
+	assert.False(t, sla[Window30d].Met)
+	assert.True(t, sla[Window30d].ErrorBudget < 0)
+
+	assert.Equal(t, int64(100), sla[WindowPrevMonth].Total)
+	assert.Equal(t, float64(90), sla[WindowPrevMonth].SLA)
+	assert.Equal(t, int64(10), sla[WindowPrevMonth].Down)
+}
+
+func TestSLAWindows(t *testing.T) {
+	r := &probe.Result{Name: "window", Endpoint: "example.com", SLO: 99}
+	assert.Nil(t, SLAWindows(r))
+	assert.Empty(t, SLAWindowsText(r, Text))
+
+	s, err := store.NewBoltStore(filepath.Join(t.TempDir(), "test.db"), store.Settings{})
+	assert.Nil(t, err)
+	store.SetDefault(s)
+	defer func() {
+		store.SetDefault(nil)
+		s.Close()
+	}()
+
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		status := probe.StatusUp
+		if i == 0 {
+			status = probe.StatusDown
+		}
+		assert.Nil(t, s.Record(probe.Result{Name: "window", StartTime: now.Add(-time.Duration(i+1) * time.Minute), Status: status}))
+	}
+	assert.Nil(t, s.Flush())
+
+	windows := SLAWindows(r)
+	assert.Len(t, windows, 5)
+	assert.Equal(t, int64(10), windows[0].Total)
+	assert.Equal(t, float64(90), windows[0].SLA)
+	assert.Equal(t, float64(-900), windows[0].ErrorBudget)
+
+	// the windows are cached
+	assert.Nil(t, s.Record(probe.Result{Name: "window", StartTime: now.Add(-30 * time.Second), Status: probe.StatusUp}))
+	assert.Nil(t, s.Flush())
+	assert.Equal(t, int64(10), SLAWindows(r)[0].Total)
+
+	text := SLAWindowsText(r, Text)
+	assert.True(t, strings.HasPrefix(text, "SLO: 99.00%, 24h: 90.00%"))
+	assert.Contains(t, text, "Error Budget (30d): -900.00%")
+	assert.Contains(t, SLAWindowsText(r, Markdown), "24h: `90.00%`")
+	assert.Contains(t, SLAWindowsText(r, HTML), "<b>24h: </b>90.00%")
+
+	sla := SLAObject(r)
+	assert.Equal(t, float64(99), sla.SLO)
+	assert.Len(t, sla.Windows, 5)
+	p := newDummyProber(r.Name)
+	probe.SetResultData(r.Name, r)
+	assert.Contains(t, SLATextSection(r), "Error Budget (30d)")
+	assert.Contains(t, SLAHTMLSection(r), "<b>Error Budget (30d): </b>")
+	assert.Contains(t, SLAMarkdownSection(r, Markdown), "- SLO: `99.00%`")
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSLACollector(func() []probe.Prober {
+		return []probe.Prober{p}
+	}))
+	families, err := registry.Gather()
+	assert.Nil(t, err)
+	assert.Len(t, families, 3)
+	for _, f := range families {
+		if strings.HasSuffix(f.GetName(), "sla_window") {
+			assert.Len(t, f.GetMetric(), 5)
+		}
+		if strings.HasSuffix(f.GetName(), "sla_slo") {
+			assert.Equal(t, float64(99), f.GetMetric()[0].GetGauge().GetValue())
+		}
+	}
+}
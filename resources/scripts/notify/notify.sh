@@ -24,6 +24,11 @@ if [[ ${EASEPROBE_TYPE} == "Status" ]]; then
     echo "----------------------------------------------------"
 fi
 
+if [[ ${EASEPROBE_TYPE} == "Batch" ]]; then
+    echo "Title: ${EASEPROBE_TITLE}"
+    echo "----------------------------------------------------"
+fi
+
 echo "${EASEPROBE_JSON}"
 echo "----------------------------------------------------"
 echo "${EASEPROBE_CSV}"
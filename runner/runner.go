@@ -0,0 +1,441 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package runner runs the probers, and controls each of them at runtime.
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/channel"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+// ErrNotFound is the error of the probe is not found
+var ErrNotFound = errors.New("probe is not found")
+
+// worker is the goroutine of a prober with its own control channels
+type worker struct {
+	prober probe.Prober
+	paused int32                  // 1 means the scheduled probe is skipped
+	done   chan bool              // closed to stop the worker
+	run    chan chan probe.Result // request to run the probe immediately
+	exec   chan func()            // request to run a function in the worker goroutine
+	exited chan bool              // closed after the worker exits
+}
+
+func newWorker(p probe.Prober) *worker {
+	return &worker{
+		prober: p,
+		paused: 0,
+		done:   make(chan bool),
+		run:    make(chan chan probe.Result),
+		exec:   make(chan func()),
+		exited: make(chan bool),
+	}
+}
+
+func (w *worker) isPaused() bool {
+	return atomic.LoadInt32(&w.paused) == 1
+}
+
+// Runner runs the probers
+type Runner struct {
+	mutex    sync.RWMutex
+	settings global.ProbeSettings
+	probers  []probe.Prober
+	workers  map[string]*worker
+	wg       sync.WaitGroup
+	save     chan probe.Result
+}
+
+// New create a Runner, the probe results are sent to the save channel
+func New(settings global.ProbeSettings, save chan probe.Result) *Runner {
+	return &Runner{
+		settings: settings,
+		probers:  []probe.Prober{},
+		workers:  map[string]*worker{},
+		save:     save,
+	}
+}
+
+// SetSettings sets the global probe settings, which is used by the new probers
+func (r *Runner) SetSettings(settings global.ProbeSettings) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.settings = settings
+}
+
+// Start starts all of the probers, the probers with bad configuration are skipped.
+func (r *Runner) Start(probers []probe.Prober) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.probers = append(r.probers, probers...)
+	if len(probers) == 0 {
+		return
+	}
+
+	// we need to run all probers in equally distributed time, not at the same time.
+	timeGap := global.DefaultProbeInterval / time.Duration(len(probers))
+	// if less than or equal to 60 probers, use 1 second instead
+	if time.Duration(len(probers))*time.Second <= time.Minute {
+		timeGap = time.Second
+	}
+	log.Debugf("Start Time Gap: %v = %v / %d", timeGap, global.DefaultProbeInterval, len(probers))
+
+	for i := 0; i < len(probers); i++ {
+		p := probers[i]
+		if p.Result().Status == probe.StatusBad {
+			continue
+		}
+		log.Infof("Ready to monitor(%s): %s - %s", p.Kind(), p.Result().Name, p.Result().Endpoint)
+		r.start(p, time.Duration(i)*timeGap)
+	}
+}
+
+// Stop stops all of the probers, and waits for all of them exit
+func (r *Runner) Stop() {
+	r.mutex.Lock()
+	for name, w := range r.workers {
+		close(w.done)
+		delete(r.workers, name)
+	}
+	r.mutex.Unlock()
+
+	r.wg.Wait()
+}
+
+// Probers returns all of the probers
+func (r *Runner) Probers() []probe.Prober {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	probers := make([]probe.Prober, len(r.probers))
+	copy(probers, r.probers)
+	return probers
+}
+
+// Get returns the prober by name
+func (r *Runner) Get(name string) (probe.Prober, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	i := r.find(name)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return r.probers[i], nil
+}
+
+// IsRunning returns true if the prober is running
+func (r *Runner) IsRunning(name string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, ok := r.workers[name]
+	return ok
+}
+
+// IsPaused returns true if the prober is paused
+func (r *Runner) IsPaused(name string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	w, ok := r.workers[name]
+	return ok && w.isPaused()
+}
+
+// Pause pauses the scheduled probe of the prober
+func (r *Runner) Pause(name string) error {
+	w, err := r.worker(name)
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt32(&w.paused, 1)
+	log.Infof("[%s / %s] The probe is paused", w.prober.Kind(), name)
+	return nil
+}
+
+// Resume resumes the scheduled probe of the prober
+func (r *Runner) Resume(name string) error {
+	w, err := r.worker(name)
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt32(&w.paused, 0)
+	log.Infof("[%s / %s] The probe is resumed", w.prober.Kind(), name)
+	return nil
+}
+
+// RunNow runs the probe immediately out of the schedule, and returns the result.
+// It works even if the prober is paused.
+func (r *Runner) RunNow(name string) (probe.Result, error) {
+	w, err := r.worker(name)
+	if err != nil {
+		return probe.Result{}, err
+	}
+
+	reply := make(chan probe.Result, 1)
+	select {
+	case w.run <- reply:
+	case <-w.exited:
+		return probe.Result{}, fmt.Errorf("probe [%s] is stopped", name)
+	}
+	// the worker always replies once it accepts the request
+	return <-reply, nil
+}
+
+// Ack acknowledges the failure of the prober, the repeat notification and the escalation
+// stop until the probe recovers or the acknowledgement expires.
+func (r *Runner) Ack(name string, a probe.AckData) (*probe.AckData, error) {
+	var result *probe.AckData
+	err := r.do(name, func(p probe.Prober) error {
+		res := p.Result()
+		if res.Status != probe.StatusDown {
+			return fmt.Errorf("probe [%s] is not down - %s", name, res.Status)
+		}
+		if len(a.By) <= 0 {
+			return fmt.Errorf("the author of the acknowledgement is required")
+		}
+		res.Stat.Ack = &a
+		res.AckURL = ""
+		result = a.Clone()
+		log.Infof("[%s / %s] The failure is acknowledged by %s", p.Kind(), name, a.By)
+		return nil
+	})
+	return result, err
+}
+
+// Unack removes the acknowledgement of the prober
+func (r *Runner) Unack(name string) error {
+	return r.do(name, func(p probe.Prober) error {
+		res := p.Result()
+		if !res.IsAcked() {
+			return fmt.Errorf("probe [%s] is not acknowledged", name)
+		}
+		res.Stat.Ack = nil
+		log.Infof("[%s / %s] The acknowledgement is removed", p.Kind(), name)
+		return nil
+	})
+}
+
+// do runs the function in the worker goroutine of the prober, so that the
+// result of the prober is not changed during the probe.
+func (r *Runner) do(name string, fn func(p probe.Prober) error) error {
+	w, err := r.worker(name)
+	if err != nil {
+		return err
+	}
+
+	reply := make(chan error, 1)
+	select {
+	case w.exec <- func() { reply <- fn(w.prober) }:
+	case <-w.exited:
+		return fmt.Errorf("probe [%s] is stopped", name)
+	}
+	if err := <-reply; err != nil {
+		return err
+	}
+	// update the result data, so that the change is visible in the SLA report immediately
+	probe.SetResultData(name, w.prober.Result())
+	return nil
+}
+
+// Add configures a new prober and starts it immediately
+func (r *Runner) Add(p probe.Prober) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.find(p.Name()) >= 0 {
+		return fmt.Errorf("probe [%s] is duplicated", p.Name())
+	}
+	if err := r.configLabels(p); err != nil {
+		return err
+	}
+
+	// check the dependency cycle before the prober is configured
+	probers := append(r.probers[:len(r.probers):len(r.probers)], p)
+	g, err := probe.NewDependencyGraph(probers)
+	if err != nil {
+		return err
+	}
+	if err := p.Config(r.settings); err != nil {
+		return err
+	}
+	probe.SetDependencyGraph(g)
+
+	// only the running channels could receive the probe results
+	for _, cName := range p.Channels() {
+		ch := channel.GetChannel(cName)
+		if ch == nil {
+			log.Warnf("[%s / %s] The channel [%s] is not found, the notification would not be sent to it",
+				p.Kind(), p.Name(), cName)
+			continue
+		}
+		ch.SetProber(p)
+	}
+
+	r.probers = probers
+	r.start(p, 0)
+	log.Infof("[%s / %s] The probe is added - %s", p.Kind(), p.Name(), p.Result().Endpoint)
+	return nil
+}
+
+// Remove stops the prober and removes it
+func (r *Runner) Remove(name string) error {
+	r.mutex.Lock()
+	i := r.find(name)
+	if i < 0 {
+		r.mutex.Unlock()
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	p := r.probers[i]
+
+	w, ok := r.workers[name]
+	if ok {
+		close(w.done)
+		delete(r.workers, name)
+	}
+
+	r.probers = append(r.probers[:i:i], r.probers[i+1:]...)
+	// removing a probe never makes a dependency cycle
+	if g, err := probe.NewDependencyGraph(r.probers); err == nil {
+		probe.SetDependencyGraph(g)
+	}
+	r.mutex.Unlock()
+
+	// wait for the worker exits without the lock, because the running probe might take a long time
+	if ok {
+		<-w.exited
+	}
+	channel.RemoveProber(p)
+
+	log.Infof("[%s / %s] The probe is removed", p.Kind(), p.Name())
+	return nil
+}
+
+func (r *Runner) find(name string) int {
+	for i, p := range r.probers {
+		if p.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *Runner) worker(name string) (*worker, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	w, ok := r.workers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return w, nil
+}
+
+// configLabels makes the new prober has the same set of labels as the running probers,
+// because Prometheus requires all of the metrics with the same name have the same set of labels.
+// The running probers are not touched, so the new prober cannot bring a new label.
+func (r *Runner) configLabels(p probe.Prober) error {
+	labels := map[string]bool{}
+	for _, rp := range r.probers {
+		for k := range rp.LabelMap() {
+			labels[k] = true
+		}
+	}
+	ls := p.LabelMap()
+	for k := range ls {
+		if len(r.probers) > 0 && !labels[k] {
+			return fmt.Errorf("label [%s] is not used by the running probes", k)
+		}
+	}
+	if ls == nil {
+		ls = map[string]string{}
+		p.SetLabelMap(ls)
+	}
+	for k := range labels {
+		if _, ok := ls[k]; !ok {
+			ls[k] = ""
+		}
+	}
+	return nil
+}
+
+func (r *Runner) start(p probe.Prober, delay time.Duration) {
+	w := newWorker(p)
+	r.workers[p.Name()] = w
+	r.wg.Add(1)
+	go r.work(w, delay)
+}
+
+func (r *Runner) work(w *worker, delay time.Duration) {
+	defer r.wg.Done()
+	defer close(w.exited)
+
+	p := w.prober
+	// Sleep a round time to avoid all probers start at the same time.
+	log.Debugf("[%s / %s] Delay %v to start the probe work", p.Kind(), p.Name(), delay)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			log.Infof("%s / %s - Received the done signal, exiting...", p.Kind(), p.Name())
+			return
+		case reply := <-w.run:
+			log.Infof("[%s / %s] Run the probe immediately", p.Kind(), p.Name())
+			reply <- r.probe(p)
+		case fn := <-w.exec:
+			fn()
+		case <-timer.C:
+			if w.isPaused() {
+				log.Debugf("[%s / %s] The probe is paused, skipped", p.Kind(), p.Name())
+			} else {
+				r.probe(p)
+			}
+			timer.Reset(p.Interval())
+			log.Debugf("%s / %s - %s Interval is up, continue...", p.Kind(), p.Name(), p.Interval())
+		}
+	}
+}
+
+func (r *Runner) probe(p probe.Prober) probe.Result {
+	res := p.Probe()
+	log.Debugf("%s: %s", p.Kind(), res.DebugJSON())
+	// send the result to the persistent channel
+	if r.save != nil {
+		r.save <- res
+	}
+	// send the result to all channels
+	for _, cName := range p.Channels() {
+		if ch := channel.GetChannel(cName); ch != nil {
+			ch.Send(res)
+		}
+	}
+	// send the result to the routing rules
+	channel.Dispatch(res)
+	return res
+}
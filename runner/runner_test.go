@@ -0,0 +1,287 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/probe/base"
+)
+
+type dummyProber struct {
+	base.DefaultProbe
+	count int32
+	down  int32
+	block chan bool // the probe waits for it if it is not nil
+}
+
+func (d *dummyProber) Config(g global.ProbeSettings) error {
+	d.DefaultProbe.Config(g, d.ProbeKind, d.ProbeTag, d.ProbeName, "endpoint", d.DoProbe)
+	return nil
+}
+
+func (d *dummyProber) DoProbe() (bool, string) {
+	atomic.AddInt32(&d.count, 1)
+	if d.block != nil {
+		<-d.block
+	}
+	if atomic.LoadInt32(&d.down) == 1 {
+		return false, "failure"
+	}
+	return true, "success"
+}
+
+func (d *dummyProber) Count() int32 {
+	return atomic.LoadInt32(&d.count)
+}
+
+func newDummyProber(name string, depends ...string) *dummyProber {
+	return &dummyProber{
+		DefaultProbe: base.DefaultProbe{
+			ProbeKind:         "dummy",
+			ProbeTag:          "tag",
+			ProbeName:         name,
+			ProbeTimeInterval: time.Hour,
+			ProbeDependsOn:    depends,
+			ProbeResult:       &probe.Result{},
+		},
+	}
+}
+
+// drain the results to avoid blocking the workers
+func drain(save chan probe.Result) chan bool {
+	done := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-save:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return done
+}
+
+func TestRunner(t *testing.T) {
+	save := make(chan probe.Result)
+	done := drain(save)
+	defer close(done)
+
+	p1 := newDummyProber("p1")
+	p1.Config(global.ProbeSettings{})
+	bad := newDummyProber("bad")
+	bad.Config(global.ProbeSettings{})
+	bad.Result().Status = probe.StatusBad
+
+	r := New(global.ProbeSettings{}, save)
+	r.Start([]probe.Prober{p1, bad})
+	assert.Len(t, r.Probers(), 2)
+	assert.True(t, r.IsRunning("p1"))
+	assert.False(t, r.IsRunning("bad"))
+
+	// the first probe starts without delay
+	assert.Eventually(t, func() bool { return p1.Count() == 1 }, time.Second, 10*time.Millisecond)
+
+	// run now
+	res, err := r.RunNow("p1")
+	assert.Nil(t, err)
+	assert.Equal(t, "p1", res.Name)
+	assert.Equal(t, probe.StatusUp, res.Status)
+	assert.Equal(t, int32(2), p1.Count())
+
+	_, err = r.RunNow("bad")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	// pause & resume
+	assert.Nil(t, r.Pause("p1"))
+	assert.True(t, r.IsPaused("p1"))
+	_, err = r.RunNow("p1")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), p1.Count())
+	assert.Nil(t, r.Resume("p1"))
+	assert.False(t, r.IsPaused("p1"))
+	assert.True(t, errors.Is(r.Pause("none"), ErrNotFound))
+	assert.True(t, errors.Is(r.Resume("none"), ErrNotFound))
+
+	p, err := r.Get("bad")
+	assert.Nil(t, err)
+	assert.Equal(t, bad, p)
+	_, err = r.Get("none")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	r.Stop()
+	assert.False(t, r.IsRunning("p1"))
+	_, err = r.RunNow("p1")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestPausedProbe(t *testing.T) {
+	save := make(chan probe.Result)
+	done := drain(save)
+	defer close(done)
+
+	p := newDummyProber("paused")
+	p.Config(global.ProbeSettings{})
+	p.ProbeTimeInterval = 10 * time.Millisecond
+
+	r := New(global.ProbeSettings{}, save)
+	r.Start([]probe.Prober{p})
+	assert.Eventually(t, func() bool { return p.Count() > 0 }, time.Second, 5*time.Millisecond)
+
+	assert.Nil(t, r.Pause("paused"))
+	// wait for the in-flight probe
+	time.Sleep(20 * time.Millisecond)
+	cnt := p.Count()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, cnt, p.Count())
+
+	assert.Nil(t, r.Resume("paused"))
+	assert.Eventually(t, func() bool { return p.Count() > cnt }, time.Second, 5*time.Millisecond)
+	r.Stop()
+}
+
+func TestAddRemove(t *testing.T) {
+	save := make(chan probe.Result)
+	done := drain(save)
+	defer close(done)
+	defer probe.SetDependencyGraph(nil)
+
+	p1 := newDummyProber("p1")
+	p1.Config(global.ProbeSettings{})
+	r := New(global.ProbeSettings{}, save)
+	r.Start([]probe.Prober{p1})
+
+	p2 := newDummyProber("p2", "p1")
+	assert.Nil(t, r.Add(p2))
+	assert.Len(t, r.Probers(), 2)
+	assert.True(t, r.IsRunning("p2"))
+	assert.Eventually(t, func() bool { return p2.Count() == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"p2"}, probe.GetDependencyGraph().Dependents("p1"))
+
+	// duplicated name
+	assert.NotNil(t, r.Add(newDummyProber("p1")))
+
+	// dependency cycle
+	p3 := newDummyProber("p3", "p3")
+	err := r.Add(p3)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "cycle")
+	assert.Len(t, r.Probers(), 2)
+	assert.False(t, r.IsRunning("p3"))
+	assert.Empty(t, p3.Result().Endpoint) // the prober is not configured
+
+	// unknown labels
+	p4 := newDummyProber("p4")
+	p4.Labels = map[string]string{"env": "prod"}
+	err = r.Add(p4)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "env")
+
+	// the new global settings are used by the new probers
+	r.SetSettings(global.ProbeSettings{Interval: 2 * time.Hour})
+	p5 := newDummyProber("p5")
+	p5.ProbeTimeInterval = 0
+	assert.Nil(t, r.Add(p5))
+	assert.Equal(t, 2*time.Hour, p5.Interval())
+	assert.Nil(t, r.Remove("p5"))
+
+	assert.Nil(t, r.Remove("p1"))
+	assert.Len(t, r.Probers(), 1)
+	assert.False(t, r.IsRunning("p1"))
+	assert.Empty(t, probe.GetDependencyGraph().Parents("p2"))
+	assert.True(t, errors.Is(r.Remove("p1"), ErrNotFound))
+
+	// the runner is not locked while the removed probe is running
+	p6 := newDummyProber("p6")
+	p6.block = make(chan bool)
+	assert.Nil(t, r.Add(p6))
+	assert.Eventually(t, func() bool { return p6.Count() == 1 }, time.Second, 10*time.Millisecond)
+	removed := make(chan error)
+	go func() { removed <- r.Remove("p6") }()
+	assert.Eventually(t, func() bool { return !r.IsRunning("p6") }, time.Second, 10*time.Millisecond)
+	assert.Len(t, r.Probers(), 1)
+	close(p6.block)
+	assert.Nil(t, <-removed)
+
+	r.Stop()
+}
+
+func TestAck(t *testing.T) {
+	save := make(chan probe.Result)
+	done := drain(save)
+	defer close(done)
+
+	// the probe starts as up, otherwise the result of the previous run is restored
+	probe.CleanData(nil)
+	p := newDummyProber("ack")
+	p.Config(global.ProbeSettings{})
+
+	r := New(global.ProbeSettings{}, save)
+	r.Start([]probe.Prober{p})
+	defer r.Stop()
+
+	_, err := r.Ack("unknown", probe.AckData{By: "alice"})
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.NotNil(t, r.Unack("unknown"))
+
+	// the probe is not down yet, the first probe could be run or not
+	_, err = r.Ack("ack", probe.AckData{By: "alice"})
+	assert.NotNil(t, err)
+
+	atomic.StoreInt32(&p.down, 1)
+	res, err := r.RunNow("ack")
+	assert.Nil(t, err)
+	assert.Equal(t, probe.StatusDown, res.Status)
+
+	// the author is required
+	_, err = r.Ack("ack", probe.AckData{})
+	assert.NotNil(t, err)
+	assert.NotNil(t, r.Unack("ack"))
+
+	now := time.Now()
+	a, err := r.Ack("ack", probe.AckData{By: "alice", Comment: "on it", Time: now, Expire: time.Hour})
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", a.By)
+	assert.True(t, p.Result().IsAcked())
+	assert.Equal(t, "on it", probe.GetResultData("ack").Stat.Ack.Comment)
+
+	// the acknowledgement is kept while the probe is down
+	res, err = r.RunNow("ack")
+	assert.Nil(t, err)
+	assert.True(t, res.IsAcked())
+	assert.False(t, res.Stat.NotificationStrategyData.IsSent)
+
+	assert.Nil(t, r.Unack("ack"))
+	assert.False(t, p.Result().IsAcked())
+
+	// the acknowledgement is cleared when the probe recovers
+	_, err = r.Ack("ack", probe.AckData{By: "bob", Time: now})
+	assert.Nil(t, err)
+	atomic.StoreInt32(&p.down, 0)
+	res, err = r.RunNow("ack")
+	assert.Nil(t, err)
+	assert.Equal(t, probe.StatusUp, res.Status)
+	assert.False(t, res.IsAcked())
+}
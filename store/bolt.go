@@ -0,0 +1,332 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/probe"
+	bolt "go.etcd.io/bbolt"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	latestBucket   = []byte("latest")  // probe name => the latest result
+	historyBucket  = []byte("history") // probe name => bucket of time => record
+	metaBucket     = []byte("meta")
+	downsampledKey = []byte("downsampled") // the history before this time is downsampled
+)
+
+// BoltStore keeps the latest results and the history of the probes in a bolt database.
+// The history of each probe is a bucket, the key is the big-endian unix nano time, so
+// that the records are ordered by time.
+type BoltStore struct {
+	file     string
+	settings Settings
+	db       *bolt.DB
+	mutex    sync.Mutex
+	pending  []pendingRecord // the history records which are not persisted yet
+}
+
+// pendingRecord is the history record of the probe waiting for Flush
+type pendingRecord struct {
+	name   string
+	record Record
+}
+
+// NewBoltStore opens the bolt store, and restores the latest results into memory
+func NewBoltStore(file string, settings Settings) (*BoltStore, error) {
+	settings.normalize()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(file, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{latestBucket, historyBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &BoltStore{file: file, settings: settings, db: db}
+	if err := s.restore(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	log.Infof("The result store is opened: %s", file)
+	return s, nil
+}
+
+// restore loads the latest results into memory, they override the results of the SLA
+// data file, so the data file is migrated into the store at the first start.
+func (s *BoltStore) restore() error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(latestBucket).ForEach(func(k, v []byte) error {
+			var r probe.Result
+			if err := yaml.Unmarshal(v, &r); err != nil {
+				log.Warnf("Cannot restore the result of [%s]: %v", k, err)
+				return nil
+			}
+			probe.SetResultData(string(k), &r)
+			return nil
+		})
+	})
+}
+
+// Kind returns the kind of the store
+func (s *BoltStore) Kind() string {
+	return Bolt
+}
+
+// Record updates the latest result in memory, and buffers it for the history.
+// The buffered records are appended to the history by Flush in one transaction,
+// because every bolt transaction syncs the file.
+func (s *BoltStore) Record(result probe.Result) error {
+	probe.SetResultData(result.Name, &result)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pending = append(s.pending, pendingRecord{name: result.Name, record: NewRecord(result)})
+	return nil
+}
+
+// Flush appends the buffered records to the history, and saves the latest results of all probes.
+// The records are kept in the buffer if they fail to be saved, so that they are retried by the next Flush.
+func (s *BoltStore) Flush() error {
+	s.mutex.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mutex.Unlock()
+
+	data := probe.GetAllResultData()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		history := tx.Bucket(historyBucket)
+		for _, p := range pending {
+			buf, err := json.Marshal(p.record)
+			if err != nil {
+				return err
+			}
+			b, err := history.CreateBucketIfNotExists([]byte(p.name))
+			if err != nil {
+				return err
+			}
+			if err := b.Put(timeKey(p.record.Time), buf); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.DeleteBucket(latestBucket); err != nil {
+			return err
+		}
+		b, err := tx.CreateBucket(latestBucket)
+		if err != nil {
+			return err
+		}
+		for name, r := range data {
+			buf, err := yaml.Marshal(r)
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(name), buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.mutex.Lock()
+		s.pending = append(pending, s.pending...)
+		s.mutex.Unlock()
+	}
+	return err
+}
+
+// Compact removes the history older than the retention, and downsamples the
+// history older than the downsampling time which is not downsampled yet.
+// The buffered records are flushed at first, so that they are compacted as well.
+func (s *BoltStore) Compact(now time.Time) error {
+	if err := s.Flush(); err != nil {
+		return err
+	}
+
+	var expire, cutoff time.Time
+	if s.settings.Retention > 0 {
+		expire = now.Add(-s.settings.Retention)
+	}
+	if s.settings.Downsample.After >= 0 {
+		cutoff = now.Add(-s.settings.Downsample.After).Truncate(s.settings.Downsample.Interval)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		var since time.Time
+		if v := meta.Get(downsampledKey); len(v) == 8 {
+			since = keyTime(v)
+		}
+		if !expire.IsZero() && expire.After(since) {
+			since = expire
+		}
+
+		history := tx.Bucket(historyBucket)
+		err := history.ForEach(func(name, v []byte) error {
+			b := history.Bucket(name)
+			if b == nil {
+				return nil
+			}
+			if !expire.IsZero() {
+				if err := deleteRange(b, time.Time{}, expire); err != nil {
+					return err
+				}
+			}
+			if !cutoff.IsZero() && cutoff.After(since) {
+				return s.downsample(b, since, cutoff)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if !cutoff.IsZero() && cutoff.After(since) {
+			return meta.Put(downsampledKey, timeKey(cutoff))
+		}
+		return nil
+	})
+}
+
+// downsample replaces the records in [from, to) with the aggregated records
+func (s *BoltStore) downsample(b *bolt.Bucket, from, to time.Time) error {
+	records, err := scan(b, from, to)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	if err := deleteRange(b, from, to); err != nil {
+		return err
+	}
+	for _, r := range Aggregate(records, s.settings.Downsample.Interval) {
+		buf, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(timeKey(r.Time), buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Query returns the history records of the probe in [from, to), the zero time means no limit.
+// The records which are not flushed yet are not included.
+func (s *BoltStore) Query(name string, from, to time.Time) ([]Record, error) {
+	records := []Record{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(historyBucket).Bucket([]byte(name))
+		if b == nil {
+			return nil
+		}
+		var err error
+		records, err = scan(b, from, to)
+		return err
+	})
+	return records, err
+}
+
+// Close saves the latest results and closes the database
+func (s *BoltStore) Close() error {
+	if err := s.Flush(); err != nil {
+		s.db.Close()
+		return err
+	}
+	return s.db.Close()
+}
+
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
+
+func keyTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key)))
+}
+
+// each calls the function with the keys and values of the bucket in [from, to)
+func each(b *bolt.Bucket, from, to time.Time, fn func(k, v []byte) error) error {
+	c := b.Cursor()
+	var k, v []byte
+	if from.IsZero() {
+		k, v = c.First()
+	} else {
+		k, v = c.Seek(timeKey(from))
+	}
+	var end []byte
+	if !to.IsZero() {
+		end = timeKey(to)
+	}
+	for ; k != nil && (end == nil || bytes.Compare(k, end) < 0); k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scan(b *bolt.Bucket, from, to time.Time) ([]Record, error) {
+	records := []Record{}
+	err := each(b, from, to, func(k, v []byte) error {
+		var r Record
+		if err := json.Unmarshal(v, &r); err != nil {
+			log.Warnf("Cannot decode the history record at %s: %v", keyTime(k), err)
+			return nil
+		}
+		records = append(records, r)
+		return nil
+	})
+	return records, err
+}
+
+func deleteRange(b *bolt.Bucket, from, to time.Time) error {
+	keys := [][]byte{}
+	each(b, from, to, func(k, v []byte) error {
+		keys = append(keys, append([]byte{}, k...))
+		return nil
+	})
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func TestBoltStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.db")
+	s, err := NewBoltStore(file, Settings{})
+	assert.Nil(t, err)
+
+	base := time.Now().Truncate(time.Hour).Add(-time.Hour)
+	for i := 0; i < 10; i++ {
+		r := probe.Result{
+			Name:          "bolt-store",
+			StartTime:     base.Add(time.Duration(i) * time.Minute),
+			RoundTripTime: time.Duration(i) * time.Millisecond,
+			Status:        probe.StatusUp,
+		}
+		if i%2 == 1 {
+			r.Status = probe.StatusDown
+		}
+		assert.Nil(t, s.Record(r))
+	}
+	assert.Equal(t, probe.StatusDown, probe.GetResultData("bolt-store").Status)
+
+	// the history is saved by Flush
+	records, err := s.Query("bolt-store", time.Time{}, time.Time{})
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+	assert.Nil(t, s.Flush())
+	assert.Empty(t, s.pending)
+
+	records, err = s.Query("bolt-store", time.Time{}, time.Time{})
+	assert.Nil(t, err)
+	assert.Len(t, records, 10)
+	assert.Equal(t, base, records[0].Time.Local())
+	records, err = s.Query("bolt-store", base.Add(2*time.Minute), base.Add(5*time.Minute))
+	assert.Nil(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, probe.StatusUp, records[0].Status)
+	assert.Equal(t, probe.StatusDown, records[1].Status)
+	records, err = s.Query("none", time.Time{}, time.Time{})
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+
+	// the latest results are restored after reopen
+	assert.Nil(t, s.Close())
+	probe.SetResultData("bolt-store", &probe.Result{Name: "bolt-store"})
+	s, err = NewBoltStore(file, Settings{})
+	assert.Nil(t, err)
+	assert.Equal(t, probe.StatusDown, probe.GetResultData("bolt-store").Status)
+	assert.Nil(t, s.Close())
+}
+
+func TestBoltStoreCompact(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.db")
+	settings := Settings{
+		Retention:  48 * time.Hour,
+		Downsample: Downsample{After: 24 * time.Hour, Interval: time.Hour},
+	}
+	s, err := NewBoltStore(file, settings)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	now := time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC)
+	// one result every 10 minutes in the last 3 days
+	for d := 72 * time.Hour; d > 0; d -= 10 * time.Minute {
+		assert.Nil(t, s.Record(probe.Result{
+			Name:      "compact",
+			StartTime: now.Add(-d),
+			Status:    probe.StatusUp,
+		}))
+	}
+
+	assert.Nil(t, s.Compact(now))
+	records, err := s.Query("compact", time.Time{}, time.Time{})
+	assert.Nil(t, err)
+	// 24 hourly records of the second day, and 144 raw records of the last day
+	assert.Len(t, records, 24+144)
+	assert.Equal(t, now.Add(-48*time.Hour), records[0].Time.UTC())
+	assert.Equal(t, int64(6), records[0].Count)
+	assert.Equal(t, int64(1), records[24].Count)
+
+	// the downsampled records are not downsampled again
+	assert.Nil(t, s.Compact(now.Add(time.Hour)))
+	records, err = s.Query("compact", time.Time{}, time.Time{})
+	assert.Nil(t, err)
+	assert.Len(t, records, 24+138)
+	assert.Equal(t, now.Add(-47*time.Hour), records[0].Time.UTC())
+	assert.Equal(t, int64(6), records[23].Count)
+	assert.Equal(t, now.Add(-23*time.Hour), records[24].Time.UTC())
+
+	// keep the history forever and never downsample
+	s2, err := NewBoltStore(filepath.Join(t.TempDir(), "forever.db"), Settings{
+		Retention:  -1,
+		Downsample: Downsample{After: -1},
+	})
+	assert.Nil(t, err)
+	defer s2.Close()
+	assert.Nil(t, s2.Record(probe.Result{Name: "forever", StartTime: now.Add(-1000 * time.Hour)}))
+	assert.Nil(t, s2.Compact(now))
+	records, err = s2.Query("forever", time.Time{}, time.Time{})
+	assert.Nil(t, err)
+	assert.Len(t, records, 1)
+}
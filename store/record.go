@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"time"
+
+	"github.com/wfusion/easeprobe/probe"
+)
+
+// Record is a point of the probe history. A raw record is a single probe result,
+// a downsampled record aggregates the results in a time bucket.
+type Record struct {
+	Time        time.Time     `json:"time" yaml:"time"`
+	Status      probe.Status  `json:"status" yaml:"status"`           // the worst status in the record
+	RTT         time.Duration `json:"rtt" yaml:"rtt"`                 // the average round trip time
+	MaxRTT      time.Duration `json:"max_rtt" yaml:"max_rtt"`         // the max round trip time
+	Count       int64         `json:"count" yaml:"count"`             // the number of the results
+	Up          int64         `json:"up" yaml:"up"`                   // the number of the available results
+	Maintenance int64         `json:"maintenance" yaml:"maintenance"` // the number of the unavailable results in the maintenance window
+	Message     string        `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// NewRecord creates the raw record from the probe result
+func NewRecord(r probe.Result) Record {
+	rec := Record{
+		Time:   r.StartTime,
+		Status: r.Status,
+		RTT:    r.RoundTripTime,
+		MaxRTT: r.RoundTripTime,
+		Count:  1,
+	}
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	if r.Status.IsAvailable() {
+		rec.Up = 1
+	} else {
+		rec.Message = r.Message
+		if r.InMaintenance() {
+			rec.Maintenance = 1
+		}
+	}
+	return rec
+}
+
+// Down returns the number of the unavailable results out of the maintenance window
+func (r *Record) Down() int64 {
+	return r.Count - r.Up - r.Maintenance
+}
+
+// SLA returns the availability percentage of the record, the results in the
+// maintenance window are not counted
+func (r *Record) SLA() float64 {
+	total := r.Count - r.Maintenance
+	if total <= 0 {
+		return 100
+	}
+	return float64(r.Up) / float64(total) * 100
+}
+
+// severity orders the status from the best to the worst
+func severity(s probe.Status) int {
+	switch s {
+	case probe.StatusUp:
+		return 0
+	case probe.StatusWarning:
+		return 1
+	case probe.StatusInit:
+		return 2
+	case probe.StatusDown:
+		return 4
+	case probe.StatusBad:
+		return 5
+	}
+	return 3
+}
+
+// Merge aggregates the other record into the record, the time of the record is kept
+func (r *Record) Merge(o Record) {
+	if r.Count+o.Count > 0 {
+		r.RTT = time.Duration((int64(r.RTT)*r.Count + int64(o.RTT)*o.Count) / (r.Count + o.Count))
+	}
+	if o.MaxRTT > r.MaxRTT {
+		r.MaxRTT = o.MaxRTT
+	}
+	if severity(o.Status) > severity(r.Status) {
+		r.Status = o.Status
+		r.Message = o.Message
+	}
+	r.Count += o.Count
+	r.Up += o.Up
+	r.Maintenance += o.Maintenance
+}
+
+// Aggregate aggregates the records ordered by time into the buckets of the interval
+func Aggregate(records []Record, interval time.Duration) []Record {
+	result := []Record{}
+	if interval <= 0 {
+		return append(result, records...)
+	}
+	for _, r := range records {
+		t := r.Time.Truncate(interval)
+		if n := len(result); n > 0 && result[n-1].Time.Equal(t) {
+			result[n-1].Merge(r)
+			continue
+		}
+		r.Time = t
+		result = append(result, r)
+	}
+	return result
+}
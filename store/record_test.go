@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func TestNewRecord(t *testing.T) {
+	now := time.Now()
+	r := NewRecord(probe.Result{StartTime: now, Status: probe.StatusUp, RoundTripTime: time.Second, Message: "ok"})
+	assert.Equal(t, now, r.Time)
+	assert.Equal(t, int64(1), r.Up)
+	assert.Equal(t, int64(0), r.Down())
+	assert.Empty(t, r.Message)
+
+	r = NewRecord(probe.Result{StartTime: now, Status: probe.StatusDown, Message: "failed"})
+	assert.Equal(t, int64(1), r.Down())
+	assert.Equal(t, "failed", r.Message)
+
+	r = NewRecord(probe.Result{StartTime: now, Status: probe.StatusDown, Maintenance: "upgrade"})
+	assert.Equal(t, int64(1), r.Maintenance)
+	assert.Equal(t, int64(0), r.Down())
+
+	r = NewRecord(probe.Result{Status: probe.StatusWarning})
+	assert.False(t, r.Time.IsZero())
+	assert.Equal(t, int64(1), r.Up)
+}
+
+func TestAggregate(t *testing.T) {
+	base := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Time: base, Status: probe.StatusUp, RTT: 100 * time.Millisecond, MaxRTT: 100 * time.Millisecond, Count: 1, Up: 1},
+		{Time: base.Add(20 * time.Minute), Status: probe.StatusDown, RTT: 300 * time.Millisecond, MaxRTT: 300 * time.Millisecond, Count: 1, Message: "failed"},
+		{Time: base.Add(40 * time.Minute), Status: probe.StatusWarning, RTT: 200 * time.Millisecond, MaxRTT: 200 * time.Millisecond, Count: 1, Up: 1},
+		{Time: base.Add(70 * time.Minute), Status: probe.StatusUp, RTT: 100 * time.Millisecond, MaxRTT: 100 * time.Millisecond, Count: 1, Up: 1},
+	}
+	result := Aggregate(records, time.Hour)
+	assert.Len(t, result, 2)
+	assert.Equal(t, base, result[0].Time)
+	assert.Equal(t, probe.StatusDown, result[0].Status)
+	assert.Equal(t, "failed", result[0].Message)
+	assert.Equal(t, 200*time.Millisecond, result[0].RTT)
+	assert.Equal(t, 300*time.Millisecond, result[0].MaxRTT)
+	assert.Equal(t, int64(3), result[0].Count)
+	assert.Equal(t, int64(2), result[0].Up)
+	assert.Equal(t, int64(1), result[0].Down())
+	assert.InDelta(t, 66.67, result[0].SLA(), 0.01)
+	assert.Equal(t, base.Add(time.Hour), result[1].Time)
+	assert.Equal(t, int64(1), result[1].Count)
+
+	// the aggregated records could be aggregated again
+	result = Aggregate(result, 24*time.Hour)
+	assert.Len(t, result, 1)
+	assert.Equal(t, int64(4), result[0].Count)
+	assert.Equal(t, 175*time.Millisecond, result[0].RTT)
+
+	assert.Equal(t, records, Aggregate(records, 0))
+
+	maintenance := Record{Count: 2, Maintenance: 2}
+	assert.Equal(t, float64(100), maintenance.SLA())
+}
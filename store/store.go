@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package store is the persistent store of the probe results. Besides the latest
+// result of each probe, the store keeps the history of the results, which could be
+// queried by time range.
+package store
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+// The kinds of the store
+const (
+	Bolt = "bolt"
+	YAML = "yaml"
+)
+
+// ErrNotSupported is returned if the store doesn't keep the history
+var ErrNotSupported = errors.New("the result history is not supported by the store")
+
+// Store is the interface of the result store
+type Store interface {
+	// Kind returns the kind of the store
+	Kind() string
+	// Record saves the result of the probe, the latest result is updated in memory
+	Record(result probe.Result) error
+	// Flush persists the latest results of all probes and the recorded history
+	Flush() error
+	// Compact removes the expired history and downsamples the old history
+	Compact(now time.Time) error
+	// Query returns the history records of the probe in [from, to), ordered by time
+	Query(name string, from, to time.Time) ([]Record, error)
+	// Close flushes and closes the store
+	Close() error
+}
+
+var defaultStore Store
+
+// SetDefault sets the store which the history is queried from
+func SetDefault(s Store) {
+	defaultStore = s
+}
+
+// Default returns the store which the history is queried from, nil if it's not set
+func Default() Store {
+	return defaultStore
+}
+
+// Downsample is the downsampling settings of the history
+type Downsample struct {
+	After    time.Duration `yaml:"after" json:"after,omitempty" jsonschema:"type=string,format=duration,title=Downsample After,description=the history older than this is downsampled - negative value means never,default=168h"`
+	Interval time.Duration `yaml:"interval" json:"interval,omitempty" jsonschema:"type=string,format=duration,title=Downsample Interval,description=the time bucket of the downsampled history,default=1h"`
+}
+
+// Settings is the settings of the result store
+type Settings struct {
+	Type       string        `yaml:"type" json:"type,omitempty" jsonschema:"enum=bolt,enum=yaml,title=Store Type,description=the type of the result store - bolt keeps the history - yaml only keeps the latest results,default=yaml"`
+	File       string        `yaml:"file" json:"file,omitempty" jsonschema:"title=Store File,description=the file of the bolt store - default is easeprobe.db in the directory of the SLA data file"`
+	Retention  time.Duration `yaml:"retention" json:"retention,omitempty" jsonschema:"type=string,format=duration,title=Retention,description=how long the history is kept - negative value means forever,default=2160h"`
+	Downsample Downsample    `yaml:"downsample" json:"downsample,omitempty" jsonschema:"title=Downsample,description=the downsampling settings of the history"`
+}
+
+// normalize sets the default values of the settings
+func (s *Settings) normalize() {
+	if s.Retention == 0 {
+		s.Retention = global.DefaultStoreRetention
+	}
+	if s.Downsample.After == 0 {
+		s.Downsample.After = global.DefaultDownsampleAfter
+	}
+	if s.Downsample.Interval <= 0 {
+		s.Downsample.Interval = global.DefaultDownsampleInterval
+	}
+}
+
+// New creates and opens the result store, the latest results in the store are
+// restored into memory. The YAML store is the default, the bolt store is opted in
+// to keep the history. The bolt store file is in the directory of the data file
+// if it's not configured, and nothing is persisted if the data file is disabled.
+func New(settings Settings, dataFile string) (Store, error) {
+	settings.normalize()
+	switch strings.ToLower(strings.TrimSpace(settings.Type)) {
+	case "", YAML:
+		return NewYAMLStore(dataFile), nil
+	case Bolt:
+		if strings.TrimSpace(dataFile) == "-" && settings.File == "" {
+			log.Infof("SLA data disabled by configuration. The result history is not kept...")
+			return NewYAMLStore("-"), nil
+		}
+		file := settings.File
+		if file == "" {
+			file = filepath.Join(filepath.Dir(dataFile), global.DefaultStoreFile)
+		}
+		return NewBoltStore(file, settings)
+	}
+	return nil, fmt.Errorf("unknown store type [%s], it should be bolt or yaml", settings.Type)
+}
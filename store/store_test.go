@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+)
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+
+	// the YAML store is the default
+	s, err := New(Settings{}, filepath.Join(dir, "data.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, YAML, s.Kind())
+
+	s, err = New(Settings{Type: Bolt}, filepath.Join(dir, "data.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, Bolt, s.Kind())
+	assert.Equal(t, filepath.Join(dir, global.DefaultStoreFile), s.(*BoltStore).file)
+	assert.Equal(t, global.DefaultStoreRetention, s.(*BoltStore).settings.Retention)
+	assert.Nil(t, s.Close())
+
+	s, err = New(Settings{Type: "YAML"}, filepath.Join(dir, "data.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, YAML, s.Kind())
+
+	// nothing is persisted if the data file is disabled
+	s, err = New(Settings{Type: Bolt}, "-")
+	assert.Nil(t, err)
+	assert.Equal(t, YAML, s.Kind())
+	assert.Nil(t, s.Flush())
+
+	_, err = New(Settings{Type: "mysql"}, "")
+	assert.NotNil(t, err)
+}
+
+func TestYAMLStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "data.yaml")
+	s := NewYAMLStore(file)
+	r := probe.Result{Name: "yaml-store", Status: probe.StatusUp, StartTime: time.Now()}
+	assert.Nil(t, s.Record(r))
+	assert.Equal(t, probe.StatusUp, probe.GetResultData("yaml-store").Status)
+
+	assert.Nil(t, s.Close())
+	_, err := os.Stat(file)
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.Compact(time.Now()))
+	_, err = s.Query("yaml-store", time.Time{}, time.Time{})
+	assert.Equal(t, ErrNotSupported, err)
+}
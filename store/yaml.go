@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"time"
+
+	"github.com/wfusion/easeprobe/probe"
+)
+
+// YAMLStore only keeps the latest results in the SLA data file, the history is not kept.
+// The data file is loaded while the configuration is initialized.
+type YAMLStore struct {
+	file string
+}
+
+// NewYAMLStore creates the YAML store with the data file, "-" means nothing is persisted
+func NewYAMLStore(file string) *YAMLStore {
+	return &YAMLStore{file: file}
+}
+
+// Kind returns the kind of the store
+func (s *YAMLStore) Kind() string {
+	return YAML
+}
+
+// Record updates the latest result of the probe in memory
+func (s *YAMLStore) Record(result probe.Result) error {
+	probe.SetResultData(result.Name, &result)
+	return nil
+}
+
+// Flush saves the latest results into the data file
+func (s *YAMLStore) Flush() error {
+	return probe.SaveDataToFile(s.file)
+}
+
+// Compact does nothing, the history is not kept
+func (s *YAMLStore) Compact(now time.Time) error {
+	return nil
+}
+
+// Query returns ErrNotSupported, the history is not kept
+func (s *YAMLStore) Query(name string, from, to time.Time) ([]Record, error) {
+	return nil, ErrNotSupported
+}
+
+// Close saves the latest results into the data file
+func (s *YAMLStore) Close() error {
+	return s.Flush()
+}
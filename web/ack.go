@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/wfusion/easeprobe/ack"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/report"
+)
+
+// ackRequest is the request body of the acknowledgement API
+type ackRequest struct {
+	By      string `json:"by"`
+	Comment string `json:"comment,omitempty"`
+	Expire  string `json:"expire,omitempty"` // the Go duration string, e.g. "1h", empty means until the probe recovers
+}
+
+// acknowledge acknowledges the failure of the probe, it's used by the API, the link and the chat apps
+func acknowledge(req ack.Request) (*probe.AckData, error) {
+	if probeRunner == nil {
+		return nil, fmt.Errorf("the probes are not running")
+	}
+	return probeRunner.Ack(req.Probe, req.Data(time.Now()))
+}
+
+func probeAck(w http.ResponseWriter, req *http.Request) {
+	name, ok := probeName(w, req)
+	if !ok {
+		return
+	}
+	var body ackRequest
+	if err := json.NewDecoder(io.LimitReader(req.Body, 64*1024)).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid acknowledgement - %v", err), http.StatusBadRequest)
+		return
+	}
+	expire, err := ack.ParseExpire(body.Expire)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a, err := acknowledge(ack.Request{Probe: name, By: body.By, Comment: body.Comment, Expire: expire})
+	if err != nil {
+		probeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, a)
+}
+
+func probeUnack(w http.ResponseWriter, req *http.Request) {
+	name, ok := probeName(w, req)
+	if !ok {
+		return
+	}
+	if err := probeRunner.Unack(name); err != nil {
+		probeError(w, err)
+		return
+	}
+	w.Write([]byte("OK"))
+}
+
+// ackHTML is the page of the acknowledgement link in the notification, the link is
+// signed by the token of the management API, so that no login is required. The page
+// is a form, so that the link previewer of the chat apps cannot acknowledge the failure.
+func ackHTML(w http.ResponseWriter, req *http.Request) {
+	name, ok := probeName(w, req)
+	if !ok {
+		return
+	}
+	if err := req.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	token := req.Form.Get("token")
+	if !ack.Verify(name, token) {
+		http.Error(w, "invalid acknowledgement link", http.StatusForbidden)
+		return
+	}
+
+	message := ""
+	if req.Method == http.MethodPost {
+		expire, err := ack.ParseExpire(req.PostForm.Get("expire"))
+		if err == nil {
+			_, err = acknowledge(ack.Request{
+				Probe:   name,
+				By:      req.PostForm.Get("by"),
+				Comment: req.PostForm.Get("comment"),
+				Expire:  expire,
+			})
+		}
+		if err != nil {
+			message = `<p style="color: #c00;">❌ Failed to acknowledge - ` + html.EscapeString(err.Error()) + `</p>`
+		}
+	}
+
+	r := probe.GetResultData(name)
+	if r == nil {
+		http.Error(w, fmt.Sprintf("probe [%s] is not found", name), http.StatusNotFound)
+		return
+	}
+	page := report.HTMLHeader("Acknowledge - " + html.EscapeString(name))
+	page += `<p><a href="/probes/` + url.PathEscape(name) + `">&rarr; ` + html.EscapeString(name) + `</a></p>`
+	page += `<table style="font-size: 16px; line-height: 20px;">` + report.SLAHTMLSection(r) + `</table>` + message
+	switch {
+	case r.IsAcked():
+		page += `<p>` + html.EscapeString(ack.Message(name, r.Stat.Ack)) + `</p>`
+	case r.Status != probe.StatusDown:
+		page += `<p>The probe is not down, nothing to acknowledge.</p>`
+	default:
+		page += `
+	<form method="post">
+		<input type="hidden" name="token" value="` + html.EscapeString(token) + `">
+		<p>Name: <input type="text" name="by" required></p>
+		<p>Comment: <input type="text" name="comment" size="60"></p>
+		<p>Expire: <select name="expire">
+			<option value="">until recovered</option>
+			<option value="30m">30 minutes</option>
+			<option value="1h">1 hour</option>
+			<option value="4h">4 hours</option>
+			<option value="24h">24 hours</option>
+		</select></p>
+		<p><input type="submit" value="✋ Acknowledge"></p>
+	</form>`
+	}
+	page += report.HTMLFooter(report.FormatTime(time.Now()))
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(page))
+}
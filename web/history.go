@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wfusion/easeprobe/channel"
+	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/report"
+	"github.com/wfusion/easeprobe/store"
+)
+
+// parseTime parses the time in RFC3339, unix seconds, or the duration relative to now, e.g. "-24h"
+func parseTime(str string, now time.Time, _default time.Time) (time.Time, error) {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return _default, nil
+	}
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t, nil
+	}
+	if sec, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	if d, err := time.ParseDuration(str); err == nil {
+		return now.Add(d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time [%s], it should be RFC3339, unix seconds or the duration relative to now", str)
+}
+
+// historyRange returns the time range and the step of the history query, the
+// default range is the last 24 hours, and the default step splits the range
+// into global.DefaultHistoryPoints points.
+func historyRange(req *http.Request, now time.Time) (from, to time.Time, step time.Duration, err error) {
+	query := req.URL.Query()
+	if to, err = parseTime(query.Get("to"), now, now); err != nil {
+		return
+	}
+	if from, err = parseTime(query.Get("from"), now, to.Add(-24*time.Hour)); err != nil {
+		return
+	}
+	if !from.Before(to) {
+		err = fmt.Errorf("invalid time range, from(%s) should be before to(%s)", from.Format(time.RFC3339), to.Format(time.RFC3339))
+		return
+	}
+
+	span := to.Sub(from)
+	if s := strings.TrimSpace(query.Get("step")); s != "" {
+		if step, err = time.ParseDuration(s); err != nil {
+			return
+		}
+		if step <= 0 || span/step > global.MaxHistoryPoints {
+			err = fmt.Errorf("invalid step [%s], it should be positive and produce at most %d points", s, global.MaxHistoryPoints)
+		}
+		return
+	}
+	step = (span/global.DefaultHistoryPoints + time.Minute - 1).Truncate(time.Minute)
+	return
+}
+
+// getHistory queries the history of the probe, returns the http status code if failed
+func getHistory(name string, req *http.Request) (*report.History, int, error) {
+	r := probe.GetResultData(name)
+	if r == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("probe [%s] not found", name)
+	}
+	s := store.Default()
+	if s == nil {
+		return nil, http.StatusNotImplemented, store.ErrNotSupported
+	}
+	from, to, step, err := historyRange(req, time.Now())
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	records, err := s.Query(name, from, to)
+	if errors.Is(err, store.ErrNotSupported) {
+		return nil, http.StatusNotImplemented, err
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	h := report.NewHistory(name, r.Endpoint, records, from, to, step)
+	return &h, http.StatusOK, nil
+}
+
+func probeHistory(w http.ResponseWriter, req *http.Request) {
+	name, ok := probeName(w, req)
+	if !ok {
+		return
+	}
+	h, code, err := getHistory(name, req)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	writeJSON(w, http.StatusOK, h)
+}
+
+func probeHTML(w http.ResponseWriter, req *http.Request) {
+	name, ok := probeName(w, req)
+	if !ok {
+		return
+	}
+	h, code, err := getHistory(name, req)
+	if err != nil && code != http.StatusNotImplemented {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var days []report.DayUptime
+	if h != nil {
+		now := time.Now()
+		records, err := store.Default().Query(name, now.AddDate(0, 0, -global.DefaultUptimeDays), time.Time{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		days = report.DailyUptime(records, global.DefaultUptimeDays, now, global.GetTimeLocation())
+	}
+
+	interval := getRefreshInterval(req.URL.Query().Get("refresh"))
+	refresh := fmt.Sprintf("%d", interval.Milliseconds())
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	sections := []string{}
+	if probeRunner != nil {
+		if p, err := probeRunner.Get(name); err == nil {
+			sections = append(sections, routingHTML(channel.GetRouting(p)))
+		}
+	}
+	w.Write([]byte(report.ProbeHTML(probe.GetResultData(name), h, days, sections...) + report.AutoRefreshJS(refresh)))
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wfusion/easeprobe/channel"
+	"github.com/wfusion/easeprobe/conf"
+	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/runner"
+	"gopkg.in/yaml.v3"
+)
+
+// probeInfo is the runtime information of a probe.
+// The full configuration is not returned, because it might have the passwords and the tokens.
+type probeInfo struct {
+	Name     string          `json:"name"`
+	Kind     string          `json:"kind"`
+	Endpoint string          `json:"endpoint"`
+	Interval time.Duration   `json:"interval"`
+	Channels []string        `json:"channels"`
+	Status   probe.Status    `json:"status"`
+	Message  string          `json:"message"`
+	Running  bool            `json:"running"`
+	Paused   bool            `json:"paused"`
+	Routing  channel.Routing `json:"routing"`
+	Ack      *probe.AckData  `json:"ack,omitempty"`
+}
+
+func newProbeInfo(p probe.Prober) probeInfo {
+	return probeInfo{
+		Name:     p.Name(),
+		Kind:     p.Kind(),
+		Endpoint: p.Result().Endpoint,
+		Interval: p.Interval(),
+		Channels: p.Channels(),
+		Status:   p.Result().Status,
+		Message:  p.Result().Message,
+		Running:  probeRunner.IsRunning(p.Name()),
+		Paused:   probeRunner.IsPaused(p.Name()),
+		Routing:  channel.GetRouting(p),
+		Ack:      p.Result().Stat.Ack.Clone(),
+	}
+}
+
+// running checks the probes are running, so that they could be managed
+func running(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if probeRunner == nil {
+			http.Error(w, "the probes are not running", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+func probeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, runner.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+func probeName(w http.ResponseWriter, req *http.Request) (string, bool) {
+	name, err := url.PathUnescape(chi.URLParam(req, "name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+	return name, true
+}
+
+func probeList(w http.ResponseWriter, req *http.Request) {
+	infos := []probeInfo{}
+	for _, p := range probeRunner.Probers() {
+		infos = append(infos, newProbeInfo(p))
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func probeGet(w http.ResponseWriter, req *http.Request) {
+	name, ok := probeName(w, req)
+	if !ok {
+		return
+	}
+	p, err := probeRunner.Get(name)
+	if err != nil {
+		probeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newProbeInfo(p))
+}
+
+// probeAdd adds the probes, the request body is the probe sections of
+// the configuration file in YAML or JSON, e.g. {"http": [{"name": "...", "url": "..."}]}
+func probeAdd(w http.ResponseWriter, req *http.Request) {
+	buf, err := io.ReadAll(io.LimitReader(req.Body, 1024*1024))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var c conf.Conf
+	if err := yaml.Unmarshal(buf, &c); err != nil {
+		http.Error(w, fmt.Sprintf("invalid probe configuration - %v", err), http.StatusBadRequest)
+		return
+	}
+	// the shell and client probes run the commands and connect with the credentials on the host
+	if (len(c.Shell) > 0 || len(c.Client) > 0) && !conf.Get().Settings.HTTPServer.AllowUnsafeProbes {
+		http.Error(w, "the shell and client probes are not allowed to be added by the API", http.StatusForbidden)
+		return
+	}
+	probers := c.AllProbers()
+	if len(probers) == 0 {
+		http.Error(w, "no probe is found in the request", http.StatusBadRequest)
+		return
+	}
+
+	// all or nothing - remove the added probes if any of them fails
+	infos := []probeInfo{}
+	for i, p := range probers {
+		if err := probeRunner.Add(p); err != nil {
+			for _, added := range probers[:i] {
+				probeRunner.Remove(added.Name())
+			}
+			http.Error(w, fmt.Sprintf("failed to add the probe [%s] - %v", p.Name(), err), http.StatusBadRequest)
+			return
+		}
+		infos = append(infos, newProbeInfo(p))
+	}
+	writeJSON(w, http.StatusCreated, infos)
+}
+
+func probeRemove(w http.ResponseWriter, req *http.Request) {
+	name, ok := probeName(w, req)
+	if !ok {
+		return
+	}
+	if err := probeRunner.Remove(name); err != nil {
+		probeError(w, err)
+		return
+	}
+	w.Write([]byte("OK"))
+}
+
+func probePause(w http.ResponseWriter, req *http.Request) {
+	name, ok := probeName(w, req)
+	if !ok {
+		return
+	}
+	if err := probeRunner.Pause(name); err != nil {
+		probeError(w, err)
+		return
+	}
+	w.Write([]byte("OK"))
+}
+
+func probeResume(w http.ResponseWriter, req *http.Request) {
+	name, ok := probeName(w, req)
+	if !ok {
+		return
+	}
+	if err := probeRunner.Resume(name); err != nil {
+		probeError(w, err)
+		return
+	}
+	w.Write([]byte("OK"))
+}
+
+func probeRun(w http.ResponseWriter, req *http.Request) {
+	name, ok := probeName(w, req)
+	if !ok {
+		return
+	}
+	result, err := probeRunner.RunNow(name)
+	if err != nil {
+		probeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
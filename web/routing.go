@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/wfusion/easeprobe/channel"
+)
+
+func escapeJoin(list []string) string {
+	escaped := make([]string, 0, len(list))
+	for _, s := range list {
+		escaped = append(escaped, html.EscapeString(s))
+	}
+	return strings.Join(escaped, ", ")
+}
+
+// routingHTML returns the routing section of the probe detail page
+func routingHTML(rt channel.Routing) string {
+	page := `<h2 style="font-weight: normal; color: #3b3b3b;">Routing</h2>`
+	page += `<table style="font-size: 16px; line-height: 20px;">
+	<tr><td class="head">Channel / Route</td><td class="head">Conditions</td><td class="head">Notifiers</td><td class="head">Continue</td></tr>`
+
+	names := make([]string, 0, len(rt.Channels))
+	for name := range rt.Channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		page += fmt.Sprintf(`
+	<tr><td class="data">Channel: %s</td><td class="data">-</td><td class="data">%s</td><td class="data">-</td></tr>`,
+			html.EscapeString(name), escapeJoin(rt.Channels[name]))
+	}
+	for _, r := range rt.Routes {
+		conditions := "-"
+		if len(r.Conditions) > 0 {
+			conditions = escapeJoin(r.Conditions)
+		}
+		page += fmt.Sprintf(`
+	<tr><td class="data">Route: %s</td><td class="data">%s</td><td class="data">%s</td><td class="data">%t</td></tr>`,
+			html.EscapeString(r.Name), conditions, escapeJoin(r.Notifiers), r.Continue)
+	}
+	return page + `</table>`
+}
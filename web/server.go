@@ -20,30 +20,46 @@ package web
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"html"
+	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/wfusion/easeprobe/ack"
 	"github.com/wfusion/easeprobe/conf"
 	"github.com/wfusion/easeprobe/global"
+	"github.com/wfusion/easeprobe/maintenance"
+	"github.com/wfusion/easeprobe/metric"
 	"github.com/wfusion/easeprobe/probe"
+	"github.com/wfusion/easeprobe/probe/heartbeat"
 	"github.com/wfusion/easeprobe/report"
+	"github.com/wfusion/easeprobe/runner"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	log "github.com/sirupsen/logrus"
 )
 
-var probers *[]probe.Prober
+var probeRunner *runner.Runner
 var webServer *http.Server
 
+func getProbers() []probe.Prober {
+	if probeRunner == nil {
+		return nil
+	}
+	return probeRunner.Probers()
+}
+
 func getRefreshInterval(refersh string) time.Duration {
 	interval := conf.Get().Settings.HTTPServer.AutoRefreshTime
 	if strings.TrimSpace(refersh) == "" {
@@ -99,6 +115,7 @@ func getFilter(req *http.Request) (*report.SLAFilter, error) {
 	filter.SLALess = getNum(req.URL.Query().Get("lte"), 100, toFloat)
 	filter.PageNum = getNum(req.URL.Query().Get("pg"), 1, toInt)
 	filter.PageSize = getNum(req.URL.Query().Get("sz"), global.DefaultPageSize, toInt)
+	filter.ProbeLink = true
 
 	if err := filter.Check(); err != nil {
 		log.Errorf(err.Error())
@@ -117,7 +134,7 @@ func slaHTML(w http.ResponseWriter, req *http.Request) {
 	}
 
 	refresh := fmt.Sprintf("%d", interval.Milliseconds())
-	html := []byte(report.SLAHTMLFilter(*probers, filter) + report.AutoRefreshJS(refresh))
+	html := []byte(report.SLAHTMLFilter(getProbers(), filter) + report.AutoRefreshJS(refresh))
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Write(html)
@@ -135,13 +152,196 @@ func slaJSON(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	_probers := filter.Filter(*probers)
+	_probers := filter.Filter(getProbers())
 	w.Write([]byte(report.SLAJSON(_probers)))
 }
 
-// SetProbers set the probers
-func SetProbers(p []probe.Prober) {
-	probers = &p
+func findHeartbeat(name string) *heartbeat.Heartbeat {
+	for _, p := range getProbers() {
+		if h, ok := p.(*heartbeat.Heartbeat); ok && h.Name() == name {
+			return h
+		}
+	}
+	return nil
+}
+
+func heartbeatPing(w http.ResponseWriter, req *http.Request) {
+	name, err := url.PathUnescape(chi.URLParam(req, "name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h := findHeartbeat(name)
+	if h == nil {
+		http.Error(w, fmt.Sprintf("heartbeat [%s] is not found", name), http.StatusNotFound)
+		return
+	}
+
+	event, code, err := heartbeat.ParseEvent(chi.URLParam(req, "event"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the request body could carry the detail of the job, e.g. the error output
+	detail := ""
+	if req.Body != nil {
+		buf, _ := io.ReadAll(io.LimitReader(req.Body, 1024))
+		detail = strings.TrimSpace(string(buf))
+	}
+
+	h.Ping(event, code, detail)
+	w.Write([]byte("OK"))
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write(buf)
+}
+
+func maintenanceList(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, http.StatusOK, maintenance.All())
+}
+
+func maintenanceGet(w http.ResponseWriter, req *http.Request) {
+	name, err := url.PathUnescape(chi.URLParam(req, "name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	window, ok := maintenance.Get(name)
+	if !ok {
+		http.Error(w, fmt.Sprintf("maintenance window [%s] is not found", name), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, window)
+}
+
+func maintenanceAdd(w http.ResponseWriter, req *http.Request) {
+	var window maintenance.Window
+	if err := json.NewDecoder(io.LimitReader(req.Body, 64*1024)).Decode(&window); err != nil {
+		http.Error(w, fmt.Sprintf("invalid maintenance window - %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := maintenance.Add(window); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	window, _ = maintenance.Get(window.Name)
+	writeJSON(w, http.StatusCreated, window)
+}
+
+func maintenanceRemove(w http.ResponseWriter, req *http.Request) {
+	name, err := url.PathUnescape(chi.URLParam(req, "name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := maintenance.Remove(name); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Write([]byte("OK"))
+}
+
+// authenticate checks the bearer token of the management API,
+// the API is disabled if the token is not configured.
+func authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token := conf.Get().Settings.HTTPServer.Token
+		if len(token) == 0 {
+			http.Error(w, "the management API is disabled - the token is not configured", http.StatusForbidden)
+			return
+		}
+		auth := req.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) != 1 {
+			log.Warnf("[Web] Unauthorized management API access from %s", req.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="easeprobe"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// scrapeTimeout return the timeout of the Prometheus scrape minus a small offset,
+// so that the probe could finish before the scrape is timed out.
+func scrapeTimeout(req *http.Request) time.Duration {
+	seconds, err := strconv.ParseFloat(req.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"), 64)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	timeout := time.Duration(seconds*float64(time.Second)) - 500*time.Millisecond
+	if timeout <= 0 {
+		return 0
+	}
+	return timeout
+}
+
+// probeTarget is the blackbox-exporter compatible endpoint - `/probe?target=...&module=...`
+// it probes the target with the module template synchronously, and returns the metrics
+// of the transient probe from a dedicated registry.
+func probeTarget(w http.ResponseWriter, req *http.Request) {
+	target := req.URL.Query().Get("target")
+	if len(target) == 0 {
+		http.Error(w, "target parameter is missing", http.StatusBadRequest)
+		return
+	}
+	name := req.URL.Query().Get("module")
+	if len(name) == 0 {
+		name = conf.DefaultModule
+	}
+	c := conf.Get()
+	module, ok := c.Modules[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown module [%s]", name), http.StatusBadRequest)
+		return
+	}
+
+	registry := prometheus.NewRegistry()
+	p, err := module.NewProber(target, scrapeTimeout(req), metric.NewRegistry(registry))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid module [%s] - %v", name, err), http.StatusBadRequest)
+		return
+	}
+	if err := p.Config(c.ProbeSettings()); err != nil {
+		http.Error(w, fmt.Sprintf("failed to configure the probe - %v", err), http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	result := p.Probe()
+	duration := time.Since(start)
+	log.Debugf("[Web] Probe the target [%s] with the module [%s] - %s", target, name, result.Message)
+
+	success := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_success",
+		Help: "Displays whether or not the probe was a success",
+	})
+	seconds := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_duration_seconds",
+		Help: "Returns how long the probe took to complete in seconds",
+	})
+	registry.MustRegister(success, seconds)
+	// the status thresholds are not reached by a single probe, use the status of the probe
+	if p.Result().Stat.StatusCounter.CurrentStatus {
+		success.Set(1)
+	}
+	seconds.Set(duration.Seconds())
+
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, req)
+}
+
+// SetRunner set the runner of the probers
+func SetRunner(r *runner.Runner) {
+	probeRunner = r
 }
 
 // Server is the http server
@@ -190,10 +390,53 @@ func Server() {
 	if c.Settings.Prometheus.Mode != conf.PrometheusModePush {
 		r.Get("/metrics", promhttp.Handler().ServeHTTP)
 	}
+	r.Get("/probe", probeTarget)
 
 	r.Route("/api/v1", func(r chi.Router) {
 		r.Get("/sla", slaJSON)
+		r.Route("/heartbeat/{name}", func(r chi.Router) {
+			for _, path := range []string{"/", "/{event}"} {
+				r.Get(path, heartbeatPing)
+				r.Post(path, heartbeatPing)
+				r.Head(path, heartbeatPing)
+			}
+		})
+		r.Route("/maintenance", func(r chi.Router) {
+			// the windows are read-only as the SLA report without the token
+			r.Get("/", maintenanceList)
+			r.Get("/{name}", maintenanceGet)
+			r.Group(func(r chi.Router) {
+				r.Use(authenticate)
+				r.Post("/", maintenanceAdd)
+				r.Delete("/{name}", maintenanceRemove)
+			})
+		})
+		r.Route("/probes", func(r chi.Router) {
+			// the history is read-only as the SLA report
+			r.Get("/{name}/history", probeHistory)
+			r.Group(func(r chi.Router) {
+				r.Use(authenticate, running)
+				r.Get("/", probeList)
+				r.Post("/", probeAdd)
+				r.Get("/{name}", probeGet)
+				r.Delete("/{name}", probeRemove)
+				r.Post("/{name}/pause", probePause)
+				r.Post("/{name}/resume", probeResume)
+				r.Post("/{name}/run", probeRun)
+				r.Post("/{name}/ack", probeAck)
+				r.Delete("/{name}/ack", probeUnack)
+			})
+		})
+		// the requests of the chat apps are verified by their signatures
+		r.Route("/ack", func(r chi.Router) {
+			r.Post("/slack", ack.SlackHandler(acknowledge))
+			r.Post("/telegram", ack.TelegramHandler(acknowledge))
+			r.Post("/discord", ack.DiscordHandler(acknowledge))
+		})
 	})
+	r.Get("/probes/{name}", probeHTML)
+	r.Get("/ack/{name}", ackHTML)
+	r.Post("/ack/{name}", ackHTML)
 
 	r.NotFound(slaHTML)
 